@@ -1,15 +1,16 @@
 package main
 
 import (
-	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 
 	"ddnsjx/internal/config"
 	"ddnsjx/internal/dnstxt"
+	"ddnsjx/internal/export"
 )
 
 func runConvert(args []string) int {
@@ -17,23 +18,41 @@ func runConvert(args []string) int {
 	fs.SetOutput(os.Stderr)
 
 	var (
-		inputPath  = fs.String("input", "dns.txt", "path to dns.txt (TSV)")
-		outputPath = fs.String("output", "config.json", "path to output config.json (use - for stdout)")
+		inputPath  = fs.String("input", "dns.txt", "path to dns.txt (TSV) or a CSV export")
+		inputFmt   = fs.String("input-format", "", "input format: txt|csv (default: csv for .csv files, txt otherwise)")
+		csvLayout  = fs.String("csv-layout", dnstxt.LayoutAuto, "CSV column layout: "+strings.Join(dnstxt.CSVLayouts(), "|"))
+		outputPath = fs.String("output", "config.json", "path to output config (.json/.yaml/.toml by extension, use - for JSON on stdout)")
 		zonePath   = fs.String("zone", "", "path to output zone file (optional, use - for stdout)")
 		domain     = fs.String("domain", "", "domain (empty: infer from records)")
 		pretty     = fs.Bool("pretty", true, "pretty-print JSON")
 		force      = fs.Bool("force", false, "write outputs even if issues exist")
 		defaultTTL = fs.Uint64("default-ttl", 300, "default TTL for zone output (ignored if --zone is empty)")
 		replace    = fs.String("replace-target", "", "replace value/target in records, format: old=new")
+		format     = fs.String("format", "", "output format: empty for a stalwart-dns config, or "+strings.Join(export.Formats, "|")+" (default --output <domain>.yaml, dnsconfig.js or dns.tf)")
+
+		soa        = fs.Bool("soa", false, "emit an apex SOA record in the zone output")
+		soaMName   = fs.String("soa-mname", "", "SOA primary name server (default: first --ns)")
+		soaRName   = fs.String("soa-rname", "", "SOA responsible mailbox, e.g. hostmaster@example.com (default hostmaster.<domain>)")
+		soaSerial  = fs.String("soa-serial", dnstxt.SerialDate, "SOA serial strategy: date|unixtime|increment (always above the serial of an existing --zone file)")
+		soaRefresh = fs.Uint("soa-refresh", 86400, "SOA refresh timer (seconds)")
+		soaRetry   = fs.Uint("soa-retry", 7200, "SOA retry timer (seconds)")
+		soaExpire  = fs.Uint("soa-expire", 3600000, "SOA expire timer (seconds)")
+		soaMinimum = fs.Uint("soa-minimum", 3600, "SOA minimum / negative-caching TTL (seconds)")
+		canonical  = fs.Bool("canonical", false, "emit zone records in canonical order, grouped by owner with aligned columns")
+
+		nameServers stringList
+		csvColumns  stringList
 	)
+	fs.Var(&nameServers, "ns", "apex name server for the zone output (repeatable)")
+	fs.Var(&csvColumns, "csv-column", "map a CSV header to a field, format: field=Header, e.g. contents=记录值 (repeatable)")
 
 	if err := fs.Parse(args); err != nil {
 		return 2
 	}
 
-	records, issues, err := dnstxt.LoadFile(*inputPath)
+	records, issues, err := loadRecords(*inputPath, *inputFmt, dnstxt.CSVOptions{Layout: *csvLayout, Domain: *domain}, csvColumns)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, "read dns.txt:", err.Error())
+		fmt.Fprintf(os.Stderr, "read %s: %s\n", *inputPath, err.Error())
 		return 1
 	}
 
@@ -86,25 +105,45 @@ func runConvert(args []string) int {
 		fmt.Fprintf(os.Stderr, "records[%s]=%d\n", k, countByType[k])
 	}
 
-	cfg := config.FileConfig{Records: records}
 	var out []byte
-	if *pretty {
-		out, err = json.MarshalIndent(cfg, "", "  ")
+	if f := strings.ToLower(strings.TrimSpace(*format)); f != "" {
+		if !flagSet(fs, "output") {
+			*outputPath = export.DefaultPath(f, resolvedDomain)
+		}
+		out, err = export.Render(f, resolvedDomain, records)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "export %s: %s\n", f, err.Error())
+			return 1
+		}
 	} else {
-		out, err = json.Marshal(cfg)
-	}
-	if err != nil {
-		fmt.Fprintln(os.Stderr, "encode config json:", err.Error())
-		return 1
+		cfg := config.FileConfig{Records: records}
+		cfgFormat := config.FormatFromPath(*outputPath)
+		out, err = config.Marshal(cfg, cfgFormat, *pretty)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "encode config %s: %s\n", cfgFormat, err.Error())
+			return 1
+		}
 	}
-	out = append(out, '\n')
 	if err := writeFileOrStdout(*outputPath, out); err != nil {
 		fmt.Fprintln(os.Stderr, err.Error())
 		return 1
 	}
 
 	if strings.TrimSpace(*zonePath) != "" {
-		zone, zIssues, err := dnstxt.RenderZone(resolvedDomain, records, dnstxt.ZoneOptions{DefaultTTL: *defaultTTL})
+		zopt := dnstxt.ZoneOptions{DefaultTTL: *defaultTTL, NS: nameServers, Canonical: *canonical}
+		if *soa {
+			zopt.SOA = &dnstxt.SOA{
+				MName:          *soaMName,
+				RName:          *soaRName,
+				SerialStrategy: *soaSerial,
+				PreviousSerial: previousSerial(*zonePath),
+				Refresh:        uint32(*soaRefresh),
+				Retry:          uint32(*soaRetry),
+				Expire:         uint32(*soaExpire),
+				Minimum:        uint32(*soaMinimum),
+			}
+		}
+		zone, zIssues, err := dnstxt.RenderZone(resolvedDomain, records, zopt)
 		if err != nil {
 			fmt.Fprintln(os.Stderr, "render zone:", err.Error())
 			if !*force {
@@ -121,6 +160,62 @@ func runConvert(args []string) int {
 	return 0
 }
 
+// loadRecords reads path as dns.txt or as CSV, chosen by format or the
+// file extension.
+func loadRecords(path, format string, opt dnstxt.CSVOptions, columns []string) ([]config.RawRecord, []dnstxt.Issue, error) {
+	format = strings.ToLower(strings.TrimSpace(format))
+	if format == "" {
+		format = "txt"
+		if strings.EqualFold(filepath.Ext(path), ".csv") {
+			format = "csv"
+		}
+	}
+	switch format {
+	case "txt":
+		return dnstxt.LoadFile(path)
+	case "csv":
+		for _, c := range columns {
+			field, header, ok := strings.Cut(c, "=")
+			if !ok {
+				return nil, nil, fmt.Errorf("invalid --csv-column %q (expected field=Header)", c)
+			}
+			if opt.Columns == nil {
+				opt.Columns = make(map[string]string)
+			}
+			opt.Columns[strings.ToLower(strings.TrimSpace(field))] = strings.TrimSpace(header)
+		}
+		return dnstxt.LoadCSVFile(path, opt)
+	default:
+		return nil, nil, fmt.Errorf("unsupported input format %q (expected txt|csv)", format)
+	}
+}
+
+// previousSerial reads the SOA serial of the zone file about to be
+// replaced, so the new serial always increases. Missing files yield 0.
+func previousSerial(path string) uint32 {
+	path = strings.TrimSpace(path)
+	if path == "-" {
+		return 0
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	serial, _ := dnstxt.ZoneSerial(string(b))
+	return serial
+}
+
+// flagSet reports whether name was given on the command line.
+func flagSet(fs *flag.FlagSet, name string) bool {
+	var set bool
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
 func printIssues(issues []dnstxt.Issue) {
 	for _, is := range issues {
 		if is.Line > 0 {
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"ddnsjx/internal/ddns"
+)
+
+// runDDNS keeps the A/AAAA records of --name pointed at the host's current
+// public address.
+func runDDNS(args []string) int {
+	fs := flag.NewFlagSet("stalwart-dns ddns", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	var (
+		providerName = fs.String("provider", "dnspod", "dns provider (see: stalwart-dns providers)")
+		domain       = fs.String("domain", "", "domain/zone name")
+		line         = fs.String("record-line", "默认", "DNSPod record line (ignored by cloudflare)")
+		ipv4         = fs.Bool("ipv4", true, "update A records")
+		ipv6         = fs.Bool("ipv6", false, "update AAAA records")
+		detect       = fs.String("detect", "http", "address detection: http|interface")
+		iface        = fs.String("interface", "", "interface to read addresses from with --detect interface (empty: all)")
+		ipv4URL      = fs.String("ipv4-url", "https://api.ipify.org", "echo endpoint returning the public IPv4 address")
+		ipv6URL      = fs.String("ipv6-url", "https://api6.ipify.org", "echo endpoint returning the public IPv6 address")
+		interval     = fs.Duration("interval", 5*time.Minute, "how often to check the address")
+		maxBackoff   = fs.Duration("max-backoff", 30*time.Minute, "longest wait between retries after errors")
+		statePath    = fs.String("state", "ddns-state.json", "file persisting the last written addresses (empty: memory only)")
+		ttl          = fs.Uint64("ttl", 0, "record TTL (0: provider default)")
+		once         = fs.Bool("once", false, "check and update once, then exit")
+		metricsAddr  = fs.String("metrics-listen", "", "serve Prometheus metrics on this address, e.g. 127.0.0.1:9153 (empty: off)")
+		ownerID      = fs.String("owner-id", "default", "ownership marker of the records ddns writes; other values at a name are left alone")
+		adopt        = fs.Bool("adopt", false, "replace and remove every value at --name, including ones not owned by --owner-id")
+
+		names     stringList
+		protected stringList
+	)
+	fs.Var(&names, "name", "record name to update, relative (mail, @) or absolute (repeatable)")
+	fs.Var(&protected, "protect", "name that must never be modified, e.g. @ or *.internal (repeatable)")
+	providerOpts := registerProviderFlags(fs)
+	telemetryOpts := registerTelemetryFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	zone := strings.TrimSuffix(strings.TrimSpace(*domain), ".")
+	if zone == "" {
+		fmt.Fprintln(os.Stderr, "domain is required (flag --domain)")
+		return 2
+	}
+	if len(names) == 0 {
+		fmt.Fprintln(os.Stderr, "at least one --name is required")
+		return 2
+	}
+	var subs []string
+	for _, n := range names {
+		sub, err := ddnsSubDomain(zone, n)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			return 2
+		}
+		subs = append(subs, sub)
+	}
+
+	var families []ddns.Family
+	if *ipv4 {
+		families = append(families, ddns.IPv4)
+	}
+	if *ipv6 {
+		families = append(families, ddns.IPv6)
+	}
+	if len(families) == 0 {
+		fmt.Fprintln(os.Stderr, "nothing to update: both --ipv4 and --ipv6 are off")
+		return 2
+	}
+
+	var detector ddns.Detector
+	switch *detect {
+	case "http":
+		detector = ddns.HTTPDetector{IPv4URL: *ipv4URL, IPv6URL: *ipv6URL}
+	case "interface":
+		detector = ddns.InterfaceDetector{Name: *iface}
+	default:
+		fmt.Fprintln(os.Stderr, "invalid --detect: "+*detect+" (expected http|interface)")
+		return 2
+	}
+	if *interval <= 0 {
+		fmt.Fprintln(os.Stderr, "--interval must be positive")
+		return 2
+	}
+
+	client, err := providerOpts.newClient(*providerName, zone, nil)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+
+	flushTraces, err := telemetryOpts.setup()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	defer flushTraces()
+	if *metricsAddr != "" {
+		serveMetrics(*metricsAddr)
+	}
+
+	logger := log.New(os.Stdout, "", log.LstdFlags)
+	u := &ddns.Updater{
+		Client:    client,
+		Zone:      zone,
+		Line:      *line,
+		Names:     subs,
+		Families:  families,
+		Detector:  detector,
+		StatePath: *statePath,
+		OwnerID:   *ownerID,
+		Adopt:     *adopt,
+		Protected: protected,
+		Logf:      logger.Printf,
+		OnRetry: func(err error) {
+			providerMetrics.Retry(*providerName, "ddns")
+		},
+	}
+	if *ttl > 0 {
+		u.TTL = ttl
+	}
+
+	if *once {
+		if err := u.Once(context.Background()); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			return 1
+		}
+		return 0
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	logger.Printf("watching %s every %s", strings.Join(names, ", "), *interval)
+	if err := u.Run(ctx, *interval, *maxBackoff); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	return 0
+}
+
+// ddnsSubDomain accepts "mail", "@" or "mail.example.com." and returns the
+// name relative to zone.
+func ddnsSubDomain(zone, name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	zone = strings.ToLower(zone)
+	switch {
+	case name == "":
+		return "", fmt.Errorf("--name is empty")
+	case name == "@" || strings.TrimSuffix(name, ".") == zone:
+		return "@", nil
+	case strings.HasSuffix(name, "."+zone+".") || strings.HasSuffix(name, "."+zone):
+		return strings.TrimSuffix(strings.TrimSuffix(name, "."), "."+zone), nil
+	case strings.HasSuffix(name, "."):
+		return "", fmt.Errorf("--name %q is not under domain %q", name, zone)
+	}
+	return name, nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"ddnsjx/internal/provider"
+)
+
+// runDS prints the DS records a DNSSEC-signing provider publishes for the
+// zone, in zone-file syntax, ready to be registered at the registrar.
+func runDS(args []string) int {
+	fs := flag.NewFlagSet("stalwart-dns ds", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	var (
+		providerName = fs.String("provider", "desec", "dns provider that signs the zone (see: stalwart-dns providers)")
+		domain       = fs.String("domain", "", "domain/zone name")
+	)
+	providerOpts := registerProviderFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	zone := strings.TrimSuffix(strings.TrimSpace(*domain), ".")
+	if zone == "" {
+		fmt.Fprintln(os.Stderr, "domain is required (flag --domain)")
+		return 2
+	}
+
+	client, err := providerOpts.newClient(*providerName, zone, nil)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	reader, ok := provider.As[provider.DSReader](client)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "provider %s does not publish DS records\n", *providerName)
+		return 1
+	}
+
+	ds, err := reader.DSRecords(context.Background(), zone)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	for _, rdata := range ds {
+		fmt.Fprintf(os.Stdout, "%s.\tIN\tDS\t%s\n", strings.ToLower(zone), rdata)
+	}
+	return 0
+}
@@ -1,7 +1,6 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"strings"
@@ -41,11 +40,11 @@ func initConfigFromDNSTxt(dnsTxtPath string, configPath string, force bool, repl
 	}
 
 	cfg := config.FileConfig{Records: records}
-	out, err := json.MarshalIndent(cfg, "", "  ")
+	format := config.FormatFromPath(configPath)
+	out, err := config.Marshal(cfg, format, true)
 	if err != nil {
-		return fmt.Errorf("encode config json: %w", err)
+		return fmt.Errorf("encode config %s: %w", format, err)
 	}
-	out = append(out, '\n')
 	if err := os.WriteFile(configPath, out, 0o644); err != nil {
 		return fmt.Errorf("write %s: %w", configPath, err)
 	}
@@ -4,44 +4,59 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"net/http"
 	"os"
 	"strings"
-	"time"
 
 	"ddnsjx/internal/app"
-	"ddnsjx/internal/cloudflareclient"
+	"ddnsjx/internal/cassette"
 	"ddnsjx/internal/config"
-	"ddnsjx/internal/dnspodclient"
 	"ddnsjx/internal/provider"
 )
 
 func main() {
-	if len(os.Args) > 1 && os.Args[1] == "convert" {
-		os.Exit(runConvert(os.Args[2:]))
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "convert":
+			os.Exit(runConvert(os.Args[2:]))
+		case "validate":
+			os.Exit(runValidate(os.Args[2:]))
+		case "providers":
+			os.Exit(runProviders(os.Args[2:]))
+		case "ds":
+			os.Exit(runDS(os.Args[2:]))
+		case "ptr":
+			os.Exit(runPTR(os.Args[2:]))
+		case "ddns":
+			os.Exit(runDDNS(os.Args[2:]))
+		case "serve":
+			os.Exit(runServe(os.Args[2:]))
+		}
 	}
 
 	var (
-		providerName = flag.String("provider", "dnspod", "dns provider: dnspod|cloudflare")
-		configPath   = flag.String("config", "config.json", "path to records config JSON")
+		providerName = flag.String("provider", "dnspod", "dns provider: "+strings.Join(provider.Names(), "|")+" (see: stalwart-dns providers)")
+		configPath   = flag.String("config", "config.json", "path to records config (.json/.yaml/.toml)")
 		domain       = flag.String("domain", "", "domain/zone name (empty: infer from config)")
 		line         = flag.String("record-line", "默认", "DNSPod record line (ignored by cloudflare)")
 		dryRun       = flag.Bool("dry-run", false, "print planned operations without calling provider API")
-		upsert       = flag.Bool("upsert", false, "if record exists, update it to match current config")
 		skipUnsup    = flag.Bool("skip-unsupported", false, "skip unsupported record types instead of failing")
 		initCfg      = flag.Bool("init", false, "initialize config.json from dns.txt and exit")
 		dnsTxtPath   = flag.String("dns-txt", "dns.txt", "path to dns.txt (for --init)")
 		force        = flag.Bool("force", false, "overwrite output file(s) for --init/convert")
-		sleep        = flag.Duration("sleep", 150*time.Millisecond, "sleep between requests")
-		retries      = flag.Int("retries", 3, "max retries for transient errors")
+		cassettePath = flag.String("cassette", "", "record or replay provider HTTP traffic with this cassette file")
+		cassetteMode = flag.String("cassette-mode", cassette.ModeReplay, "cassette mode: record|replay")
 
-		region    = flag.String("region", "ap-guangzhou", "TencentCloud region (dnspod only)")
-		secretID  = flag.String("secret-id", "", "TencentCloud secret id (empty: use env DNSPOD_SECRET_ID)")
-		secretKey = flag.String("secret-key", "", "TencentCloud secret key (empty: use env DNSPOD_SECRET_KEY)")
+		replace = flag.String("replace-target", "", "replace value/target in records, format: old=new (for --init)")
 
-		cfToken  = flag.String("cf-token", "", "Cloudflare API token (empty: use env CLOUDFLARE_API_TOKEN)")
-		cfZoneID = flag.String("cf-zone-id", "", "Cloudflare zone id (optional, empty: query by zone name)")
-		replace  = flag.String("replace-target", "", "replace value/target in records, format: old=new (for --init)")
+		overlays stringList
+		varPairs stringList
 	)
+	flag.Var(&overlays, "overlay", "config overlay merged on top of --config (repeatable)")
+	flag.Var(&varPairs, "var", "template variable override, format: key=value (repeatable)")
+	runnerOpts := registerRunnerFlags(flag.CommandLine, "if record exists, update it to match current config")
+	providerOpts := registerProviderFlags(flag.CommandLine)
+	telemetryOpts := registerTelemetryFlags(flag.CommandLine)
 
 	flag.Parse()
 
@@ -54,7 +69,19 @@ func main() {
 		return
 	}
 
-	cfg, err := config.LoadFile(*configPath)
+	vars, err := parseVars(varPairs)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+
+	runnerOptions, err := runnerOpts.options()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+
+	cfg, err := config.LoadWithOptions(*configPath, config.LoadOptions{Overlays: overlays, Vars: vars})
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err.Error())
 		os.Exit(1)
@@ -69,78 +96,67 @@ func main() {
 		os.Exit(1)
 	}
 
-	plan, err := config.BuildPlan(resolvedDomain, *line, cfg.Records)
+	plan, err := config.BuildPlanFromFile(resolvedDomain, *line, cfg)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err.Error())
 		os.Exit(1)
 	}
 
 	if *dryRun {
+		app.PrintVars(os.Stdout, cfg.Vars)
 		app.PrintPlan(os.Stdout, plan)
 		return
 	}
 
-	var client provider.Client
-	switch strings.ToLower(strings.TrimSpace(*providerName)) {
-	case "dnspod":
-		resolvedSecretID := *secretID
-		if resolvedSecretID == "" {
-			resolvedSecretID = os.Getenv("DNSPOD_SECRET_ID")
-		}
-		resolvedSecretKey := *secretKey
-		if resolvedSecretKey == "" {
-			resolvedSecretKey = os.Getenv("DNSPOD_SECRET_KEY")
-		}
-		if resolvedSecretID == "" || resolvedSecretKey == "" {
-			fmt.Fprintln(os.Stderr, "missing credentials: set DNSPOD_SECRET_ID and DNSPOD_SECRET_KEY (or pass flags)")
-			os.Exit(1)
-		}
-		client, err = dnspodclient.New(dnspodclient.NewOptions{
-			SecretID:  resolvedSecretID,
-			SecretKey: resolvedSecretKey,
-			Region:    *region,
-		})
-		if err != nil {
-			fmt.Fprintln(os.Stderr, err.Error())
-			os.Exit(1)
-		}
-	case "cloudflare":
-		token := strings.TrimSpace(*cfToken)
-		if token == "" {
-			token = strings.TrimSpace(os.Getenv("CLOUDFLARE_API_TOKEN"))
-		}
-		client, err = cloudflareclient.New(cloudflareclient.NewOptions{
-			APIToken: token,
-			ZoneID:   strings.TrimSpace(*cfZoneID),
-			ZoneName: resolvedDomain,
-		})
+	var (
+		transport http.RoundTripper
+		tape      *cassette.Transport
+	)
+	if *cassettePath != "" {
+		tape, err = cassette.Open(*cassettePath, *cassetteMode)
 		if err != nil {
 			fmt.Fprintln(os.Stderr, err.Error())
 			os.Exit(1)
 		}
-	default:
-		fmt.Fprintln(os.Stderr, "unsupported provider: "+*providerName)
+		transport = tape
+	}
+
+	client, err := providerOpts.newClient(*providerName, resolvedDomain, transport)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
 		os.Exit(1)
 	}
 
-	plan, err = validateOrFilterPlan(plan, client, *skipUnsup)
+	plan, err = validateOrFilterPlan(plan, client.Capabilities(), *skipUnsup, os.Stderr)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err.Error())
 		os.Exit(1)
 	}
 
-	runner := app.NewRunner(client, app.RunnerOptions{
-		SleepBetween: *sleep,
-		Retries:      *retries,
-		Upsert:       *upsert,
-	})
+	flushTraces, err := telemetryOpts.setup()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+
+	runnerOptions.OnRetry = func(operation string, err error) {
+		providerMetrics.Retry(*providerName, operation)
+	}
+	runner := app.NewRunner(client, runnerOptions)
 
 	ctx := context.Background()
-	if err := runner.Apply(ctx, plan); err != nil {
+	err = runner.Apply(ctx, plan)
+	flushTraces()
+	if err != nil {
 		if strings.Contains(err.Error(), "dial tcp") || strings.Contains(err.Error(), "lookup") {
 			fmt.Fprintln(os.Stderr, "\n[Network Error] Connection failed. Please check your network settings or set HTTP_PROXY/HTTPS_PROXY environment variables.")
 		}
 		fmt.Fprintln(os.Stderr, err.Error())
 		os.Exit(1)
 	}
+	if tape != nil && *cassetteMode != cassette.ModeRecord {
+		if n := tape.Remaining(); n > 0 {
+			fmt.Fprintf(os.Stderr, "cassette: %d recorded interactions were not replayed\n", n)
+		}
+	}
 }
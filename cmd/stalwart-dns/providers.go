@@ -0,0 +1,131 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	"ddnsjx/internal/provider"
+	"ddnsjx/internal/telemetry"
+
+	// Provider implementations register themselves with provider.Register.
+	_ "ddnsjx/internal/cloudflareclient"
+	_ "ddnsjx/internal/desecclient"
+	_ "ddnsjx/internal/digitaloceanclient"
+	_ "ddnsjx/internal/dnspodclient"
+	_ "ddnsjx/internal/edgeoneclient"
+	_ "ddnsjx/internal/gandiclient"
+	_ "ddnsjx/internal/hetznerclient"
+	_ "ddnsjx/internal/huaweiclient"
+	_ "ddnsjx/internal/powerdnsclient"
+	_ "ddnsjx/internal/route53client"
+)
+
+// providerFlags holds the CLI values of every registered provider setting,
+// keyed by flag name.
+type providerFlags map[string]*string
+
+// registerProviderFlags adds one flag per provider setting to fs. Defaults
+// are applied by provider.Factory.ResolveSettings, after the env lookup.
+func registerProviderFlags(fs *flag.FlagSet) providerFlags {
+	pf := make(providerFlags)
+	for _, f := range provider.Factories() {
+		for _, s := range f.Settings {
+			if s.Flag == "" {
+				continue
+			}
+			// A shared flag would only list the first provider's env
+			// variable in its usage.
+			if _, ok := pf[s.Flag]; ok {
+				panic(fmt.Sprintf("provider %s: flag --%s is already registered", f.Name, s.Flag))
+			}
+			pf[s.Flag] = fs.String(s.Flag, "", settingUsage(f.Name, s))
+		}
+	}
+	return pf
+}
+
+func settingUsage(providerName string, s provider.Setting) string {
+	usage := fmt.Sprintf("%s (%s", s.Help, providerName)
+	if s.Env != "" {
+		usage += ", env " + s.Env
+	}
+	if s.Default != "" {
+		usage += ", default " + s.Default
+	}
+	return usage + ")"
+}
+
+func (pf providerFlags) values() map[string]string {
+	out := make(map[string]string, len(pf))
+	for k, v := range pf {
+		out[k] = *v
+	}
+	return out
+}
+
+// newClient resolves the named provider and builds a client for zone,
+// instrumented into providerMetrics. A nil transport keeps the provider's
+// default.
+func (pf providerFlags) newClient(name, zone string, transport http.RoundTripper) (provider.Client, error) {
+	f, ok := provider.Lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("unsupported provider: %s (available: %s)", name, strings.Join(provider.Names(), ", "))
+	}
+	values := pf.values()
+	for _, flag := range f.SecretFlags(values) {
+		if _, warned := warnedFlags.LoadOrStore(flag, true); !warned {
+			fmt.Fprintf(os.Stderr, "warning: --%s is visible in the process list; prefer its environment variable\n", flag)
+		}
+	}
+	settings, err := f.ResolveSettings(values, os.Getenv)
+	if err != nil {
+		return nil, err
+	}
+	client, err := f.New(zone, settings, transport)
+	if err != nil {
+		return nil, errors.New(f.Redact(settings, err.Error()))
+	}
+	return telemetry.Instrument(client, name, providerMetrics), nil
+}
+
+// warnedFlags keeps the secret flag warning to once per flag, since serve
+// and ptr build a client per zone.
+var warnedFlags sync.Map
+
+func runProviders(args []string) int {
+	fs := flag.NewFlagSet("stalwart-dns providers", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	for _, f := range provider.Factories() {
+		fmt.Fprintf(os.Stdout, "%s\t%s\n", f.Name, f.Help)
+		for _, s := range f.Settings {
+			var attrs []string
+			if s.Env != "" {
+				attrs = append(attrs, "env "+s.Env)
+			}
+			if s.Default != "" {
+				attrs = append(attrs, "default "+s.Default)
+			}
+			if s.Required {
+				attrs = append(attrs, "required")
+			}
+			if s.Secret {
+				attrs = append(attrs, "secret")
+			}
+			fmt.Fprintf(os.Stdout, "  --%-18s %s", s.Flag, s.Help)
+			if len(attrs) > 0 {
+				fmt.Fprintf(os.Stdout, " [%s]", strings.Join(attrs, ", "))
+			}
+			fmt.Fprintln(os.Stdout)
+		}
+	}
+	return 0
+}
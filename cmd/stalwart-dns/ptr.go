@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"sort"
+	"strings"
+
+	"ddnsjx/internal/app"
+	"ddnsjx/internal/config"
+	"ddnsjx/internal/reverse"
+)
+
+// runPTR plans PTR records in the reverse zones for the A/AAAA records of
+// the config (or explicit --ip addresses), or with --check verifies that
+// forward and reverse DNS agree.
+func runPTR(args []string) int {
+	fs := flag.NewFlagSet("stalwart-dns ptr", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	var (
+		providerName = fs.String("provider", "dnspod", "dns provider hosting the reverse zone(s) (see: stalwart-dns providers)")
+		configPath   = fs.String("config", "config.json", "path to records config (.json/.yaml/.toml)")
+		domain       = fs.String("domain", "", "forward domain/zone name (empty: infer from config)")
+		line         = fs.String("record-line", "默认", "DNSPod record line (ignored by cloudflare)")
+		ttl          = fs.Uint64("ttl", 0, "PTR record TTL (0: provider default)")
+		dryRun       = fs.Bool("dry-run", false, "print planned PTR records without calling provider API")
+		check        = fs.Bool("check", false, "resolve forward and reverse DNS and report mismatches instead of writing records")
+
+		hosts    stringList
+		ips      stringList
+		prefixes stringList
+		overlays stringList
+		varPairs stringList
+	)
+	fs.Var(&hosts, "host", "only use A/AAAA records of this name (repeatable); with --ip, the PTR target")
+	fs.Var(&ips, "ip", "address to map to --host instead of reading the config (repeatable)")
+	fs.Var(&prefixes, "prefix", "delegated reverse prefix, e.g. 192.0.2.64/26 or 2001:db8::/48 (repeatable; default /24 or /48)")
+	fs.Var(&overlays, "overlay", "config overlay merged on top of --config (repeatable)")
+	fs.Var(&varPairs, "var", "template variable override, format: key=value (repeatable)")
+	runnerOpts := registerRunnerFlags(fs, "if record exists, update it to match the plan")
+	providerOpts := registerProviderFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	runnerOptions, err := runnerOpts.options()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 2
+	}
+
+	var zones []netip.Prefix
+	for _, p := range prefixes {
+		prefix, err := netip.ParsePrefix(strings.TrimSpace(p))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid --prefix %q: %v\n", p, err)
+			return 2
+		}
+		if _, err := reverse.Zone(prefix); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			return 2
+		}
+		zones = append(zones, prefix.Masked())
+	}
+
+	mappings, err := ptrMappings(ips, hosts, *configPath, *domain, overlays, varPairs)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	if len(mappings) == 0 {
+		fmt.Fprintln(os.Stderr, "no A/AAAA records to map (check --host or pass --ip)")
+		return 1
+	}
+
+	ctx := context.Background()
+	if *check {
+		problems := reverse.Check(ctx, net.DefaultResolver, mappings)
+		for _, p := range problems {
+			fmt.Fprintln(os.Stdout, p)
+		}
+		if len(problems) > 0 {
+			return 1
+		}
+		fmt.Fprintln(os.Stdout, "ok")
+		return 0
+	}
+
+	groups, err := reverse.Group(mappings, zones)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	keys := make([]netip.Prefix, 0, len(groups))
+	for k := range groups {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Addr().Less(keys[j].Addr()) })
+
+	var recordTTL *uint64
+	if *ttl > 0 {
+		recordTTL = ttl
+	}
+	for _, prefix := range keys {
+		plan, err := reverse.BuildPlan(prefix, groups[prefix], recordTTL)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			return 1
+		}
+		plan.RecordLine = *line
+
+		if *dryRun {
+			app.PrintPlan(os.Stdout, plan)
+			printDelegation(prefix, groups[prefix])
+			fmt.Fprintln(os.Stdout)
+			continue
+		}
+
+		client, err := providerOpts.newClient(*providerName, plan.Domain, nil)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			return 1
+		}
+		plan, err = validateOrFilterPlan(plan, client.Capabilities(), false, os.Stderr)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			return 1
+		}
+		runner := app.NewRunner(client, runnerOptions)
+		if err := runner.Apply(ctx, plan); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			return 1
+		}
+	}
+	return 0
+}
+
+// ptrMappings returns --ip addresses mapped to the single --host, or the
+// A/AAAA records of the config filtered by --host.
+func ptrMappings(ips, hosts []string, configPath, domain string, overlays, varPairs []string) ([]reverse.Mapping, error) {
+	if len(ips) > 0 {
+		if len(hosts) != 1 {
+			return nil, fmt.Errorf("--ip needs exactly one --host")
+		}
+		var out []reverse.Mapping
+		for _, s := range ips {
+			ip, err := netip.ParseAddr(strings.TrimSpace(s))
+			if err != nil {
+				return nil, fmt.Errorf("invalid --ip %q: %v", s, err)
+			}
+			out = append(out, reverse.Mapping{IP: ip, Host: hosts[0]})
+		}
+		return out, nil
+	}
+
+	vars, err := parseVars(varPairs)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := config.LoadWithOptions(configPath, config.LoadOptions{Overlays: overlays, Vars: vars})
+	if err != nil {
+		return nil, err
+	}
+	if domain == "" {
+		domain = config.InferDomain(cfg.Records)
+	}
+	if domain == "" {
+		return nil, fmt.Errorf("domain is required (flag --domain) or inferable from config")
+	}
+	plan, err := config.BuildPlanFromFile(domain, "", cfg)
+	if err != nil {
+		return nil, err
+	}
+	return reverse.FromPlan(plan, hosts...)
+}
+
+// printDelegation shows the RFC 2317 CNAMEs the parent /24 zone (usually
+// run by the ISP) must publish for a classless reverse zone.
+func printDelegation(prefix netip.Prefix, mappings []reverse.Mapping) {
+	ips := make([]netip.Addr, len(mappings))
+	for i, m := range mappings {
+		ips[i] = m.IP
+	}
+	records, err := reverse.Delegation(prefix, ips)
+	if err != nil || len(records) == 0 {
+		return
+	}
+	parent, _ := prefix.Addr().Prefix(24)
+	zone, _ := reverse.Zone(parent)
+	fmt.Fprintf(os.Stdout, "Delegation (publish in %s):\n", zone)
+	for _, r := range records {
+		fmt.Fprintf(os.Stdout, "%s\tCNAME\t%s.\n", r.SubDomain, r.Value)
+	}
+}
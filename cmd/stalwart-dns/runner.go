@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"ddnsjx/internal/app"
+)
+
+// runnerFlags are the apply settings shared by the main command, ptr and
+// serve.
+type runnerFlags struct {
+	upsert    *bool
+	ownerID   *string
+	ownership *string
+	adopt     *bool
+	noBatch   *bool
+	sleep     *time.Duration
+	retries   *int
+}
+
+// registerRunnerFlags adds the runner flags to fs; upsertUsage describes
+// --upsert for the command at hand.
+func registerRunnerFlags(fs *flag.FlagSet, upsertUsage string) runnerFlags {
+	return runnerFlags{
+		upsert:    fs.Bool("upsert", false, upsertUsage),
+		ownerID:   fs.String("owner-id", "default", "ownership marker written to managed records"),
+		ownership: fs.String("ownership", "auto", "ownership registry: auto|native|txt|off"),
+		adopt:     fs.Bool("adopt", false, "allow upserts to take over records not owned by --owner-id"),
+		noBatch:   fs.Bool("no-batch", false, "use per-record API calls even if the provider supports atomic batches"),
+		sleep:     fs.Duration("sleep", 150*time.Millisecond, "sleep between requests"),
+		retries:   fs.Int("retries", 3, "max retries for transient errors"),
+	}
+}
+
+// options validates the flags and returns them as runner options.
+func (rf runnerFlags) options() (app.RunnerOptions, error) {
+	switch strings.ToLower(strings.TrimSpace(*rf.ownership)) {
+	case app.OwnershipAuto, app.OwnershipNative, app.OwnershipTXT, app.OwnershipOff:
+	default:
+		return app.RunnerOptions{}, fmt.Errorf("invalid --ownership: %s (expected auto|native|txt|off)", *rf.ownership)
+	}
+	return app.RunnerOptions{
+		SleepBetween: *rf.sleep,
+		Retries:      *rf.retries,
+		Upsert:       *rf.upsert,
+		OwnerID:      *rf.ownerID,
+		Ownership:    *rf.ownership,
+		Adopt:        *rf.adopt,
+		DisableBatch: *rf.noBatch,
+	}, nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"ddnsjx/internal/config"
+	"ddnsjx/internal/dns"
+	"ddnsjx/internal/provider"
+	"ddnsjx/internal/server"
+)
+
+// runServe exposes plan, diff and apply over HTTP (see internal/server).
+func runServe(args []string) int {
+	fs := flag.NewFlagSet("stalwart-dns serve", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	var (
+		listen       = fs.String("listen", "127.0.0.1:8053", "address to listen on")
+		token        = fs.String("token", "", "bearer token required on /v1 requests (env STALWART_DNS_TOKEN)")
+		providerName = fs.String("provider", "dnspod", "default dns provider (see: stalwart-dns providers)")
+		configPath   = fs.String("config", "", "default config template for requests without their own config")
+		line         = fs.String("record-line", "默认", "DNSPod record line (ignored by cloudflare)")
+		skipUnsup    = fs.Bool("skip-unsupported", false, "skip unsupported record types instead of failing")
+	)
+	runnerOpts := registerRunnerFlags(fs, "update existing records for every request (requests may also ask for it)")
+	providerOpts := registerProviderFlags(fs)
+	telemetryOpts := registerTelemetryFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "token" {
+			fmt.Fprintln(os.Stderr, "warning: --token is visible in the process list; prefer STALWART_DNS_TOKEN")
+		}
+	})
+	if *token == "" {
+		*token = os.Getenv("STALWART_DNS_TOKEN")
+	}
+	runnerOptions, err := runnerOpts.options()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 2
+	}
+
+	var tpl *config.FileConfig
+	if *configPath != "" {
+		cfg, err := config.Read(*configPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			return 1
+		}
+		tpl = &cfg
+	}
+
+	flushTraces, err := telemetryOpts.setup()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	defer flushTraces()
+
+	srv, err := server.New(server.Options{
+		Token:    *token,
+		Config:   tpl,
+		Provider: *providerName,
+		NewClient: func(name, zone string) (provider.Client, error) {
+			return providerOpts.newClient(name, zone, nil)
+		},
+		FitPlan: func(plan dns.Plan, caps provider.Capabilities, warn io.Writer) (dns.Plan, error) {
+			return validateOrFilterPlan(plan, caps, *skipUnsup, warn)
+		},
+		Runner:     runnerOptions,
+		RecordLine: *line,
+		Metrics:    providerMetrics,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error()+" (flag --token or STALWART_DNS_TOKEN)")
+		return 2
+	}
+
+	httpServer := &http.Server{Addr: *listen, Handler: srv, ReadHeaderTimeout: 10 * time.Second}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = httpServer.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("listening on %s", *listen)
+	if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	log.Printf("waiting for running jobs")
+	jobsCtx, cancel := context.WithTimeout(context.Background(), jobShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(jobsCtx); err != nil {
+		log.Printf("cancelled unfinished jobs after %s", jobShutdownTimeout)
+	}
+	return 0
+}
+
+// jobShutdownTimeout bounds how long serve waits for jobs on exit before
+// cancelling them.
+const jobShutdownTimeout = time.Minute
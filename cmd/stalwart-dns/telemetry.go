@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"ddnsjx/internal/telemetry"
+)
+
+// providerMetrics collects the calls of every client built by newClient.
+var providerMetrics = telemetry.NewMetrics()
+
+type telemetryFlags struct {
+	otlpEndpoint *string
+	serviceName  *string
+}
+
+func registerTelemetryFlags(fs *flag.FlagSet) telemetryFlags {
+	return telemetryFlags{
+		otlpEndpoint: fs.String("otlp-endpoint", "", "export traces to this OTLP/HTTP collector, e.g. http://localhost:4318 (env OTEL_EXPORTER_OTLP_ENDPOINT)"),
+		serviceName:  fs.String("otlp-service-name", "", "service.name of exported traces (env OTEL_SERVICE_NAME, default stalwart-dns)"),
+	}
+}
+
+// setup installs the OTLP exporter when an endpoint is configured. The
+// returned function flushes pending spans and must run before exit.
+func (tf telemetryFlags) setup() (func(), error) {
+	endpoint := *tf.otlpEndpoint
+	if endpoint == "" {
+		endpoint = os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+	}
+	if endpoint == "" {
+		endpoint = os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+	}
+	if endpoint == "" {
+		return func() {}, nil
+	}
+	headers, err := telemetry.ParseHeaders(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"))
+	if err != nil {
+		return nil, err
+	}
+	service := *tf.serviceName
+	if service == "" {
+		service = os.Getenv("OTEL_SERVICE_NAME")
+	}
+	if service == "" {
+		service = "stalwart-dns"
+	}
+
+	exp := telemetry.NewOTLPExporter(endpoint, service, headers)
+	telemetry.SetExporter(exp)
+	return func() {
+		telemetry.SetExporter(nil)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := exp.Shutdown(ctx); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+		}
+	}, nil
+}
+
+// serveMetrics exposes providerMetrics on addr in the background.
+func serveMetrics(addr string) {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", providerMetrics.Handler())
+	srv := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil {
+			fmt.Fprintln(os.Stderr, "metrics: "+err.Error())
+		}
+	}()
+}
@@ -1,30 +1,44 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"io"
+	"os"
 	"sort"
 	"strings"
 
+	"ddnsjx/internal/config"
 	"ddnsjx/internal/dns"
 	"ddnsjx/internal/provider"
 )
 
-func validateOrFilterPlan(plan dns.Plan, client provider.Client, skipUnsupported bool) (dns.Plan, error) {
+// validateOrFilterPlan checks the whole plan against the provider's
+// capabilities before any API call. Unsupported record types are dropped
+// with --skip-unsupported, settings the provider cannot store are dropped
+// with a warning on warn, and every other mismatch is an error.
+func validateOrFilterPlan(plan dns.Plan, caps provider.Capabilities, skipUnsupported bool, warn io.Writer) (dns.Plan, error) {
 	var (
 		filtered    []dns.Record
 		unsupported = make(map[string]struct{}, 4)
+		problems    []string
+		dropped     = make(map[string]int)
 	)
 
 	for _, r := range plan.Records {
 		t := strings.ToUpper(strings.TrimSpace(r.Type))
-		if client.IsSupportedRecordType(t) {
-			filtered = append(filtered, r)
+		if !caps.SupportsType(t) {
+			unsupported[t] = struct{}{}
 			continue
 		}
-		unsupported[t] = struct{}{}
-		if skipUnsupported {
-			continue
+		for _, p := range caps.CheckRecord(r) {
+			problems = append(problems, fmt.Sprintf("%s %s: %s", t, r.SubDomain, p))
+		}
+		r, keys := caps.Strip(r)
+		for _, k := range keys {
+			dropped[k]++
 		}
+		filtered = append(filtered, r)
 	}
 
 	if len(unsupported) > 0 && !skipUnsupported {
@@ -33,9 +47,92 @@ func validateOrFilterPlan(plan dns.Plan, client provider.Client, skipUnsupported
 			types = append(types, t)
 		}
 		sort.Strings(types)
-		return dns.Plan{}, fmt.Errorf("unsupported record type(s) for provider: %s (use --skip-unsupported to ignore them)", strings.Join(types, ", "))
+		problems = append([]string{fmt.Sprintf("unsupported record type(s) for provider: %s (use --skip-unsupported to ignore them)", strings.Join(types, ", "))}, problems...)
+	}
+	if len(problems) > 0 {
+		return dns.Plan{}, fmt.Errorf("plan does not fit provider:\n  %s", strings.Join(problems, "\n  "))
+	}
+
+	keys := make([]string, 0, len(dropped))
+	for k := range dropped {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(warn, "warning: provider ignores %q, dropped from %d record(s)\n", k, dropped[k])
 	}
 
 	plan.Records = filtered
 	return plan, nil
 }
+
+func runValidate(args []string) int {
+	fs := flag.NewFlagSet("stalwart-dns validate", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+
+	var (
+		configPath  = fs.String("config", "config.json", "path to records config (.json/.yaml/.toml)")
+		domain      = fs.String("domain", "", "domain/zone name (empty: infer from config)")
+		printSchema = fs.Bool("print-schema", false, "print the config JSON Schema and exit")
+
+		overlays stringList
+		varPairs stringList
+	)
+	fs.Var(&overlays, "overlay", "config overlay merged on top of --config (repeatable)")
+	fs.Var(&varPairs, "var", "template variable override, format: key=value (repeatable)")
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if *printSchema {
+		_, _ = os.Stdout.Write(config.Schema())
+		return 0
+	}
+
+	var failed bool
+	for _, p := range append([]string{*configPath}, overlays...) {
+		errs, err := config.ValidateFile(p)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", p, err.Error())
+			failed = true
+			continue
+		}
+		for _, e := range errs {
+			if e.Line > 0 {
+				fmt.Fprintf(os.Stderr, "%s:%d:%d: %s: %s\n", p, e.Line, e.Column, e.Path, e.Message)
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "%s: %s: %s\n", p, e.Path, e.Message)
+		}
+		if len(errs) > 0 {
+			failed = true
+		}
+	}
+	if failed {
+		return 1
+	}
+
+	vars, err := parseVars(varPairs)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 2
+	}
+	cfg, err := config.LoadWithOptions(*configPath, config.LoadOptions{Overlays: overlays, Vars: vars})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+
+	resolvedDomain := strings.TrimSuffix(strings.TrimSpace(*domain), ".")
+	if resolvedDomain == "" {
+		resolvedDomain = config.InferDomain(cfg.Records)
+	}
+	if _, err := config.BuildPlanFromFile(resolvedDomain, "", cfg); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+
+	fmt.Fprintf(os.Stdout, "ok: %d records\n", len(cfg.Records))
+	return 0
+}
@@ -4,4 +4,9 @@ go 1.25.4
 
 require github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/dnspod v1.3.24
 
-require github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common v1.3.24 // indirect
+require (
+	github.com/BurntSushi/toml v1.6.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common v1.3.24
@@ -0,0 +1,193 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"ddnsjx/internal/dns"
+	"ddnsjx/internal/provider"
+)
+
+// applyBatch resolves every record up front and submits the resulting
+// changeset in one provider transaction, so no rollback is needed.
+func (r *Runner) applyBatch(ctx context.Context, b provider.Batcher, plan dns.Plan) error {
+	fmt.Fprintf(r.opt.Out, "Plan: domain=%s records=%d (batch)\n", plan.Domain, len(plan.Records))
+	fmt.Fprintln(r.opt.Out, strings.Repeat("-", 72))
+
+	type entry struct {
+		rec    dns.Record
+		action string
+		id     string
+		change int
+	}
+
+	var (
+		entries []entry
+		changes []provider.Change
+		claimed = make(map[string]struct{})
+	)
+
+	for _, rec := range plan.Records {
+		if r.ownershipEnabled() && r.opt.Ownership == OwnershipNative {
+			rec.Owner = r.opt.OwnerID
+		}
+
+		recCtx, recSpan := startRecordSpan(ctx, rec)
+		e := entry{rec: rec, change: -1}
+		err := func() error {
+			var (
+				existingID string
+				found      bool
+			)
+			err := r.withRetry(recCtx, "find", func() error {
+				var err error
+				existingID, found, err = r.client.FindRecord(recCtx, plan.Domain, plan.RecordLine, rec)
+				return err
+			})
+			if err != nil {
+				return err
+			}
+
+			switch {
+			case !found:
+				e.action = "created"
+			case !r.opt.Upsert:
+				e.action = "exists"
+				return nil
+			default:
+				if err := r.withRetry(recCtx, "find", func() error {
+					return r.checkOwned(recCtx, plan.Domain, plan.RecordLine, rec, existingID)
+				}); err != nil {
+					return err
+				}
+				same, err := r.upToDate(recCtx, plan.Domain, existingID, rec)
+				if err != nil {
+					return err
+				}
+				e.id = existingID
+				if same {
+					e.action = "unchanged"
+					return nil
+				}
+				e.action = "updated"
+			}
+			return nil
+		}()
+		recSpan.SetAttr("dns.action", e.action)
+		recSpan.End(err)
+		if err != nil {
+			fmt.Fprintf(r.opt.Out, "%s ... failed: %s\n", recordPrefix(rec), err.Error())
+			return err
+		}
+		switch e.action {
+		case "created":
+			e.change = len(changes)
+			changes = append(changes, provider.Change{Action: provider.ChangeCreate, Record: rec})
+		case "updated":
+			e.change = len(changes)
+			changes = append(changes, provider.Change{Action: provider.ChangeUpdate, RecordID: e.id, Record: rec})
+		}
+		entries = append(entries, e)
+
+		if e.change < 0 || !r.ownershipEnabled() || r.opt.Ownership != OwnershipTXT {
+			continue
+		}
+		reg := r.registryRecord(rec)
+		if _, ok := claimed[reg.SubDomain]; ok {
+			continue
+		}
+		claimed[reg.SubDomain] = struct{}{}
+		var regFound bool
+		err = r.withRetry(recCtx, "find", func() error {
+			var err error
+			_, regFound, err = r.client.FindRecord(recCtx, plan.Domain, plan.RecordLine, reg)
+			return err
+		})
+		if err != nil {
+			fmt.Fprintf(r.opt.Out, "%s ... failed: %s\n", recordPrefix(rec), err.Error())
+			return err
+		}
+		if !regFound {
+			changes = append(changes, provider.Change{Action: provider.ChangeCreate, Record: reg})
+		}
+	}
+
+	var results []provider.ChangeResult
+	if len(changes) > 0 {
+		fmt.Fprintf(r.opt.Out, "submitting %d changes ... ", len(changes))
+		err := r.withRetry(ctx, "batch", func() error {
+			var err error
+			results, err = b.ApplyBatch(ctx, plan.Domain, plan.RecordLine, changes)
+			return err
+		})
+		if err != nil {
+			fmt.Fprintf(r.opt.Out, "failed: %s\n", err.Error())
+			fmt.Fprintln(r.opt.Out, "batch rejected, no changes were applied")
+			return err
+		}
+		fmt.Fprintln(r.opt.Out, "OK")
+	}
+
+	for _, e := range entries {
+		id := e.id
+		if e.change >= 0 && e.change < len(results) && results[e.change].RecordID != "" {
+			id = results[e.change].RecordID
+		}
+		fmt.Fprintf(r.opt.Out, "%s ... ", recordPrefix(e.rec))
+		switch e.action {
+		case "created":
+			fmt.Fprintf(r.opt.Out, "OK (ID: %s)\n", id)
+		case "updated":
+			fmt.Fprintf(r.opt.Out, "updated (ID: %s)\n", id)
+		case "unchanged":
+			fmt.Fprintf(r.opt.Out, "unchanged (ID: %s)\n", id)
+		default:
+			fmt.Fprintln(r.opt.Out, "exists (skip)")
+		}
+	}
+
+	fmt.Fprintln(r.opt.Out, strings.Repeat("-", 72))
+	if len(changes) > 0 {
+		if err := r.finalize(ctx, plan.Domain); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintln(r.opt.Out, "done")
+	return nil
+}
+
+// upToDate reports whether the existing record recordID already matches
+// rec, so an upsert can leave it alone. Clients without
+// provider.RecordMatcher always report false.
+func (r *Runner) upToDate(ctx context.Context, domain, recordID string, rec dns.Record) (bool, error) {
+	m, ok := provider.As[provider.RecordMatcher](r.client)
+	if !ok {
+		return false, nil
+	}
+	var same bool
+	err := r.withRetry(ctx, "match", func() error {
+		var err error
+		same, err = m.RecordUpToDate(ctx, domain, recordID, rec)
+		return err
+	})
+	return same, err
+}
+
+// withRetry runs fn, retrying errors that report themselves retryable.
+// operation names the call for RunnerOptions.OnRetry.
+func (r *Runner) withRetry(ctx context.Context, operation string, fn func() error) error {
+	var err error
+	for i := 0; i <= r.opt.Retries; i++ {
+		if err = fn(); err == nil {
+			return nil
+		}
+		backoff, ok := retryDelay(err, i)
+		if !ok || i == r.opt.Retries {
+			return err
+		}
+		r.retrying(operation, err)
+		_ = sleepWithContext(ctx, backoff)
+	}
+	return err
+}
@@ -0,0 +1,58 @@
+package app
+
+import (
+	"context"
+
+	"ddnsjx/internal/dns"
+)
+
+// DiffEntry is what Apply would do with one planned record.
+type DiffEntry struct {
+	Action   string `json:"action"` // create, update or skip
+	Type     string `json:"type"`
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	RecordID string `json:"record_id,omitempty"`
+}
+
+// Diff looks up every planned record without changing anything. Records
+// that are not found would be created; found records are updated with
+// Upsert and skipped otherwise. Clients without provider.RecordMatcher
+// cannot compare TTL and other settings, so "update" may rewrite a record
+// that already matches.
+func (r *Runner) Diff(ctx context.Context, plan dns.Plan) ([]DiffEntry, error) {
+	if err := r.checkProtected(plan); err != nil {
+		return nil, err
+	}
+
+	out := make([]DiffEntry, 0, len(plan.Records))
+	for _, rec := range plan.Records {
+		var (
+			id    string
+			found bool
+		)
+		err := r.withRetry(ctx, "find", func() error {
+			var err error
+			id, found, err = r.client.FindRecord(ctx, plan.Domain, plan.RecordLine, rec)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		e := DiffEntry{Action: "create", Type: rec.Type, Name: rec.SubDomain, Value: rec.Value}
+		if found {
+			e.Action, e.RecordID = "skip", id
+			if r.opt.Upsert {
+				same, err := r.upToDate(ctx, plan.Domain, id, rec)
+				if err != nil {
+					return nil, err
+				}
+				if !same {
+					e.Action = "update"
+				}
+			}
+		}
+		out = append(out, e)
+	}
+	return out, nil
+}
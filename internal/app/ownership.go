@@ -0,0 +1,147 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"ddnsjx/internal/dns"
+	"ddnsjx/internal/provider"
+)
+
+const (
+	OwnershipOff    = "off"
+	OwnershipAuto   = "auto"
+	OwnershipNative = "native"
+	OwnershipTXT    = "txt"
+)
+
+// NotOwnedError is returned when an existing record carries no ownership
+// marker for this runner's owner and adoption was not requested.
+type NotOwnedError struct {
+	Type      string
+	SubDomain string
+	Owner     string
+}
+
+func (e NotOwnedError) Error() string {
+	return fmt.Sprintf("refusing to modify %s %s: not owned by stalwart-dns owner=%s (use --adopt to take it over)", e.Type, e.SubDomain, e.Owner)
+}
+
+// ProtectedError is returned before any API call when a plan touches a
+// name listed in dns.Plan.Protected.
+type ProtectedError struct {
+	Type      string
+	SubDomain string
+}
+
+func (e ProtectedError) Error() string {
+	return fmt.Sprintf("refusing to modify %s %s: name is protected", e.Type, e.SubDomain)
+}
+
+// resolveOwnership picks the registry for client. The TXT registry adds a
+// record per managed record, so it is only used when asked for by name;
+// auto and native turn ownership off on providers without OwnerReader.
+func resolveOwnership(client provider.Client, mode string) string {
+	mode = strings.ToLower(strings.TrimSpace(mode))
+	switch mode {
+	case "", OwnershipAuto, OwnershipNative:
+		if _, ok := provider.As[provider.OwnerReader](client); ok {
+			return OwnershipNative
+		}
+		return OwnershipOff
+	default:
+		return mode
+	}
+}
+
+func (r *Runner) ownershipEnabled() bool {
+	return r.opt.OwnerID != "" && r.opt.Ownership != OwnershipOff
+}
+
+// RegistryRecord is the companion TXT that marks rec as owned by ownerID
+// when the provider cannot store a marker natively, e.g. "_sdns-mx.mail".
+// A wildcard label is written as "_wildcard", since "*" is only valid as
+// the leftmost label: "*.dev" is registered at "_sdns-a._wildcard.dev".
+func RegistryRecord(ownerID string, rec dns.Record) dns.Record {
+	label := "_sdns-" + strings.ToLower(rec.Type)
+	sub := label
+	if rec.SubDomain != "" && rec.SubDomain != "@" {
+		name := rec.SubDomain
+		if rest, ok := strings.CutPrefix(name, "*"); ok && (rest == "" || rest[0] == '.') {
+			name = "_wildcard" + rest
+		}
+		sub = label + "." + name
+	}
+	return dns.Record{
+		SubDomain: sub,
+		Type:      "TXT",
+		Value:     "heritage=stalwart-dns,owner=" + ownerID,
+	}
+}
+
+func (r *Runner) registryRecord(rec dns.Record) dns.Record {
+	return RegistryRecord(r.opt.OwnerID, rec)
+}
+
+// checkProtected refuses plans that touch a protected name, including the
+// registry records the TXT registry would write next to them.
+func (r *Runner) checkProtected(plan dns.Plan) error {
+	for _, rec := range plan.Records {
+		if plan.IsProtected(rec.SubDomain) {
+			return ProtectedError{Type: rec.Type, SubDomain: rec.SubDomain}
+		}
+		if r.ownershipEnabled() && r.opt.Ownership == OwnershipTXT {
+			if reg := r.registryRecord(rec); plan.IsProtected(reg.SubDomain) {
+				return ProtectedError{Type: reg.Type, SubDomain: reg.SubDomain}
+			}
+		}
+	}
+	return nil
+}
+
+// checkOwned verifies that the existing record recordID belongs to this
+// runner's owner.
+func (r *Runner) checkOwned(ctx context.Context, domain, recordLine string, rec dns.Record, recordID string) error {
+	if !r.ownershipEnabled() || r.opt.Adopt {
+		return nil
+	}
+
+	if r.opt.Ownership == OwnershipNative {
+		reader, _ := provider.As[provider.OwnerReader](r.client)
+		owner, err := reader.RecordOwner(ctx, domain, recordID)
+		if err != nil {
+			return err
+		}
+		if owner == r.opt.OwnerID {
+			return nil
+		}
+		return NotOwnedError{Type: rec.Type, SubDomain: rec.SubDomain, Owner: r.opt.OwnerID}
+	}
+
+	_, found, err := r.client.FindRecord(ctx, domain, recordLine, r.registryRecord(rec))
+	if err != nil {
+		return err
+	}
+	if found {
+		return nil
+	}
+	return NotOwnedError{Type: rec.Type, SubDomain: rec.SubDomain, Owner: r.opt.OwnerID}
+}
+
+// claim writes the TXT registry record for rec. It returns the id of a
+// newly created registry record so it can be rolled back, or "" if the
+// marker already existed or is stored natively.
+func (r *Runner) claim(ctx context.Context, domain, recordLine string, rec dns.Record) (string, error) {
+	if !r.ownershipEnabled() || r.opt.Ownership != OwnershipTXT {
+		return "", nil
+	}
+	id, status, err := r.client.CreateRecord(ctx, domain, recordLine, r.registryRecord(rec))
+	if err != nil {
+		return "", fmt.Errorf("write ownership record for %s %s: %w", rec.Type, rec.SubDomain, err)
+	}
+	if status == provider.CreateStatusSuccess {
+		return id, nil
+	}
+	return "", nil
+}
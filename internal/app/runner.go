@@ -5,17 +5,39 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"ddnsjx/internal/dns"
 	"ddnsjx/internal/provider"
+	"ddnsjx/internal/telemetry"
 )
 
 type RunnerOptions struct {
 	SleepBetween time.Duration
 	Retries      int
 	Upsert       bool
+
+	// OwnerID tags records this runner creates; empty disables ownership
+	// checks. Ownership selects how the tag is stored (auto|native|txt|off)
+	// and Adopt allows taking over records that carry no tag.
+	OwnerID   string
+	Ownership string
+	Adopt     bool
+
+	// DisableBatch forces per-record calls even when the client implements
+	// provider.Batcher.
+	DisableBatch bool
+
+	// Out receives progress output; nil means os.Stdout.
+	Out io.Writer
+
+	// OnRetry, if set, is called before a provider call is retried after a
+	// transient error; operation is create, find, match, batch or finalize.
+	OnRetry func(operation string, err error)
 }
 
 type Runner struct {
@@ -30,54 +52,146 @@ func NewRunner(client provider.Client, opt RunnerOptions) *Runner {
 	if opt.SleepBetween < 0 {
 		opt.SleepBetween = 0
 	}
+	if opt.Out == nil {
+		opt.Out = os.Stdout
+	}
+	opt.OwnerID = strings.TrimSpace(opt.OwnerID)
+	opt.Ownership = resolveOwnership(client, opt.Ownership)
 	return &Runner{client: client, opt: opt}
 }
 
+func PrintVars(w io.Writer, vars map[string]string) {
+	if len(vars) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(vars))
+	for k := range vars {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	fmt.Fprintf(w, "Vars: %d\n", len(keys))
+	for _, k := range keys {
+		fmt.Fprintf(w, "  %s=%s\n", k, vars[k])
+	}
+}
+
 func PrintPlan(w io.Writer, plan dns.Plan) {
 	fmt.Fprintf(w, "Domain: %s\n", plan.Domain)
 	fmt.Fprintf(w, "RecordLine: %s\n", plan.RecordLine)
 	fmt.Fprintf(w, "Records: %d\n", len(plan.Records))
+	if len(plan.Protected) > 0 {
+		fmt.Fprintf(w, "Protected: %s\n", strings.Join(plan.Protected, ", "))
+	}
 	fmt.Fprintln(w, strings.Repeat("-", 72))
 	for _, r := range plan.Records {
+		fields := []string{r.Type, r.SubDomain}
 		if r.Priority != nil {
-			fmt.Fprintf(w, "%s\t%s\tprio=%d\t%s\t%s\n", r.Type, r.SubDomain, *r.Priority, r.Value, r.Remark)
-			continue
+			fields = append(fields, fmt.Sprintf("prio=%d", *r.Priority))
+		}
+		fields = append(fields, r.Value)
+		fields = append(fields, recordAttrs(r)...)
+		fields = append(fields, r.Remark)
+		fmt.Fprintln(w, strings.Join(fields, "\t"))
+	}
+}
+
+// recordAttrs renders the optional per-record settings shown by PrintPlan.
+func recordAttrs(r dns.Record) []string {
+	var attrs []string
+	if r.Line != "" {
+		attrs = append(attrs, "line="+r.Line)
+	}
+	if r.LineID != "" {
+		attrs = append(attrs, "line_id="+r.LineID)
+	}
+	if r.Weight != nil {
+		attrs = append(attrs, fmt.Sprintf("weight=%d", *r.Weight))
+	}
+	if r.Status != "" {
+		attrs = append(attrs, "status="+r.Status)
+	}
+	if cf := r.Cloudflare; cf != nil {
+		if cf.Proxied != nil {
+			attrs = append(attrs, fmt.Sprintf("proxied=%t", *cf.Proxied))
+		}
+		if cf.FlattenCNAME != nil {
+			attrs = append(attrs, fmt.Sprintf("flatten_cname=%t", *cf.FlattenCNAME))
+		}
+		if len(cf.Tags) > 0 {
+			attrs = append(attrs, "tags="+strings.Join(cf.Tags, ","))
+		}
+		if cf.Comment != nil {
+			attrs = append(attrs, "comment="+*cf.Comment)
 		}
-		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Type, r.SubDomain, r.Value, r.Remark)
 	}
+	return attrs
 }
 
+// Apply creates the plan's records, traced as a runner.apply span with one
+// runner.record span per record.
 func (r *Runner) Apply(ctx context.Context, plan dns.Plan) error {
+	ctx, span := telemetry.StartSpan(ctx, "runner.apply",
+		telemetry.String("dns.zone", plan.Domain), telemetry.String("dns.records", strconv.Itoa(len(plan.Records))))
+	err := r.apply(ctx, plan)
+	span.End(err)
+	return err
+}
+
+func (r *Runner) apply(ctx context.Context, plan dns.Plan) error {
 	var created []string
 
-	fmt.Printf("Plan: domain=%s records=%d\n", plan.Domain, len(plan.Records))
-	fmt.Println(strings.Repeat("-", 72))
+	if err := r.checkProtected(plan); err != nil {
+		return err
+	}
+
+	if b, ok := provider.As[provider.Batcher](r.client); ok && !r.opt.DisableBatch {
+		return r.applyBatch(ctx, b, plan)
+	}
+
+	fmt.Fprintf(r.opt.Out, "Plan: domain=%s records=%d\n", plan.Domain, len(plan.Records))
+	fmt.Fprintln(r.opt.Out, strings.Repeat("-", 72))
 
+	changed := false
 	for _, rec := range plan.Records {
-		prefix := fmt.Sprintf("[%s] %s", rec.Type, rec.SubDomain)
-		if len(prefix) < 30 {
-			prefix += strings.Repeat(" ", 30-len(prefix))
+		fmt.Fprintf(r.opt.Out, "%s ... ", recordPrefix(rec))
+
+		if r.ownershipEnabled() && r.opt.Ownership == OwnershipNative {
+			rec.Owner = r.opt.OwnerID
 		}
-		fmt.Printf("%s ... ", prefix)
 
-		id, action, err := r.applyOneWithRetry(ctx, plan.Domain, plan.RecordLine, rec)
+		recCtx, recSpan := startRecordSpan(ctx, rec)
+		id, action, err := r.applyOneWithRetry(recCtx, plan.Domain, plan.RecordLine, rec)
+		if err == nil && action == "created" {
+			created = append(created, id)
+		}
+		if err == nil && (action == "created" || action == "updated") {
+			var claimID string
+			claimID, err = r.claim(recCtx, plan.Domain, plan.RecordLine, rec)
+			if claimID != "" {
+				created = append(created, claimID)
+			}
+		}
+		recSpan.SetAttr("dns.action", action)
+		recSpan.End(err)
 		if err != nil {
-			fmt.Printf("failed: %s\n", err.Error())
-			fmt.Println("rollback...")
+			fmt.Fprintf(r.opt.Out, "failed: %s\n", err.Error())
+			fmt.Fprintln(r.opt.Out, "rollback...")
 			r.rollback(ctx, plan.Domain, created)
 			return err
 		}
 
+		if action == "created" || action == "updated" {
+			changed = true
+		}
 		switch action {
 		case "created":
-			fmt.Printf("OK (ID: %s)\n", id)
-			created = append(created, id)
+			fmt.Fprintf(r.opt.Out, "OK (ID: %s)\n", id)
 		case "exists":
-			fmt.Println("exists (skip)")
+			fmt.Fprintln(r.opt.Out, "exists (skip)")
 		case "updated":
-			fmt.Printf("updated (ID: %s)\n", id)
+			fmt.Fprintf(r.opt.Out, "updated (ID: %s)\n", id)
 		default:
-			fmt.Println("OK")
+			fmt.Fprintln(r.opt.Out, "OK")
 		}
 
 		if r.opt.SleepBetween > 0 {
@@ -85,11 +199,43 @@ func (r *Runner) Apply(ctx context.Context, plan dns.Plan) error {
 		}
 	}
 
-	fmt.Println(strings.Repeat("-", 72))
-	fmt.Println("done")
+	fmt.Fprintln(r.opt.Out, strings.Repeat("-", 72))
+	if changed {
+		if err := r.finalize(ctx, plan.Domain); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintln(r.opt.Out, "done")
+	return nil
+}
+
+// finalize runs the client's zone-wide step, if any, after records changed.
+// The changes themselves stay in place when it fails.
+func (r *Runner) finalize(ctx context.Context, domain string) error {
+	f, ok := provider.As[provider.Finalizer](r.client)
+	if !ok {
+		return nil
+	}
+	fmt.Fprintf(r.opt.Out, "finalizing %s ... ", domain)
+	if err := r.withRetry(ctx, "finalize", func() error { return f.Finalize(ctx, domain) }); err != nil {
+		fmt.Fprintf(r.opt.Out, "failed: %s\n", err.Error())
+		return fmt.Errorf("records applied but finalize failed: %w", err)
+	}
+	fmt.Fprintln(r.opt.Out, "OK")
 	return nil
 }
 
+func recordPrefix(rec dns.Record) string {
+	prefix := fmt.Sprintf("[%s] %s", rec.Type, rec.SubDomain)
+	if rec.Line != "" {
+		prefix += " (" + rec.Line + ")"
+	}
+	if len(prefix) < 30 {
+		prefix += strings.Repeat(" ", 30-len(prefix))
+	}
+	return prefix
+}
+
 func (r *Runner) applyOneWithRetry(ctx context.Context, domain, recordLine string, rec dns.Record) (recordID string, action string, err error) {
 	attempts := 1
 	if r.opt.Retries > 0 {
@@ -113,6 +259,9 @@ func (r *Runner) applyOneWithRetry(ctx context.Context, domain, recordLine strin
 			if !found || existingID == "" {
 				return "", "", fmt.Errorf("record exists but cannot locate record id for update: %s %s", rec.Type, rec.SubDomain)
 			}
+			if err := r.checkOwned(ctx, domain, recordLine, rec, existingID); err != nil {
+				return "", "", err
+			}
 			if updErr := r.client.UpdateRecord(ctx, domain, recordLine, existingID, rec); updErr != nil {
 				return "", "", updErr
 			}
@@ -121,27 +270,42 @@ func (r *Runner) applyOneWithRetry(ctx context.Context, domain, recordLine strin
 		if err == nil {
 			return "", "", fmt.Errorf("unexpected create status: %s", status)
 		}
-		if !isRetryable(err) {
+		backoff, ok := retryDelay(err, i)
+		if !ok {
 			return "", "", err
 		}
 		lastErr = err
-		backoff := time.Duration(250*(i+1)) * time.Millisecond
+		if i+1 == attempts {
+			break
+		}
+		r.retrying("create", err)
 		_ = sleepWithContext(ctx, backoff)
 	}
 
 	return "", "", lastErr
 }
 
+func startRecordSpan(ctx context.Context, rec dns.Record) (context.Context, *telemetry.Span) {
+	return telemetry.StartSpan(ctx, "runner.record",
+		telemetry.String("dns.record.type", rec.Type), telemetry.String("dns.record.name", rec.SubDomain))
+}
+
+func (r *Runner) retrying(operation string, err error) {
+	if r.opt.OnRetry != nil {
+		r.opt.OnRetry(operation, err)
+	}
+}
+
 func (r *Runner) rollback(ctx context.Context, domain string, ids []string) {
 	if len(ids) == 0 {
 		return
 	}
 
-	fmt.Printf("reverting %d records...\n", len(ids))
+	fmt.Fprintf(r.opt.Out, "reverting %d records...\n", len(ids))
 	for i := len(ids) - 1; i >= 0; i-- {
 		id := ids[i]
 		_ = r.client.DeleteRecord(ctx, domain, id)
-		fmt.Printf("reverted %s\n", id)
+		fmt.Fprintf(r.opt.Out, "reverted %s\n", id)
 		_ = sleepWithContext(ctx, r.opt.SleepBetween)
 	}
 }
@@ -155,6 +319,31 @@ func isRetryable(err error) bool {
 	return errors.As(err, &r) && r.Retryable()
 }
 
+// throttled is implemented by errors that carry the server's Retry-After
+// hint, such as deSEC rate limiting.
+type throttled interface {
+	RetryDelay() time.Duration
+}
+
+// maxRetryDelay is the longest Retry-After hint the runner waits for;
+// longer throttling fails the run instead of stalling it.
+const maxRetryDelay = 2 * time.Minute
+
+// retryDelay returns how long to wait before retrying err after the given
+// attempt (0-based), and false if err should not be retried.
+func retryDelay(err error, attempt int) (time.Duration, bool) {
+	if !isRetryable(err) {
+		return 0, false
+	}
+	var t throttled
+	if errors.As(err, &t) {
+		if d := t.RetryDelay(); d > 0 {
+			return d, d <= maxRetryDelay
+		}
+	}
+	return time.Duration(250*(attempt+1)) * time.Millisecond, true
+}
+
 func sleepWithContext(ctx context.Context, d time.Duration) error {
 	if d <= 0 {
 		return nil
@@ -2,10 +2,14 @@ package app
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"testing"
+	"time"
 
 	"ddnsjx/internal/dns"
 	"ddnsjx/internal/provider"
+	"ddnsjx/internal/provider/memprovider"
 )
 
 type fakeClient struct {
@@ -18,8 +22,8 @@ type fakeClient struct {
 	updateCalls int
 }
 
-func (c *fakeClient) IsSupportedRecordType(t string) bool {
-	return true
+func (c *fakeClient) Capabilities() provider.Capabilities {
+	return provider.Capabilities{RecordTypes: []string{"MX", "TXT"}}
 }
 
 func (c *fakeClient) CreateRecord(ctx context.Context, domain, recordLine string, record dns.Record) (string, provider.CreateStatus, error) {
@@ -89,7 +93,9 @@ type upsertClient struct {
 	updateCalled bool
 }
 
-func (c *upsertClient) IsSupportedRecordType(t string) bool { return true }
+func (c *upsertClient) Capabilities() provider.Capabilities {
+	return provider.Capabilities{RecordTypes: []string{"TXT"}}
+}
 
 func (c *upsertClient) CreateRecord(ctx context.Context, zone string, recordLine string, record dns.Record) (string, provider.CreateStatus, error) {
 	return "", provider.CreateStatusExists, nil
@@ -127,3 +133,404 @@ func TestRunnerUpsertUpdatesOnExists(t *testing.T) {
 		t.Fatalf("expected update to be called")
 	}
 }
+
+type ownedClient struct {
+	upsertClient
+	owner string
+}
+
+func (c *ownedClient) RecordOwner(ctx context.Context, zone string, recordID string) (string, error) {
+	return c.owner, nil
+}
+
+func TestRunnerUpsertRefusesForeignRecord(t *testing.T) {
+	client := &ownedClient{owner: "someone-else"}
+	r := NewRunner(client, RunnerOptions{Upsert: true, OwnerID: "mail"})
+
+	plan := dns.Plan{
+		Domain:  "example.com",
+		Records: []dns.Record{{Type: "TXT", SubDomain: "@", Value: "a"}},
+	}
+
+	err := r.Apply(context.Background(), plan)
+	var notOwned NotOwnedError
+	if !errors.As(err, &notOwned) {
+		t.Fatalf("expected NotOwnedError, got %v", err)
+	}
+	if client.updateCalled {
+		t.Fatalf("update must not be called for a foreign record")
+	}
+
+	r = NewRunner(client, RunnerOptions{Upsert: true, OwnerID: "mail", Adopt: true})
+	if err := r.Apply(context.Background(), plan); err != nil {
+		t.Fatalf("unexpected error with adopt: %v", err)
+	}
+	if !client.updateCalled {
+		t.Fatalf("expected update with adopt")
+	}
+}
+
+func TestRunnerTXTRegistryRolledBack(t *testing.T) {
+	client := &fakeClient{failOnIndex: 3}
+	r := NewRunner(client, RunnerOptions{OwnerID: "mail", Ownership: OwnershipTXT})
+
+	plan := dns.Plan{
+		Domain: "example.com",
+		Records: []dns.Record{
+			{Type: "MX", SubDomain: "@", Value: "mail.example.com"},
+			{Type: "TXT", SubDomain: "_dmarc", Value: "v=DMARC1; p=none"},
+		},
+	}
+
+	if err := r.Apply(context.Background(), plan); err == nil {
+		t.Fatalf("expected error")
+	}
+	// MX + its registry TXT were created, the DMARC record failed.
+	if len(client.deletedIDs) != 2 || client.deletedIDs[0] != client.createdIDs[1] || client.deletedIDs[1] != client.createdIDs[0] {
+		t.Fatalf("expected record and registry rollback, got deleted=%v created=%v", client.deletedIDs, client.createdIDs)
+	}
+}
+
+func TestRunnerTXTRegistryIsOptIn(t *testing.T) {
+	plan := dns.Plan{Domain: "example.com", Records: []dns.Record{{Type: "MX", SubDomain: "@", Value: "mail.example.com"}}}
+	for _, mode := range []string{OwnershipAuto, OwnershipNative} {
+		client := &fakeClient{failOnIndex: -1}
+		if err := NewRunner(client, RunnerOptions{OwnerID: "mail", Ownership: mode}).Apply(context.Background(), plan); err != nil {
+			t.Fatalf("%s: %v", mode, err)
+		}
+		if client.createCalls != 1 {
+			t.Fatalf("%s: expected no registry record without OwnerReader, got %d creates", mode, client.createCalls)
+		}
+	}
+	if got := resolveOwnership(&ownedClient{}, OwnershipAuto); got != OwnershipNative {
+		t.Fatalf("expected native ownership with an OwnerReader, got %s", got)
+	}
+}
+
+func TestRegistryRecordWildcard(t *testing.T) {
+	cases := map[string]string{
+		"*.dev":   "_sdns-a._wildcard.dev",
+		"*":       "_sdns-a._wildcard",
+		"mail":    "_sdns-a.mail",
+		"@":       "_sdns-a",
+		"x*.mail": "_sdns-a.x*.mail",
+	}
+	for sub, want := range cases {
+		if got := RegistryRecord("mail", dns.Record{Type: "A", SubDomain: sub}).SubDomain; got != want {
+			t.Fatalf("registry name of %q = %q, want %q", sub, got, want)
+		}
+	}
+}
+
+func TestRunnerRefusesProtectedRegistryName(t *testing.T) {
+	client := &fakeClient{failOnIndex: -1}
+	r := NewRunner(client, RunnerOptions{OwnerID: "mail", Ownership: OwnershipTXT})
+	plan := dns.Plan{
+		Domain:    "example.com",
+		Records:   []dns.Record{{Type: "MX", SubDomain: "@", Value: "mail.example.com"}},
+		Protected: []string{"_sdns-mx"},
+	}
+
+	var protected ProtectedError
+	if err := r.Apply(context.Background(), plan); !errors.As(err, &protected) || protected.SubDomain != "_sdns-mx" {
+		t.Fatalf("expected ProtectedError for the registry name, got %v", err)
+	}
+	if _, err := r.Diff(context.Background(), plan); !errors.As(err, &protected) {
+		t.Fatalf("expected Diff to refuse too, got %v", err)
+	}
+	if client.createCalls != 0 || client.findCalls != 0 {
+		t.Fatalf("expected no API calls, got %d creates %d finds", client.createCalls, client.findCalls)
+	}
+}
+
+func TestRunnerRefusesProtectedName(t *testing.T) {
+	client := &fakeClient{}
+	r := NewRunner(client, RunnerOptions{})
+
+	plan := dns.Plan{
+		Domain:    "example.com",
+		Records:   []dns.Record{{Type: "TXT", SubDomain: "x.internal", Value: "a"}},
+		Protected: []string{"*.internal"},
+	}
+
+	var protected ProtectedError
+	if err := r.Apply(context.Background(), plan); !errors.As(err, &protected) {
+		t.Fatalf("expected ProtectedError, got %v", err)
+	}
+	if client.createCalls != 0 {
+		t.Fatalf("expected no API calls, got %d creates", client.createCalls)
+	}
+}
+
+func TestPrintPlanShowsRecordSettings(t *testing.T) {
+	weight := uint64(30)
+	var b strings.Builder
+	PrintPlan(&b, dns.Plan{
+		Domain:     "example.com",
+		RecordLine: "默认",
+		Records:    []dns.Record{{Type: "A", SubDomain: "mail", Value: "192.0.2.1", Line: "联通", Weight: &weight, Status: "ENABLE"}},
+	})
+	if !strings.Contains(b.String(), "A\tmail\t192.0.2.1\tline=联通\tweight=30\tstatus=ENABLE\t") {
+		t.Fatalf("unexpected plan output:\n%s", b.String())
+	}
+}
+
+type batchClient struct {
+	fakeClient
+	existing map[string]string
+	batches  [][]provider.Change
+	failErr  error
+}
+
+func (c *batchClient) FindRecord(ctx context.Context, zone string, recordLine string, record dns.Record) (string, bool, error) {
+	id, ok := c.existing[record.SubDomain]
+	return id, ok, nil
+}
+
+func (c *batchClient) ApplyBatch(ctx context.Context, zone string, recordLine string, changes []provider.Change) ([]provider.ChangeResult, error) {
+	c.batches = append(c.batches, changes)
+	if c.failErr != nil {
+		return nil, c.failErr
+	}
+	results := make([]provider.ChangeResult, len(changes))
+	for i := range changes {
+		results[i] = provider.ChangeResult{RecordID: "batch-" + changes[i].Record.SubDomain}
+	}
+	return results, nil
+}
+
+func TestRunnerUsesBatchWhenAvailable(t *testing.T) {
+	client := &batchClient{existing: map[string]string{"b": "existing-b"}}
+	r := NewRunner(client, RunnerOptions{Upsert: true})
+
+	plan := dns.Plan{
+		Domain: "example.com",
+		Records: []dns.Record{
+			{Type: "TXT", SubDomain: "a", Value: "a"},
+			{Type: "TXT", SubDomain: "b", Value: "b"},
+		},
+	}
+
+	if err := r.Apply(context.Background(), plan); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if client.createCalls != 0 || client.updateCalls != 0 {
+		t.Fatalf("expected no per-record calls, got create=%d update=%d", client.createCalls, client.updateCalls)
+	}
+	if len(client.batches) != 1 || len(client.batches[0]) != 2 {
+		t.Fatalf("expected one batch with 2 changes, got %v", client.batches)
+	}
+	if got := client.batches[0][1]; got.Action != provider.ChangeUpdate || got.RecordID != "existing-b" {
+		t.Fatalf("expected update of existing-b, got %+v", got)
+	}
+
+	client.failErr = fakeRetryableError{}
+	if err := r.Apply(context.Background(), plan); err == nil {
+		t.Fatalf("expected batch error")
+	}
+	if client.deleteCalls != 0 {
+		t.Fatalf("batch failure must not trigger rollback deletes")
+	}
+}
+
+func TestRunnerAgainstMemProvider(t *testing.T) {
+	client := memprovider.New(memprovider.Options{Zones: []string{"example.com"}})
+	r := NewRunner(client, RunnerOptions{Upsert: true, OwnerID: "mail", Ownership: OwnershipNative})
+
+	prio := uint64(10)
+	plan := dns.Plan{
+		Domain:     "example.com",
+		RecordLine: "默认",
+		Records: []dns.Record{
+			{Type: "MX", SubDomain: "@", Value: "mail.example.com", Priority: &prio},
+			{Type: "TXT", SubDomain: "@", Value: "v=spf1 mx -all"},
+		},
+	}
+	if err := r.Apply(context.Background(), plan); err != nil {
+		t.Fatalf("first apply: %v", err)
+	}
+
+	ttl := uint64(3600)
+	plan.Records[1].TTL = &ttl
+	if err := r.Apply(context.Background(), plan); err != nil {
+		t.Fatalf("second apply: %v", err)
+	}
+
+	got := client.Records("example.com")
+	if len(got) != 2 {
+		t.Fatalf("expected 2 records, got %+v", got)
+	}
+	if got[1].TTL == nil || *got[1].TTL != 3600 || got[1].Owner != "mail" {
+		t.Fatalf("expected TXT updated to ttl 3600 and owned by mail, got %+v", got[1])
+	}
+}
+
+type finalizingBatchClient struct {
+	batchClient
+	finalized []string
+}
+
+func (c *finalizingBatchClient) Finalize(ctx context.Context, zone string) error {
+	c.finalized = append(c.finalized, zone)
+	return nil
+}
+
+type finalizingClient struct {
+	fakeClient
+	finalized []string
+}
+
+func (c *finalizingClient) Finalize(ctx context.Context, zone string) error {
+	c.finalized = append(c.finalized, zone)
+	return nil
+}
+
+func TestRunnerFinalizesOnceAfterChanges(t *testing.T) {
+	plan := dns.Plan{
+		Domain: "example.com",
+		Records: []dns.Record{
+			{Type: "TXT", SubDomain: "a", Value: "a"},
+			{Type: "TXT", SubDomain: "b", Value: "b"},
+		},
+	}
+
+	single := &finalizingClient{}
+	if err := NewRunner(single, RunnerOptions{}).Apply(context.Background(), plan); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(single.finalized) != 1 || single.finalized[0] != "example.com" {
+		t.Fatalf("expected one finalize for example.com, got %v", single.finalized)
+	}
+
+	batch := &finalizingBatchClient{batchClient: batchClient{existing: map[string]string{}}}
+	r := NewRunner(batch, RunnerOptions{})
+	if err := r.Apply(context.Background(), plan); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	batch.existing = map[string]string{"a": "id-a", "b": "id-b"}
+	if err := r.Apply(context.Background(), plan); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(batch.finalized) != 1 {
+		t.Fatalf("expected finalize only for the run that changed records, got %v", batch.finalized)
+	}
+}
+
+type throttledError struct{ wait time.Duration }
+
+func (throttledError) Error() string               { return "throttled" }
+func (throttledError) Retryable() bool             { return true }
+func (e throttledError) RetryDelay() time.Duration { return e.wait }
+
+func TestRetryDelayHonoursRetryAfter(t *testing.T) {
+	if d, ok := retryDelay(throttledError{wait: 3 * time.Second}, 0); !ok || d != 3*time.Second {
+		t.Fatalf("expected the Retry-After hint, got %v %v", d, ok)
+	}
+	if d, ok := retryDelay(throttledError{}, 1); !ok || d != 500*time.Millisecond {
+		t.Fatalf("expected the default backoff without a hint, got %v %v", d, ok)
+	}
+	if _, ok := retryDelay(throttledError{wait: time.Hour}, 0); ok {
+		t.Fatalf("expected throttling beyond %v not to be retried", maxRetryDelay)
+	}
+	if _, ok := retryDelay(fakeRetryableError{}, 0); ok {
+		t.Fatalf("expected a non-retryable error not to be retried")
+	}
+}
+
+// flakyClient fails the first create of each record with a short throttle.
+type flakyClient struct {
+	fakeClient
+	failed map[string]bool
+}
+
+func (c *flakyClient) CreateRecord(ctx context.Context, domain, recordLine string, record dns.Record) (string, provider.CreateStatus, error) {
+	if !c.failed[record.SubDomain] {
+		c.failed[record.SubDomain] = true
+		return "", provider.CreateStatusFail, throttledError{wait: time.Millisecond}
+	}
+	return c.fakeClient.CreateRecord(ctx, domain, recordLine, record)
+}
+
+func TestRunnerReportsRetries(t *testing.T) {
+	client := &flakyClient{failed: map[string]bool{}}
+	var retried []string
+	var out strings.Builder
+	r := NewRunner(client, RunnerOptions{
+		Retries: 1,
+		Out:     &out,
+		OnRetry: func(operation string, err error) { retried = append(retried, operation) },
+	})
+	plan := dns.Plan{Domain: "example.com", Records: []dns.Record{
+		{Type: "TXT", SubDomain: "a", Value: "a"},
+		{Type: "TXT", SubDomain: "b", Value: "b"},
+	}}
+	if err := r.Apply(context.Background(), plan); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(retried, ",") != "create,create" {
+		t.Fatalf("expected one create retry per record, got %v", retried)
+	}
+	if !strings.Contains(out.String(), "done") {
+		t.Fatalf("expected progress output in Out, got %q", out.String())
+	}
+}
+
+// matchingBatchClient reports "b" as already up to date and fails the
+// first ownership lookup with a short throttle.
+type matchingBatchClient struct {
+	batchClient
+	ownerCalls int
+}
+
+func (c *matchingBatchClient) RecordUpToDate(ctx context.Context, zone string, recordID string, record dns.Record) (bool, error) {
+	return recordID == "existing-b", nil
+}
+
+func (c *matchingBatchClient) RecordOwner(ctx context.Context, zone string, recordID string) (string, error) {
+	c.ownerCalls++
+	if c.ownerCalls == 1 {
+		return "", throttledError{wait: time.Millisecond}
+	}
+	return "mail", nil
+}
+
+func TestRunnerBatchSkipsUpToDateRecords(t *testing.T) {
+	client := &matchingBatchClient{batchClient: batchClient{existing: map[string]string{"b": "existing-b", "c": "existing-c"}}}
+	var retried []string
+	var out strings.Builder
+	r := NewRunner(client, RunnerOptions{
+		Upsert:  true,
+		OwnerID: "mail",
+		Retries: 1,
+		Out:     &out,
+		OnRetry: func(operation string, err error) { retried = append(retried, operation) },
+	})
+	plan := dns.Plan{
+		Domain: "example.com",
+		Records: []dns.Record{
+			{Type: "TXT", SubDomain: "b", Value: "b"},
+			{Type: "TXT", SubDomain: "c", Value: "c"},
+		},
+	}
+
+	if err := r.Apply(context.Background(), plan); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(client.batches) != 1 || len(client.batches[0]) != 1 || client.batches[0][0].RecordID != "existing-c" {
+		t.Fatalf("expected only existing-c in the batch, got %v", client.batches)
+	}
+	if strings.Join(retried, ",") != "find" {
+		t.Fatalf("expected the ownership lookup to be retried, got %v", retried)
+	}
+	if !strings.Contains(out.String(), "unchanged (ID: existing-b)") {
+		t.Fatalf("expected b reported unchanged, got %q", out.String())
+	}
+
+	entries, err := r.Diff(context.Background(), plan)
+	if err != nil {
+		t.Fatalf("Diff: %v", err)
+	}
+	if entries[0].Action != "skip" || entries[1].Action != "update" {
+		t.Fatalf("expected skip and update, got %+v", entries)
+	}
+}
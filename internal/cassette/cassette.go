@@ -0,0 +1,242 @@
+// Package cassette records HTTP exchanges with a provider API to a JSON file
+// and replays them later without network access. Only the request headers
+// APIs dispatch on (see dispatchHeaders) are stored, so credentials and
+// signatures stay out of the cassette; requests are matched on method, URL,
+// those headers and body.
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+)
+
+const (
+	ModeRecord = "record"
+	ModeReplay = "replay"
+)
+
+type Request struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+}
+
+// dispatchHeaders select the API operation on endpoints that take every
+// call at one URL (TencentCloud's X-TC-Action), so they are recorded and
+// matched. Nothing secret may be added here.
+var dispatchHeaders = []string{"X-TC-Action", "X-TC-Version"}
+
+type Response struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body"`
+}
+
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Transport is an http.RoundTripper that records or replays a cassette.
+type Transport struct {
+	mu   sync.Mutex
+	path string
+	mode string
+	next http.RoundTripper
+	tape Cassette
+	used []bool
+}
+
+// Load opens path for replay.
+func Load(path string) (*Transport, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c Cassette
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, fmt.Errorf("parse cassette %s: %w", path, err)
+	}
+	return &Transport{path: path, mode: ModeReplay, tape: c, used: make([]bool, len(c.Interactions))}, nil
+}
+
+// Record forwards requests to next (http.DefaultTransport when nil) and
+// writes every exchange to path, replacing any previous content.
+func Record(path string, next http.RoundTripper) (*Transport, error) {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	t := &Transport{path: path, mode: ModeRecord, next: next}
+	if err := t.save(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Open returns a Transport for mode.
+func Open(path, mode string) (*Transport, error) {
+	switch mode {
+	case ModeRecord:
+		return Record(path, nil)
+	case ModeReplay, "":
+		return Load(path)
+	default:
+		return nil, fmt.Errorf("invalid cassette mode %q (expected %s or %s)", mode, ModeRecord, ModeReplay)
+	}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+	want := Request{Method: req.Method, URL: req.URL.String(), Body: body}
+	for _, k := range dispatchHeaders {
+		if v := req.Header.Get(k); v != "" {
+			if want.Headers == nil {
+				want.Headers = make(map[string]string)
+			}
+			want.Headers[k] = v
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.mode == ModeReplay {
+		return t.replay(req, want)
+	}
+	return t.record(req, want)
+}
+
+// Remaining reports how many recorded interactions were not replayed.
+func (t *Transport) Remaining() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var n int
+	for _, u := range t.used {
+		if !u {
+			n++
+		}
+	}
+	return n
+}
+
+// replay answers with the first unused interaction matching want, so a
+// request repeated during a session gets its responses in recorded order.
+func (t *Transport) replay(req *http.Request, want Request) (*http.Response, error) {
+	for i, it := range t.tape.Interactions {
+		if t.used[i] || !matches(it.Request, want) {
+			continue
+		}
+		t.used[i] = true
+		return it.Response.toHTTP(req), nil
+	}
+	return nil, fmt.Errorf("cassette %s: no recorded response for %s %s %v %s", t.path, want.Method, want.URL, want.Headers, want.Body)
+}
+
+func (t *Transport) record(req *http.Request, want Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	b, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(b))
+
+	rec := Response{Status: resp.StatusCode, Body: string(b)}
+	for _, k := range []string{"Content-Type", "Retry-After"} {
+		if v := resp.Header.Get(k); v != "" {
+			if rec.Headers == nil {
+				rec.Headers = make(map[string]string)
+			}
+			rec.Headers[k] = v
+		}
+	}
+	t.tape.Interactions = append(t.tape.Interactions, Interaction{Request: want, Response: rec})
+	if err := t.save(); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (t *Transport) save() error {
+	if t.tape.Interactions == nil {
+		t.tape.Interactions = []Interaction{}
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(t.tape); err != nil {
+		return err
+	}
+	return os.WriteFile(t.path, buf.Bytes(), 0o600)
+}
+
+func (r Response) toHTTP(req *http.Request) *http.Response {
+	h := make(http.Header)
+	for k, v := range r.Headers {
+		h.Set(k, v)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.Status, http.StatusText(r.Status)),
+		StatusCode:    r.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        h,
+		Body:          io.NopCloser(bytes.NewReader([]byte(r.Body))),
+		ContentLength: int64(len(r.Body)),
+		Request:       req,
+	}
+}
+
+func readBody(req *http.Request) (string, error) {
+	if req.Body == nil {
+		return "", nil
+	}
+	b, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return "", err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(b))
+	return string(b), nil
+}
+
+// matches compares requests, including their dispatch headers, treating JSON bodies as equal when they
+// decode to the same value so key order and spacing do not matter.
+func matches(a, b Request) bool {
+	if a.Method != b.Method || a.URL != b.URL || len(a.Headers) != len(b.Headers) {
+		return false
+	}
+	for k, v := range a.Headers {
+		if b.Headers[k] != v {
+			return false
+		}
+	}
+	if a.Body == b.Body {
+		return true
+	}
+	var av, bv any
+	if json.Unmarshal([]byte(a.Body), &av) != nil || json.Unmarshal([]byte(b.Body), &bv) != nil {
+		return false
+	}
+	ab, _ := json.Marshal(av)
+	bb, _ := json.Marshal(bv)
+	return bytes.Equal(ab, bb)
+}
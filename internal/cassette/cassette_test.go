@@ -0,0 +1,134 @@
+package cassette
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecordThenReplay(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		b, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=secret")
+		_, _ = io.WriteString(w, `{"call":`+string(rune('0'+calls))+`,"echo":`+string(b)+`}`)
+	}))
+	path := filepath.Join(t.TempDir(), "tape.json")
+
+	rec, err := Record(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hc := &http.Client{Transport: rec}
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest("POST", srv.URL+"/records", strings.NewReader(`{"a": 1, "b": 2}`))
+		req.Header.Set("Authorization", "Bearer secret-token")
+		resp, err := hc.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	srv.Close()
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(raw), "secret") {
+		t.Fatalf("cassette leaked credentials:\n%s", raw)
+	}
+
+	tape, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hc = &http.Client{Transport: tape}
+	for _, want := range []string{`"call":1`, `"call":2`} {
+		// Key order differs from the recording; JSON bodies still match.
+		req, _ := http.NewRequest("POST", srv.URL+"/records", strings.NewReader(`{"b":2,"a":1}`))
+		resp, err := hc.Do(req)
+		if err != nil {
+			t.Fatalf("replay: %v", err)
+		}
+		b, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != 200 || !strings.Contains(string(b), want) || resp.Header.Get("Content-Type") != "application/json" {
+			t.Fatalf("unexpected replay response %d %s", resp.StatusCode, b)
+		}
+	}
+	if tape.Remaining() != 0 {
+		t.Fatalf("expected every interaction to be used")
+	}
+
+	req, _ := http.NewRequest("POST", srv.URL+"/records", strings.NewReader(`{"a":3}`))
+	if _, err := hc.Do(req); err == nil || !strings.Contains(err.Error(), "no recorded response") {
+		t.Fatalf("expected unmatched request error, got %v", err)
+	}
+}
+
+func TestReplayMatchesDispatchHeaders(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.Header.Get("X-TC-Action"))
+	}))
+	path := filepath.Join(t.TempDir(), "tape.json")
+
+	rec, err := Record(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hc := &http.Client{Transport: rec}
+	for _, action := range []string{"DescribeRecordList", "CreateRecord"} {
+		req, _ := http.NewRequest("POST", srv.URL+"/", strings.NewReader(`{"Domain":"example.com"}`))
+		req.Header.Set("X-TC-Action", action)
+		req.Header.Set("X-TC-Version", "2021-03-23")
+		req.Header.Set("Authorization", "TC3-HMAC-SHA256 secret")
+		resp, err := hc.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	srv.Close()
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(raw), "secret") {
+		t.Fatalf("cassette leaked credentials:\n%s", raw)
+	}
+
+	tape, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hc = &http.Client{Transport: tape}
+	// Same URL and body; only the action tells the two calls apart.
+	for _, action := range []string{"CreateRecord", "DescribeRecordList"} {
+		req, _ := http.NewRequest("POST", srv.URL+"/", strings.NewReader(`{"Domain":"example.com"}`))
+		req.Header.Set("X-TC-Action", action)
+		req.Header.Set("X-TC-Version", "2021-03-23")
+		resp, err := hc.Do(req)
+		if err != nil {
+			t.Fatalf("replay %s: %v", action, err)
+		}
+		b, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if string(b) != action {
+			t.Fatalf("replay %s: got response for %s", action, b)
+		}
+	}
+
+	req, _ := http.NewRequest("POST", srv.URL+"/", strings.NewReader(`{"Domain":"example.com"}`))
+	req.Header.Set("X-TC-Action", "DeleteRecord")
+	if _, err := hc.Do(req); err == nil || !strings.Contains(err.Error(), "no recorded response") {
+		t.Fatalf("expected unmatched action error, got %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package cloudflareclient
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"ddnsjx/internal/provider"
+)
+
+type cfBatchResult struct {
+	Deletes []cfDNSRecord `json:"deletes"`
+	Puts    []cfDNSRecord `json:"puts"`
+	Posts   []cfDNSRecord `json:"posts"`
+}
+
+// ApplyBatch submits changes to the dns_records/batch endpoint, which
+// applies deletes, puts and posts (in that order) as one transaction.
+func (c *client) ApplyBatch(ctx context.Context, zone string, _ string, changes []provider.Change) ([]provider.ChangeResult, error) {
+	zoneID, err := c.resolveZoneID(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+
+	type slot struct {
+		list  string
+		index int
+	}
+
+	var (
+		deletes []map[string]any
+		puts    []map[string]any
+		posts   []map[string]any
+		slots   = make([]slot, len(changes))
+	)
+	for i, ch := range changes {
+		switch ch.Action {
+		case provider.ChangeCreate:
+			body, err := buildCreateBody(zone, ch.Record)
+			if err != nil {
+				return nil, err
+			}
+			slots[i] = slot{list: "posts", index: len(posts)}
+			posts = append(posts, body)
+		case provider.ChangeUpdate:
+			body, err := buildUpdateBody(zone, ch.Record)
+			if err != nil {
+				return nil, err
+			}
+			body["id"] = strings.TrimSpace(ch.RecordID)
+			slots[i] = slot{list: "puts", index: len(puts)}
+			puts = append(puts, body)
+		case provider.ChangeDelete:
+			slots[i] = slot{list: "deletes", index: len(deletes)}
+			deletes = append(deletes, map[string]any{"id": strings.TrimSpace(ch.RecordID)})
+		default:
+			return nil, fmt.Errorf("unsupported batch action: %s", ch.Action)
+		}
+	}
+
+	body := map[string]any{}
+	if len(deletes) > 0 {
+		body["deletes"] = deletes
+	}
+	if len(puts) > 0 {
+		body["puts"] = puts
+	}
+	if len(posts) > 0 {
+		body["posts"] = posts
+	}
+
+	var resp cfResponse[cfBatchResult]
+	if err := c.do(ctx, "POST", "/zones/"+url.PathEscape(zoneID)+"/dns_records/batch", body, &resp); err != nil {
+		return nil, err
+	}
+	if !resp.Success {
+		return nil, pickError(resp.Errors)
+	}
+
+	results := make([]provider.ChangeResult, len(changes))
+	for i, s := range slots {
+		var list []cfDNSRecord
+		switch s.list {
+		case "posts":
+			list = resp.Result.Posts
+		case "puts":
+			list = resp.Result.Puts
+		case "deletes":
+			list = resp.Result.Deletes
+		}
+		if s.index < len(list) {
+			results[i] = provider.ChangeResult{RecordID: list[s.index].ID}
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,15 @@
+package cloudflareclient
+
+import "ddnsjx/internal/provider"
+
+func capabilities() provider.Capabilities {
+	return provider.Capabilities{
+		RecordTypes:  []string{"A", "AAAA", "CNAME", "MX", "TXT", "SRV", "NS", "CAA", "PTR", "NAPTR", "TLSA", "SSHFP", "HTTPS", "SVCB", "DS"},
+		MinTTL:       60,
+		MaxTTL:       86400,
+		AutoTTL:      1,
+		MaxTXTLength: 2048,
+		Comments:     true,
+		Proxy:        true,
+	}
+}
@@ -5,28 +5,32 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
-	"time"
 
 	"ddnsjx/internal/dns"
 	"ddnsjx/internal/provider"
+	"ddnsjx/internal/restclient"
 )
 
 type NewOptions struct {
 	APIToken string
 	ZoneID   string
 	ZoneName string
+	// BaseURL overrides the API root (default https://api.cloudflare.com/client/v4).
+	BaseURL string
+	// Transport replaces the HTTP transport, e.g. with a cassette.
+	Transport http.RoundTripper
 }
 
+const defaultBaseURL = "https://api.cloudflare.com/client/v4"
+
 type client struct {
-	token    string
 	zoneID   string
 	zoneName string
-	http     *http.Client
+	rest     *restclient.Client
 }
 
 type Error struct {
@@ -41,6 +45,14 @@ func (e Error) Error() string {
 	return fmt.Sprintf("[%d] %s", e.Code, e.Message)
 }
 
+// ErrorCode returns the API error code for metrics.
+func (e Error) ErrorCode() string {
+	if e.Code == 0 {
+		return ""
+	}
+	return strconv.Itoa(e.Code)
+}
+
 func (e Error) Retryable() bool {
 	switch e.Code {
 	case 10000, 10001, 10100, 10101, 9103:
@@ -54,16 +66,24 @@ func New(opt NewOptions) (provider.Client, error) {
 	if strings.TrimSpace(opt.APIToken) == "" {
 		return nil, fmt.Errorf("missing Cloudflare api token")
 	}
+	baseURL := strings.TrimRight(strings.TrimSpace(opt.BaseURL), "/")
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
 	return &client{
-		token:    strings.TrimSpace(opt.APIToken),
 		zoneID:   strings.TrimSpace(opt.ZoneID),
 		zoneName: strings.TrimSpace(opt.ZoneName),
-		http:     &http.Client{Timeout: 20 * time.Second},
+		rest: restclient.New(restclient.Options{
+			BaseURL:     baseURL,
+			Auth:        restclient.BearerToken(strings.TrimSpace(opt.APIToken)),
+			DecodeError: decodeError,
+			Transport:   opt.Transport,
+		}),
 	}, nil
 }
 
-func (c *client) IsSupportedRecordType(t string) bool {
-	return IsSupportedRecordType(t)
+func (c *client) Capabilities() provider.Capabilities {
+	return capabilities()
 }
 
 func (c *client) CreateRecord(ctx context.Context, zone string, _ string, record dns.Record) (string, provider.CreateStatus, error) {
@@ -87,6 +107,9 @@ func (c *client) CreateRecord(ctx context.Context, zone string, _ string, record
 
 	var resp cfResponse[cfDNSRecord]
 	if err := c.do(ctx, "POST", "/zones/"+url.PathEscape(zoneID)+"/dns_records", body, &resp); err != nil {
+		if e, ok := err.(Error); ok && (e.Code == 81057 || e.Code == 81058) {
+			return "", provider.CreateStatusExists, nil
+		}
 		return "", provider.CreateStatusFail, err
 	}
 	if !resp.Success {
@@ -124,10 +147,38 @@ func (c *client) DeleteRecord(ctx context.Context, zone string, recordID string)
 }
 
 func (c *client) FindRecord(ctx context.Context, zone string, _ string, record dns.Record) (string, bool, error) {
-	zoneID, err := c.resolveZoneID(ctx, zone)
+	records, err := c.listAt(ctx, zone, record)
 	if err != nil {
 		return "", false, err
 	}
+	// Cloudflare returns every record at name+type (several TLSA, SRV, ...);
+	// only one with the same content is this record.
+	for _, r := range records {
+		if recordMatches(r, record) {
+			return r.ID, true, nil
+		}
+	}
+	return "", false, nil
+}
+
+// ListRecords returns every record at record's name and type.
+func (c *client) ListRecords(ctx context.Context, zone string, _ string, record dns.Record) ([]provider.ExistingRecord, error) {
+	records, err := c.listAt(ctx, zone, record)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]provider.ExistingRecord, 0, len(records))
+	for _, r := range records {
+		out = append(out, provider.ExistingRecord{ID: r.ID, Value: r.Content})
+	}
+	return out, nil
+}
+
+func (c *client) listAt(ctx context.Context, zone string, record dns.Record) ([]cfDNSRecord, error) {
+	zoneID, err := c.resolveZoneID(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
 
 	query := url.Values{}
 	query.Set("type", strings.ToUpper(strings.TrimSpace(record.Type)))
@@ -135,56 +186,42 @@ func (c *client) FindRecord(ctx context.Context, zone string, _ string, record d
 
 	var resp cfResponse[[]cfDNSRecord]
 	if err := c.do(ctx, "GET", "/zones/"+url.PathEscape(zoneID)+"/dns_records?"+query.Encode(), nil, &resp); err != nil {
-		return "", false, err
+		return nil, err
 	}
 	if !resp.Success {
-		return "", false, pickError(resp.Errors)
+		return nil, pickError(resp.Errors)
 	}
-	if len(resp.Result) == 0 {
-		return "", false, nil
-	}
-	if len(resp.Result) > 0 {
-		// Exact match check for multiple records (like TLSA, SRV)
-		// Cloudflare API returns all records matching name+type. We must filter by content/data to know if *this specific* record exists.
-		for _, r := range resp.Result {
-			if recordMatches(r, record) {
-				return r.ID, true, nil
-			}
-		}
-		// If we found records but none matched exactly, it means this specific record (with this content) does not exist.
-		// However, we must return "false" so CreateRecord proceeds.
-		// NOTE: If your intention is "upsert" logic where we overwrite *any* existing record of this type,
-		// that requires different handling (e.g. delete all and recreate, or update the first one).
-		// But here FindRecord implies "find THIS exact record".
-		return "", false, nil
-	}
-	return "", false, nil
+	return resp.Result, nil
 }
 
 func recordMatches(cfRec cfDNSRecord, localRec dns.Record) bool {
-	// TLSA special handling (Cloudflare stores parts in 'data')
-	if strings.ToUpper(localRec.Type) == "TLSA" && cfRec.Data != nil {
-		usage, selector, matchingType, cert, err := splitTLSAValue(localRec.Value)
-		if err == nil {
-			// Compare parsed values
-			// Note: cfRec.Data values are float64 when unmarshaled from JSON usually, or int. Use fmt.Sprint to be safe.
-			u, _ := toInt(cfRec.Data["usage"])
-			s, _ := toInt(cfRec.Data["selector"])
-			m, _ := toInt(cfRec.Data["matching_type"])
-			c, _ := cfRec.Data["certificate"].(string)
-
-			if u == int(usage) && s == int(selector) && m == int(matchingType) && c == cert {
-				return true
-			}
+	switch strings.ToUpper(localRec.Type) {
+	case "CAA", "NAPTR", "SSHFP", "TLSA", "HTTPS", "SVCB", "DS":
+		// These are created from structured data; compare the data the
+		// API reports, which may be written differently from ours.
+		got := cfRec.Content
+		if c, ok := dataContent(localRec.Type, cfRec.Data); ok {
+			got = c
 		}
+		return dns.SameRData(localRec.Type, got, localRec.Value)
+	case "SRV":
+		// Cloudflare reports SRV content as "<weight> <port> <target>" with
+		// the priority in its own field.
+		priority, weight, port, target, err := splitSRVValue(localRec.Value)
+		if err != nil {
+			return false
+		}
+		want := fmt.Sprintf("%d %d %s", weight, port, strings.TrimSuffix(target, "."))
+		return strings.TrimSuffix(cfRec.Content, ".") == want && cfRec.Priority != nil && *cfRec.Priority == priority
+	case "TXT":
+		return dns.SameTXT(cfRec.Content, localRec.Value)
+	case "MX":
+		if localRec.Priority != nil && (cfRec.Priority == nil || *cfRec.Priority != *localRec.Priority) {
+			return false
+		}
+		return strings.TrimSuffix(cfRec.Content, ".") == strings.TrimSuffix(strings.TrimSpace(localRec.Value), ".")
 	}
 
-	// Simple content match for standard types
-	if cfRec.Content == localRec.Value {
-		return true
-	}
-	
-	// Fallback: compare standard content if available (some types might populate content string too)
 	return cfRec.Content == localRec.Value
 }
 
@@ -212,8 +249,17 @@ func (c *client) UpdateRecord(ctx context.Context, zone string, _ string, record
 		return err
 	}
 
+	path := "/zones/" + url.PathEscape(zoneID) + "/dns_records/" + url.PathEscape(strings.TrimSpace(recordID))
+	var cur cfResponse[cfDNSRecord]
+	if err := c.do(ctx, "GET", path, nil, &cur); err != nil {
+		return err
+	}
+	if cur.Success && !needsUpdate(cur.Result, record, body) {
+		return nil
+	}
+
 	var resp cfResponse[cfDNSRecord]
-	if err := c.do(ctx, "PUT", "/zones/"+url.PathEscape(zoneID)+"/dns_records/"+url.PathEscape(strings.TrimSpace(recordID)), body, &resp); err != nil {
+	if err := c.do(ctx, "PUT", path, body, &resp); err != nil {
 		return err
 	}
 	if !resp.Success {
@@ -222,6 +268,44 @@ func (c *client) UpdateRecord(ctx context.Context, zone string, _ string, record
 	return nil
 }
 
+// RecordUpToDate reports whether recordID already matches what
+// UpdateRecord would write.
+func (c *client) RecordUpToDate(ctx context.Context, zone string, recordID string, record dns.Record) (bool, error) {
+	zoneID, err := c.resolveZoneID(ctx, zone)
+	if err != nil {
+		return false, err
+	}
+	body, err := buildUpdateBody(zone, record)
+	if err != nil {
+		return false, err
+	}
+	var resp cfResponse[cfDNSRecord]
+	if err := c.do(ctx, "GET", "/zones/"+url.PathEscape(zoneID)+"/dns_records/"+url.PathEscape(strings.TrimSpace(recordID)), nil, &resp); err != nil {
+		return false, err
+	}
+	if !resp.Success {
+		return false, pickError(resp.Errors)
+	}
+	return !needsUpdate(resp.Result, record, body), nil
+}
+
+// RecordOwner reads the ownership marker stored in the record's comment.
+func (c *client) RecordOwner(ctx context.Context, zone string, recordID string) (string, error) {
+	zoneID, err := c.resolveZoneID(ctx, zone)
+	if err != nil {
+		return "", err
+	}
+	var resp cfResponse[cfDNSRecord]
+	if err := c.do(ctx, "GET", "/zones/"+url.PathEscape(zoneID)+"/dns_records/"+url.PathEscape(strings.TrimSpace(recordID)), nil, &resp); err != nil {
+		return "", err
+	}
+	if !resp.Success {
+		return "", pickError(resp.Errors)
+	}
+	owner, _ := provider.ParseOwnerMarker(resp.Result.Comment)
+	return owner, nil
+}
+
 func (c *client) resolveZoneID(ctx context.Context, zoneOrName string) (string, error) {
 	if strings.TrimSpace(c.zoneID) != "" {
 		return c.zoneID, nil
@@ -255,40 +339,18 @@ func (c *client) resolveZoneID(ctx context.Context, zoneOrName string) (string,
 }
 
 func (c *client) do(ctx context.Context, method, path string, body any, out any) error {
-	var reqBody io.Reader
-	if body != nil {
-		b, err := json.Marshal(body)
-		if err != nil {
-			return err
-		}
-		reqBody = bytes.NewReader(b)
-	}
-
-	req, err := http.NewRequestWithContext(ctx, method, "https://api.cloudflare.com/client/v4"+path, reqBody)
-	if err != nil {
-		return err
-	}
-	req.Header.Set("Authorization", "Bearer "+c.token)
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.http.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	b, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return err
-	}
+	_, err := c.rest.Do(ctx, method, path, body, out)
+	return err
+}
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return Error{Code: resp.StatusCode, Message: string(bytes.TrimSpace(b))}
-	}
-	if out == nil {
-		return nil
+// decodeError reports the Cloudflare error code from the envelope API
+// errors come back in (usually 4xx), rather than the HTTP status.
+func decodeError(status int, _ http.Header, b []byte) error {
+	var env cfResponse[json.RawMessage]
+	if json.Unmarshal(b, &env) == nil && len(env.Errors) > 0 {
+		return pickError(env.Errors)
 	}
-	return json.Unmarshal(b, out)
+	return Error{Code: status, Message: string(bytes.TrimSpace(b))}
 }
 
 type cfResponse[T any] struct {
@@ -308,11 +370,21 @@ type cfZone struct {
 }
 
 type cfDNSRecord struct {
-	ID      string         `json:"id"`
-	Type    string         `json:"type"`
-	Name    string         `json:"name"`
-	Content string         `json:"content"`
-	Data    map[string]any `json:"data,omitempty"`
+	ID       string           `json:"id"`
+	Type     string           `json:"type"`
+	Name     string           `json:"name"`
+	Content  string           `json:"content"`
+	Data     map[string]any   `json:"data,omitempty"`
+	TTL      int              `json:"ttl"`
+	Priority *uint64          `json:"priority,omitempty"`
+	Proxied  bool             `json:"proxied"`
+	Comment  string           `json:"comment,omitempty"`
+	Tags     []string         `json:"tags,omitempty"`
+	Settings cfRecordSettings `json:"settings"`
+}
+
+type cfRecordSettings struct {
+	FlattenCNAME bool `json:"flatten_cname,omitempty"`
 }
 
 func pickError(errs []cfAPIError) error {
@@ -367,33 +439,116 @@ func buildCreateBody(zone string, record dns.Record) (map[string]any, error) {
 			"target":   strings.TrimSuffix(strings.TrimSpace(target), "."),
 		}
 		body["priority"] = priority
-	case "TLSA":
-		usage, selector, matchingType, cert, err := splitTLSAValue(record.Value)
+	case "TXT":
+		// Cloudflare expects RFC 1035 quoted strings of at most 255 bytes.
+		body["content"] = dns.QuoteTXT(record.Value)
+	case "CAA", "NAPTR", "SSHFP", "TLSA", "HTTPS", "SVCB", "DS":
+		data, err := structuredData(t, record.Value)
 		if err != nil {
 			return nil, err
 		}
-		// Cloudflare API expects integers for usage/selector/matching_type
-		body["data"] = map[string]any{
-			"usage":         int(usage),
-			"selector":      int(selector),
-			"matching_type": int(matchingType),
-			"certificate":   strings.TrimSpace(cert),
-		}
+		body["data"] = data
 	default:
 		body["content"] = strings.TrimSpace(record.Value)
 	}
 
-	// debug: print body for TLSA
-	if t == "TLSA" {
-		b, _ := json.Marshal(body)
-		fmt.Printf("DEBUG: TLSA Create Body: %s\n", string(b))
-	}
-
+	applyOptions(body, t, record)
 	return body, nil
 }
 
 func buildUpdateBody(zone string, record dns.Record) (map[string]any, error) {
-	return buildCreateBody(zone, record)
+	body, err := buildCreateBody(zone, record)
+	if err != nil {
+		return nil, err
+	}
+	// PUT replaces the whole record: send empty values explicitly so
+	// removed comments/tags are cleared.
+	if _, ok := body["comment"]; !ok {
+		body["comment"] = ""
+	}
+	if _, ok := body["tags"]; !ok {
+		body["tags"] = []string{}
+	}
+	return body, nil
+}
+
+// applyOptions adds proxied, comment, tags and settings to a record body.
+// The comment defaults to the remark and carries the ownership marker.
+func applyOptions(body map[string]any, t string, record dns.Record) {
+	opt := record.Cloudflare
+	if opt == nil {
+		opt = &dns.CloudflareOptions{}
+	}
+
+	if opt.Proxied != nil {
+		body["proxied"] = *opt.Proxied
+	}
+
+	comment := record.Remark
+	if opt.Comment != nil {
+		comment = *opt.Comment
+	}
+	if comment = provider.WithOwnerMarker(comment, record.Owner); comment != "" {
+		body["comment"] = comment
+	}
+
+	if len(opt.Tags) > 0 {
+		body["tags"] = opt.Tags
+	}
+
+	if t == "CNAME" && opt.FlattenCNAME != nil {
+		body["settings"] = map[string]any{"flatten_cname": *opt.FlattenCNAME}
+	}
+}
+
+// needsUpdate reports whether cur differs from the body UpdateRecord would
+// send, so unchanged records are not rewritten.
+func needsUpdate(cur cfDNSRecord, record dns.Record, body map[string]any) bool {
+	if !recordMatches(cur, record) {
+		return true
+	}
+	if ttl, _ := body["ttl"].(int); ttl != cur.TTL {
+		return true
+	}
+	if p, ok := body["priority"].(uint64); ok && (cur.Priority == nil || *cur.Priority != p) {
+		return true
+	}
+	proxied, _ := body["proxied"].(bool)
+	if proxied != cur.Proxied {
+		return true
+	}
+	comment, _ := body["comment"].(string)
+	if comment != cur.Comment {
+		return true
+	}
+	tags, _ := body["tags"].([]string)
+	if !sameTags(tags, cur.Tags) {
+		return true
+	}
+	if settings, ok := body["settings"].(map[string]any); ok {
+		want, _ := settings["flatten_cname"].(bool)
+		if want != cur.Settings.FlattenCNAME {
+			return true
+		}
+	}
+	return false
+}
+
+func sameTags(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	seen := make(map[string]int, len(a))
+	for _, t := range a {
+		seen[t]++
+	}
+	for _, t := range b {
+		if seen[t] == 0 {
+			return false
+		}
+		seen[t]--
+	}
+	return true
 }
 
 func ttlOrAuto(v *uint64) int {
@@ -441,28 +596,3 @@ func splitSRVValue(v string) (priority uint64, weight uint64, port uint64, targe
 	target = f[3]
 	return priority, weight, port, target, nil
 }
-
-func splitTLSAValue(v string) (usage uint64, selector uint64, matchingType uint64, cert string, err error) {
-	f := strings.Fields(strings.TrimSpace(v))
-	if len(f) < 4 {
-		return 0, 0, 0, "", fmt.Errorf("TLSA value expects: \"<usage> <selector> <matching-type> <data>\", got %q", v)
-	}
-
-	usage, err = strconv.ParseUint(f[0], 10, 64)
-	if err != nil {
-		return 0, 0, 0, "", fmt.Errorf("invalid TLSA usage: %q", f[0])
-	}
-
-	selector, err = strconv.ParseUint(f[1], 10, 64)
-	if err != nil {
-		return 0, 0, 0, "", fmt.Errorf("invalid TLSA selector: %q", f[1])
-	}
-
-	matchingType, err = strconv.ParseUint(f[2], 10, 64)
-	if err != nil {
-		return 0, 0, 0, "", fmt.Errorf("invalid TLSA matching-type: %q", f[2])
-	}
-
-	cert = f[3]
-	return usage, selector, matchingType, cert, nil
-}
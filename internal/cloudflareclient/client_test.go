@@ -0,0 +1,185 @@
+package cloudflareclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"ddnsjx/internal/dns"
+	"ddnsjx/internal/provider"
+)
+
+func TestBuildCreateBodyOptions(t *testing.T) {
+	proxied := true
+	flatten := true
+	rec := dns.Record{
+		Type:      "CNAME",
+		SubDomain: "autoconfig",
+		Value:     "mail.example.com",
+		Remark:    "Thunderbird autoconfig",
+		Owner:     "mail",
+		Cloudflare: &dns.CloudflareOptions{
+			Proxied:      &proxied,
+			Tags:         []string{"team:mail"},
+			FlattenCNAME: &flatten,
+		},
+	}
+
+	body, err := buildCreateBody("example.com", rec)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if body["proxied"] != true {
+		t.Fatalf("expected proxied=true, got %v", body["proxied"])
+	}
+	if body["comment"] != "Thunderbird autoconfig [stalwart-dns:owner=mail]" {
+		t.Fatalf("expected remark as comment with owner marker, got %v", body["comment"])
+	}
+	if settings, _ := body["settings"].(map[string]any); settings["flatten_cname"] != true {
+		t.Fatalf("expected flatten_cname setting, got %v", body["settings"])
+	}
+
+	comment := "custom"
+	rec.Cloudflare.Comment = &comment
+	rec.Owner = ""
+	body, err = buildCreateBody("example.com", rec)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if body["comment"] != "custom" {
+		t.Fatalf("expected explicit comment to win, got %v", body["comment"])
+	}
+}
+
+func TestNeedsUpdate(t *testing.T) {
+	rec := dns.Record{Type: "TXT", SubDomain: "_dmarc", Value: "v=DMARC1; p=none", Remark: "dmarc"}
+	body, err := buildUpdateBody("example.com", rec)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	cur := cfDNSRecord{Type: "TXT", Name: "_dmarc.example.com", Content: "v=DMARC1; p=none", TTL: 1, Comment: "dmarc"}
+	if needsUpdate(cur, rec, body) {
+		t.Fatalf("identical record should not need an update")
+	}
+
+	cur.Comment = "old"
+	if !needsUpdate(cur, rec, body) {
+		t.Fatalf("changed comment should need an update")
+	}
+}
+
+func TestLongTXTIsSplitAndMatched(t *testing.T) {
+	dkim := "v=DKIM1; k=rsa; p=" + strings.Repeat("M", 400)
+	rec := dns.Record{Type: "TXT", SubDomain: "default._domainkey", Value: dkim}
+
+	body, err := buildCreateBody("example.com", rec)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	content, _ := body["content"].(string)
+	if got := dns.ParseTXT(content); len(got) != 2 || got.Joined() != dkim {
+		t.Fatalf("expected two quoted strings, got %q", content)
+	}
+
+	split := cfDNSRecord{Type: "TXT", Content: `"v=DKIM1; k=rsa; p=` + strings.Repeat("M", 100) + `" "` + strings.Repeat("M", 300) + `"`}
+	if !recordMatches(split, rec) {
+		t.Fatalf("differently split strings with the same text should match")
+	}
+}
+
+func TestStructuredData(t *testing.T) {
+	rec := dns.Record{Type: "CAA", SubDomain: "@", Value: `0 issue "letsencrypt.org"`}
+	body, err := buildCreateBody("example.com", rec)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	data, _ := body["data"].(map[string]any)
+	if data["flags"] != 0 || data["tag"] != "issue" || data["value"] != "letsencrypt.org" {
+		t.Fatalf("unexpected CAA data: %v", body["data"])
+	}
+	if _, ok := body["content"]; ok {
+		t.Fatalf("CAA should be sent as data only, got content %v", body["content"])
+	}
+
+	cur := cfDNSRecord{Type: "CAA", Content: "0 issue letsencrypt.org", Data: map[string]any{"flags": float64(0), "tag": "issue", "value": "letsencrypt.org"}}
+	if !recordMatches(cur, rec) {
+		t.Fatalf("CAA data read back should match the configured value")
+	}
+
+	naptr := dns.Record{Type: "NAPTR", SubDomain: "@", Value: `100 10 "S" "SIP+D2U" "" _sip._udp.example.com.`}
+	body, err = buildCreateBody("example.com", naptr)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	data, _ = body["data"].(map[string]any)
+	if data["order"] != 100 || data["service"] != "SIP+D2U" || data["regex"] != "" || data["replacement"] != "_sip._udp.example.com." {
+		t.Fatalf("unexpected NAPTR data: %v", body["data"])
+	}
+
+	if _, err := buildCreateBody("example.com", dns.Record{Type: "DS", SubDomain: "sub", Value: "12345 13 2 not-hex"}); err == nil {
+		t.Fatalf("expected an error for an invalid DS digest")
+	}
+}
+
+func TestRecordMatches(t *testing.T) {
+	prio := uint64(10)
+	other := uint64(20)
+	cases := []struct {
+		name  string
+		cf    cfDNSRecord
+		local dns.Record
+		want  bool
+	}{
+		{"srv", cfDNSRecord{Type: "SRV", Content: "5 443 sip.example.net", Priority: &prio}, dns.Record{Type: "SRV", Value: "10 5 443 sip.example.net."}, true},
+		{"srv priority", cfDNSRecord{Type: "SRV", Content: "5 443 sip.example.net", Priority: &other}, dns.Record{Type: "SRV", Value: "10 5 443 sip.example.net"}, false},
+		{"mx", cfDNSRecord{Type: "MX", Content: "mx.example.net", Priority: &prio}, dns.Record{Type: "MX", Value: "mx.example.net.", Priority: &prio}, true},
+		{"mx priority", cfDNSRecord{Type: "MX", Content: "mx.example.net", Priority: &other}, dns.Record{Type: "MX", Value: "mx.example.net", Priority: &prio}, false},
+		{"tlsa", cfDNSRecord{Type: "TLSA", Data: map[string]any{"usage": float64(3), "selector": float64(1), "matching_type": float64(1), "certificate": "ABCD"}}, dns.Record{Type: "TLSA", Value: "3 1 1 abcd"}, true},
+		{"tlsa other", cfDNSRecord{Type: "TLSA", Data: map[string]any{"usage": float64(3), "selector": float64(1), "matching_type": float64(1), "certificate": "abcd"}}, dns.Record{Type: "TLSA", Value: "3 1 1 ef01"}, false},
+		{"a other value", cfDNSRecord{Type: "A", Content: "192.0.2.1"}, dns.Record{Type: "A", Value: "192.0.2.2"}, false},
+	}
+	for _, c := range cases {
+		if got := recordMatches(c.cf, c.local); got != c.want {
+			t.Fatalf("%s: recordMatches = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestAPIErrorCodes(t *testing.T) {
+	deny := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case deny:
+			writeCF(w, http.StatusForbidden, nil, cfAPIError{Code: 10000, Message: "Authentication error"})
+		case r.Method == http.MethodPost:
+			writeCF(w, http.StatusBadRequest, nil, cfAPIError{Code: 81057, Message: "Record already exists."})
+		default:
+			writeCF(w, http.StatusOK, []cfDNSRecord{})
+		}
+	}))
+	defer srv.Close()
+	c, err := New(NewOptions{APIToken: "test", ZoneID: fakeZoneID, BaseURL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	// A 4xx with the API envelope reports the Cloudflare code, not the
+	// HTTP status.
+	_, _, err = c.FindRecord(ctx, "example.com", "", dns.Record{SubDomain: "www", Type: "A", Value: "192.0.2.1"})
+	var apiErr Error
+	if !errors.As(err, &apiErr) || apiErr.Code != 10000 {
+		t.Fatalf("expected code 10000, got %v", err)
+	}
+
+	// A create that races another writer is reported as existing.
+	deny = false
+	_, status, err := c.CreateRecord(ctx, "example.com", "", dns.Record{SubDomain: "www", Type: "A", Value: "192.0.2.1"})
+	if err != nil || status != provider.CreateStatusExists {
+		t.Fatalf("expected an existing record, got %s %v", status, err)
+	}
+}
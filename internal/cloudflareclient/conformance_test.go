@@ -0,0 +1,239 @@
+package cloudflareclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"ddnsjx/internal/provider"
+	"ddnsjx/internal/provider/providertest"
+)
+
+func TestConformance(t *testing.T) {
+	providertest.Run(t, func(t *testing.T) provider.Client {
+		srv := httptest.NewServer(newFakeAPI("example.com"))
+		t.Cleanup(srv.Close)
+		c, err := New(NewOptions{APIToken: "test", BaseURL: srv.URL})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}, providertest.Options{})
+}
+
+// fakeAPI is a stand-in for the parts of the Cloudflare v4 API the client
+// uses. Like the real API it reports SRV content as "weight port target"
+// with the priority in its own field, and rejects identical records and
+// CNAMEs that share a name with other records.
+type fakeAPI struct {
+	mu      sync.Mutex
+	zone    string
+	nextID  int
+	records map[string]cfDNSRecord
+}
+
+func newFakeAPI(zone string) *fakeAPI {
+	return &fakeAPI{zone: zone, records: make(map[string]cfDNSRecord)}
+}
+
+const fakeZoneID = "zone-1"
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer test" {
+		writeCF(w, http.StatusForbidden, nil, cfAPIError{Code: 10000, Message: "Authentication error"})
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/zones")
+	switch {
+	case path == "" && r.Method == http.MethodGet:
+		var zones []cfZone
+		if r.URL.Query().Get("name") == f.zone {
+			zones = append(zones, cfZone{ID: fakeZoneID, Name: f.zone})
+		}
+		writeCF(w, http.StatusOK, zones)
+	case path == "/"+fakeZoneID+"/dns_records" && r.Method == http.MethodGet:
+		q := r.URL.Query()
+		out := []cfDNSRecord{}
+		for _, id := range f.sortedIDs() {
+			rec := f.records[id]
+			if (q.Get("type") == "" || rec.Type == q.Get("type")) && (q.Get("name") == "" || rec.Name == q.Get("name")) {
+				out = append(out, rec)
+			}
+		}
+		writeCF(w, http.StatusOK, out)
+	case path == "/"+fakeZoneID+"/dns_records" && r.Method == http.MethodPost:
+		rec, err := decodeFakeRecord(r)
+		if err != nil {
+			writeCF(w, http.StatusBadRequest, nil, cfAPIError{Code: 9207, Message: err.Error()})
+			return
+		}
+		if apiErr := f.conflict("", rec); apiErr != nil {
+			writeCF(w, http.StatusBadRequest, nil, *apiErr)
+			return
+		}
+		f.nextID++
+		rec.ID = fmt.Sprintf("rec-%03d", f.nextID)
+		f.records[rec.ID] = rec
+		writeCF(w, http.StatusOK, rec)
+	case strings.HasPrefix(path, "/"+fakeZoneID+"/dns_records/"):
+		id := strings.TrimPrefix(path, "/"+fakeZoneID+"/dns_records/")
+		cur, ok := f.records[id]
+		if !ok {
+			writeCF(w, http.StatusNotFound, nil, cfAPIError{Code: 81044, Message: "Record does not exist."})
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			writeCF(w, http.StatusOK, cur)
+		case http.MethodPut:
+			rec, err := decodeFakeRecord(r)
+			if err != nil {
+				writeCF(w, http.StatusBadRequest, nil, cfAPIError{Code: 9207, Message: err.Error()})
+				return
+			}
+			if apiErr := f.conflict(id, rec); apiErr != nil {
+				writeCF(w, http.StatusBadRequest, nil, *apiErr)
+				return
+			}
+			rec.ID = id
+			f.records[id] = rec
+			writeCF(w, http.StatusOK, rec)
+		case http.MethodDelete:
+			delete(f.records, id)
+			writeCF(w, http.StatusOK, map[string]string{"id": id})
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	default:
+		writeCF(w, http.StatusNotFound, nil, cfAPIError{Code: 7003, Message: "Could not route to " + r.URL.Path})
+	}
+}
+
+func (f *fakeAPI) conflict(selfID string, rec cfDNSRecord) *cfAPIError {
+	for id, cur := range f.records {
+		if id == selfID || cur.Name != rec.Name {
+			continue
+		}
+		if cur.Type == rec.Type && cur.Content == rec.Content && samePriority(cur.Priority, rec.Priority) {
+			return &cfAPIError{Code: 81058, Message: "An identical record already exists."}
+		}
+		if cur.Type == "CNAME" || rec.Type == "CNAME" {
+			return &cfAPIError{Code: 81053, Message: "An A, AAAA, or CNAME record with that host already exists."}
+		}
+	}
+	return nil
+}
+
+func (f *fakeAPI) sortedIDs() []string {
+	ids := make([]string, 0, len(f.records))
+	for id := range f.records {
+		ids = append(ids, id)
+	}
+	// ids are zero padded, so lexical order is creation order
+	sort.Strings(ids)
+	return ids
+}
+
+// decodeFakeRecord turns a request body into the record the API would
+// store, deriving content from structured data the way Cloudflare does.
+func decodeFakeRecord(r *http.Request) (cfDNSRecord, error) {
+	var body struct {
+		Type     string           `json:"type"`
+		Name     string           `json:"name"`
+		Content  string           `json:"content"`
+		Data     map[string]any   `json:"data"`
+		TTL      int              `json:"ttl"`
+		Priority *uint64          `json:"priority"`
+		Proxied  bool             `json:"proxied"`
+		Comment  string           `json:"comment"`
+		Tags     []string         `json:"tags"`
+		Settings cfRecordSettings `json:"settings"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return cfDNSRecord{}, err
+	}
+	rec := cfDNSRecord{
+		Type:     body.Type,
+		Name:     body.Name,
+		Content:  body.Content,
+		Data:     body.Data,
+		TTL:      body.TTL,
+		Priority: body.Priority,
+		Proxied:  body.Proxied,
+		Comment:  body.Comment,
+		Tags:     body.Tags,
+		Settings: body.Settings,
+	}
+	switch body.Type {
+	case "SRV":
+		if body.Data == nil {
+			return cfDNSRecord{}, fmt.Errorf("SRV requires data")
+		}
+		p, _ := toInt(body.Data["priority"])
+		wt, _ := toInt(body.Data["weight"])
+		port, _ := toInt(body.Data["port"])
+		target, _ := body.Data["target"].(string)
+		prio := uint64(p)
+		rec.Priority = &prio
+		rec.Content = fmt.Sprintf("%d %d %s", wt, port, target)
+	case "CAA", "NAPTR", "SSHFP", "TLSA", "HTTPS", "SVCB", "DS":
+		if body.Data == nil {
+			return cfDNSRecord{}, fmt.Errorf("%s requires data", body.Type)
+		}
+		num := func(k string) int { n, _ := toInt(body.Data[k]); return n }
+		str := func(k string) string { v, _ := body.Data[k].(string); return v }
+		switch body.Type {
+		case "CAA":
+			// Cloudflare reports the CAA value unquoted.
+			rec.Content = fmt.Sprintf("%d %s %s", num("flags"), str("tag"), str("value"))
+		case "NAPTR":
+			rec.Content = fmt.Sprintf("%d %d %q %q %q %s", num("order"), num("preference"), str("flags"), str("service"), str("regex"), str("replacement"))
+		case "SSHFP":
+			rec.Content = fmt.Sprintf("%d %d %s", num("algorithm"), num("type"), str("fingerprint"))
+		case "TLSA":
+			rec.Content = fmt.Sprintf("%d %d %d %s", num("usage"), num("selector"), num("matching_type"), str("certificate"))
+		case "HTTPS", "SVCB":
+			rec.Content = strings.TrimSpace(fmt.Sprintf("%d %s %s", num("priority"), str("target"), str("value")))
+		case "DS":
+			rec.Content = fmt.Sprintf("%d %d %d %s", num("key_tag"), num("algorithm"), num("digest_type"), strings.ToUpper(str("digest")))
+		}
+	case "MX":
+		if body.Priority == nil {
+			return cfDNSRecord{}, fmt.Errorf("MX requires priority")
+		}
+	}
+	if rec.Content == "" {
+		return cfDNSRecord{}, fmt.Errorf("content is required")
+	}
+	return rec, nil
+}
+
+func samePriority(a, b *uint64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func writeCF(w http.ResponseWriter, status int, result any, errs ...cfAPIError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if errs == nil {
+		errs = []cfAPIError{}
+	}
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"success":  len(errs) == 0,
+		"errors":   errs,
+		"messages": []any{},
+		"result":   result,
+	})
+}
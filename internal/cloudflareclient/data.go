@@ -0,0 +1,106 @@
+package cloudflareclient
+
+import (
+	"strings"
+
+	"ddnsjx/internal/dns"
+)
+
+// structuredData encodes a value as the "data" object Cloudflare requires
+// for CAA, NAPTR, SSHFP, TLSA, HTTPS, SVCB and DS records.
+func structuredData(t, value string) (map[string]any, error) {
+	switch t {
+	case "CAA":
+		r, err := dns.ParseCAA(value)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"flags": int(r.Flags), "tag": strings.ToLower(r.Tag), "value": r.Value}, nil
+	case "NAPTR":
+		r, err := dns.ParseNAPTR(value)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{
+			"order":       int(r.Order),
+			"preference":  int(r.Preference),
+			"flags":       r.Flags,
+			"service":     r.Service,
+			"regex":       r.Regexp,
+			"replacement": r.Replacement,
+		}, nil
+	case "SSHFP":
+		r, err := dns.ParseSSHFP(value)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"algorithm": int(r.Algorithm), "type": int(r.Type), "fingerprint": strings.ToLower(r.Fingerprint)}, nil
+	case "TLSA":
+		r, err := dns.ParseTLSA(value)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{
+			"usage":         int(r.Usage),
+			"selector":      int(r.Selector),
+			"matching_type": int(r.MatchingType),
+			"certificate":   strings.ToLower(r.Certificate),
+		}, nil
+	case "HTTPS", "SVCB":
+		r, err := dns.ParseSVCB(value)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"priority": int(r.Priority), "target": r.Target, "value": strings.Join(r.Params, " ")}, nil
+	case "DS":
+		r, err := dns.ParseDS(value)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{
+			"key_tag":     int(r.KeyTag),
+			"algorithm":   int(r.Algorithm),
+			"digest_type": int(r.DigestType),
+			"digest":      strings.ToLower(r.Digest),
+		}, nil
+	}
+	return nil, nil
+}
+
+// dataContent renders the data object of a record read back from the API
+// in presentation form. ok is false when data is missing.
+func dataContent(t string, data map[string]any) (string, bool) {
+	if data == nil {
+		return "", false
+	}
+	num := func(key string) int {
+		n, _ := toInt(data[key])
+		return n
+	}
+	str := func(key string) string {
+		s, _ := data[key].(string)
+		return s
+	}
+	switch strings.ToUpper(t) {
+	case "CAA":
+		return dns.CAA{Flags: uint8(num("flags")), Tag: str("tag"), Value: str("value")}.String(), true
+	case "NAPTR":
+		return dns.NAPTR{
+			Order:       uint16(num("order")),
+			Preference:  uint16(num("preference")),
+			Flags:       str("flags"),
+			Service:     str("service"),
+			Regexp:      str("regex"),
+			Replacement: str("replacement"),
+		}.String(), true
+	case "SSHFP":
+		return dns.SSHFP{Algorithm: uint8(num("algorithm")), Type: uint8(num("type")), Fingerprint: str("fingerprint")}.String(), true
+	case "TLSA":
+		return dns.TLSA{Usage: uint8(num("usage")), Selector: uint8(num("selector")), MatchingType: uint8(num("matching_type")), Certificate: str("certificate")}.String(), true
+	case "HTTPS", "SVCB":
+		return dns.SVCB{Priority: uint16(num("priority")), Target: str("target"), Params: strings.Fields(str("value"))}.String(), true
+	case "DS":
+		return dns.DS{KeyTag: uint16(num("key_tag")), Algorithm: uint8(num("algorithm")), DigestType: uint8(num("digest_type")), Digest: str("digest")}.String(), true
+	}
+	return "", false
+}
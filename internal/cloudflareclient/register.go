@@ -0,0 +1,28 @@
+package cloudflareclient
+
+import (
+	"net/http"
+
+	"ddnsjx/internal/provider"
+)
+
+func init() {
+	provider.Register(provider.Factory{
+		Name: "cloudflare",
+		Help: "Cloudflare DNS (API v4, token auth)",
+		Settings: []provider.Setting{
+			{Name: "token", Flag: "cf-token", Env: "CLOUDFLARE_API_TOKEN", Help: "Cloudflare API token", Required: true, Secret: true},
+			{Name: "zone_id", Flag: "cf-zone-id", Env: "CLOUDFLARE_ZONE_ID", Help: "Cloudflare zone id (empty: query by zone name)"},
+			{Name: "base_url", Flag: "cf-base-url", Env: "CLOUDFLARE_BASE_URL", Help: "Cloudflare API root (default " + defaultBaseURL + ")"},
+		},
+		New: func(zone string, s provider.Settings, transport http.RoundTripper) (provider.Client, error) {
+			return New(NewOptions{
+				APIToken:  s.Get("token"),
+				ZoneID:    s.Get("zone_id"),
+				ZoneName:  zone,
+				BaseURL:   s.Get("base_url"),
+				Transport: transport,
+			})
+		},
+	})
+}
@@ -0,0 +1,42 @@
+package cloudflareclient
+
+import (
+	"context"
+	"testing"
+
+	"ddnsjx/internal/cassette"
+	"ddnsjx/internal/dns"
+	"ddnsjx/internal/provider"
+)
+
+// TestReplayCreateSRV replays testdata/create_srv.json: the request bodies
+// must encode SRV as structured data, and the SRV content the API returns
+// ("weight port target") must be recognised as the same record.
+func TestReplayCreateSRV(t *testing.T) {
+	tape, err := cassette.Load("testdata/create_srv.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := New(NewOptions{APIToken: "replay", Transport: tape})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	ttl := uint64(3600)
+	rec := dns.Record{SubDomain: "_submission._tcp", Type: "SRV", Value: "0 1 587 mail.example.com.", TTL: &ttl, Remark: "Stalwart submission", Owner: "mail"}
+
+	id, status, err := c.CreateRecord(ctx, "example.com", "", rec)
+	if err != nil || status != provider.CreateStatusSuccess || id != "372e67954025e0ba6aaa6d586b9e0b59" {
+		t.Fatalf("create: id=%q status=%s err=%v", id, status, err)
+	}
+	if got, found, err := c.FindRecord(ctx, "example.com", "", rec); err != nil || !found || got != id {
+		t.Fatalf("find: id=%q found=%v err=%v", got, found, err)
+	}
+	if _, status, err := c.CreateRecord(ctx, "example.com", "", rec); err != nil || status != provider.CreateStatusExists {
+		t.Fatalf("create again: status=%s err=%v", status, err)
+	}
+	if n := tape.Remaining(); n != 0 {
+		t.Fatalf("%d interactions were not replayed", n)
+	}
+}
@@ -4,20 +4,163 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 )
 
 type FileConfig struct {
-	Records []RawRecord `json:"records"`
+	Vars      map[string]string `json:"vars,omitempty" toml:"vars,omitempty"`
+	Protected []string          `json:"protected,omitempty" toml:"protected,omitempty"`
+	Records   []RawRecord       `json:"records" toml:"records"`
+}
+
+// LoadOptions controls how LoadWithOptions assembles the effective config.
+// Overlays are merged on top of the base file in order; Vars take precedence
+// over every `vars` section.
+type LoadOptions struct {
+	Overlays []string
+	Vars     map[string]string
 }
 
 func LoadFile(path string) (FileConfig, error) {
+	return LoadWithOptions(path, LoadOptions{})
+}
+
+func LoadWithOptions(path string, opt LoadOptions) (FileConfig, error) {
+	base, err := readFile(path)
+	if err != nil {
+		return FileConfig{}, err
+	}
+
+	layers := []FileConfig{base}
+	for _, p := range opt.Overlays {
+		ov, err := readFile(p)
+		if err != nil {
+			return FileConfig{}, fmt.Errorf("overlay %s: %w", p, err)
+		}
+		layers = append(layers, ov)
+	}
+	return resolveLayers(layers, opt.Overlays, opt.Vars)
+}
+
+// Resolve expands an in-memory config the way LoadWithOptions expands a
+// file: vars override its `vars` section and templates and each loops in
+// protected names and records are expanded.
+func Resolve(cfg FileConfig, vars map[string]string) (FileConfig, error) {
+	return resolveLayers([]FileConfig{cfg}, nil, vars)
+}
+
+// resolveLayers merges the base config and its overlays (named by
+// overlays, for error messages) and expands templates.
+func resolveLayers(layers []FileConfig, overlays []string, overrides map[string]string) (FileConfig, error) {
+	vars := mergeVars(layers, overrides)
+
+	var cfg FileConfig
+	cfg.Vars = vars
+	for _, layer := range layers {
+		for i, name := range layer.Protected {
+			expanded, err := expandString(fmt.Sprintf("protected[%d]", i), name, vars)
+			if err != nil {
+				return FileConfig{}, err
+			}
+			cfg.Protected = append(cfg.Protected, expanded)
+		}
+	}
+	for i, layer := range layers {
+		records, err := expandRecords(layer.Records, vars)
+		if err != nil {
+			if i == 0 {
+				return FileConfig{}, err
+			}
+			return FileConfig{}, fmt.Errorf("overlay %s: %w", overlays[i-1], err)
+		}
+		if i == 0 {
+			cfg.Records = records
+			continue
+		}
+		cfg.Records = mergeRecords(cfg.Records, records)
+	}
+	return cfg, nil
+}
+
+// Read returns the config file as written, without merging or expanding
+// templates; Resolve expands it later.
+func Read(path string) (FileConfig, error) {
+	return readFile(path)
+}
+
+func readFile(path string) (FileConfig, error) {
 	b, err := os.ReadFile(path)
 	if err != nil {
 		return FileConfig{}, fmt.Errorf("read config: %w", err)
 	}
+	format := FormatFromPath(path)
+	if format == FormatJSON {
+		var cfg FileConfig
+		if err := json.Unmarshal(b, &cfg); err != nil {
+			return FileConfig{}, fmt.Errorf("parse config json: %w", err)
+		}
+		return cfg, nil
+	}
+
+	doc, err := parseDocument(format, b)
+	if err != nil {
+		return FileConfig{}, fmt.Errorf("parse config %s: %w", format, err)
+	}
+	return decodeNode(doc)
+}
+
+// decodeNode maps a YAML/TOML document onto FileConfig through its JSON
+// form, so the json struct tags stay the single source of field names.
+func decodeNode(doc *node) (FileConfig, error) {
+	b, err := json.Marshal(doc.value())
+	if err != nil {
+		return FileConfig{}, fmt.Errorf("decode config: %w", err)
+	}
 	var cfg FileConfig
 	if err := json.Unmarshal(b, &cfg); err != nil {
-		return FileConfig{}, fmt.Errorf("parse config json: %w", err)
+		return FileConfig{}, fmt.Errorf("decode config: %w", err)
 	}
 	return cfg, nil
 }
+
+func mergeVars(layers []FileConfig, overrides map[string]string) map[string]string {
+	var vars map[string]string
+	set := func(k, v string) {
+		if vars == nil {
+			vars = make(map[string]string)
+		}
+		vars[k] = v
+	}
+	for _, layer := range layers {
+		for k, v := range layer.Vars {
+			set(k, v)
+		}
+	}
+	for k, v := range overrides {
+		set(k, v)
+	}
+	return vars
+}
+
+// mergeRecords replaces every base record sharing type and name with an
+// overlay record (RRset semantics) and appends the rest of the overlay.
+func mergeRecords(base, overlay []RawRecord) []RawRecord {
+	replaced := make(map[string]struct{}, len(overlay))
+	for _, r := range overlay {
+		replaced[recordSetKey(r)] = struct{}{}
+	}
+
+	out := make([]RawRecord, 0, len(base)+len(overlay))
+	for _, r := range base {
+		if _, ok := replaced[recordSetKey(r)]; ok {
+			continue
+		}
+		out = append(out, r)
+	}
+	return append(out, overlay...)
+}
+
+func recordSetKey(r RawRecord) string {
+	name := strings.ToLower(strings.TrimSuffix(strings.TrimSpace(r.Name), "."))
+	return strings.ToUpper(strings.TrimSpace(r.Type)) + " " + name
+}
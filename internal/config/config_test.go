@@ -0,0 +1,146 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, dir, name, data string) string {
+	t.Helper()
+	p := filepath.Join(dir, name)
+	if err := os.WriteFile(p, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestLoadWithOptionsExpandsVars(t *testing.T) {
+	dir := t.TempDir()
+	base := writeFile(t, dir, "config.json", `{
+  "vars": {"Domain": "example.com", "MailHost": "mail.example.com"},
+  "records": [
+    {"type": "MX", "name": "{{ .Domain }}.", "contents": "10 {{ .MailHost }}."},
+    {"type": "CNAME", "name": "autoconfig.{{ .Domain }}.", "contents": "{{ .MailHost }}."}
+  ]
+}`)
+
+	cfg, err := LoadWithOptions(base, LoadOptions{Vars: map[string]string{"MailHost": "mx.example.com"}})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if cfg.Records[0].Name != "example.com." || cfg.Records[0].Contents != "10 mx.example.com." {
+		t.Fatalf("unexpected MX record: %+v", cfg.Records[0])
+	}
+	if cfg.Records[1].Contents != "mx.example.com." {
+		t.Fatalf("unexpected CNAME contents: %q", cfg.Records[1].Contents)
+	}
+	if cfg.Vars["MailHost"] != "mx.example.com" {
+		t.Fatalf("expected --var override to win, got %q", cfg.Vars["MailHost"])
+	}
+}
+
+func TestLoadWithOptionsOverlayReplacesRecordSet(t *testing.T) {
+	dir := t.TempDir()
+	base := writeFile(t, dir, "config.json", `{
+  "vars": {"Domain": "example.com"},
+  "records": [
+    {"type": "TXT", "name": "{{ .Domain }}.", "contents": "v=spf1 mx -all"},
+    {"type": "TXT", "name": "_dmarc.{{ .Domain }}.", "contents": "v=DMARC1; p=none"}
+  ]
+}`)
+	overlay := writeFile(t, dir, "prod.json", `{
+  "vars": {"Domain": "example.org"},
+  "records": [
+    {"type": "TXT", "name": "_dmarc.{{ .Domain }}", "contents": "v=DMARC1; p=reject"},
+    {"type": "A", "name": "mail.{{ .Domain }}.", "contents": "192.0.2.1"}
+  ]
+}`)
+
+	cfg, err := LoadWithOptions(base, LoadOptions{Overlays: []string{overlay}})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if len(cfg.Records) != 3 {
+		t.Fatalf("expected 3 records, got %d: %+v", len(cfg.Records), cfg.Records)
+	}
+	if cfg.Records[0].Name != "example.org." {
+		t.Fatalf("expected overlay vars to apply to base records, got %q", cfg.Records[0].Name)
+	}
+	if cfg.Records[1].Contents != "v=DMARC1; p=reject" {
+		t.Fatalf("expected overlay DMARC record, got %q", cfg.Records[1].Contents)
+	}
+}
+
+func TestLoadWithOptionsUnknownVar(t *testing.T) {
+	dir := t.TempDir()
+	base := writeFile(t, dir, "config.json", `{"records": [{"type": "TXT", "name": "{{ .Missing }}.", "contents": "x"}]}`)
+
+	_, err := LoadWithOptions(base, LoadOptions{})
+	if err == nil || !strings.Contains(err.Error(), "record[0]") {
+		t.Fatalf("expected record[0] template error, got %v", err)
+	}
+}
+
+func TestLoadWithOptionsExpandsEach(t *testing.T) {
+	dir := t.TempDir()
+	base := writeFile(t, dir, "config.yaml", `
+vars:
+  Domain: example.com
+records:
+  - type: MX
+    name: "{{ .Domain }}."
+    contents: "{{ .n }}0 mx{{ .n }}.{{ .Domain }}."
+    each:
+      n: ["1..3"]
+  - type: TLSA
+    name: "_{{ .port }}._tcp.{{ .host }}.{{ .Domain }}."
+    contents: "3 1 1 aa11"
+    parsed:
+      tlsa: {usage: 3, selector: 1, matching_type: 1, certificate: "aa11"}
+    each:
+      host: [mx1, mx2]
+      port: ["25", "465"]
+  - type: CNAME
+    name: "node{{ .i }}.{{ .Domain }}."
+    contents: "pool.{{ .Domain }}."
+    each:
+      i: ["08..10"]
+`)
+
+	cfg, err := LoadWithOptions(base, LoadOptions{})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	var got []string
+	for _, r := range cfg.Records {
+		if r.Each != nil {
+			t.Fatalf("each should be cleared after expansion: %+v", r)
+		}
+		got = append(got, r.Type+" "+r.Name+" "+r.Contents)
+	}
+	want := []string{
+		"MX example.com. 10 mx1.example.com.",
+		"MX example.com. 20 mx2.example.com.",
+		"MX example.com. 30 mx3.example.com.",
+		"TLSA _25._tcp.mx1.example.com. 3 1 1 aa11",
+		"TLSA _465._tcp.mx1.example.com. 3 1 1 aa11",
+		"TLSA _25._tcp.mx2.example.com. 3 1 1 aa11",
+		"TLSA _465._tcp.mx2.example.com. 3 1 1 aa11",
+		"CNAME node08.example.com. pool.example.com.",
+		"CNAME node09.example.com. pool.example.com.",
+		"CNAME node10.example.com. pool.example.com.",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected records:\n%s", strings.Join(got, "\n"))
+	}
+	if cfg.Records[3].Parsed.TLSA == cfg.Records[4].Parsed.TLSA {
+		t.Fatalf("expanded records must not share parsed data")
+	}
+
+	bad := writeFile(t, dir, "bad.json", `{"records": [{"type": "A", "name": "x.example.com.", "contents": "192.0.2.1", "each": {"n": ["5..1"]}}]}`)
+	if _, err := LoadWithOptions(bad, LoadOptions{}); err == nil || !strings.Contains(err.Error(), "record[0]") {
+		t.Fatalf("expected record[0] range error, got %v", err)
+	}
+}
@@ -0,0 +1,413 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+	FormatTOML = "toml"
+)
+
+// FormatFromPath picks the config format from the file extension.
+// Anything that is not .yaml/.yml/.toml is treated as JSON.
+func FormatFromPath(path string) string {
+	switch strings.ToLower(filepath.Ext(strings.TrimSpace(path))) {
+	case ".yaml", ".yml":
+		return FormatYAML
+	case ".toml":
+		return FormatTOML
+	default:
+		return FormatJSON
+	}
+}
+
+type nodeKind int
+
+const (
+	kindNull nodeKind = iota
+	kindBool
+	kindNumber
+	kindString
+	kindArray
+	kindObject
+)
+
+func (k nodeKind) String() string {
+	switch k {
+	case kindBool:
+		return "boolean"
+	case kindNumber:
+		return "number"
+	case kindString:
+		return "string"
+	case kindArray:
+		return "array"
+	case kindObject:
+		return "object"
+	default:
+		return "null"
+	}
+}
+
+// node is a format-independent document tree. Object keys keep their
+// source order, and Line/Col are 1-based (0 when the format has no positions).
+type node struct {
+	kind   nodeKind
+	line   int
+	col    int
+	scalar string
+	items  []*node
+	keys   []string
+	values []*node
+}
+
+func (n *node) field(key string) (*node, bool) {
+	for i, k := range n.keys {
+		if k == key {
+			return n.values[i], true
+		}
+	}
+	return nil, false
+}
+
+func (n *node) value() any {
+	switch n.kind {
+	case kindBool:
+		return n.scalar == "true"
+	case kindNumber:
+		return json.Number(n.scalar)
+	case kindString:
+		return n.scalar
+	case kindArray:
+		out := make([]any, 0, len(n.items))
+		for _, it := range n.items {
+			out = append(out, it.value())
+		}
+		return out
+	case kindObject:
+		out := make(map[string]any, len(n.keys))
+		for i, k := range n.keys {
+			out[k] = n.values[i].value()
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
+// PositionError is a parse error with its location in the source document.
+type PositionError struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e PositionError) Error() string {
+	if e.Line == 0 {
+		return e.Message
+	}
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Message)
+}
+
+func parseDocument(format string, data []byte) (*node, error) {
+	switch format {
+	case FormatYAML:
+		return parseYAML(data)
+	case FormatTOML:
+		return parseTOML(data)
+	default:
+		return parseJSON(data)
+	}
+}
+
+type jsonParser struct {
+	dec        *json.Decoder
+	data       []byte
+	lineStarts []int
+}
+
+func parseJSON(data []byte) (*node, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	p := &jsonParser{dec: dec, data: data, lineStarts: lineStarts(data)}
+	n, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err == nil {
+		line, col := p.pos(int(dec.InputOffset()))
+		return nil, PositionError{Line: line, Column: col, Message: "unexpected data after top-level value"}
+	}
+	return n, nil
+}
+
+func (p *jsonParser) parseValue() (*node, error) {
+	off := p.skip(int(p.dec.InputOffset()))
+	tok, err := p.dec.Token()
+	if err != nil {
+		return nil, p.wrap(err, off)
+	}
+	line, col := p.pos(off)
+	n := &node{line: line, col: col}
+
+	switch t := tok.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			n.kind = kindObject
+			for p.dec.More() {
+				keyOff := p.skip(int(p.dec.InputOffset()))
+				keyTok, err := p.dec.Token()
+				if err != nil {
+					return nil, p.wrap(err, keyOff)
+				}
+				key, _ := keyTok.(string)
+				v, err := p.parseValue()
+				if err != nil {
+					return nil, err
+				}
+				n.keys = append(n.keys, key)
+				n.values = append(n.values, v)
+			}
+		case '[':
+			n.kind = kindArray
+			for p.dec.More() {
+				v, err := p.parseValue()
+				if err != nil {
+					return nil, err
+				}
+				n.items = append(n.items, v)
+			}
+		default:
+			line, col := p.pos(off)
+			return nil, PositionError{Line: line, Column: col, Message: fmt.Sprintf("unexpected %q", t)}
+		}
+		closeOff := p.skip(int(p.dec.InputOffset()))
+		if _, err := p.dec.Token(); err != nil {
+			return nil, p.wrap(err, closeOff)
+		}
+	case string:
+		n.kind = kindString
+		n.scalar = t
+	case json.Number:
+		n.kind = kindNumber
+		n.scalar = t.String()
+	case bool:
+		n.kind = kindBool
+		n.scalar = strconv.FormatBool(t)
+	case nil:
+		n.kind = kindNull
+	}
+	return n, nil
+}
+
+func (p *jsonParser) skip(off int) int {
+	for off < len(p.data) {
+		switch p.data[off] {
+		case ' ', '\t', '\r', '\n', ',', ':':
+			off++
+		default:
+			return off
+		}
+	}
+	return off
+}
+
+func (p *jsonParser) pos(off int) (int, int) {
+	i := sort.Search(len(p.lineStarts), func(i int) bool { return p.lineStarts[i] > off }) - 1
+	if i < 0 {
+		i = 0
+	}
+	return i + 1, off - p.lineStarts[i] + 1
+}
+
+func (p *jsonParser) wrap(err error, off int) error {
+	var se *json.SyntaxError
+	if errors.As(err, &se) {
+		off = int(se.Offset)
+	}
+	line, col := p.pos(off)
+	return PositionError{Line: line, Column: col, Message: err.Error()}
+}
+
+func lineStarts(data []byte) []int {
+	starts := []int{0}
+	for i, c := range data {
+		if c == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+	return starts
+}
+
+func parseYAML(data []byte) (*node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, yamlError(err)
+	}
+	if doc.Kind == 0 {
+		return &node{kind: kindObject}, nil
+	}
+	return fromYAML(&doc)
+}
+
+func fromYAML(y *yaml.Node) (*node, error) {
+	switch y.Kind {
+	case yaml.DocumentNode:
+		if len(y.Content) == 0 {
+			return &node{kind: kindNull, line: y.Line, col: y.Column}, nil
+		}
+		return fromYAML(y.Content[0])
+	case yaml.AliasNode:
+		return fromYAML(y.Alias)
+	}
+
+	n := &node{line: y.Line, col: y.Column}
+	switch y.Kind {
+	case yaml.MappingNode:
+		n.kind = kindObject
+		for i := 0; i+1 < len(y.Content); i += 2 {
+			v, err := fromYAML(y.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			n.keys = append(n.keys, y.Content[i].Value)
+			n.values = append(n.values, v)
+		}
+	case yaml.SequenceNode:
+		n.kind = kindArray
+		for _, c := range y.Content {
+			v, err := fromYAML(c)
+			if err != nil {
+				return nil, err
+			}
+			n.items = append(n.items, v)
+		}
+	case yaml.ScalarNode:
+		switch y.ShortTag() {
+		case "!!null":
+			n.kind = kindNull
+		case "!!bool":
+			var b bool
+			if err := y.Decode(&b); err != nil {
+				return nil, PositionError{Line: y.Line, Column: y.Column, Message: err.Error()}
+			}
+			n.kind = kindBool
+			n.scalar = strconv.FormatBool(b)
+		case "!!int":
+			var v int64
+			if err := y.Decode(&v); err != nil {
+				var u uint64
+				if err := y.Decode(&u); err != nil {
+					return nil, PositionError{Line: y.Line, Column: y.Column, Message: err.Error()}
+				}
+				n.kind = kindNumber
+				n.scalar = strconv.FormatUint(u, 10)
+				break
+			}
+			n.kind = kindNumber
+			n.scalar = strconv.FormatInt(v, 10)
+		case "!!float":
+			var f float64
+			if err := y.Decode(&f); err != nil {
+				return nil, PositionError{Line: y.Line, Column: y.Column, Message: err.Error()}
+			}
+			n.kind = kindNumber
+			n.scalar = strconv.FormatFloat(f, 'g', -1, 64)
+		default:
+			n.kind = kindString
+			n.scalar = y.Value
+		}
+	default:
+		return nil, PositionError{Line: y.Line, Column: y.Column, Message: "unsupported yaml node"}
+	}
+	return n, nil
+}
+
+func yamlError(err error) error {
+	// yaml.v3 reports "yaml: line N: ..." without a column.
+	msg := err.Error()
+	rest, ok := strings.CutPrefix(msg, "yaml: line ")
+	if !ok {
+		return err
+	}
+	num, text, ok := strings.Cut(rest, ": ")
+	if !ok {
+		return err
+	}
+	line, convErr := strconv.Atoi(num)
+	if convErr != nil {
+		return err
+	}
+	return PositionError{Line: line, Column: 1, Message: text}
+}
+
+func parseTOML(data []byte) (*node, error) {
+	var m map[string]any
+	if _, err := toml.Decode(string(data), &m); err != nil {
+		var pe toml.ParseError
+		if errors.As(err, &pe) {
+			return nil, PositionError{Line: pe.Position.Line, Column: pe.Position.Col, Message: pe.Message}
+		}
+		return nil, err
+	}
+	return fromAny(m), nil
+}
+
+// fromAny converts decoded TOML values. TOML decoding does not keep key
+// positions, so these nodes carry no line/column.
+func fromAny(v any) *node {
+	switch t := v.(type) {
+	case nil:
+		return &node{kind: kindNull}
+	case bool:
+		return &node{kind: kindBool, scalar: strconv.FormatBool(t)}
+	case int64:
+		return &node{kind: kindNumber, scalar: strconv.FormatInt(t, 10)}
+	case float64:
+		return &node{kind: kindNumber, scalar: strconv.FormatFloat(t, 'g', -1, 64)}
+	case string:
+		return &node{kind: kindString, scalar: t}
+	case time.Time:
+		return &node{kind: kindString, scalar: t.Format(time.RFC3339)}
+	case []map[string]any:
+		n := &node{kind: kindArray}
+		for _, it := range t {
+			n.items = append(n.items, fromAny(it))
+		}
+		return n
+	case []any:
+		n := &node{kind: kindArray}
+		for _, it := range t {
+			n.items = append(n.items, fromAny(it))
+		}
+		return n
+	case map[string]any:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		n := &node{kind: kindObject}
+		for _, k := range keys {
+			n.keys = append(n.keys, k)
+			n.values = append(n.values, fromAny(t[k]))
+		}
+		return n
+	default:
+		return &node{kind: kindString, scalar: fmt.Sprint(t)}
+	}
+}
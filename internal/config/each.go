@@ -0,0 +1,106 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxEachRecords bounds how many records one each block may produce.
+const maxEachRecords = 10000
+
+// expandEach returns the template variables of every iteration of an each
+// block: vars plus one value per loop variable, for every combination.
+// Variables are iterated in name order, the last varying fastest. A value
+// "1..4" stands for 1, 2, 3 and 4; "01..10" pads to the width of its
+// bounds.
+func expandEach(each map[string][]string, vars map[string]string) ([]map[string]string, error) {
+	names := make([]string, 0, len(each))
+	for name := range each {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	lists := make([][]string, len(names))
+	total := 1
+	for i, name := range names {
+		if name == "" {
+			return nil, fmt.Errorf("each: empty variable name")
+		}
+		var values []string
+		for _, v := range each[name] {
+			expanded, err := expandString("each."+name, v, vars)
+			if err != nil {
+				return nil, err
+			}
+			r, err := expandRange(expanded)
+			if err != nil {
+				return nil, fmt.Errorf("each.%s: %w", name, err)
+			}
+			values = append(values, r...)
+		}
+		if len(values) == 0 {
+			return nil, fmt.Errorf("each.%s: no values", name)
+		}
+		lists[i] = values
+		total *= len(values)
+		if total > maxEachRecords {
+			return nil, fmt.Errorf("each: expands to more than %d records", maxEachRecords)
+		}
+	}
+
+	out := make([]map[string]string, 0, total)
+	idx := make([]int, len(names))
+	for {
+		m := make(map[string]string, len(vars)+len(names))
+		for k, v := range vars {
+			m[k] = v
+		}
+		for i, name := range names {
+			m[name] = lists[i][idx[i]]
+		}
+		out = append(out, m)
+
+		i := len(idx) - 1
+		for ; i >= 0; i-- {
+			idx[i]++
+			if idx[i] < len(lists[i]) {
+				break
+			}
+			idx[i] = 0
+		}
+		if i < 0 {
+			return out, nil
+		}
+	}
+}
+
+// expandRange expands "start..stop" into its numbers; other values are
+// returned as they are.
+func expandRange(v string) ([]string, error) {
+	lo, hi, ok := strings.Cut(strings.TrimSpace(v), "..")
+	if !ok {
+		return []string{v}, nil
+	}
+	start, err1 := strconv.Atoi(lo)
+	stop, err2 := strconv.Atoi(hi)
+	if err1 != nil || err2 != nil || start < 0 {
+		return []string{v}, nil
+	}
+	if stop < start {
+		return nil, fmt.Errorf("range %q ends before it starts", v)
+	}
+	if stop-start >= maxEachRecords {
+		return nil, fmt.Errorf("range %q is longer than %d", v, maxEachRecords)
+	}
+	width := 0
+	if len(lo) > 1 && lo[0] == '0' {
+		width = len(lo)
+	}
+	out := make([]string, 0, stop-start+1)
+	for n := start; n <= stop; n++ {
+		out = append(out, fmt.Sprintf("%0*d", width, n))
+	}
+	return out, nil
+}
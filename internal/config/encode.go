@@ -0,0 +1,90 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Marshal encodes cfg in the given format. Field order follows the struct
+// tags so YAML and TOML output reads the same as config.json.
+func Marshal(cfg FileConfig, format string, pretty bool) ([]byte, error) {
+	if format == FormatTOML {
+		// The toml tags mirror the json tags.
+		var buf bytes.Buffer
+		enc := toml.NewEncoder(&buf)
+		enc.Indent = ""
+		if err := enc.Encode(cfg); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	var (
+		b   []byte
+		err error
+	)
+	if pretty || format != FormatJSON {
+		b, err = json.MarshalIndent(cfg, "", "  ")
+	} else {
+		b, err = json.Marshal(cfg)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case FormatJSON:
+		return append(b, '\n'), nil
+	case FormatYAML:
+		doc, err := parseJSON(b)
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(toYAML(doc)); err != nil {
+			return nil, err
+		}
+		if err := enc.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("unsupported config format: %s", format)
+	}
+}
+
+func toYAML(n *node) *yaml.Node {
+	switch n.kind {
+	case kindObject:
+		y := &yaml.Node{Kind: yaml.MappingNode}
+		for i, k := range n.keys {
+			y.Content = append(y.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: k}, toYAML(n.values[i]))
+		}
+		return y
+	case kindArray:
+		y := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, it := range n.items {
+			y.Content = append(y.Content, toYAML(it))
+		}
+		return y
+	case kindString:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: n.scalar}
+	case kindNumber:
+		tag := "!!int"
+		if strings.ContainsAny(n.scalar, ".eE") {
+			tag = "!!float"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: n.scalar}
+	case kindBool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: n.scalar}
+	default:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+	}
+}
@@ -8,20 +8,51 @@ import (
 )
 
 type RawRecord struct {
-	Type     string     `json:"type"`
-	Name     string     `json:"name"`
-	Contents string     `json:"contents"`
-	Remark   string     `json:"remark,omitempty"`
-	TTL      *uint64    `json:"ttl,omitempty"`
-	Parsed   *RawParsed `json:"parsed,omitempty"`
+	Type     string     `json:"type" toml:"type"`
+	Name     string     `json:"name" toml:"name"`
+	Contents string     `json:"contents" toml:"contents"`
+	Remark   string     `json:"remark,omitempty" toml:"remark,omitempty"`
+	TTL      *uint64    `json:"ttl,omitempty" toml:"ttl,omitempty"`
+	Parsed   *RawParsed `json:"parsed,omitempty" toml:"parsed,omitempty"`
+
+	// Line, LineID, Weight and Status are DNSPod per-record settings:
+	// resolution line (overrides --record-line), line id, load-balancing
+	// weight (0-100, not the SRV weight) and ENABLE/DISABLE.
+	Line   string  `json:"line,omitempty" toml:"line,omitempty"`
+	LineID string  `json:"line_id,omitempty" toml:"line_id,omitempty"`
+	Weight *uint64 `json:"weight,omitempty" toml:"weight,omitempty"`
+	Status string  `json:"status,omitempty" toml:"status,omitempty"`
+
+	Cloudflare *CloudflareOptions `json:"cloudflare,omitempty" toml:"cloudflare,omitempty"`
+
+	// Each repeats the record for every combination of its loop variables,
+	// which templates reference like vars. See expandEach.
+	Each map[string][]string `json:"each,omitempty" toml:"each,omitempty"`
+}
+
+// CloudflareOptions are Cloudflare-only record settings. When Comment is
+// omitted the record's remark is used as its Cloudflare comment.
+type CloudflareOptions struct {
+	Proxied      *bool    `json:"proxied,omitempty" toml:"proxied,omitempty"`
+	Comment      *string  `json:"comment,omitempty" toml:"comment,omitempty"`
+	Tags         []string `json:"tags,omitempty" toml:"tags,omitempty"`
+	FlattenCNAME *bool    `json:"flatten_cname,omitempty" toml:"flatten_cname,omitempty"`
 }
 
 type RawParsed struct {
-	Priority *uint64 `json:"priority,omitempty"`
-	Weight   *uint64 `json:"weight,omitempty"`
-	Port     *uint64 `json:"port,omitempty"`
-	Target   *string `json:"target,omitempty"`
-	Exchange *string `json:"exchange,omitempty"`
+	Priority *uint64 `json:"priority,omitempty" toml:"priority,omitempty"`
+	Weight   *uint64 `json:"weight,omitempty" toml:"weight,omitempty"`
+	Port     *uint64 `json:"port,omitempty" toml:"port,omitempty"`
+	Target   *string `json:"target,omitempty" toml:"target,omitempty"`
+	Exchange *string `json:"exchange,omitempty" toml:"exchange,omitempty"`
+
+	// Typed data of multi-field record types; SVCB also holds HTTPS.
+	CAA   *dns.CAA   `json:"caa,omitempty" toml:"caa,omitempty"`
+	NAPTR *dns.NAPTR `json:"naptr,omitempty" toml:"naptr,omitempty"`
+	SSHFP *dns.SSHFP `json:"sshfp,omitempty" toml:"sshfp,omitempty"`
+	TLSA  *dns.TLSA  `json:"tlsa,omitempty" toml:"tlsa,omitempty"`
+	SVCB  *dns.SVCB  `json:"svcb,omitempty" toml:"svcb,omitempty"`
+	DS    *dns.DS    `json:"ds,omitempty" toml:"ds,omitempty"`
 }
 
 func InferDomain(records []RawRecord) string {
@@ -56,6 +87,44 @@ func BuildPlan(domain, recordLine string, records []RawRecord) (dns.Plan, error)
 	return plan, nil
 }
 
+// BuildPlanFromFile is BuildPlan plus the file-level settings that travel
+// with the plan, such as the protected name list.
+func BuildPlanFromFile(domain, recordLine string, cfg FileConfig) (dns.Plan, error) {
+	plan, err := BuildPlan(domain, recordLine, cfg.Records)
+	if err != nil {
+		return dns.Plan{}, err
+	}
+	for i, name := range cfg.Protected {
+		sub, err := protectedSubDomain(domain, name)
+		if err != nil {
+			return dns.Plan{}, fmt.Errorf("protected[%d]: %w", i, err)
+		}
+		plan.Protected = append(plan.Protected, sub)
+	}
+	return plan, nil
+}
+
+// protectedSubDomain accepts "@", a name relative to domain ("_dmarc",
+// "*.internal") or an absolute one ("_dmarc.example.com.") and returns
+// the relative form used by dns.Plan.Protected. Only names ending in a dot
+// must be under domain; others are taken as relative unless they already
+// end with it.
+func protectedSubDomain(domain, name string) (string, error) {
+	name = strings.TrimSpace(name)
+	switch {
+	case name == "" || name == ".":
+		return "", fmt.Errorf("name is empty")
+	case name == "@":
+		return "@", nil
+	case strings.HasSuffix(name, "."):
+		return toSubDomain(domain, name)
+	}
+	if sub, err := toSubDomain(domain, name); err == nil {
+		return sub, nil
+	}
+	return name, nil
+}
+
 func normalizeRecord(domain string, rr RawRecord) (dns.Record, error) {
 	t := strings.ToUpper(strings.TrimSpace(rr.Type))
 	if t == "" {
@@ -80,6 +149,21 @@ func normalizeRecord(domain string, rr RawRecord) (dns.Record, error) {
 
 	remark := strings.TrimSpace(rr.Remark)
 
+	status := strings.ToUpper(strings.TrimSpace(rr.Status))
+	switch status {
+	case "", "ENABLE", "DISABLE":
+	default:
+		return dns.Record{}, fmt.Errorf("invalid status %q (expected ENABLE or DISABLE)", rr.Status)
+	}
+	if rr.Weight != nil && *rr.Weight > 100 {
+		return dns.Record{}, fmt.Errorf("invalid weight %d (expected 0-100)", *rr.Weight)
+	}
+
+	cf, err := normalizeCloudflare(t, rr.Cloudflare)
+	if err != nil {
+		return dns.Record{}, err
+	}
+
 	var (
 		value    string
 		priority *uint64
@@ -103,20 +187,66 @@ func normalizeRecord(domain string, rr RawRecord) (dns.Record, error) {
 		value = trimTrailingDot(contents)
 	case "TXT":
 		value = contents
+	case "CAA", "NAPTR", "SSHFP", "TLSA", "HTTPS", "SVCB", "DS":
+		value, err = structuredValue(t, rr)
+		if err != nil {
+			return dns.Record{}, err
+		}
 	default:
 		value = contents
 	}
 
 	return dns.Record{
-		SubDomain: sub,
-		Type:      t,
-		Value:     value,
-		Priority:  priority,
-		Remark:    remark,
-		TTL:       rr.TTL,
+		SubDomain:  sub,
+		Type:       t,
+		Value:      value,
+		Priority:   priority,
+		Remark:     remark,
+		TTL:        rr.TTL,
+		Line:       strings.TrimSpace(rr.Line),
+		LineID:     strings.TrimSpace(rr.LineID),
+		Weight:     rr.Weight,
+		Status:     status,
+		Cloudflare: cf,
 	}, nil
 }
 
+func normalizeCloudflare(t string, opt *CloudflareOptions) (*dns.CloudflareOptions, error) {
+	if opt == nil {
+		return nil, nil
+	}
+	if opt.Proxied != nil && *opt.Proxied {
+		switch t {
+		case "A", "AAAA", "CNAME":
+		default:
+			return nil, fmt.Errorf("cloudflare.proxied is only valid for A, AAAA and CNAME records")
+		}
+	}
+	if opt.FlattenCNAME != nil && t != "CNAME" {
+		return nil, fmt.Errorf("cloudflare.flatten_cname is only valid for CNAME records")
+	}
+
+	out := &dns.CloudflareOptions{
+		Proxied:      opt.Proxied,
+		FlattenCNAME: opt.FlattenCNAME,
+	}
+	if opt.Comment != nil {
+		c := strings.TrimSpace(*opt.Comment)
+		out.Comment = &c
+	}
+	for _, tag := range opt.Tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		if !strings.Contains(tag, ":") {
+			return nil, fmt.Errorf("invalid cloudflare tag %q (expected name:value)", tag)
+		}
+		out.Tags = append(out.Tags, tag)
+	}
+	return out, nil
+}
+
 func toSubDomain(domain, name string) (string, error) {
 	domain = strings.TrimSuffix(strings.TrimSpace(domain), ".")
 	name = strings.TrimSuffix(strings.TrimSpace(name), ".")
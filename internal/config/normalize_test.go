@@ -1,6 +1,10 @@
 package config
 
-import "testing"
+import (
+	"testing"
+
+	"ddnsjx/internal/dns"
+)
 
 func TestInferDomain(t *testing.T) {
 	got := InferDomain([]RawRecord{{Name: "_smtp._tls.iqwq.com."}, {Name: "iqwq.com."}})
@@ -27,6 +31,34 @@ func TestToSubDomain(t *testing.T) {
 	}
 }
 
+func TestBuildPlanProtectedForms(t *testing.T) {
+	cases := []struct {
+		name string
+		want string
+	}{
+		{"@", "@"},
+		{"_dmarc", "_dmarc"},
+		{"*.internal", "*.internal"},
+		{"_dmarc.iqwq.com.", "_dmarc"},
+		{"mail.iqwq.com", "mail"},
+		{"iqwq.com.", "@"},
+	}
+	for _, c := range cases {
+		plan, err := BuildPlanFromFile("iqwq.com", "", FileConfig{Protected: []string{c.name}})
+		if err != nil {
+			t.Fatalf("protected %q: %v", c.name, err)
+		}
+		if len(plan.Protected) != 1 || plan.Protected[0] != c.want {
+			t.Fatalf("protected %q: got %v, want %q", c.name, plan.Protected, c.want)
+		}
+	}
+	for _, name := range []string{"", "mail.example.org."} {
+		if _, err := BuildPlanFromFile("iqwq.com", "", FileConfig{Protected: []string{name}}); err == nil {
+			t.Fatalf("expected protected %q to be rejected", name)
+		}
+	}
+}
+
 func TestParseSRV(t *testing.T) {
 	p, w, po, target, err := parseSRV(RawRecord{Contents: "0 1 443 mail.iqwq.com."})
 	if err != nil {
@@ -65,3 +97,54 @@ func TestNormalizeRecordSRVValue(t *testing.T) {
 		t.Fatalf("SRV should not use Priority/MX field")
 	}
 }
+
+func TestNormalizeRecordDNSPodSettings(t *testing.T) {
+	weight := uint64(20)
+	rec, err := normalizeRecord("iqwq.com", RawRecord{Type: "A", Name: "mail.iqwq.com.", Contents: "192.0.2.1", Line: "电信", Weight: &weight, Status: "disable"})
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if rec.Line != "电信" || rec.Weight == nil || *rec.Weight != 20 || rec.Status != "DISABLE" {
+		t.Fatalf("unexpected settings: %+v", rec)
+	}
+	if rec.LineOr("默认") != "电信" {
+		t.Fatalf("expected record line to override plan line")
+	}
+
+	if _, err := normalizeRecord("iqwq.com", RawRecord{Type: "A", Name: "mail.iqwq.com.", Contents: "192.0.2.1", Status: "paused"}); err == nil {
+		t.Fatalf("expected invalid status error")
+	}
+}
+
+func TestNormalizeRecordStructuredTypes(t *testing.T) {
+	cases := []struct {
+		raw  RawRecord
+		want string
+	}{
+		{RawRecord{Type: "CAA", Name: "iqwq.com.", Contents: "0 issue letsencrypt.org"}, `0 issue "letsencrypt.org"`},
+		{RawRecord{Type: "NAPTR", Name: "iqwq.com.", Contents: `100 10 S SIP+D2T "" _sip._tcp.iqwq.com`}, `100 10 "S" "SIP+D2T" "" _sip._tcp.iqwq.com.`},
+		{RawRecord{Type: "TLSA", Name: "_25._tcp.mail.iqwq.com.", Contents: "3 1 1 ABCD EF01"}, "3 1 1 abcdef01"},
+		{RawRecord{Type: "HTTPS", Name: "iqwq.com.", Contents: `1 . alpn="h2,h3" port=8443`}, `1 . alpn="h2,h3" port=8443`},
+		{RawRecord{Type: "DS", Name: "sub.iqwq.com.", Contents: "1 1 1 00", Parsed: &RawParsed{DS: &dns.DS{KeyTag: 12345, Algorithm: 13, DigestType: 2, Digest: "AA11"}}}, "12345 13 2 aa11"},
+	}
+	for _, c := range cases {
+		rec, err := normalizeRecord("iqwq.com", c.raw)
+		if err != nil {
+			t.Fatalf("%s: unexpected err: %v", c.raw.Type, err)
+		}
+		if rec.Value != c.want {
+			t.Fatalf("%s: expected %q, got %q", c.raw.Type, c.want, rec.Value)
+		}
+	}
+
+	for _, raw := range []RawRecord{
+		{Type: "CAA", Name: "iqwq.com.", Contents: "0 is-sue letsencrypt.org"},
+		{Type: "SSHFP", Name: "iqwq.com.", Contents: "1 1 not-hex"},
+		{Type: "SVCB", Name: "iqwq.com.", Contents: "0 svc.iqwq.com. alpn=h2"},
+		{Type: "NAPTR", Name: "iqwq.com.", Contents: `100 10 "U" "E2U+sip" "!^.*$!sip:info@iqwq.com!" sip.iqwq.com.`},
+	} {
+		if _, err := normalizeRecord("iqwq.com", raw); err == nil {
+			t.Fatalf("%s %q: expected a validation error", raw.Type, raw.Contents)
+		}
+	}
+}
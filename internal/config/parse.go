@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+
+	"ddnsjx/internal/dns"
 )
 
 func parseMX(rr RawRecord) (uint64, string, error) {
@@ -45,3 +47,87 @@ func parseSRV(rr RawRecord) (priority, weight, port uint64, target string, err e
 	target = fields[3]
 	return p, w, po, target, nil
 }
+
+// structuredValue returns the canonical value of a multi-field record,
+// from its typed parsed field when set, otherwise from contents.
+func structuredValue(t string, rr RawRecord) (string, error) {
+	if p := rr.Parsed; p != nil {
+		type validator interface {
+			Validate() error
+			String() string
+		}
+		var v validator
+		switch {
+		case t == "CAA" && p.CAA != nil:
+			v = *p.CAA
+		case t == "NAPTR" && p.NAPTR != nil:
+			v = *p.NAPTR
+		case t == "SSHFP" && p.SSHFP != nil:
+			v = *p.SSHFP
+		case t == "TLSA" && p.TLSA != nil:
+			v = *p.TLSA
+		case (t == "HTTPS" || t == "SVCB") && p.SVCB != nil:
+			v = *p.SVCB
+		case t == "DS" && p.DS != nil:
+			v = *p.DS
+		}
+		if v != nil {
+			if err := v.Validate(); err != nil {
+				return "", err
+			}
+			return v.String(), nil
+		}
+	}
+	return dns.ParseRData(t, rr.Contents)
+}
+
+// ParseStructured parses the contents of a multi-field record type into
+// its typed parsed field and canonical contents. It returns nil for types
+// without one.
+func ParseStructured(t, contents string) (*RawParsed, string, error) {
+	var (
+		p   RawParsed
+		out string
+	)
+	switch strings.ToUpper(t) {
+	case "CAA":
+		r, err := dns.ParseCAA(contents)
+		if err != nil {
+			return nil, "", err
+		}
+		p.CAA, out = &r, r.String()
+	case "NAPTR":
+		r, err := dns.ParseNAPTR(contents)
+		if err != nil {
+			return nil, "", err
+		}
+		p.NAPTR, out = &r, r.String()
+	case "SSHFP":
+		r, err := dns.ParseSSHFP(contents)
+		if err != nil {
+			return nil, "", err
+		}
+		p.SSHFP, out = &r, r.String()
+	case "TLSA":
+		r, err := dns.ParseTLSA(contents)
+		if err != nil {
+			return nil, "", err
+		}
+		p.TLSA, out = &r, r.String()
+	case "HTTPS", "SVCB":
+		r, err := dns.ParseSVCB(contents)
+		if err != nil {
+			return nil, "", err
+		}
+		p.SVCB, out = &r, r.String()
+	case "DS":
+		r, err := dns.ParseDS(contents)
+		if err != nil {
+			return nil, "", err
+		}
+		p.DS, out = &r, r.String()
+	default:
+		return nil, contents, nil
+	}
+	return &p, out, nil
+}
@@ -0,0 +1,236 @@
+package config
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+//go:embed config.schema.json
+var schemaJSON []byte
+
+// Schema returns the JSON Schema describing FileConfig.
+func Schema() []byte {
+	return schemaJSON
+}
+
+// SchemaError is a single schema violation. Line/Column are 0 when the
+// source format does not track positions (TOML).
+type SchemaError struct {
+	Path    string
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e SchemaError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("%s: %s", e.Path, e.Message)
+	}
+	return fmt.Sprintf("line %d, column %d: %s: %s", e.Line, e.Column, e.Path, e.Message)
+}
+
+// ValidateFile checks a config file against Schema. A returned error means
+// the file could not be read or parsed at all.
+func ValidateFile(path string) ([]SchemaError, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+	return ValidateDocument(FormatFromPath(path), b)
+}
+
+func ValidateDocument(format string, data []byte) ([]SchemaError, error) {
+	doc, err := parseDocument(format, data)
+	if err != nil {
+		return nil, fmt.Errorf("parse config %s: %w", format, err)
+	}
+	root, err := loadSchema()
+	if err != nil {
+		return nil, err
+	}
+	v := &schemaValidator{root: root}
+	v.validate(root, doc, "$")
+	return v.errs, nil
+}
+
+func loadSchema() (map[string]any, error) {
+	dec := json.NewDecoder(bytes.NewReader(schemaJSON))
+	dec.UseNumber()
+	var s map[string]any
+	if err := dec.Decode(&s); err != nil {
+		return nil, fmt.Errorf("parse embedded schema: %w", err)
+	}
+	return s, nil
+}
+
+// schemaKeywords is the subset of JSON Schema (draft 2020-12) that
+// schemaValidator implements, plus the annotations it ignores. Only local
+// "#/..." references are resolved, and additionalProperties does not look
+// at patternProperties. config.schema.json must stay within this list;
+// TestSchemaUsesSupportedKeywords checks it.
+var schemaKeywords = map[string]bool{
+	"$ref": true, "type": true, "enum": true, "pattern": true, "minLength": true,
+	"minimum": true, "maximum": true, "required": true, "properties": true,
+	"additionalProperties": true, "items": true,
+
+	// annotations and containers, not validated
+	"$schema": true, "$id": true, "$defs": true, "$comment": true,
+	"title": true, "description": true, "default": true, "examples": true,
+}
+
+// schemaValidator validates a parsed document against the keywords in
+// schemaKeywords.
+type schemaValidator struct {
+	root map[string]any
+	errs []SchemaError
+}
+
+func (v *schemaValidator) fail(n *node, path, format string, args ...any) {
+	v.errs = append(v.errs, SchemaError{Path: path, Line: n.line, Column: n.col, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *schemaValidator) resolve(s map[string]any) map[string]any {
+	ref, ok := s["$ref"].(string)
+	if !ok {
+		return s
+	}
+	cur := v.root
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		next, ok := cur[part].(map[string]any)
+		if !ok {
+			return s
+		}
+		cur = next
+	}
+	return cur
+}
+
+func (v *schemaValidator) validate(s map[string]any, n *node, path string) {
+	s = v.resolve(s)
+
+	if t, ok := s["type"]; ok && !matchesType(t, n) {
+		v.fail(n, path, "expected %s, got %s", typeNames(t), n.kind)
+		return
+	}
+
+	if enum, ok := s["enum"].([]any); ok {
+		var found bool
+		for _, e := range enum {
+			if fmt.Sprint(e) == n.scalar {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.fail(n, path, "value %q is not one of %v", n.scalar, enum)
+		}
+	}
+
+	switch n.kind {
+	case kindString:
+		if p, ok := s["pattern"].(string); ok {
+			if re, err := regexp.Compile(p); err == nil && !re.MatchString(n.scalar) {
+				v.fail(n, path, "value %q does not match %s", n.scalar, p)
+			}
+		}
+		if min, ok := schemaNumber(s["minLength"]); ok && float64(utf8.RuneCountInString(n.scalar)) < min {
+			v.fail(n, path, "must not be empty")
+		}
+	case kindNumber:
+		f, _ := strconv.ParseFloat(n.scalar, 64)
+		if min, ok := schemaNumber(s["minimum"]); ok && f < min {
+			v.fail(n, path, "value %s is below minimum %v", n.scalar, min)
+		}
+		if max, ok := schemaNumber(s["maximum"]); ok && f > max {
+			v.fail(n, path, "value %s is above maximum %v", n.scalar, max)
+		}
+	case kindObject:
+		if req, ok := s["required"].([]any); ok {
+			for _, r := range req {
+				key, _ := r.(string)
+				if _, ok := n.field(key); !ok {
+					v.fail(n, path, "missing required field %q", key)
+				}
+			}
+		}
+		props, _ := s["properties"].(map[string]any)
+		for i, key := range n.keys {
+			child := n.values[i]
+			childPath := path + "." + key
+			if ps, ok := props[key].(map[string]any); ok {
+				v.validate(ps, child, childPath)
+				continue
+			}
+			switch ap := s["additionalProperties"].(type) {
+			case bool:
+				if !ap {
+					v.fail(child, childPath, "unknown field %q", key)
+				}
+			case map[string]any:
+				v.validate(ap, child, childPath)
+			}
+		}
+	case kindArray:
+		if items, ok := s["items"].(map[string]any); ok {
+			for i, it := range n.items {
+				v.validate(items, it, fmt.Sprintf("%s[%d]", path, i))
+			}
+		}
+	}
+}
+
+func matchesType(t any, n *node) bool {
+	switch tt := t.(type) {
+	case string:
+		return matchesTypeName(tt, n)
+	case []any:
+		for _, it := range tt {
+			if name, ok := it.(string); ok && matchesTypeName(name, n) {
+				return true
+			}
+		}
+		return false
+	default:
+		return true
+	}
+}
+
+func matchesTypeName(name string, n *node) bool {
+	switch name {
+	case "integer":
+		return n.kind == kindNumber && !strings.ContainsAny(n.scalar, ".eE")
+	case "number":
+		return n.kind == kindNumber
+	default:
+		return name == n.kind.String()
+	}
+}
+
+func typeNames(t any) string {
+	switch tt := t.(type) {
+	case []any:
+		var names []string
+		for _, it := range tt {
+			names = append(names, fmt.Sprint(it))
+		}
+		return strings.Join(names, " or ")
+	default:
+		return fmt.Sprint(t)
+	}
+}
+
+func schemaNumber(v any) (float64, bool) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return 0, false
+	}
+	f, err := n.Float64()
+	return f, err == nil
+}
@@ -0,0 +1,171 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"ddnsjx/internal/dns"
+)
+
+func TestValidateDocumentReportsPositions(t *testing.T) {
+	doc := "records:\n  - type: MX\n    name: example.com.\n    contents: 10 mail.example.com.\n    ttl: \"300\"\n    extra: 1\n"
+	errs, err := ValidateDocument(FormatYAML, []byte(doc))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 errors, got %v", errs)
+	}
+	if errs[0].Path != "$.records[0].ttl" || errs[0].Line != 5 || errs[0].Column != 10 {
+		t.Fatalf("unexpected ttl error: %+v", errs[0])
+	}
+	if errs[1].Path != "$.records[0].extra" || errs[1].Line != 6 {
+		t.Fatalf("unexpected extra error: %+v", errs[1])
+	}
+
+	errs, err = ValidateDocument(FormatJSON, []byte("{\n  \"records\": [\n    {\"type\": \"MX\", \"name\": \"a.\"}\n  ]\n}\n"))
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if len(errs) != 1 || errs[0].Line != 3 || errs[0].Column != 5 || !strings.Contains(errs[0].Message, "contents") {
+		t.Fatalf("unexpected json errors: %+v", errs)
+	}
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	ttl := uint64(300)
+	prio := uint64(10)
+	exchange := "mail.example.com."
+	cfg := FileConfig{
+		Vars: map[string]string{"Domain": "example.com"},
+		Records: []RawRecord{
+			{Type: "MX", Name: "example.com.", Contents: "10 mail.example.com.", TTL: &ttl, Parsed: &RawParsed{Priority: &prio, Exchange: &exchange}},
+			{Type: "TXT", Name: "_dmarc.example.com.", Contents: "v=DMARC1; p=none", Remark: "说明"},
+		},
+	}
+
+	for _, format := range []string{FormatJSON, FormatYAML, FormatTOML} {
+		b, err := Marshal(cfg, format, true)
+		if err != nil {
+			t.Fatalf("%s: marshal: %v", format, err)
+		}
+		doc, err := parseDocument(format, b)
+		if err != nil {
+			t.Fatalf("%s: parse: %v\n%s", format, err, b)
+		}
+		got, err := decodeNode(doc)
+		if err != nil {
+			t.Fatalf("%s: decode: %v", format, err)
+		}
+		if !reflect.DeepEqual(got, cfg) {
+			t.Fatalf("%s: round trip mismatch:\n%s", format, b)
+		}
+		errs, err := ValidateDocument(format, b)
+		if err != nil || len(errs) > 0 {
+			t.Fatalf("%s: schema validation failed: %v %v", format, err, errs)
+		}
+	}
+}
+
+func TestMarshalTOMLEscapes(t *testing.T) {
+	cfg := FileConfig{
+		Vars:    map[string]string{"odd key": "bell\a tab\t nul\x00 \"q\" \\"},
+		Records: []RawRecord{{Type: "TXT", Name: "example.com.", Contents: "v=1\x7f\v"}},
+	}
+	b, err := Marshal(cfg, FormatTOML, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := parseDocument(FormatTOML, b)
+	if err != nil {
+		t.Fatalf("output is not valid TOML: %v\n%s", err, b)
+	}
+	got, err := decodeNode(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, cfg) {
+		t.Fatalf("round trip mismatch:\n%s", b)
+	}
+}
+
+// TestSchemaUsesSupportedKeywords fails when config.schema.json uses a
+// keyword schemaValidator would silently ignore.
+func TestSchemaUsesSupportedKeywords(t *testing.T) {
+	var schema map[string]any
+	if err := json.Unmarshal(Schema(), &schema); err != nil {
+		t.Fatal(err)
+	}
+	var walk func(s map[string]any, path string)
+	walk = func(s map[string]any, path string) {
+		for k, v := range s {
+			if !schemaKeywords[k] {
+				t.Errorf("%s: unsupported schema keyword %q", path, k)
+			}
+			switch k {
+			case "properties", "$defs":
+				for name, sub := range v.(map[string]any) {
+					walk(sub.(map[string]any), path+"/"+k+"/"+name)
+				}
+			case "items", "additionalProperties":
+				if sub, ok := v.(map[string]any); ok {
+					walk(sub, path+"/"+k)
+				}
+			}
+		}
+	}
+	walk(schema, "#")
+}
+
+// TestSchemaCoversStructs keeps config.schema.json in sync with the json
+// tags of the config structs.
+func TestSchemaCoversStructs(t *testing.T) {
+	var schema map[string]any
+	if err := json.Unmarshal(Schema(), &schema); err != nil {
+		t.Fatal(err)
+	}
+	defs := schema["$defs"].(map[string]any)
+
+	check := func(name string, props map[string]any, typ reflect.Type) {
+		for i := 0; i < typ.NumField(); i++ {
+			f := typ.Field(i)
+			if f.Tag.Get("toml") != f.Tag.Get("json") {
+				t.Errorf("%s.%s: toml tag %q does not mirror json tag %q", name, f.Name, f.Tag.Get("toml"), f.Tag.Get("json"))
+			}
+			tag := strings.Split(f.Tag.Get("json"), ",")[0]
+			if tag == "" || tag == "-" {
+				continue
+			}
+			if _, ok := props[tag]; !ok {
+				t.Errorf("%s: schema is missing property %q", name, tag)
+			}
+		}
+		if len(props) != countJSONFields(typ) {
+			t.Errorf("%s: schema has %d properties, struct has %d", name, len(props), countJSONFields(typ))
+		}
+	}
+
+	check("FileConfig", schema["properties"].(map[string]any), reflect.TypeOf(FileConfig{}))
+	check("RawRecord", defs["RawRecord"].(map[string]any)["properties"].(map[string]any), reflect.TypeOf(RawRecord{}))
+	check("RawParsed", defs["RawParsed"].(map[string]any)["properties"].(map[string]any), reflect.TypeOf(RawParsed{}))
+	check("CloudflareOptions", defs["CloudflareOptions"].(map[string]any)["properties"].(map[string]any), reflect.TypeOf(CloudflareOptions{}))
+	for name, typ := range map[string]reflect.Type{
+		"CAA": reflect.TypeOf(dns.CAA{}), "NAPTR": reflect.TypeOf(dns.NAPTR{}), "SSHFP": reflect.TypeOf(dns.SSHFP{}),
+		"TLSA": reflect.TypeOf(dns.TLSA{}), "SVCB": reflect.TypeOf(dns.SVCB{}), "DS": reflect.TypeOf(dns.DS{}),
+	} {
+		check(name, defs[name].(map[string]any)["properties"].(map[string]any), typ)
+	}
+}
+
+func countJSONFields(typ reflect.Type) int {
+	var n int
+	for i := 0; i < typ.NumField(); i++ {
+		tag := strings.Split(typ.Field(i).Tag.Get("json"), ",")[0]
+		if tag != "" && tag != "-" {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,160 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+func expandRecords(records []RawRecord, vars map[string]string) ([]RawRecord, error) {
+	if records == nil {
+		return nil, nil
+	}
+	out := make([]RawRecord, 0, len(records))
+	for i, rr := range records {
+		if len(rr.Each) == 0 {
+			expanded, err := expandRecord(rr, vars)
+			if err != nil {
+				return nil, fmt.Errorf("record[%d]: %w", i, err)
+			}
+			out = append(out, expanded)
+			continue
+		}
+		iterations, err := expandEach(rr.Each, vars)
+		if err != nil {
+			return nil, fmt.Errorf("record[%d]: %w", i, err)
+		}
+		rr.Each = nil
+		for _, loopVars := range iterations {
+			expanded, err := expandRecord(rr, loopVars)
+			if err != nil {
+				return nil, fmt.Errorf("record[%d]: %w", i, err)
+			}
+			out = append(out, expanded)
+		}
+	}
+	return out, nil
+}
+
+func expandRecord(rr RawRecord, vars map[string]string) (RawRecord, error) {
+	var err error
+	if rr.Name, err = expandString("name", rr.Name, vars); err != nil {
+		return RawRecord{}, err
+	}
+	if rr.Contents, err = expandString("contents", rr.Contents, vars); err != nil {
+		return RawRecord{}, err
+	}
+	if rr.Remark, err = expandString("remark", rr.Remark, vars); err != nil {
+		return RawRecord{}, err
+	}
+	if rr.Parsed != nil {
+		p := *rr.Parsed
+		if p.Target, err = expandStringPtr("parsed.target", p.Target, vars); err != nil {
+			return RawRecord{}, err
+		}
+		if p.Exchange, err = expandStringPtr("parsed.exchange", p.Exchange, vars); err != nil {
+			return RawRecord{}, err
+		}
+		if err := expandParsedData(&p, vars); err != nil {
+			return RawRecord{}, err
+		}
+		rr.Parsed = &p
+	}
+	if rr.Cloudflare != nil {
+		cf := *rr.Cloudflare
+		if cf.Comment, err = expandStringPtr("cloudflare.comment", cf.Comment, vars); err != nil {
+			return RawRecord{}, err
+		}
+		rr.Cloudflare = &cf
+	}
+	return rr, nil
+}
+
+func expandStringPtr(field string, s *string, vars map[string]string) (*string, error) {
+	if s == nil {
+		return nil, nil
+	}
+	v, err := expandString(field, *s, vars)
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// expandString renders s as a text/template with vars as its data, so
+// `{{ .MailHost }}` refers to vars["MailHost"]. Unknown variables are errors.
+func expandString(field, s string, vars map[string]string) (string, error) {
+	if !strings.Contains(s, "{{") {
+		return s, nil
+	}
+	tpl, err := template.New(field).Option("missingkey=error").Parse(s)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", field, err)
+	}
+	if vars == nil {
+		vars = map[string]string{}
+	}
+	var b strings.Builder
+	if err := tpl.Execute(&b, vars); err != nil {
+		return "", fmt.Errorf("%s: %w", field, err)
+	}
+	return b.String(), nil
+}
+
+// expandParsedData expands the text fields of the typed parsed data. The
+// structs are copied, so records repeated by each do not share them.
+func expandParsedData(p *RawParsed, vars map[string]string) error {
+	var err error
+	if p.CAA != nil {
+		v := *p.CAA
+		if v.Value, err = expandString("parsed.caa.value", v.Value, vars); err != nil {
+			return err
+		}
+		p.CAA = &v
+	}
+	if p.NAPTR != nil {
+		v := *p.NAPTR
+		if v.Regexp, err = expandString("parsed.naptr.regexp", v.Regexp, vars); err != nil {
+			return err
+		}
+		if v.Replacement, err = expandString("parsed.naptr.replacement", v.Replacement, vars); err != nil {
+			return err
+		}
+		p.NAPTR = &v
+	}
+	if p.SSHFP != nil {
+		v := *p.SSHFP
+		if v.Fingerprint, err = expandString("parsed.sshfp.fingerprint", v.Fingerprint, vars); err != nil {
+			return err
+		}
+		p.SSHFP = &v
+	}
+	if p.TLSA != nil {
+		v := *p.TLSA
+		if v.Certificate, err = expandString("parsed.tlsa.certificate", v.Certificate, vars); err != nil {
+			return err
+		}
+		p.TLSA = &v
+	}
+	if p.SVCB != nil {
+		v := *p.SVCB
+		if v.Target, err = expandString("parsed.svcb.target", v.Target, vars); err != nil {
+			return err
+		}
+		v.Params = append([]string(nil), v.Params...)
+		for i := range v.Params {
+			if v.Params[i], err = expandString("parsed.svcb.params", v.Params[i], vars); err != nil {
+				return err
+			}
+		}
+		p.SVCB = &v
+	}
+	if p.DS != nil {
+		v := *p.DS
+		if v.Digest, err = expandString("parsed.ds.digest", v.Digest, vars); err != nil {
+			return err
+		}
+		p.DS = &v
+	}
+	return nil
+}
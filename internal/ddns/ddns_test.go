@@ -0,0 +1,306 @@
+package ddns
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"ddnsjx/internal/app"
+	"ddnsjx/internal/dns"
+	"ddnsjx/internal/provider"
+	"ddnsjx/internal/provider/memprovider"
+)
+
+type fakeDetector map[Family]string
+
+func (f fakeDetector) Detect(ctx context.Context, family Family) (netip.Addr, error) {
+	s, ok := f[family]
+	if !ok {
+		return netip.Addr{}, errors.New("no address")
+	}
+	return netip.MustParseAddr(s), nil
+}
+
+type countingClient struct {
+	*memprovider.Client
+	lookups int
+}
+
+func (c *countingClient) FindRecord(ctx context.Context, zone, line string, rec dns.Record) (string, bool, error) {
+	c.lookups++
+	return c.Client.FindRecord(ctx, zone, line, rec)
+}
+
+func (c *countingClient) ListRecords(ctx context.Context, zone, line string, rec dns.Record) ([]provider.ExistingRecord, error) {
+	c.lookups++
+	return c.Client.ListRecords(ctx, zone, line, rec)
+}
+
+// valueClient finds records by name, type and value, as Cloudflare does,
+// and has no RecordLister.
+type valueClient struct {
+	records map[string]dns.Record
+	nextID  int
+}
+
+func (c *valueClient) Capabilities() provider.Capabilities { return provider.Capabilities{} }
+
+func (c *valueClient) CreateRecord(ctx context.Context, zone, line string, rec dns.Record) (string, provider.CreateStatus, error) {
+	if id, found, _ := c.FindRecord(ctx, zone, line, rec); found {
+		return id, provider.CreateStatusExists, nil
+	}
+	c.nextID++
+	id := strconv.Itoa(c.nextID)
+	c.records[id] = rec
+	return id, provider.CreateStatusSuccess, nil
+}
+
+func (c *valueClient) DeleteRecord(ctx context.Context, zone, id string) error {
+	delete(c.records, id)
+	return nil
+}
+
+func (c *valueClient) FindRecord(ctx context.Context, zone, line string, rec dns.Record) (string, bool, error) {
+	for id, r := range c.records {
+		if r.SubDomain == rec.SubDomain && r.Type == rec.Type && r.Value == rec.Value {
+			return id, true, nil
+		}
+	}
+	return "", false, nil
+}
+
+func (c *valueClient) UpdateRecord(ctx context.Context, zone, line, id string, rec dns.Record) error {
+	if _, ok := c.records[id]; !ok {
+		return errors.New("record not found: " + id)
+	}
+	c.records[id] = rec
+	return nil
+}
+
+// listingClient adds a RecordLister to valueClient.
+type listingClient struct {
+	*valueClient
+}
+
+func (c listingClient) ListRecords(ctx context.Context, zone, line string, rec dns.Record) ([]provider.ExistingRecord, error) {
+	var out []provider.ExistingRecord
+	for id, r := range c.records {
+		if r.SubDomain == rec.SubDomain && r.Type == rec.Type {
+			out = append(out, provider.ExistingRecord{ID: id, Value: r.Value})
+		}
+	}
+	return out, nil
+}
+
+func (c *valueClient) values() []string {
+	var out []string
+	for _, r := range c.records {
+		out = append(out, r.Value)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func TestUpdaterUpdatesOnlyOnChange(t *testing.T) {
+	mem := memprovider.New(memprovider.Options{Zones: []string{"example.com"}})
+	if _, _, err := mem.CreateRecord(context.Background(), "example.com", "", dns.Record{SubDomain: "mail", Type: "A", Value: "198.51.100.1", Owner: "ddns"}); err != nil {
+		t.Fatal(err)
+	}
+	client := &countingClient{Client: mem}
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	det := fakeDetector{IPv4: "192.0.2.10"}
+	u := &Updater{
+		Client:    client,
+		Zone:      "example.com",
+		Names:     []string{"mail", "@"},
+		Families:  []Family{IPv4},
+		Detector:  det,
+		StatePath: statePath,
+		OwnerID:   "ddns",
+	}
+
+	if err := u.Once(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	records := mem.Records("example.com")
+	if len(records) != 2 || records[0].Value != "192.0.2.10" || records[1].SubDomain != "@" || records[1].Value != "192.0.2.10" {
+		t.Fatalf("unexpected records: %+v", records)
+	}
+	if client.lookups != 2 {
+		t.Fatalf("expected 2 lookups, got %d", client.lookups)
+	}
+
+	// Unchanged address: no provider calls, also after a restart.
+	restarted := &Updater{Client: client, Zone: "example.com", Names: []string{"mail", "@"}, Families: []Family{IPv4}, Detector: det, StatePath: statePath}
+	if err := restarted.Once(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if client.lookups != 2 {
+		t.Fatalf("expected no lookups for an unchanged address, got %d", client.lookups-2)
+	}
+
+	det[IPv4] = "192.0.2.11"
+	if err := restarted.Once(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	records = mem.Records("example.com")
+	if len(records) != 2 || records[0].Value != "192.0.2.11" || records[1].Value != "192.0.2.11" {
+		t.Fatalf("unexpected records after change: %+v", records)
+	}
+	st, err := LoadState(statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Records["A mail.example.com"] != "192.0.2.11" || st.Records["A example.com"] != "192.0.2.11" {
+		t.Fatalf("unexpected state: %+v", st)
+	}
+
+	// A family that cannot be detected is reported but does not stop the other.
+	u.Families = []Family{IPv6, IPv4}
+	if err := u.Once(context.Background()); err == nil {
+		t.Fatalf("expected an error for the missing IPv6 address")
+	}
+}
+
+func TestUpdaterReplacesOldAddress(t *testing.T) {
+	// Without a lister the record is found by the address written last.
+	fake := &valueClient{records: map[string]dns.Record{}}
+	det := fakeDetector{IPv4: "198.51.100.1"}
+	u := &Updater{Client: fake, Zone: "example.com", Names: []string{"home"}, Families: []Family{IPv4}, Detector: det}
+	if err := u.Once(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	det[IPv4] = "192.0.2.20"
+	if err := u.Once(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := fake.values(); len(got) != 1 || got[0] != "192.0.2.20" {
+		t.Fatalf("expected the record updated in place, got %v", got)
+	}
+
+	// With a lister, the value written last is replaced and a value
+	// another tool manages at the same name survives.
+	fake = &valueClient{records: map[string]dns.Record{
+		"1": {SubDomain: "home", Type: "A", Value: "198.51.100.1"},
+		"2": {SubDomain: "home", Type: "A", Value: "198.51.100.2"},
+		"3": {SubDomain: "www", Type: "A", Value: "198.51.100.1"},
+	}, nextID: 3}
+	det[IPv4] = "198.51.100.1"
+	u = &Updater{Client: listingClient{fake}, Zone: "example.com", Names: []string{"home"}, Families: []Family{IPv4}, Detector: det, OwnerID: "ddns"}
+	if err := u.Once(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	det[IPv4] = "192.0.2.20"
+	if err := u.Once(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if fake.records["1"].Value != "192.0.2.20" || fake.records["2"].Value != "198.51.100.2" || fake.records["3"].Value != "198.51.100.1" || len(fake.records) != 3 {
+		t.Fatalf("expected home updated in place and the foreign value kept, got %v", fake.records)
+	}
+
+	// Without state or marker nothing is owned: the address is added next
+	// to the foreign values, unless Adopt takes them over.
+	fake = &valueClient{records: map[string]dns.Record{
+		"1": {SubDomain: "home", Type: "A", Value: "198.51.100.1"},
+		"2": {SubDomain: "home", Type: "A", Value: "198.51.100.2"},
+	}, nextID: 2}
+	u = &Updater{Client: listingClient{fake}, Zone: "example.com", Names: []string{"home"}, Families: []Family{IPv4}, Detector: det, OwnerID: "ddns"}
+	if err := u.Once(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := fake.values(); len(got) != 3 {
+		t.Fatalf("expected foreign values untouched, got %v", got)
+	}
+	delete(fake.records, "3")
+	u = &Updater{Client: listingClient{fake}, Zone: "example.com", Names: []string{"home"}, Families: []Family{IPv4}, Detector: det, OwnerID: "ddns", Adopt: true}
+	if err := u.Once(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := fake.values(); len(got) != 1 || got[0] != "192.0.2.20" {
+		t.Fatalf("expected adopt to replace the values, got %v", got)
+	}
+
+	// The companion TXT of the runner's registry marks the name as owned.
+	fake = &valueClient{records: map[string]dns.Record{
+		"1": {SubDomain: "home", Type: "A", Value: "198.51.100.1"},
+		"2": app.RegistryRecord("ddns", dns.Record{SubDomain: "home", Type: "A"}),
+	}, nextID: 2}
+	u = &Updater{Client: listingClient{fake}, Zone: "example.com", Names: []string{"home"}, Families: []Family{IPv4}, Detector: det, OwnerID: "ddns"}
+	if err := u.Once(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if fake.records["1"].Value != "192.0.2.20" || len(fake.records) != 2 {
+		t.Fatalf("expected the registry-owned record updated, got %v", fake.records)
+	}
+
+	// Protected names are refused before any call.
+	u = &Updater{Client: listingClient{fake}, Zone: "example.com", Names: []string{"home"}, Families: []Family{IPv4}, Detector: fakeDetector{IPv4: "203.0.113.1"}, Protected: []string{"home"}}
+	var perr app.ProtectedError
+	if err := u.Once(context.Background()); !errors.As(err, &perr) || fake.records["1"].Value != "192.0.2.20" {
+		t.Fatalf("expected a protected error and no change, got %v %v", err, fake.records)
+	}
+}
+
+func TestNextBackoff(t *testing.T) {
+	var got []time.Duration
+	var b time.Duration
+	for i := 0; i < 7; i++ {
+		b = nextBackoff(b, 5*time.Minute, 5*time.Minute)
+		got = append(got, b)
+	}
+	want := []time.Duration{30 * time.Second, time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute, 5 * time.Minute, 5 * time.Minute}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("unexpected backoff sequence: %v", got)
+		}
+	}
+	if d := nextBackoff(0, 10*time.Second, time.Minute); d != 10*time.Second {
+		t.Fatalf("short intervals should retry at the interval, got %s", d)
+	}
+}
+
+func TestPickPublic(t *testing.T) {
+	ips := []netip.Addr{
+		netip.MustParseAddr("127.0.0.1"),
+		netip.MustParseAddr("10.0.0.5"),
+		netip.MustParseAddr("100.64.1.1"),
+		netip.MustParseAddr("fe80::1"),
+		netip.MustParseAddr("fd00::1"),
+		netip.MustParseAddr("2001:db8::5"),
+		netip.MustParseAddr("203.0.113.9"),
+	}
+	if ip, ok := pickPublic(ips, IPv4); !ok || ip.String() != "203.0.113.9" {
+		t.Fatalf("unexpected IPv4 pick: %v %v", ip, ok)
+	}
+	if ip, ok := pickPublic(ips, IPv6); !ok || ip.String() != "2001:db8::5" {
+		t.Fatalf("unexpected IPv6 pick: %v %v", ip, ok)
+	}
+	if _, ok := pickPublic(ips[:5], IPv4); ok {
+		t.Fatalf("private addresses should not be picked")
+	}
+}
+
+func TestHTTPDetector(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("203.0.113.7\n"))
+	}))
+	defer srv.Close()
+
+	ip, err := HTTPDetector{IPv4URL: srv.URL}.Detect(context.Background(), IPv4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ip.String() != "203.0.113.7" {
+		t.Fatalf("unexpected address %s", ip)
+	}
+	if _, err := (HTTPDetector{IPv6URL: srv.URL}).Detect(context.Background(), IPv6); err == nil {
+		t.Fatalf("expected an error for an IPv4 answer to an IPv6 lookup")
+	}
+}
@@ -0,0 +1,160 @@
+// Package ddns keeps A/AAAA records pointed at a host's current public
+// address.
+package ddns
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+)
+
+// Family selects the address family to detect and the record type to
+// update.
+type Family string
+
+const (
+	IPv4 Family = "ipv4"
+	IPv6 Family = "ipv6"
+)
+
+// RecordType returns "A" for IPv4 and "AAAA" for IPv6.
+func (f Family) RecordType() string {
+	if f == IPv6 {
+		return "AAAA"
+	}
+	return "A"
+}
+
+func (f Family) matches(ip netip.Addr) bool {
+	if f == IPv6 {
+		return ip.Is6() && !ip.Is4In6()
+	}
+	return ip.Unmap().Is4()
+}
+
+// Detector reports the host's current public address of a family.
+type Detector interface {
+	Detect(ctx context.Context, family Family) (netip.Addr, error)
+}
+
+// HTTPDetector asks an echo endpoint that answers with the caller's
+// address as plain text. Connections are forced onto the requested family
+// so one dual-stack URL may serve both, and never go through HTTP_PROXY,
+// which would report the proxy's address.
+type HTTPDetector struct {
+	IPv4URL string
+	IPv6URL string
+	Timeout time.Duration
+}
+
+func (d HTTPDetector) Detect(ctx context.Context, family Family) (netip.Addr, error) {
+	url, network := d.IPv4URL, "tcp4"
+	if family == IPv6 {
+		url, network = d.IPv6URL, "tcp6"
+	}
+	if url == "" {
+		return netip.Addr{}, fmt.Errorf("no %s echo URL configured", family)
+	}
+	timeout := d.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	dialer := &net.Dialer{Timeout: timeout}
+	client := &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, addr string) (net.Conn, error) {
+				return dialer.DialContext(ctx, network, addr)
+			},
+		},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("detect %s: %w", family, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 256))
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("detect %s: %w", family, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return netip.Addr{}, fmt.Errorf("detect %s: %s returned %s", family, url, resp.Status)
+	}
+	ip, err := netip.ParseAddr(strings.TrimSpace(string(body)))
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("detect %s: %s returned %q, not an address", family, url, strings.TrimSpace(string(body)))
+	}
+	if !family.matches(ip) {
+		return netip.Addr{}, fmt.Errorf("detect %s: %s returned %s", family, url, ip)
+	}
+	return ip.Unmap(), nil
+}
+
+// InterfaceDetector picks the first public unicast address configured on a
+// local interface (all interfaces when Name is empty). It only works when
+// the host is not behind NAT, which is usually the case for IPv6.
+type InterfaceDetector struct {
+	Name string
+}
+
+func (d InterfaceDetector) Detect(ctx context.Context, family Family) (netip.Addr, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	for _, iface := range ifaces {
+		if d.Name != "" && iface.Name != d.Name {
+			continue
+		}
+		if iface.Flags&net.FlagUp == 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			return netip.Addr{}, err
+		}
+		var ips []netip.Addr
+		for _, a := range addrs {
+			if n, ok := a.(*net.IPNet); ok {
+				if ip, ok := netip.AddrFromSlice(n.IP); ok {
+					ips = append(ips, ip.Unmap())
+				}
+			}
+		}
+		if ip, ok := pickPublic(ips, family); ok {
+			return ip, nil
+		}
+	}
+	where := "any interface"
+	if d.Name != "" {
+		where = "interface " + d.Name
+	}
+	return netip.Addr{}, fmt.Errorf("no public %s address on %s", family, where)
+}
+
+// pickPublic returns the first globally routable address of family.
+func pickPublic(ips []netip.Addr, family Family) (netip.Addr, bool) {
+	for _, ip := range ips {
+		if family.matches(ip) && ip.IsGlobalUnicast() && !ip.IsPrivate() && !isShared(ip) {
+			return ip, true
+		}
+	}
+	return netip.Addr{}, false
+}
+
+// cgnat is the RFC 6598 shared address space used by carrier-grade NAT.
+var cgnat = netip.MustParsePrefix("100.64.0.0/10")
+
+func isShared(ip netip.Addr) bool {
+	return cgnat.Contains(ip)
+}
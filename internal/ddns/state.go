@@ -0,0 +1,67 @@
+package ddns
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// State is the last address written for each record, persisted between
+// runs so a restart does not call the provider when nothing changed.
+type State struct {
+	// Records maps "<type> <fqdn>" to the address last written.
+	Records map[string]string `json:"records"`
+	Updated time.Time         `json:"updated,omitempty"`
+}
+
+func stateKey(recordType, fqdn string) string {
+	return recordType + " " + fqdn
+}
+
+// LoadState reads path; a missing file is an empty state.
+func LoadState(path string) (State, error) {
+	st := State{Records: map[string]string{}}
+	if path == "" {
+		return st, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return st, nil
+	}
+	if err != nil {
+		return st, err
+	}
+	if err := json.Unmarshal(data, &st); err != nil {
+		return st, err
+	}
+	if st.Records == nil {
+		st.Records = map[string]string{}
+	}
+	return st, nil
+}
+
+// Save writes the state atomically next to path.
+func (s State) Save(path string) error {
+	if path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".ddns-state-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
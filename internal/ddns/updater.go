@@ -0,0 +1,313 @@
+package ddns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+	"time"
+
+	"ddnsjx/internal/app"
+	"ddnsjx/internal/dns"
+	"ddnsjx/internal/provider"
+	"ddnsjx/internal/telemetry"
+)
+
+// Updater points the A/AAAA records of Names at the detected address.
+type Updater struct {
+	Client   provider.Client
+	Zone     string
+	Line     string
+	Names    []string // sub-domains of Zone, "@" for the apex
+	Families []Family
+	TTL      *uint64
+	Detector Detector
+
+	// StatePath persists the last written addresses; empty keeps them in
+	// memory only.
+	StatePath string
+	Logf      func(format string, args ...any)
+	// OnRetry, if set, is called before a failed pass is retried.
+	OnRetry func(err error)
+
+	// OwnerID is written as the ownership marker of records the updater
+	// creates or updates, on providers that store it natively. Another
+	// value at a name is only replaced or removed when it holds the
+	// address last written, carries OwnerID (natively or through the
+	// app.RegistryRecord TXT), or Adopt is set.
+	OwnerID string
+	Adopt   bool
+	// Protected names are never touched (see dns.Plan.Protected).
+	Protected []string
+
+	state  State
+	loaded bool
+}
+
+// Once detects the current addresses and updates every record whose last
+// written address differs. Records are looked up by name and type, not by
+// the new address: a record this updater owns is updated in place and its
+// other stale addresses are removed, so the old IP never stays published
+// next to the new one. Values owned by someone else are left alone.
+// Missing records are created.
+func (u *Updater) Once(ctx context.Context) (err error) {
+	ctx, span := telemetry.StartSpan(ctx, "ddns.update", telemetry.String("dns.zone", u.Zone))
+	defer func() { span.End(err) }()
+
+	if !u.loaded {
+		st, err := LoadState(u.StatePath)
+		if err != nil {
+			return fmt.Errorf("load state: %w", err)
+		}
+		u.state, u.loaded = st, true
+	}
+
+	protected := dns.Plan{Protected: u.Protected}
+	var errs []error
+	for _, family := range u.Families {
+		ip, err := u.Detector.Detect(ctx, family)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, name := range u.Names {
+			rec := dns.Record{SubDomain: name, Type: family.RecordType(), Value: ip.String(), TTL: u.TTL, Owner: u.OwnerID}
+			if protected.IsProtected(name) {
+				errs = append(errs, app.ProtectedError{Type: rec.Type, SubDomain: name})
+				continue
+			}
+			key := stateKey(rec.Type, u.fqdn(name))
+			if u.state.Records[key] == rec.Value {
+				continue
+			}
+			action, err := u.apply(ctx, rec, u.state.Records[key])
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s %s: %w", rec.Type, u.fqdn(name), err))
+				continue
+			}
+			u.logf("%s %s -> %s (%s)", rec.Type, u.fqdn(name), rec.Value, action)
+			u.state.Records[key] = rec.Value
+			u.state.Updated = time.Now().UTC()
+			if err := u.state.Save(u.StatePath); err != nil {
+				errs = append(errs, fmt.Errorf("save state: %w", err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// apply points rec's name at rec.Value. last is the address written by the
+// previous pass, if any.
+func (u *Updater) apply(ctx context.Context, rec dns.Record, last string) (string, error) {
+	if l, ok := provider.As[provider.RecordLister](u.Client); ok {
+		existing, err := l.ListRecords(ctx, u.Zone, u.Line, rec)
+		if err != nil {
+			return "", err
+		}
+		return u.replace(ctx, rec, last, existing)
+	}
+
+	// FindRecord matches the value too, so without a lister the record to
+	// update is the one holding the address we wrote last.
+	if last != "" {
+		old := rec
+		old.Value = last
+		id, found, err := u.Client.FindRecord(ctx, u.Zone, u.Line, old)
+		if err != nil {
+			return "", err
+		}
+		if found && id != "" {
+			if err := u.Client.UpdateRecord(ctx, u.Zone, u.Line, id, rec); err != nil {
+				return "", err
+			}
+			return "updated", nil
+		}
+	}
+	id, found, err := u.Client.FindRecord(ctx, u.Zone, u.Line, rec)
+	if err != nil {
+		return "", err
+	}
+	if found && id != "" {
+		if err := u.Client.UpdateRecord(ctx, u.Zone, u.Line, id, rec); err != nil {
+			return "", err
+		}
+		return "updated", nil
+	}
+	return u.create(ctx, rec)
+}
+
+// replace points rec's name at rec.Value given the existing records there:
+// it keeps a record already holding the address, otherwise updates one it
+// owns, and deletes its other values. Without an owned record it creates
+// a new one next to the foreign values.
+func (u *Updater) replace(ctx context.Context, rec dns.Record, last string, existing []provider.ExistingRecord) (string, error) {
+	if len(existing) == 0 {
+		return u.create(ctx, rec)
+	}
+	owned, err := u.owned(ctx, rec, last, existing)
+	if err != nil {
+		return "", err
+	}
+	keep := -1
+	for i, e := range existing {
+		if sameAddr(e.Value, rec.Value) {
+			keep = i
+			break
+		}
+	}
+	if keep < 0 {
+		for i := range existing {
+			if owned[i] {
+				keep = i
+				break
+			}
+		}
+	}
+
+	action := "exists"
+	switch {
+	case keep < 0:
+		if action, err = u.create(ctx, rec); err != nil {
+			return "", err
+		}
+	case owned[keep]:
+		if err := u.Client.UpdateRecord(ctx, u.Zone, u.Line, existing[keep].ID, rec); err != nil {
+			return "", err
+		}
+		action = "updated"
+	}
+
+	stale := 0
+	for i, e := range existing {
+		if i == keep || !owned[i] {
+			continue
+		}
+		if err := u.Client.DeleteRecord(ctx, u.Zone, e.ID); err != nil {
+			return "", fmt.Errorf("remove stale %s: %w", e.Value, err)
+		}
+		stale++
+	}
+	if stale > 0 {
+		action += fmt.Sprintf(", %d stale removed", stale)
+	}
+	return action, nil
+}
+
+// owned reports which of existing this updater may replace or delete.
+func (u *Updater) owned(ctx context.Context, rec dns.Record, last string, existing []provider.ExistingRecord) ([]bool, error) {
+	out := make([]bool, len(existing))
+	all := u.Adopt
+	reader, native := provider.As[provider.OwnerReader](u.Client)
+	if !all && !native && u.OwnerID != "" {
+		_, found, err := u.Client.FindRecord(ctx, u.Zone, u.Line, app.RegistryRecord(u.OwnerID, rec))
+		if err != nil {
+			return nil, err
+		}
+		all = found
+	}
+	for i, e := range existing {
+		switch {
+		case all || last != "" && sameAddr(e.Value, last):
+			out[i] = true
+		case native && u.OwnerID != "":
+			owner, err := reader.RecordOwner(ctx, u.Zone, e.ID)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = owner == u.OwnerID
+		}
+	}
+	return out, nil
+}
+
+func (u *Updater) create(ctx context.Context, rec dns.Record) (string, error) {
+	_, status, err := u.Client.CreateRecord(ctx, u.Zone, u.Line, rec)
+	if err != nil {
+		return "", err
+	}
+	if status == provider.CreateStatusExists {
+		return "exists", nil
+	}
+	return "created", nil
+}
+
+func sameAddr(a, b string) bool {
+	x, errX := netip.ParseAddr(strings.TrimSpace(a))
+	y, errY := netip.ParseAddr(strings.TrimSpace(b))
+	if errX != nil || errY != nil {
+		return strings.EqualFold(strings.TrimSpace(a), strings.TrimSpace(b))
+	}
+	return x == y
+}
+
+// Run calls Once every interval until ctx is done. After a failed pass it
+// retries sooner, backing off exponentially up to maxBackoff (or longer
+// when the provider asks for it with Retry-After).
+func (u *Updater) Run(ctx context.Context, interval, maxBackoff time.Duration) error {
+	var backoff time.Duration
+	for {
+		wait := interval
+		if err := u.Once(ctx); err != nil {
+			backoff = nextBackoff(backoff, interval, maxBackoff)
+			wait = backoff
+			var t throttled
+			if errors.As(err, &t) && t.RetryDelay() > wait {
+				wait = t.RetryDelay()
+			}
+			u.logf("update failed, retrying in %s: %v", wait, err)
+			if u.OnRetry != nil {
+				u.OnRetry(err)
+			}
+		} else {
+			backoff = 0
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+	}
+}
+
+// minBackoff is the first retry delay after a failed pass.
+const minBackoff = 30 * time.Second
+
+// nextBackoff doubles prev, starting at minBackoff (or interval when that is
+// shorter) and capped at max.
+func nextBackoff(prev, interval, max time.Duration) time.Duration {
+	next := prev * 2
+	if prev == 0 {
+		next = minBackoff
+		if interval > 0 && interval < next {
+			next = interval
+		}
+	}
+	if max > 0 && next > max {
+		next = max
+	}
+	return next
+}
+
+// throttled is implemented by provider errors that carry a Retry-After
+// hint.
+type throttled interface {
+	RetryDelay() time.Duration
+}
+
+func (u *Updater) fqdn(name string) string {
+	zone := strings.TrimSuffix(u.Zone, ".")
+	if name == "@" || name == "" {
+		return zone
+	}
+	return name + "." + zone
+}
+
+func (u *Updater) logf(format string, args ...any) {
+	if u.Logf != nil {
+		u.Logf(format, args...)
+	}
+}
@@ -0,0 +1,76 @@
+package desecclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"ddnsjx/internal/rrset"
+)
+
+type apiRRSet struct {
+	Subname string   `json:"subname"`
+	Type    string   `json:"type"`
+	TTL     uint64   `json:"ttl,omitempty"`
+	Records []string `json:"records"`
+}
+
+type domainResponse struct {
+	Name       string `json:"name"`
+	MinimumTTL uint64 `json:"minimum_ttl"`
+	Keys       []struct {
+		DNSKey  string   `json:"dnskey"`
+		DS      []string `json:"ds"`
+		Managed bool     `json:"managed"`
+	} `json:"keys"`
+}
+
+func domainPath(zone string) string {
+	return "/domains/" + url.PathEscape(zoneName(zone))
+}
+
+// SetName returns the set name of sub: relative to the zone, "@" for the
+// apex.
+func (c *client) SetName(_, sub string) string {
+	return rrset.Relative(sub)
+}
+
+// GetSet returns the RRset at name and type; a missing set is returned
+// empty.
+func (c *client) GetSet(ctx context.Context, zone, name, typ string) (*rrset.Set, error) {
+	path := domainPath(zone) + "/rrsets/" + url.PathEscape(name) + "/" + url.PathEscape(typ) + "/"
+
+	var resp apiRRSet
+	if err := c.do(ctx, "GET", path, nil, &resp); err != nil {
+		var e Error
+		if errors.As(err, &e) && e.Status == http.StatusNotFound {
+			return &rrset.Set{Name: name, Type: typ}, nil
+		}
+		return nil, err
+	}
+	return &rrset.Set{Name: name, Type: typ, TTL: resp.TTL, Values: resp.Records}, nil
+}
+
+// SubmitSets sends every set in one bulk PATCH, which deSEC applies
+// atomically; an empty record list deletes the set.
+func (c *client) SubmitSets(ctx context.Context, zone string, updates []rrset.Update) error {
+	var sets []apiRRSet
+	for _, u := range updates {
+		s := u.After
+		e := apiRRSet{Subname: subname(s.Name), Type: s.Type, Records: s.Values}
+		if len(s.Values) > 0 {
+			e.TTL = s.TTL
+		}
+		if e.Records == nil {
+			e.Records = []string{}
+		}
+		sets = append(sets, e)
+	}
+	return c.do(ctx, "PATCH", domainPath(zone)+"/rrsets/", sets, nil)
+}
+
+func (c *client) do(ctx context.Context, method, path string, body any, out any) error {
+	_, err := c.rest.Do(ctx, method, path, body, out)
+	return err
+}
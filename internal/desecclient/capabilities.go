@@ -0,0 +1,13 @@
+package desecclient
+
+import "ddnsjx/internal/provider"
+
+// capabilities reflects deSEC's defaults: a minimum TTL of 3600 (lower
+// per-domain minimums are granted on request) and at most one day.
+func capabilities() provider.Capabilities {
+	return provider.Capabilities{
+		RecordTypes: []string{"A", "AAAA", "CAA", "CNAME", "DS", "HTTPS", "MX", "NAPTR", "NS", "PTR", "SRV", "SSHFP", "SVCB", "TLSA", "TXT"},
+		MinTTL:      3600,
+		MaxTTL:      86400,
+	}
+}
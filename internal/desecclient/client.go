@@ -0,0 +1,141 @@
+// Package desecclient implements provider.Client for deSEC (desec.io).
+//
+// deSEC stores RRsets (all records of one name and type, sharing a TTL) and
+// accepts a list of RRsets in one bulk PATCH, applied atomically. Every
+// per-record operation goes through the same path as ApplyBatch: read the
+// RRsets, edit them in memory and PATCH back the ones that changed. Record
+// ids are synthesised from subname ("@" for the apex), type and content.
+//
+// deSEC throttles aggressively; throttled responses carry Retry-After,
+// which Error exposes to the runner through RetryDelay.
+package desecclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"ddnsjx/internal/dns"
+	"ddnsjx/internal/provider"
+	"ddnsjx/internal/restclient"
+	"ddnsjx/internal/rrset"
+)
+
+const (
+	defaultBaseURL = "https://desec.io/api/v1"
+	defaultTTL     = 3600
+)
+
+type NewOptions struct {
+	APIToken string
+	// BaseURL overrides the API root (default https://desec.io/api/v1).
+	BaseURL string
+	// Transport replaces the HTTP transport, e.g. with a cassette.
+	Transport http.RoundTripper
+}
+
+type client struct {
+	rest *restclient.Client
+	sets rrset.Engine
+}
+
+type Error struct {
+	Status  int
+	Message string
+	// RetryAfter is the server's Retry-After hint on throttled responses.
+	RetryAfter time.Duration
+}
+
+func (e Error) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("[%d] %s (retry after %s)", e.Status, e.Message, e.RetryAfter)
+	}
+	return fmt.Sprintf("[%d] %s", e.Status, e.Message)
+}
+
+func (e Error) Retryable() bool {
+	return restclient.RetryableStatus(e.Status)
+}
+
+func (e Error) RetryDelay() time.Duration {
+	return e.RetryAfter
+}
+
+func New(opt NewOptions) (provider.Client, error) {
+	if strings.TrimSpace(opt.APIToken) == "" {
+		return nil, fmt.Errorf("missing deSEC API token")
+	}
+	baseURL := strings.TrimSpace(opt.BaseURL)
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+	c := &client{rest: restclient.New(restclient.Options{
+		BaseURL:     baseURL,
+		Auth:        restclient.Header("Authorization", "Token "+strings.TrimSpace(opt.APIToken)),
+		DecodeError: decodeError,
+		Transport:   opt.Transport,
+	})}
+	c.sets = rrset.Engine{Store: c, Provider: "desec", DefaultTTL: defaultTTL}
+	return c, nil
+}
+
+// decodeError reads {"detail": "..."} and otherwise keeps the body, which
+// for validation errors lists the problems per field or per RRset.
+func decodeError(status int, h http.Header, b []byte) error {
+	e := Error{Status: status, Message: strings.TrimSpace(string(b)), RetryAfter: restclient.RetryAfter(h)}
+	var body struct {
+		Detail string `json:"detail"`
+	}
+	if json.Unmarshal(b, &body) == nil && body.Detail != "" {
+		e.Message = body.Detail
+	}
+	return e
+}
+
+func (c *client) Capabilities() provider.Capabilities {
+	return capabilities()
+}
+
+func (c *client) CreateRecord(ctx context.Context, zone string, _ string, record dns.Record) (string, provider.CreateStatus, error) {
+	return c.sets.Create(ctx, zone, record)
+}
+
+func (c *client) DeleteRecord(ctx context.Context, zone string, recordID string) error {
+	return c.sets.Delete(ctx, zone, recordID)
+}
+
+// FindRecord looks for record's content in the RRset at its name and type.
+func (c *client) FindRecord(ctx context.Context, zone string, _ string, record dns.Record) (string, bool, error) {
+	return c.sets.Find(ctx, zone, record)
+}
+
+// ListRecords returns every record of the RRset at record's name and type.
+func (c *client) ListRecords(ctx context.Context, zone string, _ string, record dns.Record) ([]provider.ExistingRecord, error) {
+	return c.sets.List(ctx, zone, record)
+}
+
+// UpdateRecord replaces the record identified by recordID with record. The
+// record's TTL applies to the whole RRset.
+func (c *client) UpdateRecord(ctx context.Context, zone string, _ string, recordID string, record dns.Record) error {
+	return c.sets.Update(ctx, zone, recordID, record)
+}
+
+// DSRecords returns the DS records deSEC publishes for the zone's keys,
+// for registration at the registrar.
+func (c *client) DSRecords(ctx context.Context, zone string) ([]string, error) {
+	var d domainResponse
+	if err := c.do(ctx, "GET", domainPath(zone)+"/", nil, &d); err != nil {
+		return nil, err
+	}
+	var out []string
+	for _, k := range d.Keys {
+		out = append(out, k.DS...)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("deSEC reports no DS records for %s", zoneName(zone))
+	}
+	return out, nil
+}
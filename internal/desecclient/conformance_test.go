@@ -0,0 +1,199 @@
+package desecclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"ddnsjx/internal/dns"
+	"ddnsjx/internal/provider"
+	"ddnsjx/internal/provider/providertest"
+)
+
+func newTestClient(t *testing.T, api *fakeAPI) provider.Client {
+	t.Helper()
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+	c, err := New(NewOptions{APIToken: "test", BaseURL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestConformance(t *testing.T) {
+	providertest.Run(t, func(t *testing.T) provider.Client {
+		return newTestClient(t, newFakeAPI("example.com"))
+	}, providertest.Options{ContentIDs: true})
+}
+
+func TestBulkPatch(t *testing.T) {
+	api := newFakeAPI("example.com")
+	api.sets["/MX"] = &apiRRSet{Subname: "", Type: "MX", TTL: 3600, Records: []string{"10 mx1.example.net."}}
+	c := newTestClient(t, api)
+	ctx := context.Background()
+
+	ttl := uint64(7200)
+	prio := uint64(20)
+	results, err := c.(provider.Batcher).ApplyBatch(ctx, "example.com", "", []provider.Change{
+		{Action: provider.ChangeCreate, Record: dns.Record{SubDomain: "@", Type: "MX", Value: "mx2.example.net", Priority: &prio, TTL: &ttl}},
+		{Action: provider.ChangeCreate, Record: dns.Record{SubDomain: "www", Type: "A", Value: "192.0.2.1"}},
+		{Action: provider.ChangeDelete, RecordID: "@ MX 10 mx1.example.net."},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].RecordID != "@ MX 20 mx2.example.net." {
+		t.Fatalf("unexpected apex id %q", results[0].RecordID)
+	}
+	if api.patches != 1 {
+		t.Fatalf("expected one bulk PATCH, got %d", api.patches)
+	}
+	mx := api.sets["/MX"]
+	if len(mx.Records) != 1 || mx.Records[0] != "20 mx2.example.net." || mx.TTL != 7200 {
+		t.Fatalf("unexpected apex MX rrset %+v", mx)
+	}
+
+	low := uint64(60)
+	_, err = c.(provider.Batcher).ApplyBatch(ctx, "example.com", "", []provider.Change{
+		{Action: provider.ChangeCreate, Record: dns.Record{SubDomain: "a", Type: "A", Value: "192.0.2.2"}},
+		{Action: provider.ChangeCreate, Record: dns.Record{SubDomain: "b", Type: "A", Value: "192.0.2.3", TTL: &low}},
+	})
+	var e Error
+	if !errors.As(err, &e) || e.Status != http.StatusBadRequest {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+	if _, ok := api.sets["a/A"]; ok {
+		t.Fatalf("a rejected bulk PATCH must not apply any RRset")
+	}
+}
+
+func TestThrottlingAndDS(t *testing.T) {
+	api := newFakeAPI("example.com")
+	api.throttle = 1
+	c := newTestClient(t, api)
+	ctx := context.Background()
+
+	_, _, err := c.FindRecord(ctx, "example.com", "", dns.Record{SubDomain: "www", Type: "A", Value: "192.0.2.1"})
+	var e Error
+	if !errors.As(err, &e) || !e.Retryable() || e.RetryDelay() != 2*time.Second {
+		t.Fatalf("expected a retryable throttling error with the Retry-After hint, got %v", err)
+	}
+	if !strings.Contains(e.Error(), "throttled") {
+		t.Fatalf("expected the detail message, got %q", e.Error())
+	}
+
+	ds, err := c.(provider.DSReader).DSRecords(ctx, "example.com.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ds) != 2 || !strings.HasPrefix(ds[0], "12345 13 2 ") {
+		t.Fatalf("unexpected DS records %v", ds)
+	}
+}
+
+// fakeAPI is a stand-in for the deSEC domain and RRset endpoints. Like the
+// real service it answers 404 for a missing RRset, validates a bulk PATCH
+// as a whole before applying any of it and throttles with 429 and
+// Retry-After.
+type fakeAPI struct {
+	mu       sync.Mutex
+	domain   string
+	sets     map[string]*apiRRSet
+	patches  int
+	throttle int
+}
+
+func newFakeAPI(domain string) *fakeAPI {
+	return &fakeAPI{domain: domain, sets: make(map[string]*apiRRSet)}
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "Token test" {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"detail": "Invalid token."})
+		return
+	}
+	if f.throttle > 0 {
+		f.throttle--
+		w.Header().Set("Retry-After", "2")
+		writeJSON(w, http.StatusTooManyRequests, map[string]string{"detail": "Request was throttled. Expected available in 2 seconds."})
+		return
+	}
+
+	rest, ok := strings.CutPrefix(r.URL.Path, "/domains/"+f.domain+"/")
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"detail": "Not found."})
+		return
+	}
+	parts := strings.Split(strings.TrimSuffix(rest, "/"), "/")
+	switch {
+	case rest == "" && r.Method == "GET":
+		writeJSON(w, http.StatusOK, map[string]any{
+			"name":        f.domain,
+			"minimum_ttl": 3600,
+			"keys": []map[string]any{{
+				"dnskey":  "257 3 13 AAAA",
+				"ds":      []string{"12345 13 2 aa11", "12345 13 4 bb22"},
+				"managed": true,
+			}},
+		})
+	case len(parts) == 3 && parts[0] == "rrsets" && r.Method == "GET":
+		name := parts[1]
+		if name == "@" {
+			name = ""
+		}
+		set, ok := f.sets[name+"/"+parts[2]]
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"detail": "Not found."})
+			return
+		}
+		writeJSON(w, http.StatusOK, set)
+	case rest == "rrsets/" && r.Method == "PATCH":
+		var sets []apiRRSet
+		if err := json.NewDecoder(r.Body).Decode(&sets); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"detail": err.Error()})
+			return
+		}
+		problems := make([]map[string][]string, len(sets))
+		var invalid bool
+		for i, s := range sets {
+			problems[i] = map[string][]string{}
+			if len(s.Records) > 0 && s.TTL < 3600 {
+				problems[i]["ttl"] = []string{"Ensure this value is greater than or equal to 3600."}
+				invalid = true
+			}
+		}
+		if invalid {
+			writeJSON(w, http.StatusBadRequest, problems)
+			return
+		}
+		f.patches++
+		for _, s := range sets {
+			key := s.Subname + "/" + s.Type
+			if len(s.Records) == 0 {
+				delete(f.sets, key)
+				continue
+			}
+			set := s
+			f.sets[key] = &set
+		}
+		writeJSON(w, http.StatusOK, sets)
+	default:
+		writeJSON(w, http.StatusNotFound, map[string]string{"detail": "Not found."})
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
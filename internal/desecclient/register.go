@@ -0,0 +1,25 @@
+package desecclient
+
+import (
+	"net/http"
+
+	"ddnsjx/internal/provider"
+)
+
+func init() {
+	provider.Register(provider.Factory{
+		Name: "desec",
+		Help: "deSEC (desec.io, DNSSEC-signed; token auth)",
+		Settings: []provider.Setting{
+			{Name: "token", Flag: "desec-token", Env: "DESEC_TOKEN", Help: "deSEC API token", Required: true, Secret: true},
+			{Name: "base_url", Flag: "desec-base-url", Env: "DESEC_BASE_URL", Help: "deSEC API root (default " + defaultBaseURL + ")"},
+		},
+		New: func(_ string, s provider.Settings, transport http.RoundTripper) (provider.Client, error) {
+			return New(NewOptions{
+				APIToken:  s.Get("token"),
+				BaseURL:   s.Get("base_url"),
+				Transport: transport,
+			})
+		},
+	})
+}
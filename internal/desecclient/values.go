@@ -0,0 +1,16 @@
+package desecclient
+
+import "strings"
+
+func zoneName(zone string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(zone), "."))
+}
+
+// subname returns the deSEC subname of a set name: relative to the zone
+// and empty for the apex, which set names and record ids write as "@".
+func subname(name string) string {
+	if name == "@" {
+		return ""
+	}
+	return name
+}
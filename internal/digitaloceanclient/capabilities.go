@@ -0,0 +1,10 @@
+package digitaloceanclient
+
+import "ddnsjx/internal/provider"
+
+func capabilities() provider.Capabilities {
+	return provider.Capabilities{
+		RecordTypes: []string{"A", "AAAA", "CAA", "CNAME", "MX", "NS", "SRV", "TXT"},
+		MinTTL:      30,
+	}
+}
@@ -0,0 +1,200 @@
+// Package digitaloceanclient implements provider.Client for DigitalOcean
+// domains (API v2).
+package digitaloceanclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"ddnsjx/internal/dns"
+	"ddnsjx/internal/provider"
+	"ddnsjx/internal/restclient"
+)
+
+const defaultBaseURL = "https://api.digitalocean.com/v2"
+
+type NewOptions struct {
+	APIToken string
+	// BaseURL overrides the API root (default https://api.digitalocean.com/v2).
+	BaseURL string
+	// Transport replaces the HTTP transport, e.g. with a cassette.
+	Transport http.RoundTripper
+}
+
+type client struct {
+	rest *restclient.Client
+}
+
+type Error struct {
+	Status  int
+	ID      string
+	Message string
+}
+
+func (e Error) Error() string {
+	if e.ID == "" {
+		return fmt.Sprintf("[%d] %s", e.Status, e.Message)
+	}
+	return fmt.Sprintf("[%s] %s", e.ID, e.Message)
+}
+
+// ErrorCode returns the API error id, or the HTTP status when there is
+// none, for metrics.
+func (e Error) ErrorCode() string {
+	if e.ID == "" {
+		return strconv.Itoa(e.Status)
+	}
+	return e.ID
+}
+
+func (e Error) Retryable() bool {
+	return restclient.RetryableStatus(e.Status)
+}
+
+func New(opt NewOptions) (provider.Client, error) {
+	if strings.TrimSpace(opt.APIToken) == "" {
+		return nil, fmt.Errorf("missing DigitalOcean api token")
+	}
+	baseURL := strings.TrimSpace(opt.BaseURL)
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+	return &client{rest: restclient.New(restclient.Options{
+		BaseURL:     baseURL,
+		Auth:        restclient.BearerToken(strings.TrimSpace(opt.APIToken)),
+		DecodeError: decodeError,
+		Transport:   opt.Transport,
+	})}, nil
+}
+
+func decodeError(status int, _ http.Header, b []byte) error {
+	var e struct {
+		ID      string `json:"id"`
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(b, &e) == nil && e.Message != "" {
+		return Error{Status: status, ID: e.ID, Message: e.Message}
+	}
+	return nil
+}
+
+func (c *client) Capabilities() provider.Capabilities {
+	return capabilities()
+}
+
+func recordsPath(zone string) string {
+	return "/domains/" + url.PathEscape(zoneName(zone)) + "/records"
+}
+
+// CreateRecord looks for an identical record first, since DigitalOcean
+// stores duplicates.
+func (c *client) CreateRecord(ctx context.Context, zone string, recordLine string, record dns.Record) (string, provider.CreateStatus, error) {
+	if id, found, err := c.FindRecord(ctx, zone, recordLine, record); err != nil {
+		return "", provider.CreateStatusFail, err
+	} else if found {
+		return id, provider.CreateStatusExists, nil
+	}
+
+	body, err := toDO(record)
+	if err != nil {
+		return "", provider.CreateStatusFail, err
+	}
+	var resp struct {
+		Record doRecord `json:"domain_record"`
+	}
+	if _, err := c.rest.Do(ctx, "POST", recordsPath(zone), body, &resp); err != nil {
+		return "", provider.CreateStatusFail, err
+	}
+	return strconv.FormatInt(resp.Record.ID, 10), provider.CreateStatusSuccess, nil
+}
+
+func (c *client) DeleteRecord(ctx context.Context, zone string, recordID string) error {
+	_, err := c.rest.Do(ctx, "DELETE", recordsPath(zone)+"/"+url.PathEscape(strings.TrimSpace(recordID)), nil, nil)
+	return err
+}
+
+func (c *client) FindRecord(ctx context.Context, zone string, _ string, record dns.Record) (string, bool, error) {
+	want, err := toDO(record)
+	if err != nil {
+		return "", false, err
+	}
+	records, err := c.listAt(ctx, zone, record)
+	if err != nil {
+		return "", false, err
+	}
+	var found []string
+	for _, r := range records {
+		if want.matches(r) {
+			found = append(found, strconv.FormatInt(r.ID, 10))
+		}
+	}
+	switch len(found) {
+	case 0:
+		return "", false, nil
+	case 1:
+		return found[0], true, nil
+	default:
+		return "", false, fmt.Errorf("multiple existing records found for %s %s; cannot safely update", record.Type, record.SubDomain)
+	}
+}
+
+// ListRecords returns every record at record's name and type.
+func (c *client) ListRecords(ctx context.Context, zone string, _ string, record dns.Record) ([]provider.ExistingRecord, error) {
+	records, err := c.listAt(ctx, zone, record)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]provider.ExistingRecord, 0, len(records))
+	for _, r := range records {
+		out = append(out, provider.ExistingRecord{ID: strconv.FormatInt(r.ID, 10), Value: r.Data})
+	}
+	return out, nil
+}
+
+// listAt pages through the records the API returns for record's name and
+// type.
+func (c *client) listAt(ctx context.Context, zone string, record dns.Record) ([]doRecord, error) {
+	typ := strings.ToUpper(strings.TrimSpace(record.Type))
+	name := relativeName(record.SubDomain)
+
+	var found []doRecord
+	err := restclient.Paginate(ctx, func(page int) (bool, error) {
+		query := url.Values{}
+		query.Set("type", typ)
+		query.Set("name", fqdn(zone, record.SubDomain))
+		query.Set("page", strconv.Itoa(page))
+		query.Set("per_page", "200")
+		var resp struct {
+			Records []doRecord `json:"domain_records"`
+			Links   struct {
+				Pages struct {
+					Next string `json:"next"`
+				} `json:"pages"`
+			} `json:"links"`
+		}
+		if _, err := c.rest.Do(ctx, "GET", recordsPath(zone)+"?"+query.Encode(), nil, &resp); err != nil {
+			return false, err
+		}
+		for _, r := range resp.Records {
+			if strings.EqualFold(r.Type, typ) && strings.EqualFold(r.Name, name) {
+				found = append(found, r)
+			}
+		}
+		return resp.Links.Pages.Next != "", nil
+	})
+	return found, err
+}
+
+func (c *client) UpdateRecord(ctx context.Context, zone string, _ string, recordID string, record dns.Record) error {
+	body, err := toDO(record)
+	if err != nil {
+		return err
+	}
+	_, err = c.rest.Do(ctx, "PUT", recordsPath(zone)+"/"+url.PathEscape(strings.TrimSpace(recordID)), body, nil)
+	return err
+}
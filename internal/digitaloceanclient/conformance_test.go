@@ -0,0 +1,219 @@
+package digitaloceanclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"ddnsjx/internal/dns"
+	"ddnsjx/internal/provider"
+	"ddnsjx/internal/provider/providertest"
+)
+
+func newTestClient(t *testing.T, api *fakeAPI) provider.Client {
+	t.Helper()
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+	c, err := New(NewOptions{APIToken: "test", BaseURL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestConformance(t *testing.T) {
+	providertest.Run(t, func(t *testing.T) provider.Client {
+		return newTestClient(t, newFakeAPI("example.com"))
+	}, providertest.Options{})
+}
+
+func TestStructuredFields(t *testing.T) {
+	api := newFakeAPI("example.com")
+	c := newTestClient(t, api)
+	ctx := context.Background()
+
+	id, _, err := c.CreateRecord(ctx, "example.com", "", dns.Record{SubDomain: "_imaps._tcp", Type: "SRV", Value: "0 1 993 mail.example.com"})
+	if err != nil {
+		t.Fatalf("create SRV: %v", err)
+	}
+	srv := api.records[mustInt(t, id)]
+	if *srv.Priority != 0 || *srv.Weight != 1 || *srv.Port != 993 || srv.Data != "mail.example.com." {
+		t.Fatalf("unexpected SRV fields %+v", srv)
+	}
+
+	id, _, err = c.CreateRecord(ctx, "example.com", "", dns.Record{SubDomain: "@", Type: "CAA", Value: `0 issue "letsencrypt.org"`})
+	if err != nil {
+		t.Fatalf("create CAA: %v", err)
+	}
+	caa := api.records[mustInt(t, id)]
+	if *caa.Flags != 0 || caa.Tag != "issue" || caa.Data != "letsencrypt.org" || caa.Name != "@" {
+		t.Fatalf("unexpected CAA fields %+v", caa)
+	}
+
+	api.pageSize = 1
+	for i := 0; i < 3; i++ {
+		api.add(doRecord{Type: "A", Name: "www", Data: fmt.Sprintf("192.0.2.%d", i+1)})
+	}
+	if _, found, err := c.FindRecord(ctx, "example.com", "", dns.Record{SubDomain: "www", Type: "A", Value: "192.0.2.3"}); err != nil || !found {
+		t.Fatalf("expected record on the last page: %v %v", found, err)
+	}
+
+	_, _, err = c.FindRecord(ctx, "example.org", "", dns.Record{SubDomain: "www", Type: "A", Value: "192.0.2.1"})
+	var apiErr Error
+	if !errors.As(err, &apiErr) || apiErr.ID != "not_found" || apiErr.Retryable() {
+		t.Fatalf("expected not_found Error, got %#v", err)
+	}
+}
+
+func mustInt(t *testing.T, id string) int64 {
+	t.Helper()
+	n, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		t.Fatalf("record id %q is not numeric", id)
+	}
+	return n
+}
+
+// fakeAPI is a stand-in for the DigitalOcean domain records API. It
+// filters by type and full name, paginates with links.pages.next, strips
+// the trailing dot from hostnames on read and accepts duplicates.
+type fakeAPI struct {
+	mu       sync.Mutex
+	zone     string
+	nextID   int64
+	pageSize int
+	records  map[int64]doRecord
+}
+
+func newFakeAPI(zone string) *fakeAPI {
+	return &fakeAPI{zone: zone, records: make(map[int64]doRecord)}
+}
+
+func (f *fakeAPI) add(r doRecord) int64 {
+	f.nextID++
+	r.ID = f.nextID
+	if r.TTL == 0 {
+		r.TTL = 1800
+	}
+	f.records[r.ID] = r
+	return r.ID
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer test" {
+		writeDOError(w, http.StatusUnauthorized, "unauthorized", "Unable to authenticate you")
+		return
+	}
+	base := "/domains/" + f.zone + "/records"
+	if !strings.HasPrefix(r.URL.Path, base) {
+		writeDOError(w, http.StatusNotFound, "not_found", "The resource you were accessing could not be found.")
+		return
+	}
+
+	switch id := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, base), "/"); {
+	case id == "" && r.Method == http.MethodGet:
+		f.list(w, r.URL)
+	case id == "" && r.Method == http.MethodPost:
+		var rec doRecord
+		if err := json.NewDecoder(r.Body).Decode(&rec); err != nil {
+			writeDOError(w, http.StatusUnprocessableEntity, "unprocessable_entity", err.Error())
+			return
+		}
+		n := f.add(rec)
+		writeJSON(w, http.StatusCreated, map[string]any{"domain_record": f.view(f.records[n])})
+	default:
+		n, _ := strconv.ParseInt(id, 10, 64)
+		cur, ok := f.records[n]
+		if !ok {
+			writeDOError(w, http.StatusNotFound, "not_found", "The resource you were accessing could not be found.")
+			return
+		}
+		switch r.Method {
+		case http.MethodPut:
+			var rec doRecord
+			if err := json.NewDecoder(r.Body).Decode(&rec); err != nil {
+				writeDOError(w, http.StatusUnprocessableEntity, "unprocessable_entity", err.Error())
+				return
+			}
+			rec.ID = cur.ID
+			if rec.TTL == 0 {
+				rec.TTL = cur.TTL
+			}
+			f.records[n] = rec
+			writeJSON(w, http.StatusOK, map[string]any{"domain_record": f.view(rec)})
+		case http.MethodDelete:
+			delete(f.records, n)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	}
+}
+
+func (f *fakeAPI) list(w http.ResponseWriter, u *url.URL) {
+	q := u.Query()
+	var all []doRecord
+	for _, rec := range f.records {
+		name := rec.Name + "." + f.zone
+		if rec.Name == "@" {
+			name = f.zone
+		}
+		if (q.Get("type") == "" || rec.Type == q.Get("type")) && (q.Get("name") == "" || name == q.Get("name")) {
+			all = append(all, f.view(rec))
+		}
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].ID < all[j].ID })
+
+	perPage, _ := strconv.Atoi(q.Get("per_page"))
+	if f.pageSize > 0 {
+		perPage = f.pageSize
+	}
+	if perPage <= 0 {
+		perPage = 20
+	}
+	page, _ := strconv.Atoi(q.Get("page"))
+	page = max(page, 1)
+	lo := min(len(all), (page-1)*perPage)
+	hi := min(len(all), lo+perPage)
+
+	pages := map[string]string{}
+	if hi < len(all) {
+		next := *u
+		q.Set("page", strconv.Itoa(page+1))
+		next.RawQuery = q.Encode()
+		pages["next"] = "https://api.digitalocean.com/v2" + next.RequestURI()
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"domain_records": append([]doRecord{}, all[lo:hi]...),
+		"links":          map[string]any{"pages": pages},
+		"meta":           map[string]int{"total": len(all)},
+	})
+}
+
+// view returns rec as the API reports it, without trailing dots.
+func (f *fakeAPI) view(rec doRecord) doRecord {
+	rec.Data = strings.TrimSuffix(rec.Data, ".")
+	return rec
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeDOError(w http.ResponseWriter, status int, id, message string) {
+	writeJSON(w, status, map[string]string{"id": id, "message": message})
+}
@@ -0,0 +1,141 @@
+package digitaloceanclient
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"ddnsjx/internal/dns"
+)
+
+// doRecord is a DigitalOcean domain record. MX, SRV and CAA keep their
+// numeric fields and tag outside data.
+type doRecord struct {
+	ID       int64   `json:"id,omitempty"`
+	Type     string  `json:"type"`
+	Name     string  `json:"name"`
+	Data     string  `json:"data"`
+	Priority *uint64 `json:"priority"`
+	Port     *uint64 `json:"port"`
+	Weight   *uint64 `json:"weight"`
+	Flags    *uint64 `json:"flags"`
+	Tag      string  `json:"tag,omitempty"`
+	TTL      uint64  `json:"ttl,omitempty"`
+}
+
+// toDO splits record into DigitalOcean's fields. Hostnames in data must be
+// absolute (trailing dot) or they are taken as relative to the domain.
+func toDO(record dns.Record) (doRecord, error) {
+	v := strings.TrimSpace(record.Value)
+	out := doRecord{
+		Type: strings.ToUpper(strings.TrimSpace(record.Type)),
+		Name: relativeName(record.SubDomain),
+		Data: v,
+	}
+	if record.TTL != nil && *record.TTL > 0 {
+		out.TTL = *record.TTL
+	}
+
+	switch out.Type {
+	case "MX":
+		if record.Priority == nil {
+			return doRecord{}, fmt.Errorf("MX priority is required for %s", record.SubDomain)
+		}
+		out.Priority = record.Priority
+		out.Data = ensureDot(v)
+	case "CNAME", "NS":
+		out.Data = ensureDot(v)
+	case "TXT":
+		// DigitalOcean takes TXT as plain text and splits it itself.
+		out.Data = dns.TXTText(v)
+	case "SRV":
+		f := strings.Fields(v)
+		if len(f) != 4 {
+			return doRecord{}, fmt.Errorf("SRV value expects: \"<priority> <weight> <port> <target>\", got %q", v)
+		}
+		nums, err := parseUints(f[:3])
+		if err != nil {
+			return doRecord{}, fmt.Errorf("invalid SRV value %q: %w", v, err)
+		}
+		out.Priority, out.Weight, out.Port = &nums[0], &nums[1], &nums[2]
+		out.Data = ensureDot(f[3])
+	case "CAA":
+		f := strings.SplitN(v, " ", 3)
+		if len(f) != 3 {
+			return doRecord{}, fmt.Errorf("CAA value expects: \"<flags> <tag> <value>\", got %q", v)
+		}
+		nums, err := parseUints(f[:1])
+		if err != nil {
+			return doRecord{}, fmt.Errorf("invalid CAA value %q: %w", v, err)
+		}
+		out.Flags = &nums[0]
+		out.Tag = f[1]
+		out.Data = strings.Trim(strings.TrimSpace(f[2]), `"`)
+	}
+	return out, nil
+}
+
+// matches reports whether r holds the same data as want. Hostnames in data
+// are compared without their trailing dot, which reads may omit.
+func (want doRecord) matches(r doRecord) bool {
+	if !strings.EqualFold(r.Type, want.Type) || !strings.EqualFold(r.Name, want.Name) {
+		return false
+	}
+	if !sameUint(r.Priority, want.Priority) || !sameUint(r.Port, want.Port) || !sameUint(r.Weight, want.Weight) || !sameUint(r.Flags, want.Flags) {
+		return false
+	}
+	if !strings.EqualFold(r.Tag, want.Tag) {
+		return false
+	}
+	if want.Type == "TXT" {
+		return dns.SameTXT(r.Data, want.Data)
+	}
+	return strings.EqualFold(strings.TrimSuffix(r.Data, "."), strings.TrimSuffix(want.Data, "."))
+}
+
+// sameUint compares optional numbers; a field want does not set is
+// ignored.
+func sameUint(got, want *uint64) bool {
+	return want == nil || (got != nil && *got == *want)
+}
+
+func parseUints(fields []string) ([]uint64, error) {
+	out := make([]uint64, len(fields))
+	for i, f := range fields {
+		n, err := strconv.ParseUint(f, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = n
+	}
+	return out, nil
+}
+
+func zoneName(zone string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(zone), "."))
+}
+
+func fqdn(zone, sub string) string {
+	sub = strings.ToLower(strings.TrimSpace(sub))
+	if sub == "" || sub == "@" {
+		return zoneName(zone)
+	}
+	return sub + "." + zoneName(zone)
+}
+
+// relativeName returns the record name relative to the domain, "@" for
+// the apex.
+func relativeName(sub string) string {
+	sub = strings.ToLower(strings.TrimSpace(sub))
+	if sub == "" {
+		return "@"
+	}
+	return sub
+}
+
+func ensureDot(s string) string {
+	if s == "" || strings.HasSuffix(s, ".") {
+		return s
+	}
+	return s + "."
+}
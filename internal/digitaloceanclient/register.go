@@ -0,0 +1,25 @@
+package digitaloceanclient
+
+import (
+	"net/http"
+
+	"ddnsjx/internal/provider"
+)
+
+func init() {
+	provider.Register(provider.Factory{
+		Name: "digitalocean",
+		Help: "DigitalOcean domains (API v2, token auth)",
+		Settings: []provider.Setting{
+			{Name: "token", Flag: "do-token", Env: "DIGITALOCEAN_TOKEN", Help: "DigitalOcean API token", Required: true, Secret: true},
+			{Name: "base_url", Flag: "do-base-url", Env: "DIGITALOCEAN_BASE_URL", Help: "DigitalOcean API root (default " + defaultBaseURL + ")"},
+		},
+		New: func(_ string, s provider.Settings, transport http.RoundTripper) (provider.Client, error) {
+			return New(NewOptions{
+				APIToken:  s.Get("token"),
+				BaseURL:   s.Get("base_url"),
+				Transport: transport,
+			})
+		},
+	})
+}
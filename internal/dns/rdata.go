@@ -0,0 +1,378 @@
+package dns
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Structured values of record types whose data has several fields. Each
+// ParseX reads the presentation (zone file) form, lowercasing hex and tags
+// and making names absolute, and String writes it back canonically, so
+// values written differently compare equal after a round trip.
+
+// CAA is a certification authority authorization (RFC 8659).
+type CAA struct {
+	Flags uint8  `json:"flags" toml:"flags"`
+	Tag   string `json:"tag" toml:"tag"`
+	Value string `json:"value" toml:"value"`
+}
+
+// NAPTR is a naming authority pointer (RFC 3403).
+type NAPTR struct {
+	Order       uint16 `json:"order" toml:"order"`
+	Preference  uint16 `json:"preference" toml:"preference"`
+	Flags       string `json:"flags" toml:"flags"`
+	Service     string `json:"service" toml:"service"`
+	Regexp      string `json:"regexp" toml:"regexp"`
+	Replacement string `json:"replacement" toml:"replacement"`
+}
+
+// SSHFP is an SSH host key fingerprint (RFC 4255).
+type SSHFP struct {
+	Algorithm   uint8  `json:"algorithm" toml:"algorithm"`
+	Type        uint8  `json:"type" toml:"type"`
+	Fingerprint string `json:"fingerprint" toml:"fingerprint"`
+}
+
+// TLSA is a DANE certificate association (RFC 6698).
+type TLSA struct {
+	Usage        uint8  `json:"usage" toml:"usage"`
+	Selector     uint8  `json:"selector" toml:"selector"`
+	MatchingType uint8  `json:"matching_type" toml:"matching_type"`
+	Certificate  string `json:"certificate" toml:"certificate"`
+}
+
+// SVCB is a service binding, also used for HTTPS records (RFC 9460).
+// Params are kept as written, e.g. `alpn="h2,h3"` or `port=8443`.
+type SVCB struct {
+	Priority uint16   `json:"priority" toml:"priority"`
+	Target   string   `json:"target" toml:"target"`
+	Params   []string `json:"params,omitempty" toml:"params,omitempty"`
+}
+
+// DS is a delegation signer (RFC 4034).
+type DS struct {
+	KeyTag     uint16 `json:"key_tag" toml:"key_tag"`
+	Algorithm  uint8  `json:"algorithm" toml:"algorithm"`
+	DigestType uint8  `json:"digest_type" toml:"digest_type"`
+	Digest     string `json:"digest" toml:"digest"`
+}
+
+// ParseRData validates v for record type t and returns its canonical form.
+// Types without a structured parser are returned unchanged.
+func ParseRData(t, v string) (string, error) {
+	switch strings.ToUpper(t) {
+	case "CAA":
+		r, err := ParseCAA(v)
+		return r.String(), err
+	case "NAPTR":
+		r, err := ParseNAPTR(v)
+		return r.String(), err
+	case "SSHFP":
+		r, err := ParseSSHFP(v)
+		return r.String(), err
+	case "TLSA":
+		r, err := ParseTLSA(v)
+		return r.String(), err
+	case "HTTPS", "SVCB":
+		r, err := ParseSVCB(v)
+		return r.String(), err
+	case "DS":
+		r, err := ParseDS(v)
+		return r.String(), err
+	}
+	return v, nil
+}
+
+// SameRData reports whether a and b are the same data for type t, ignoring
+// quoting, hex case and trailing dots. Values that do not parse are
+// compared as text.
+func SameRData(t, a, b string) bool {
+	ca, errA := ParseRData(t, a)
+	cb, errB := ParseRData(t, b)
+	if errA != nil || errB != nil {
+		return strings.TrimSpace(a) == strings.TrimSpace(b)
+	}
+	return strings.EqualFold(ca, cb)
+}
+
+func ParseCAA(v string) (CAA, error) {
+	f, err := rdataFields(v)
+	if err != nil || len(f) < 3 {
+		return CAA{}, fmt.Errorf("CAA value expects: \"<flags> <tag> <value>\", got %q", v)
+	}
+	flags, err := parseUint8("CAA flags", f[0])
+	if err != nil {
+		return CAA{}, err
+	}
+	value := unquoteField(f[2])
+	if len(f) > 3 {
+		value = strings.Join(f[2:], " ")
+	}
+	r := CAA{Flags: flags, Tag: strings.ToLower(f[1]), Value: value}
+	return r, r.Validate()
+}
+
+func (r CAA) Validate() error {
+	if r.Tag == "" {
+		return fmt.Errorf("CAA tag is empty")
+	}
+	for _, c := range r.Tag {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			return fmt.Errorf("invalid CAA tag %q (letters and digits only)", r.Tag)
+		}
+	}
+	return nil
+}
+
+func (r CAA) String() string {
+	return fmt.Sprintf("%d %s %s", r.Flags, strings.ToLower(r.Tag), TXT{r.Value}.Quoted())
+}
+
+func ParseNAPTR(v string) (NAPTR, error) {
+	f, err := rdataFields(v)
+	if err != nil || len(f) != 6 {
+		return NAPTR{}, fmt.Errorf("NAPTR value expects: \"<order> <preference> <flags> <service> <regexp> <replacement>\", got %q", v)
+	}
+	order, err := parseUint16("NAPTR order", f[0])
+	if err != nil {
+		return NAPTR{}, err
+	}
+	pref, err := parseUint16("NAPTR preference", f[1])
+	if err != nil {
+		return NAPTR{}, err
+	}
+	r := NAPTR{
+		Order:       order,
+		Preference:  pref,
+		Flags:       unquoteField(f[2]),
+		Service:     unquoteField(f[3]),
+		Regexp:      unquoteField(f[4]),
+		Replacement: absoluteName(f[5]),
+	}
+	return r, r.Validate()
+}
+
+func (r NAPTR) Validate() error {
+	for _, c := range r.Flags {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			return fmt.Errorf("invalid NAPTR flags %q", r.Flags)
+		}
+	}
+	if strings.TrimSpace(r.Replacement) == "" {
+		return fmt.Errorf("NAPTR replacement is empty (use \".\" for none)")
+	}
+	if r.Regexp != "" && r.Replacement != "." {
+		return fmt.Errorf("NAPTR regexp and replacement are mutually exclusive")
+	}
+	return nil
+}
+
+func (r NAPTR) String() string {
+	return fmt.Sprintf("%d %d %s %s %s %s", r.Order, r.Preference,
+		TXT{r.Flags}.Quoted(), TXT{r.Service}.Quoted(), TXT{r.Regexp}.Quoted(), absoluteName(r.Replacement))
+}
+
+func ParseSSHFP(v string) (SSHFP, error) {
+	f, err := rdataFields(v)
+	if err != nil || len(f) < 3 {
+		return SSHFP{}, fmt.Errorf("SSHFP value expects: \"<algorithm> <type> <fingerprint>\", got %q", v)
+	}
+	alg, err := parseUint8("SSHFP algorithm", f[0])
+	if err != nil {
+		return SSHFP{}, err
+	}
+	typ, err := parseUint8("SSHFP type", f[1])
+	if err != nil {
+		return SSHFP{}, err
+	}
+	r := SSHFP{Algorithm: alg, Type: typ, Fingerprint: strings.ToLower(strings.Join(f[2:], ""))}
+	return r, r.Validate()
+}
+
+func (r SSHFP) Validate() error {
+	return checkHex("SSHFP fingerprint", r.Fingerprint)
+}
+
+func (r SSHFP) String() string {
+	return fmt.Sprintf("%d %d %s", r.Algorithm, r.Type, strings.ToLower(r.Fingerprint))
+}
+
+func ParseTLSA(v string) (TLSA, error) {
+	f, err := rdataFields(v)
+	if err != nil || len(f) < 4 {
+		return TLSA{}, fmt.Errorf("TLSA value expects: \"<usage> <selector> <matching-type> <data>\", got %q", v)
+	}
+	var n [3]uint8
+	for i, name := range []string{"TLSA usage", "TLSA selector", "TLSA matching-type"} {
+		if n[i], err = parseUint8(name, f[i]); err != nil {
+			return TLSA{}, err
+		}
+	}
+	r := TLSA{Usage: n[0], Selector: n[1], MatchingType: n[2], Certificate: strings.ToLower(strings.Join(f[3:], ""))}
+	return r, r.Validate()
+}
+
+func (r TLSA) Validate() error {
+	return checkHex("TLSA data", r.Certificate)
+}
+
+func (r TLSA) String() string {
+	return fmt.Sprintf("%d %d %d %s", r.Usage, r.Selector, r.MatchingType, strings.ToLower(r.Certificate))
+}
+
+func ParseSVCB(v string) (SVCB, error) {
+	f, err := rdataFields(v)
+	if err != nil || len(f) < 2 {
+		return SVCB{}, fmt.Errorf("SVCB/HTTPS value expects: \"<priority> <target> [key=value ...]\", got %q", v)
+	}
+	prio, err := parseUint16("SVCB priority", f[0])
+	if err != nil {
+		return SVCB{}, err
+	}
+	r := SVCB{Priority: prio, Target: absoluteName(f[1]), Params: f[2:]}
+	return r, r.Validate()
+}
+
+// svcParamKeys are the registered SvcParamKeys; others are written keyNNNNN.
+var svcParamKeys = map[string]bool{
+	"mandatory": true, "alpn": true, "no-default-alpn": true, "port": true,
+	"ipv4hint": true, "ech": true, "ipv6hint": true, "dohpath": true, "ohttp": true,
+}
+
+func (r SVCB) Validate() error {
+	if strings.TrimSpace(r.Target) == "" {
+		return fmt.Errorf("SVCB target is empty (use \".\" for the owner name)")
+	}
+	if r.Priority == 0 && len(r.Params) > 0 {
+		return fmt.Errorf("SVCB alias form (priority 0) takes no parameters")
+	}
+	for _, p := range r.Params {
+		key, _, _ := strings.Cut(p, "=")
+		if svcParamKeys[strings.ToLower(key)] {
+			continue
+		}
+		if n, ok := strings.CutPrefix(strings.ToLower(key), "key"); ok && n != "" && isDigits(n) {
+			continue
+		}
+		return fmt.Errorf("unknown SVCB parameter %q", key)
+	}
+	return nil
+}
+
+func (r SVCB) String() string {
+	parts := append([]string{strconv.Itoa(int(r.Priority)), absoluteName(r.Target)}, r.Params...)
+	return strings.Join(parts, " ")
+}
+
+func ParseDS(v string) (DS, error) {
+	f, err := rdataFields(v)
+	if err != nil || len(f) < 4 {
+		return DS{}, fmt.Errorf("DS value expects: \"<key-tag> <algorithm> <digest-type> <digest>\", got %q", v)
+	}
+	tag, err := parseUint16("DS key tag", f[0])
+	if err != nil {
+		return DS{}, err
+	}
+	alg, err := parseUint8("DS algorithm", f[1])
+	if err != nil {
+		return DS{}, err
+	}
+	dt, err := parseUint8("DS digest type", f[2])
+	if err != nil {
+		return DS{}, err
+	}
+	r := DS{KeyTag: tag, Algorithm: alg, DigestType: dt, Digest: strings.ToLower(strings.Join(f[3:], ""))}
+	return r, r.Validate()
+}
+
+func (r DS) Validate() error {
+	return checkHex("DS digest", r.Digest)
+}
+
+func (r DS) String() string {
+	return fmt.Sprintf("%d %d %d %s", r.KeyTag, r.Algorithm, r.DigestType, strings.ToLower(r.Digest))
+}
+
+// rdataFields splits presentation-form data at whitespace outside quoted
+// strings. Quoted fields keep their quotes, see unquoteField.
+func rdataFields(v string) ([]string, error) {
+	var (
+		out    []string
+		cur    strings.Builder
+		quoted bool
+	)
+	for i := 0; i < len(v); i++ {
+		c := v[i]
+		switch {
+		case c == '\\' && i+1 < len(v):
+			cur.WriteByte(c)
+			cur.WriteByte(v[i+1])
+			i++
+		case c == '"':
+			quoted = !quoted
+			cur.WriteByte(c)
+		case !quoted && (c == ' ' || c == '\t' || c == '\r' || c == '\n'):
+			if cur.Len() > 0 {
+				out = append(out, cur.String())
+				cur.Reset()
+			}
+		default:
+			cur.WriteByte(c)
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("unterminated quoted string in %q", v)
+	}
+	if cur.Len() > 0 {
+		out = append(out, cur.String())
+	}
+	return out, nil
+}
+
+// unquoteField decodes a quoted character-string; bare fields are
+// returned as they are.
+func unquoteField(f string) string {
+	if strings.HasPrefix(f, `"`) {
+		if t, ok := parseQuoted(f); ok && len(t) == 1 {
+			return t[0]
+		}
+	}
+	return f
+}
+
+func absoluteName(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.HasSuffix(s, ".") {
+		return s
+	}
+	return s + "."
+}
+
+func checkHex(what, s string) error {
+	if s == "" {
+		return fmt.Errorf("%s is empty", what)
+	}
+	if _, err := hex.DecodeString(s); err != nil {
+		return fmt.Errorf("%s %q is not hexadecimal", what, s)
+	}
+	return nil
+}
+
+func parseUint8(what, s string) (uint8, error) {
+	n, err := strconv.ParseUint(s, 10, 8)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %q", what, s)
+	}
+	return uint8(n), nil
+}
+
+func parseUint16(what, s string) (uint16, error) {
+	n, err := strconv.ParseUint(s, 10, 16)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %q", what, s)
+	}
+	return uint16(n), nil
+}
@@ -0,0 +1,61 @@
+package dns
+
+import "testing"
+
+func TestParseRDataCanonical(t *testing.T) {
+	cases := []struct {
+		typ, in, want string
+	}{
+		{"CAA", `0 issue "letsencrypt.org"`, `0 issue "letsencrypt.org"`},
+		{"CAA", `128 ISSUEWILD ;`, `128 issuewild ";"`},
+		{"CAA", `0 iodef mailto:ca@example.com`, `0 iodef "mailto:ca@example.com"`},
+		{"NAPTR", `100 10 "S" "SIP+D2U" "" _sip._udp.example.com.`, `100 10 "S" "SIP+D2U" "" _sip._udp.example.com.`},
+		{"NAPTR", `10 100 "U" "E2U+sip" "!^.*$!sip:info@example.com!" .`, `10 100 "U" "E2U+sip" "!^.*$!sip:info@example.com!" .`},
+		{"SSHFP", "4 2 AB CD", "4 2 abcd"},
+		{"TLSA", "3 1 1 AA11", "3 1 1 aa11"},
+		{"HTTPS", `1 . alpn="h2,h3" ipv4hint=192.0.2.1`, `1 . alpn="h2,h3" ipv4hint=192.0.2.1`},
+		{"SVCB", "0 svc.example.net", "0 svc.example.net."},
+		{"DS", "12345 13 2 AA11 BB22", "12345 13 2 aa11bb22"},
+		{"A", "192.0.2.1", "192.0.2.1"},
+	}
+	for _, c := range cases {
+		got, err := ParseRData(c.typ, c.in)
+		if err != nil {
+			t.Fatalf("%s %q: unexpected err: %v", c.typ, c.in, err)
+		}
+		if got != c.want {
+			t.Fatalf("%s %q: expected %q, got %q", c.typ, c.in, c.want, got)
+		}
+	}
+}
+
+func TestParseRDataRejectsInvalid(t *testing.T) {
+	cases := []struct{ typ, in string }{
+		{"CAA", "256 issue ca.example"},
+		{"CAA", "0 issue"},
+		{"CAA", `0 issue "unterminated`},
+		{"NAPTR", `100 10 "S" "SIP+D2U" _sip._udp.example.com.`},
+		{"SSHFP", "1 1 xyz"},
+		{"TLSA", "3 1 1"},
+		{"SVCB", "1 svc.example.net. nosuchkey=1"},
+		{"HTTPS", "0 . alpn=h2"},
+		{"DS", "70000 13 2 aa11"},
+	}
+	for _, c := range cases {
+		if _, err := ParseRData(c.typ, c.in); err == nil {
+			t.Fatalf("%s %q: expected an error", c.typ, c.in)
+		}
+	}
+}
+
+func TestSameRData(t *testing.T) {
+	if !SameRData("CAA", `0 issue "letsencrypt.org"`, "0 issue letsencrypt.org") {
+		t.Fatalf("quoted and bare CAA values should match")
+	}
+	if !SameRData("TLSA", "3 1 1 AA11", "3 1 1 aa11") {
+		t.Fatalf("hex case should not matter")
+	}
+	if SameRData("DS", "12345 13 2 aa11", "12345 13 2 bb22") {
+		t.Fatalf("different digests should not match")
+	}
+}
@@ -0,0 +1,154 @@
+package dns
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxTXTString is the longest character-string inside a TXT record
+// (RFC 1035 §3.3).
+const MaxTXTString = 255
+
+// TXT is a TXT record value as its list of character-strings. Consumers
+// such as SPF and DKIM read the strings concatenated, so two values with
+// the same Joined text are equivalent however they are split.
+type TXT []string
+
+// ParseTXT reads a TXT value as written in config or returned by a
+// provider. A value in zone-file form, one or more quoted strings
+// optionally wrapped in parentheses, is split into its strings with \"
+// \\ and \DDD escapes decoded; anything else is a single plain string.
+func ParseTXT(v string) TXT {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return TXT{}
+	}
+	if strings.HasPrefix(v, `"`) || strings.HasPrefix(v, "(") {
+		if t, ok := parseQuoted(v); ok {
+			return t
+		}
+	}
+	return TXT{v}
+}
+
+func parseQuoted(v string) (TXT, bool) {
+	if strings.HasPrefix(v, "(") {
+		if !strings.HasSuffix(v, ")") {
+			return nil, false
+		}
+		v = strings.TrimSpace(v[1 : len(v)-1])
+	}
+	var out TXT
+	for {
+		v = strings.TrimLeft(v, " \t\r\n")
+		if v == "" {
+			return out, len(out) > 0
+		}
+		if v[0] != '"' {
+			return nil, false
+		}
+		var (
+			b      strings.Builder
+			closed bool
+			i      = 1
+		)
+		for i < len(v) && !closed {
+			switch c := v[i]; c {
+			case '"':
+				closed = true
+				i++
+			case '\\':
+				if i+3 < len(v) && isDigits(v[i+1:i+4]) {
+					n, _ := strconv.Atoi(v[i+1 : i+4])
+					if n > 255 {
+						return nil, false
+					}
+					b.WriteByte(byte(n))
+					i += 4
+					continue
+				}
+				if i+1 >= len(v) {
+					return nil, false
+				}
+				b.WriteByte(v[i+1])
+				i += 2
+			default:
+				b.WriteByte(c)
+				i++
+			}
+		}
+		if !closed {
+			return nil, false
+		}
+		out = append(out, b.String())
+		v = v[i:]
+	}
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Joined returns the strings concatenated, the text TXT consumers see.
+func (t TXT) Joined() string {
+	return strings.Join(t, "")
+}
+
+// Split returns the value re-split into strings of at most MaxTXTString
+// bytes, cutting at UTF-8 boundaries where possible. Strings that already
+// fit are kept as they are.
+func (t TXT) Split() TXT {
+	var out TXT
+	for _, s := range t {
+		for len(s) > MaxTXTString {
+			n := MaxTXTString
+			for n > MaxTXTString-utf8.UTFMax && !utf8.RuneStart(s[n]) {
+				n--
+			}
+			out = append(out, s[:n])
+			s = s[n:]
+		}
+		out = append(out, s)
+	}
+	if len(out) == 0 {
+		out = TXT{""}
+	}
+	return out
+}
+
+// Quoted renders the value in zone-file form: each string quoted, with
+// quotes and backslashes escaped, separated by spaces.
+func (t TXT) Quoted() string {
+	if len(t) == 0 {
+		return `""`
+	}
+	parts := make([]string, len(t))
+	for i, s := range t {
+		parts[i] = `"` + strings.ReplaceAll(strings.ReplaceAll(s, `\`, `\\`), `"`, `\"`) + `"`
+	}
+	return strings.Join(parts, " ")
+}
+
+// QuoteTXT renders v as wire-legal quoted strings of at most MaxTXTString
+// bytes each, the form the RRset-based provider APIs expect.
+func QuoteTXT(v string) string {
+	return ParseTXT(v).Split().Quoted()
+}
+
+// TXTText returns the text of a TXT value, for provider APIs that take
+// plain text and split it into character-strings themselves.
+func TXTText(v string) string {
+	return ParseTXT(v).Joined()
+}
+
+// SameTXT reports whether two TXT values carry the same text, regardless
+// of quoting and of how they are split into strings.
+func SameTXT(a, b string) bool {
+	return ParseTXT(a).Joined() == ParseTXT(b).Joined()
+}
@@ -0,0 +1,56 @@
+package dns
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTXT(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want TXT
+	}{
+		{"", TXT{}},
+		{"v=spf1 mx -all", TXT{"v=spf1 mx -all"}},
+		{`"v=spf1 mx -all"`, TXT{"v=spf1 mx -all"}},
+		{`"part1" "part2"`, TXT{"part1", "part2"}},
+		{`( "a"  "b" )`, TXT{"a", "b"}},
+		{`"say \"hi\" \\ \065"`, TXT{`say "hi" \ A`}},
+		{`"unterminated`, TXT{`"unterminated`}},
+		{`"a" trailing`, TXT{`"a" trailing`}},
+	} {
+		if got := ParseTXT(tc.in); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("ParseTXT(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestTXTSplitAndQuote(t *testing.T) {
+	dkim := "v=DKIM1; k=rsa; p=" + strings.Repeat("A", 400)
+	split := ParseTXT(dkim).Split()
+	if len(split) != 2 || len(split[0]) != MaxTXTString || split.Joined() != dkim {
+		t.Fatalf("unexpected split %d strings, first %d bytes", len(split), len(split[0]))
+	}
+
+	quoted := QuoteTXT(dkim)
+	if !strings.HasPrefix(quoted, `"v=DKIM1; k=rsa; p=AAA`) || strings.Count(quoted, `" "`) != 1 {
+		t.Fatalf("unexpected quoted value %q", quoted)
+	}
+	if !SameTXT(quoted, dkim) || !SameTXT(`"part1" "part2"`, "part1part2") {
+		t.Fatalf("expected quoted and plain values to match")
+	}
+	if SameTXT(`"a" "b"`, "a b") {
+		t.Fatalf("string boundaries must not turn into spaces")
+	}
+
+	multi := strings.Repeat("é", 200)
+	for _, s := range ParseTXT(multi).Split() {
+		if !strings.HasPrefix(s, "é") || len(s) > MaxTXTString {
+			t.Fatalf("split cut through a UTF-8 sequence: %q", s[:4])
+		}
+	}
+	if got := QuoteTXT(`say "hi"`); got != `"say \"hi\""` {
+		t.Fatalf("QuoteTXT escaped to %s", got)
+	}
+}
@@ -1,5 +1,7 @@
 package dns
 
+import "strings"
+
 type Record struct {
 	SubDomain string
 	Type      string
@@ -7,10 +9,60 @@ type Record struct {
 	Priority  *uint64
 	Remark    string
 	TTL       *uint64
+	// Line and LineID override Plan.RecordLine for this record; Weight and
+	// Status ("ENABLE"/"DISABLE") are optional. Only DNSPod uses them.
+	Line   string
+	LineID string
+	Weight *uint64
+	Status string
+	// Cloudflare carries Cloudflare-only settings; nil means defaults.
+	Cloudflare *CloudflareOptions
+	// Owner is the ownership marker written by providers that can store
+	// it natively (see provider.OwnerReader). Empty means unmarked.
+	Owner string
+}
+
+// CloudflareOptions are per-record Cloudflare settings. A nil Comment
+// means "use Record.Remark".
+type CloudflareOptions struct {
+	Proxied      *bool
+	Comment      *string
+	Tags         []string
+	FlattenCNAME *bool
 }
 
 type Plan struct {
 	Domain     string
 	RecordLine string
 	Records    []Record
+	// Protected lists sub-domains ("@", "_dmarc", "*.internal") that must
+	// never be created, updated or deleted.
+	Protected []string
+}
+
+// LineOr returns the record's own line, or def when it has none.
+func (r Record) LineOr(def string) string {
+	if r.Line != "" {
+		return r.Line
+	}
+	return def
+}
+
+// IsProtected reports whether sub matches an entry of Protected. A leading
+// "*." entry matches every name below it.
+func (p Plan) IsProtected(sub string) bool {
+	sub = strings.ToLower(strings.TrimSpace(sub))
+	for _, prot := range p.Protected {
+		prot = strings.ToLower(strings.TrimSpace(prot))
+		if prot == sub {
+			return true
+		}
+		if prot == "*" && sub != "@" {
+			return true
+		}
+		if parent, ok := strings.CutPrefix(prot, "*."); ok && strings.HasSuffix(sub, "."+parent) {
+			return true
+		}
+	}
+	return false
 }
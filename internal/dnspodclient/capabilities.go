@@ -0,0 +1,20 @@
+package dnspodclient
+
+import "ddnsjx/internal/provider"
+
+// DefaultMinTTL is the smallest TTL on the DNSPod free plan; paid plans
+// go lower and can override it through NewOptions.MinTTL.
+const DefaultMinTTL = 600
+
+func capabilities(minTTL uint64) provider.Capabilities {
+	return provider.Capabilities{
+		RecordTypes:  []string{"A", "AAAA", "CNAME", "MX", "TXT", "SRV", "NS", "CAA", "PTR", "NAPTR"},
+		MinTTL:       minTTL,
+		MaxTTL:       604800,
+		MaxTXTLength: 512,
+		Lines:        true,
+		Weights:      true,
+		Status:       true,
+		Comments:     true,
+	}
+}
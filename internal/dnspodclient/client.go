@@ -3,6 +3,8 @@ package dnspodclient
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
@@ -20,10 +22,19 @@ type NewOptions struct {
 	SecretID  string
 	SecretKey string
 	Region    string
+	// MinTTL overrides DefaultMinTTL for plans that allow shorter TTLs.
+	MinTTL uint64
+	// BaseURL overrides the API endpoint (default https://dnspod.tencentcloudapi.com).
+	BaseURL string
+	// Transport replaces the HTTP transport, e.g. with a cassette.
+	Transport http.RoundTripper
 }
 
+const defaultBaseURL = "https://dnspod.tencentcloudapi.com"
+
 type client struct {
-	sdk *dnspod.Client
+	sdk    *dnspod.Client
+	minTTL uint64
 }
 
 type Error struct {
@@ -38,6 +49,11 @@ func (e Error) Error() string {
 	return fmt.Sprintf("[%s] %s", e.Code, e.Message)
 }
 
+// ErrorCode returns the API error code for metrics.
+func (e Error) ErrorCode() string {
+	return e.Code
+}
+
 func (e Error) Retryable() bool {
 	switch e.Code {
 	case "ResourceInsufficient.OverLimit", "RequestLimitExceeded", "InternalError", "InternalError.Unknown":
@@ -82,27 +98,68 @@ func New(opt NewOptions) (provider.Client, error) {
 
 	cred := common.NewCredential(opt.SecretID, opt.SecretKey)
 	cpf := profile.NewClientProfile()
-	cpf.HttpProfile.Endpoint = "dnspod.tencentcloudapi.com"
+	if err := applyBaseURL(cpf.HttpProfile, opt.BaseURL); err != nil {
+		return nil, err
+	}
 	sdk, err := dnspod.NewClient(cred, opt.Region, cpf)
 	if err != nil {
 		return nil, fmt.Errorf("create dnspod client: %w", err)
 	}
-	return &client{sdk: sdk}, nil
+	if opt.Transport != nil {
+		sdk.WithHttpTransport(opt.Transport)
+	}
+	if opt.MinTTL == 0 {
+		opt.MinTTL = DefaultMinTTL
+	}
+	return &client{sdk: sdk, minTTL: opt.MinTTL}, nil
+}
+
+// applyBaseURL points the SDK at base, given as "https://host[:port]" or a
+// bare host name.
+func applyBaseURL(hp *profile.HttpProfile, base string) error {
+	base = strings.TrimRight(strings.TrimSpace(base), "/")
+	if base == "" {
+		base = defaultBaseURL
+	}
+	if !strings.Contains(base, "://") {
+		base = "https://" + base
+	}
+	u, err := url.Parse(base)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid dnspod base url %q", base)
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+	default:
+		return fmt.Errorf("invalid dnspod base url %q: scheme must be http or https", base)
+	}
+	hp.Scheme = strings.ToUpper(u.Scheme)
+	hp.Endpoint = u.Host
+	return nil
 }
 
-func (c *client) IsSupportedRecordType(t string) bool {
-	return IsSupportedRecordType(t)
+func (c *client) Capabilities() provider.Capabilities {
+	return capabilities(c.minTTL)
 }
 
 func (c *client) CreateRecord(ctx context.Context, domain, recordLine string, record dns.Record) (string, provider.CreateStatus, error) {
 	req := dnspod.NewCreateRecordRequest()
 	req.Domain = common.StringPtr(domain)
 	req.RecordType = common.StringPtr(record.Type)
-	req.RecordLine = common.StringPtr(recordLine)
-	req.Value = common.StringPtr(record.Value)
+	req.RecordLine = common.StringPtr(record.LineOr(recordLine))
+	req.Value = common.StringPtr(apiValue(record))
 	req.SubDomain = common.StringPtr(record.SubDomain)
-	if record.Remark != "" {
-		req.Remark = common.StringPtr(record.Remark)
+	if record.LineID != "" {
+		req.RecordLineId = common.StringPtr(record.LineID)
+	}
+	if record.Weight != nil {
+		req.Weight = common.Uint64Ptr(*record.Weight)
+	}
+	if record.Status != "" {
+		req.Status = common.StringPtr(record.Status)
+	}
+	if remark := provider.WithOwnerMarker(record.Remark, record.Owner); remark != "" {
+		req.Remark = common.StringPtr(remark)
 	}
 	if record.TTL != nil {
 		req.TTL = common.Uint64Ptr(*record.TTL)
@@ -121,7 +178,7 @@ func (c *client) CreateRecord(ctx context.Context, domain, recordLine string, re
 
 	if sdkErr, ok := err.(*errors.TencentCloudSDKError); ok {
 		switch sdkErr.Code {
-		case "InvalidParameter.RecordExists":
+		case dnspod.INVALIDPARAMETER_DOMAINRECORDEXIST, "InvalidParameter.RecordExists":
 			return "", provider.CreateStatusExists, nil
 		default:
 			return "", provider.CreateStatusFail, Error{Code: sdkErr.Code, Message: sdkErr.Message}
@@ -0,0 +1,242 @@
+package dnspodclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"ddnsjx/internal/dns"
+	"ddnsjx/internal/provider"
+	"ddnsjx/internal/provider/providertest"
+
+	dnspod "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/dnspod/v20210323"
+)
+
+func TestConformance(t *testing.T) {
+	providertest.Run(t, func(t *testing.T) provider.Client {
+		srv := httptest.NewServer(newFakeAPI("example.com"))
+		t.Cleanup(srv.Close)
+
+		c, err := New(NewOptions{SecretID: "id", SecretKey: "key", BaseURL: srv.URL})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}, providertest.Options{})
+}
+
+// fakeAPI is a stand-in for the DNSPod API 3.0 actions the client calls.
+// It answers in the TC3 envelope and mirrors the real service: host names
+// come back with a trailing dot, an empty record list is reported as
+// ResourceNotFound.NoDataOfRecord and identical records are rejected.
+type fakeAPI struct {
+	mu      sync.Mutex
+	domain  string
+	nextID  uint64
+	records map[uint64]fakeRecord
+}
+
+type fakeRecord struct {
+	ID     uint64
+	Sub    string
+	Type   string
+	Line   string
+	LineID string
+	Value  string
+	MX     uint64
+	TTL    uint64
+	Weight *uint64
+	Status string
+	Remark string
+}
+
+type fakeParams struct {
+	Domain       string
+	SubDomain    string
+	Subdomain    string
+	RecordType   string
+	RecordLine   string
+	RecordLineId string
+	RecordId     uint64
+	Value        string
+	MX           uint64
+	TTL          uint64
+	Weight       *uint64
+	Status       string
+	Remark       string
+}
+
+func newFakeAPI(domain string) *fakeAPI {
+	return &fakeAPI{domain: domain, records: make(map[uint64]fakeRecord)}
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var p fakeParams
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		writeTC3Error(w, "InvalidParameter", err.Error())
+		return
+	}
+	if p.Domain != f.domain {
+		writeTC3Error(w, "InvalidParameterValue.DomainNotExists", "当前域名有误，请返回重新操作。")
+		return
+	}
+
+	switch r.Header.Get("X-TC-Action") {
+	case "CreateRecord":
+		rec := f.fromParams(p)
+		for _, cur := range f.records {
+			if cur.Sub == rec.Sub && cur.Type == rec.Type && cur.Line == rec.Line && cur.Value == rec.Value && cur.MX == rec.MX {
+				writeTC3Error(w, dnspod.INVALIDPARAMETER_DOMAINRECORDEXIST, "记录已经存在，无需再次添加。")
+				return
+			}
+		}
+		f.nextID++
+		rec.ID = f.nextID
+		f.records[rec.ID] = rec
+		writeTC3(w, map[string]any{"RecordId": rec.ID})
+	case "ModifyRecord":
+		if _, ok := f.records[p.RecordId]; !ok {
+			writeTC3Error(w, "InvalidParameter.RecordIdInvalid", "记录编号错误。")
+			return
+		}
+		rec := f.fromParams(p)
+		rec.ID = p.RecordId
+		f.records[rec.ID] = rec
+		writeTC3(w, map[string]any{"RecordId": rec.ID})
+	case "DeleteRecord":
+		if _, ok := f.records[p.RecordId]; !ok {
+			writeTC3Error(w, "InvalidParameter.RecordIdInvalid", "记录编号错误。")
+			return
+		}
+		delete(f.records, p.RecordId)
+		writeTC3(w, map[string]any{})
+	case "DescribeRecordList":
+		var list []map[string]any
+		for _, rec := range f.sorted() {
+			if p.Subdomain != "" && rec.Sub != p.Subdomain {
+				continue
+			}
+			if p.RecordType != "" && rec.Type != p.RecordType {
+				continue
+			}
+			item := map[string]any{
+				"RecordId": rec.ID, "Name": rec.Sub, "Type": rec.Type, "Line": rec.Line, "LineId": rec.LineID,
+				"Value": rec.Value, "MX": rec.MX, "TTL": rec.TTL, "Status": rec.Status, "Remark": rec.Remark,
+			}
+			if rec.Weight != nil {
+				item["Weight"] = *rec.Weight
+			}
+			list = append(list, item)
+		}
+		if len(list) == 0 {
+			writeTC3Error(w, dnspod.RESOURCENOTFOUND_NODATAOFRECORD, "记录列表为空。")
+			return
+		}
+		writeTC3(w, map[string]any{
+			"RecordCountInfo": map[string]any{"TotalCount": len(list), "ListCount": len(list), "SubdomainCount": len(list)},
+			"RecordList":      list,
+		})
+	case "DescribeRecord":
+		rec, ok := f.records[p.RecordId]
+		if !ok {
+			writeTC3Error(w, "InvalidParameter.RecordIdInvalid", "记录编号错误。")
+			return
+		}
+		writeTC3(w, map[string]any{"RecordInfo": map[string]any{
+			"Id": rec.ID, "SubDomain": rec.Sub, "RecordType": rec.Type, "RecordLine": rec.Line,
+			"Value": rec.Value, "MX": rec.MX, "TTL": rec.TTL, "Remark": rec.Remark,
+		}})
+	default:
+		writeTC3Error(w, "InvalidAction", "unsupported action "+r.Header.Get("X-TC-Action"))
+	}
+}
+
+func (f *fakeAPI) fromParams(p fakeParams) fakeRecord {
+	rec := fakeRecord{
+		Sub:    p.SubDomain,
+		Type:   p.RecordType,
+		Line:   p.RecordLine,
+		LineID: p.RecordLineId,
+		Value:  p.Value,
+		MX:     p.MX,
+		TTL:    p.TTL,
+		Weight: p.Weight,
+		Status: p.Status,
+		Remark: p.Remark,
+	}
+	if rec.Sub == "" {
+		rec.Sub = "@"
+	}
+	if rec.Line == "" {
+		rec.Line = "默认"
+	}
+	if rec.LineID == "" {
+		rec.LineID = "0"
+	}
+	if rec.Status == "" {
+		rec.Status = "ENABLE"
+	}
+	switch rec.Type {
+	case "CNAME", "MX", "NS", "PTR":
+		if !strings.HasSuffix(rec.Value, ".") {
+			rec.Value += "."
+		}
+	}
+	return rec
+}
+
+func (f *fakeAPI) sorted() []fakeRecord {
+	out := make([]fakeRecord, 0, len(f.records))
+	for _, rec := range f.records {
+		out = append(out, rec)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+func writeTC3(w http.ResponseWriter, resp map[string]any) {
+	resp["RequestId"] = "fake-request"
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"Response": resp})
+}
+
+func writeTC3Error(w http.ResponseWriter, code, message string) {
+	writeTC3(w, map[string]any{"Error": map[string]string{"Code": code, "Message": message}})
+}
+
+func TestErrorCodes(t *testing.T) {
+	srv := httptest.NewServer(newFakeAPI("example.com"))
+	defer srv.Close()
+	c, err := New(NewOptions{SecretID: "id", SecretKey: "key", BaseURL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	rec := dns.Record{SubDomain: "www", Type: "A", Value: "192.0.2.1"}
+
+	// NoDataOfRecord is an empty list, not a failure.
+	if _, found, err := c.FindRecord(ctx, "example.com", "默认", rec); err != nil || found {
+		t.Fatalf("find in empty zone: found=%v err=%v", found, err)
+	}
+
+	if _, _, err := c.CreateRecord(ctx, "example.com", "默认", rec); err != nil {
+		t.Fatal(err)
+	}
+	if _, status, err := c.CreateRecord(ctx, "example.com", "默认", rec); err != nil || status != provider.CreateStatusExists {
+		t.Fatalf("expected DomainRecordExist to report an existing record, got %s %v", status, err)
+	}
+
+	var apiErr Error
+	if _, _, err := c.FindRecord(ctx, "example.net", "默认", rec); !errors.As(err, &apiErr) || apiErr.Code != "InvalidParameterValue.DomainNotExists" {
+		t.Fatalf("expected the API error code, got %v", err)
+	}
+}
@@ -3,10 +3,12 @@ package dnspodclient
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 
 	"ddnsjx/internal/dns"
+	"ddnsjx/internal/provider"
 
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/errors"
@@ -14,6 +16,58 @@ import (
 )
 
 func (c *client) FindRecord(ctx context.Context, domain string, recordLine string, record dns.Record) (string, bool, error) {
+	matches, err := c.listAt(ctx, domain, recordLine, record)
+	if err != nil {
+		return "", false, err
+	}
+	recordLine = record.LineOr(recordLine)
+
+	// A different value at the same name and type is another record.
+	matches = slices.DeleteFunc(matches, func(it *dnspod.RecordListItem) bool {
+		return it.Value == nil || !sameValue(record, *it.Value)
+	})
+	// Weighted records may repeat a value on one line; narrow down by
+	// weight before giving up.
+	if len(matches) > 1 && record.Weight != nil {
+		matches = narrowMatches(matches, func(it *dnspod.RecordListItem) bool {
+			return it.Weight != nil && *it.Weight == *record.Weight
+		})
+	}
+
+	if len(matches) == 0 {
+		return "", false, nil
+	}
+	if len(matches) > 1 {
+		return "", false, fmt.Errorf("multiple existing records found for %s %s line=%s; cannot safely update", record.Type, record.SubDomain, recordLine)
+	}
+	if matches[0].RecordId == nil {
+		return "", false, fmt.Errorf("existing record missing id for %s %s", record.Type, record.SubDomain)
+	}
+	return strconv.FormatUint(*matches[0].RecordId, 10), true, nil
+}
+
+// ListRecords returns every record at record's name, type and line.
+func (c *client) ListRecords(ctx context.Context, domain string, recordLine string, record dns.Record) ([]provider.ExistingRecord, error) {
+	items, err := c.listAt(ctx, domain, recordLine, record)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]provider.ExistingRecord, 0, len(items))
+	for _, it := range items {
+		if it.RecordId == nil {
+			return nil, fmt.Errorf("existing record missing id for %s %s", record.Type, record.SubDomain)
+		}
+		var value string
+		if it.Value != nil {
+			value = *it.Value
+		}
+		out = append(out, provider.ExistingRecord{ID: strconv.FormatUint(*it.RecordId, 10), Value: value})
+	}
+	return out, nil
+}
+
+// listAt returns the records at record's name and type on its line.
+func (c *client) listAt(ctx context.Context, domain string, recordLine string, record dns.Record) ([]*dnspod.RecordListItem, error) {
 	req := dnspod.NewDescribeRecordListRequest()
 	req.Domain = common.StringPtr(domain)
 	req.Subdomain = common.StringPtr(record.SubDomain)
@@ -25,15 +79,20 @@ func (c *client) FindRecord(ctx context.Context, domain string, recordLine strin
 	resp, err := c.sdk.DescribeRecordList(req)
 	if err != nil {
 		if sdkErr, ok := err.(*errors.TencentCloudSDKError); ok {
-			return "", false, Error{Code: sdkErr.Code, Message: sdkErr.Message}
+			// An empty result is reported as an error rather than an empty list.
+			if sdkErr.Code == dnspod.RESOURCENOTFOUND_NODATAOFRECORD {
+				return nil, nil
+			}
+			return nil, Error{Code: sdkErr.Code, Message: sdkErr.Message}
 		}
-		return "", false, err
+		return nil, err
 	}
-
-	if resp == nil || resp.Response == nil || len(resp.Response.RecordList) == 0 {
-		return "", false, nil
+	if resp == nil || resp.Response == nil {
+		return nil, nil
 	}
 
+	recordLine = record.LineOr(recordLine)
+
 	var matches []*dnspod.RecordListItem
 	for _, it := range resp.Response.RecordList {
 		if it == nil {
@@ -42,19 +101,43 @@ func (c *client) FindRecord(ctx context.Context, domain string, recordLine strin
 		if it.Line != nil && recordLine != "" && strings.TrimSpace(*it.Line) != strings.TrimSpace(recordLine) {
 			continue
 		}
+		if it.LineId != nil && record.LineID != "" && strings.TrimSpace(*it.LineId) != record.LineID {
+			continue
+		}
 		matches = append(matches, it)
 	}
+	return matches, nil
+}
 
-	if len(matches) == 0 {
-		return "", false, nil
+// narrowMatches keeps the items accepted by keep, unless that would drop
+// every candidate.
+func narrowMatches(items []*dnspod.RecordListItem, keep func(*dnspod.RecordListItem) bool) []*dnspod.RecordListItem {
+	var out []*dnspod.RecordListItem
+	for _, it := range items {
+		if keep(it) {
+			out = append(out, it)
+		}
 	}
-	if len(matches) > 1 {
-		return "", false, fmt.Errorf("multiple existing records found for %s %s line=%s; cannot safely update", record.Type, record.SubDomain, recordLine)
+	if len(out) == 0 {
+		return items
 	}
-	if matches[0].RecordId == nil {
-		return "", false, fmt.Errorf("existing record missing id for %s %s", record.Type, record.SubDomain)
+	return out
+}
+
+// apiValue is the value DNSPod expects: TXT is sent as plain text, which
+// DNSPod splits into character-strings itself.
+func apiValue(record dns.Record) string {
+	if strings.EqualFold(record.Type, "TXT") {
+		return dns.TXTText(record.Value)
 	}
-	return strconv.FormatUint(*matches[0].RecordId, 10), true, nil
+	return record.Value
+}
+
+func sameValue(record dns.Record, value string) bool {
+	if strings.EqualFold(record.Type, "TXT") {
+		return dns.SameTXT(value, record.Value)
+	}
+	return strings.TrimSuffix(strings.TrimSpace(value), ".") == strings.TrimSuffix(strings.TrimSpace(record.Value), ".")
 }
 
 func (c *client) UpdateRecord(ctx context.Context, domain string, recordLine string, recordID string, record dns.Record) error {
@@ -68,10 +151,19 @@ func (c *client) UpdateRecord(ctx context.Context, domain string, recordLine str
 	req.RecordId = common.Uint64Ptr(id)
 	req.SubDomain = common.StringPtr(record.SubDomain)
 	req.RecordType = common.StringPtr(record.Type)
-	req.RecordLine = common.StringPtr(recordLine)
-	req.Value = common.StringPtr(record.Value)
-	if record.Remark != "" {
-		req.Remark = common.StringPtr(record.Remark)
+	req.RecordLine = common.StringPtr(record.LineOr(recordLine))
+	req.Value = common.StringPtr(apiValue(record))
+	if record.LineID != "" {
+		req.RecordLineId = common.StringPtr(record.LineID)
+	}
+	if record.Weight != nil {
+		req.Weight = common.Uint64Ptr(*record.Weight)
+	}
+	if record.Status != "" {
+		req.Status = common.StringPtr(record.Status)
+	}
+	if remark := provider.WithOwnerMarker(record.Remark, record.Owner); remark != "" {
+		req.Remark = common.StringPtr(remark)
 	}
 	if record.TTL != nil {
 		req.TTL = common.Uint64Ptr(*record.TTL)
@@ -92,3 +184,31 @@ func (c *client) UpdateRecord(ctx context.Context, domain string, recordLine str
 	}
 	return err
 }
+
+// RecordOwner reads the ownership marker stored in the record's remark.
+func (c *client) RecordOwner(ctx context.Context, domain string, recordID string) (string, error) {
+	id, err := strconv.ParseUint(strings.TrimSpace(recordID), 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid record id %q: %w", recordID, err)
+	}
+
+	req := dnspod.NewDescribeRecordRequest()
+	req.Domain = common.StringPtr(domain)
+	req.RecordId = common.Uint64Ptr(id)
+	if ctx != nil {
+		req.SetContext(ctx)
+	}
+
+	resp, err := c.sdk.DescribeRecord(req)
+	if err != nil {
+		if sdkErr, ok := err.(*errors.TencentCloudSDKError); ok {
+			return "", Error{Code: sdkErr.Code, Message: sdkErr.Message}
+		}
+		return "", err
+	}
+	if resp == nil || resp.Response == nil || resp.Response.RecordInfo == nil || resp.Response.RecordInfo.Remark == nil {
+		return "", nil
+	}
+	owner, _ := provider.ParseOwnerMarker(*resp.Response.RecordInfo.Remark)
+	return owner, nil
+}
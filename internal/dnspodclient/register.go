@@ -0,0 +1,36 @@
+package dnspodclient
+
+import (
+	"net/http"
+	"strconv"
+
+	"ddnsjx/internal/provider"
+)
+
+func init() {
+	provider.Register(provider.Factory{
+		Name: "dnspod",
+		Help: "Tencent Cloud DNSPod (API 3.0)",
+		Settings: []provider.Setting{
+			{Name: "secret_id", Flag: "secret-id", Env: "DNSPOD_SECRET_ID", Help: "TencentCloud secret id", Required: true},
+			{Name: "secret_key", Flag: "secret-key", Env: "DNSPOD_SECRET_KEY", Help: "TencentCloud secret key", Required: true, Secret: true},
+			{Name: "region", Flag: "region", Default: "ap-guangzhou", Help: "TencentCloud region"},
+			{Name: "min_ttl", Flag: "dnspod-min-ttl", Default: strconv.Itoa(DefaultMinTTL), Help: "smallest TTL allowed by your DNSPod plan"},
+			{Name: "base_url", Flag: "dnspod-base-url", Env: "DNSPOD_BASE_URL", Help: "DNSPod API endpoint (default " + defaultBaseURL + ")"},
+		},
+		New: func(_ string, s provider.Settings, transport http.RoundTripper) (provider.Client, error) {
+			minTTL, err := s.Uint64("min_ttl")
+			if err != nil {
+				return nil, err
+			}
+			return New(NewOptions{
+				SecretID:  s.Get("secret_id"),
+				SecretKey: s.Get("secret_key"),
+				Region:    s.Get("region"),
+				MinTTL:    minTTL,
+				BaseURL:   s.Get("base_url"),
+				Transport: transport,
+			})
+		},
+	})
+}
@@ -0,0 +1,47 @@
+package dnspodclient
+
+import (
+	"context"
+	"testing"
+
+	"ddnsjx/internal/cassette"
+	"ddnsjx/internal/dns"
+	"ddnsjx/internal/provider"
+)
+
+// TestReplayCreateMX replays testdata/create_mx.json: an empty record list
+// comes back as ResourceNotFound.NoDataOfRecord, the MX priority and owner
+// remark are sent with CreateRecord, and a duplicate create is reported as
+// InvalidParameter.DomainRecordExist.
+func TestReplayCreateMX(t *testing.T) {
+	tape, err := cassette.Load("testdata/create_mx.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := New(NewOptions{SecretID: "AKIDreplay", SecretKey: "replay", Transport: tape})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	ttl := uint64(600)
+	prio := uint64(10)
+	rec := dns.Record{SubDomain: "@", Type: "MX", Value: "mail.example.com", Priority: &prio, TTL: &ttl, Remark: "Stalwart MX", Owner: "mail"}
+
+	if _, found, err := c.FindRecord(ctx, "example.com", "默认", rec); err != nil || found {
+		t.Fatalf("find before create: found=%v err=%v", found, err)
+	}
+	id, status, err := c.CreateRecord(ctx, "example.com", "默认", rec)
+	if err != nil || status != provider.CreateStatusSuccess || id != "1632850" {
+		t.Fatalf("create: id=%q status=%s err=%v", id, status, err)
+	}
+	if got, found, err := c.FindRecord(ctx, "example.com", "默认", rec); err != nil || !found || got != id {
+		t.Fatalf("find: id=%q found=%v err=%v", got, found, err)
+	}
+	if _, status, err := c.CreateRecord(ctx, "example.com", "默认", rec); err != nil || status != provider.CreateStatusExists {
+		t.Fatalf("create again: status=%s err=%v", status, err)
+	}
+	if n := tape.Remaining(); n != 0 {
+		t.Fatalf("%d interactions were not replayed", n)
+	}
+}
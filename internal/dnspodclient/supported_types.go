@@ -1,13 +0,0 @@
-package dnspodclient
-
-import "strings"
-
-func IsSupportedRecordType(t string) bool {
-	t = strings.ToUpper(strings.TrimSpace(t))
-	switch t {
-	case "A", "AAAA", "CNAME", "MX", "TXT", "SRV", "NS", "CAA", "PTR", "NAPTR":
-		return true
-	default:
-		return false
-	}
-}
@@ -0,0 +1,371 @@
+package dnstxt
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"ddnsjx/internal/config"
+)
+
+// CSV layouts: LayoutAuto picks the preset matching the most header
+// columns.
+const (
+	LayoutAuto    = "auto"
+	LayoutGeneric = "generic"
+	LayoutDNSPod  = "dnspod"
+	LayoutAliyun  = "aliyun"
+)
+
+// CSV fields a column can be mapped to.
+const (
+	FieldType     = "type"
+	FieldName     = "name"
+	FieldContents = "contents"
+	FieldPriority = "priority"
+	FieldTTL      = "ttl"
+	FieldRemark   = "remark"
+	FieldLine     = "line"
+	FieldLineID   = "line_id"
+	FieldWeight   = "weight"
+	FieldStatus   = "status"
+)
+
+var csvFields = []string{FieldType, FieldName, FieldContents, FieldPriority, FieldTTL, FieldRemark, FieldLine, FieldLineID, FieldWeight, FieldStatus}
+
+// csvLayouts lists the header names of each field per preset, compared
+// after normalizeHeader.
+var csvLayouts = map[string]map[string][]string{
+	LayoutGeneric: {
+		FieldType:     {"type", "record type"},
+		FieldName:     {"name", "host", "hostname"},
+		FieldContents: {"contents", "content", "value", "data", "target"},
+		FieldPriority: {"priority", "prio", "mx priority", "preference"},
+		FieldTTL:      {"ttl"},
+		FieldRemark:   {"remark", "comment", "notes"},
+		FieldLine:     {"line"},
+		FieldLineID:   {"line_id", "line id"},
+		FieldWeight:   {"weight"},
+		FieldStatus:   {"status"},
+	},
+	// DNSPod console export: 主机记录,记录类型,线路类型,记录值,MX优先级,TTL（秒）,权重,状态,备注
+	LayoutDNSPod: {
+		FieldType:     {"记录类型"},
+		FieldName:     {"主机记录"},
+		FieldContents: {"记录值"},
+		FieldPriority: {"mx优先级", "mx"},
+		FieldTTL:      {"ttl"},
+		FieldRemark:   {"备注"},
+		FieldLine:     {"线路类型", "记录线路"},
+		FieldLineID:   {"线路id"},
+		FieldWeight:   {"权重"},
+		FieldStatus:   {"状态", "记录状态"},
+	},
+	// Alibaba Cloud DNS export: 记录类型,主机记录,解析线路,记录值,MX优先级,TTL值,状态(暂停/启用),备注
+	LayoutAliyun: {
+		FieldType:     {"记录类型"},
+		FieldName:     {"主机记录"},
+		FieldContents: {"记录值"},
+		FieldPriority: {"mx优先级"},
+		FieldTTL:      {"ttl值", "ttl"},
+		FieldRemark:   {"备注"},
+		FieldLine:     {"解析线路"},
+		FieldStatus:   {"状态"},
+	},
+}
+
+// CSVLayouts returns the preset layout names.
+func CSVLayouts() []string {
+	out := []string{LayoutAuto}
+	for name := range csvLayouts {
+		out = append(out, name)
+	}
+	sort.Strings(out[1:])
+	return out
+}
+
+type CSVOptions struct {
+	// Layout is a preset from CSVLayouts; empty means LayoutAuto.
+	Layout string
+	// Columns maps fields (FieldType, ...) to header names and takes
+	// precedence over the layout.
+	Columns map[string]string
+	// Domain qualifies relative host records such as "www" and "@". When
+	// empty, names are taken as absolute.
+	Domain string
+	// Comma is the field separator; zero detects tab or comma from the
+	// header row.
+	Comma rune
+}
+
+func LoadCSVFile(path string, opt CSVOptions) ([]config.RawRecord, []Issue, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	return ParseCSV(f, opt)
+}
+
+// ParseCSV reads a header row followed by one record per row. Quoted
+// fields may contain separators, quotes and newlines. A separate priority
+// column is merged into MX contents.
+func ParseCSV(r io.Reader, opt CSVOptions) ([]config.RawRecord, []Issue, error) {
+	data, err := readText(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	comma := opt.Comma
+	if comma == 0 {
+		comma = ','
+		if first, _, _ := strings.Cut(data, "\n"); strings.Contains(first, "\t") {
+			comma = '\t'
+		}
+	}
+	cr := csv.NewReader(strings.NewReader(data))
+	cr.Comma = comma
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+
+	var (
+		records []config.RawRecord
+		issues  []Issue
+		columns map[string]int
+	)
+	for {
+		row, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		lineNo, _ := cr.FieldPos(0)
+		if err != nil {
+			var perr *csv.ParseError
+			if !errors.As(err, &perr) {
+				return nil, issues, err
+			}
+			issues = append(issues, Issue{Line: perr.StartLine, Level: "error", Message: perr.Err.Error()})
+			continue
+		}
+		if blankRow(row) || strings.HasPrefix(strings.TrimSpace(row[0]), "#") {
+			continue
+		}
+
+		if columns == nil {
+			columns, err = mapColumns(row, opt)
+			if err != nil {
+				return nil, issues, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			continue
+		}
+
+		get := func(field string) string {
+			i, ok := columns[field]
+			if !ok || i >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(row[i])
+		}
+
+		f := rawFields{
+			Type:     get(FieldType),
+			Name:     get(FieldName),
+			Contents: get(FieldContents),
+			TTL:      get(FieldTTL),
+			Remark:   get(FieldRemark),
+			Line:     get(FieldLine),
+			LineID:   get(FieldLineID),
+			Weight:   get(FieldWeight),
+		}
+
+		var rowIssues []Issue
+		f.Name, rowIssues = qualifyName(lineNo, f.Name, opt.Domain)
+		issues = append(issues, rowIssues...)
+
+		if status := get(FieldStatus); status != "" {
+			s, ok := csvStatus(status)
+			if !ok {
+				issues = append(issues, Issue{Line: lineNo, Level: "warn", Message: fmt.Sprintf("unknown status %q, ignored", status)})
+			}
+			f.Status = s
+		}
+
+		if prio := get(FieldPriority); prio != "" && strings.EqualFold(f.Type, "MX") && len(strings.Fields(f.Contents)) == 1 {
+			f.Contents = prio + " " + f.Contents
+		}
+
+		rec, recIssues, ok := buildRecord(lineNo, f)
+		issues = append(issues, recIssues...)
+		if ok {
+			records = append(records, rec)
+		}
+	}
+	if columns == nil {
+		return nil, issues, fmt.Errorf("missing header row")
+	}
+	return records, issues, nil
+}
+
+// readText decodes r as UTF-8 or, when it starts with a byte order mark,
+// UTF-16 (Excel's "Unicode Text"). Other encodings such as GBK are
+// rejected rather than silently garbled.
+func readText(r io.Reader) (string, error) {
+	b, err := io.ReadAll(bufio.NewReader(r))
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case bytes.HasPrefix(b, []byte{0xef, 0xbb, 0xbf}):
+		b = b[3:]
+	case bytes.HasPrefix(b, []byte{0xff, 0xfe}), bytes.HasPrefix(b, []byte{0xfe, 0xff}):
+		return decodeUTF16(b), nil
+	}
+	if !utf8.Valid(b) {
+		return "", fmt.Errorf("input is not UTF-8 (save the sheet as \"CSV UTF-8\")")
+	}
+	return string(b), nil
+}
+
+func decodeUTF16(b []byte) string {
+	bigEndian := b[0] == 0xfe
+	b = b[2:]
+	u := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		if bigEndian {
+			u = append(u, uint16(b[i])<<8|uint16(b[i+1]))
+		} else {
+			u = append(u, uint16(b[i+1])<<8|uint16(b[i]))
+		}
+	}
+	return string(utf16.Decode(u))
+}
+
+// mapColumns resolves the header row to column indexes.
+func mapColumns(header []string, opt CSVOptions) (map[string]int, error) {
+	index := make(map[string]int, len(header))
+	for i, h := range header {
+		if _, dup := index[normalizeHeader(h)]; !dup {
+			index[normalizeHeader(h)] = i
+		}
+	}
+
+	layout := strings.ToLower(strings.TrimSpace(opt.Layout))
+	var candidates []string
+	switch layout {
+	case "", LayoutAuto:
+		candidates = CSVLayouts()[1:]
+	default:
+		if _, ok := csvLayouts[layout]; !ok {
+			return nil, fmt.Errorf("unknown csv layout %q (expected %s)", opt.Layout, strings.Join(CSVLayouts(), "|"))
+		}
+		candidates = []string{layout}
+	}
+
+	var best map[string]int
+	for _, name := range candidates {
+		cols := make(map[string]int)
+		for field, names := range csvLayouts[name] {
+			for _, n := range names {
+				if i, ok := index[n]; ok {
+					cols[field] = i
+					break
+				}
+			}
+		}
+		if best == nil || len(cols) > len(best) {
+			best = cols
+		}
+	}
+
+	for field, name := range opt.Columns {
+		if !validField(field) {
+			return nil, fmt.Errorf("unknown csv field %q (expected %s)", field, strings.Join(csvFields, "|"))
+		}
+		i, ok := index[normalizeHeader(name)]
+		if !ok {
+			return nil, fmt.Errorf("column %q for %s not found in header", name, field)
+		}
+		best[field] = i
+	}
+
+	for _, field := range []string{FieldType, FieldName, FieldContents} {
+		if _, ok := best[field]; !ok {
+			return nil, fmt.Errorf("missing column: %s (header %q)", field, strings.Join(header, ","))
+		}
+	}
+	return best, nil
+}
+
+func validField(field string) bool {
+	for _, f := range csvFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeHeader lowercases a header and drops units and hints in
+// parentheses, so "TTL（秒）" and "状态(暂停/启用)" match "ttl" and "状态".
+func normalizeHeader(h string) string {
+	h = strings.TrimSpace(strings.TrimPrefix(h, "\ufeff"))
+	for _, open := range []string{"(", "（"} {
+		if i := strings.Index(h, open); i > 0 {
+			h = h[:i]
+		}
+	}
+	h = strings.Join(strings.Fields(h), " ")
+	return strings.ToLower(h)
+}
+
+// qualifyName turns a host record relative to domain into an absolute
+// name. Names already under domain or ending in a dot are kept.
+func qualifyName(lineNo int, name, domain string) (string, []Issue) {
+	name = strings.TrimSpace(name)
+	domain = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(domain), "."))
+	if name == "" || strings.HasSuffix(name, ".") {
+		return name, nil
+	}
+	if domain == "" {
+		if name == "@" || !strings.Contains(name, ".") {
+			return name, []Issue{{Line: lineNo, Level: "error", Message: fmt.Sprintf("relative name %q needs a domain", name)}}
+		}
+		return name, nil
+	}
+	lower := strings.ToLower(name)
+	if name == "@" || lower == domain {
+		return domain + ".", nil
+	}
+	if strings.HasSuffix(lower, "."+domain) {
+		return name + ".", nil
+	}
+	return name + "." + domain + ".", nil
+}
+
+// csvStatus maps console status labels to ENABLE/DISABLE.
+func csvStatus(s string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "enable", "enabled", "active", "启用", "正常", "已启用":
+		return "ENABLE", true
+	case "disable", "disabled", "paused", "暂停", "停用", "禁用", "已暂停":
+		return "DISABLE", true
+	}
+	return "", false
+}
+
+func blankRow(row []string) bool {
+	for _, c := range row {
+		if strings.TrimSpace(c) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,90 @@
+package dnstxt
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+func TestParseCSVDNSPodExport(t *testing.T) {
+	in := "\ufeff主机记录,记录类型,线路类型,记录值,MX优先级,TTL（秒）,权重,状态,备注\n" +
+		"@,MX,默认,mail.example.com.,10,600,,启用,\n" +
+		"mail,A,电信,192.0.2.10,,600,20,暂停,\"mail, primary\"\n" +
+		"_dmarc,TXT,默认,\"v=DMARC1; p=none\",,600,,启用,\n"
+
+	records, issues, err := ParseCSV(strings.NewReader(in), CSVOptions{Domain: "example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 0 {
+		t.Fatalf("unexpected issues: %+v", issues)
+	}
+	if len(records) != 3 {
+		t.Fatalf("expected 3 records, got %d", len(records))
+	}
+
+	mx := records[0]
+	if mx.Name != "example.com." || mx.Contents != "10 mail.example.com." || mx.Parsed == nil || *mx.Parsed.Priority != 10 {
+		t.Fatalf("unexpected MX record: %+v", mx)
+	}
+	a := records[1]
+	if a.Name != "mail.example.com." || a.Line != "电信" || a.Weight == nil || *a.Weight != 20 || a.Status != "DISABLE" || a.Remark != "mail, primary" {
+		t.Fatalf("unexpected A record: %+v", a)
+	}
+	if txt := records[2]; txt.Contents != "v=DMARC1; p=none" || txt.TTL == nil || *txt.TTL != 600 {
+		t.Fatalf("unexpected TXT record: %+v", txt)
+	}
+}
+
+func TestParseCSVAliyunLayoutAndCustomColumns(t *testing.T) {
+	in := "记录类型,主机记录,解析线路,记录值,MX优先级,TTL值,状态(暂停/启用),备注\n" +
+		"CNAME,autoconfig,默认,mail.example.com,,600,启用,\"multi\nline\"\n"
+
+	records, issues, err := ParseCSV(strings.NewReader(in), CSVOptions{Layout: LayoutAliyun, Domain: "example.com."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 0 || len(records) != 1 {
+		t.Fatalf("unexpected result: %+v %+v", records, issues)
+	}
+	if r := records[0]; r.Name != "autoconfig.example.com." || r.Contents != "mail.example.com." || r.Remark != "multi\nline" || r.Status != "ENABLE" {
+		t.Fatalf("unexpected record: %+v", r)
+	}
+
+	custom := "Kind;Host;Answer;Pref\nMX;example.com;mx1.example.net;5\n"
+	records, _, err = ParseCSV(strings.NewReader(custom), CSVOptions{
+		Comma:   ';',
+		Columns: map[string]string{FieldType: "kind", FieldName: "Host", FieldContents: "Answer", FieldPriority: "Pref"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].Name != "example.com." || records[0].Contents != "5 mx1.example.net." {
+		t.Fatalf("unexpected records: %+v", records)
+	}
+
+	if _, _, err := ParseCSV(strings.NewReader("a,b,c\n1,2,3\n"), CSVOptions{}); err == nil {
+		t.Fatalf("expected an error for a header without type/name/contents")
+	}
+}
+
+func TestParseCSVUTF16TabSeparated(t *testing.T) {
+	text := "Type\tName\tValue\tTTL\nA\twww.example.com\t192.0.2.1\t300\n"
+	u := utf16.Encode([]rune(text))
+	b := []byte{0xff, 0xfe}
+	for _, c := range u {
+		b = append(b, byte(c), byte(c>>8))
+	}
+
+	records, issues, err := ParseCSV(strings.NewReader(string(b)), CSVOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 0 || len(records) != 1 || records[0].Name != "www.example.com." || records[0].Contents != "192.0.2.1" {
+		t.Fatalf("unexpected result: %+v %+v", records, issues)
+	}
+
+	if _, _, err := ParseCSV(strings.NewReader("type,name,value\nA,www,\xc4\xe3\xba\xc3\n"), CSVOptions{}); err == nil {
+		t.Fatalf("expected an error for non-UTF-8 input")
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"ddnsjx/internal/config"
+	"ddnsjx/internal/dns"
 )
 
 type Issue struct {
@@ -46,6 +47,22 @@ func Parse(r io.Reader) ([]config.RawRecord, []Issue, error) {
 			continue
 		}
 
+		if isGenerate(raw) {
+			rows, err := expandGenerate(raw)
+			if err != nil {
+				issues = append(issues, Issue{Line: lineNo, Level: "error", Message: err.Error()})
+				continue
+			}
+			for _, f := range rows {
+				rec, recIssues, ok := buildRecord(lineNo, f)
+				issues = append(issues, recIssues...)
+				if ok {
+					records = append(records, rec)
+				}
+			}
+			continue
+		}
+
 		cols, hadTabs := splitColumns(raw)
 		if len(cols) == 0 {
 			continue
@@ -114,53 +131,69 @@ func parseRecordLine(lineNo int, cols []string, hadTabs bool, header map[string]
 		return strings.TrimSpace(cols[i]), true
 	}
 
-	var (
-		t       string
-		name    string
-		content string
-		ttlRaw  string
-		remark  string
-	)
-
+	var f rawFields
 	if header != nil {
 		var ok bool
-		t, ok = get("type")
+		f.Type, ok = get("type")
 		if !ok {
 			issues = append(issues, Issue{Line: lineNo, Level: "error", Message: "missing column: type"})
 			return config.RawRecord{}, issues, false
 		}
-		name, ok = get("name")
+		f.Name, ok = get("name")
 		if !ok {
 			issues = append(issues, Issue{Line: lineNo, Level: "error", Message: "missing column: name"})
 			return config.RawRecord{}, issues, false
 		}
-		content, ok = get("contents")
+		f.Contents, ok = get("contents")
 		if !ok {
-			content, _ = get("content")
+			f.Contents, _ = get("content")
 		}
-		if content == "" {
-			content, _ = get("value")
+		if f.Contents == "" {
+			f.Contents, _ = get("value")
 		}
-		if content == "" {
+		if f.Contents == "" {
 			issues = append(issues, Issue{Line: lineNo, Level: "error", Message: "missing column: contents"})
 			return config.RawRecord{}, issues, false
 		}
-		ttlRaw, _ = get("ttl")
-		remark, _ = get("remark")
+		f.TTL, _ = get("ttl")
+		f.Remark, _ = get("remark")
+		f.Line, _ = get("line")
+		f.LineID, _ = get("line_id")
+		f.Weight, _ = get("weight")
+		f.Status, _ = get("status")
 	} else {
 		if len(cols) < 3 {
 			issues = append(issues, Issue{Line: lineNo, Level: "error", Message: "expected at least 3 columns: Type Name Contents"})
 			return config.RawRecord{}, issues, false
 		}
-		t = cols[0]
-		name = cols[1]
+		f.Type = cols[0]
+		f.Name = cols[1]
 		if hadTabs {
-			content = strings.Join(cols[2:], "\t")
+			f.Contents = strings.Join(cols[2:], "\t")
 		} else {
-			content = strings.Join(cols[2:], " ")
+			f.Contents = strings.Join(cols[2:], " ")
 		}
 	}
 
+	rec, recIssues, ok := buildRecord(lineNo, f)
+	return rec, append(issues, recIssues...), ok
+}
+
+// rawFields are the text columns of one input row, before validation.
+type rawFields struct {
+	Type, Name, Contents string
+	TTL, Remark          string
+	Line, LineID         string
+	Weight, Status       string
+}
+
+// buildRecord validates and normalizes one row of any input format.
+func buildRecord(lineNo int, f rawFields) (config.RawRecord, []Issue, bool) {
+	var issues []Issue
+
+	t, name, content := f.Type, f.Name, f.Contents
+	ttlRaw, remark, line, lineID, weight, status := f.TTL, f.Remark, f.Line, f.LineID, f.Weight, f.Status
+
 	t = strings.ToUpper(strings.TrimSpace(t))
 	name = strings.TrimSpace(name)
 	content = strings.TrimSpace(content)
@@ -172,9 +205,11 @@ func parseRecordLine(lineNo int, cols []string, hadTabs bool, header map[string]
 
 	name = maybeEnsureTrailingDot(name)
 
-	if t == "TXT" {
-		if len(content) >= 2 && strings.HasPrefix(content, "\"") && strings.HasSuffix(content, "\"") {
-			content = strings.TrimSuffix(strings.TrimPrefix(content, "\""), "\"")
+	// A single quoted TXT string is stored as its text; values split into
+	// several strings keep their zone-file form (see dns.ParseTXT).
+	if t == "TXT" && strings.HasPrefix(content, "\"") {
+		if txt := dns.ParseTXT(content); len(txt) == 1 && !strings.HasPrefix(txt[0], "\"") {
+			content = txt[0]
 		}
 	}
 
@@ -183,6 +218,9 @@ func parseRecordLine(lineNo int, cols []string, hadTabs bool, header map[string]
 		Name:     name,
 		Contents: content,
 		Remark:   strings.TrimSpace(remark),
+		Line:     line,
+		LineID:   lineID,
+		Status:   strings.ToUpper(status),
 	}
 
 	if ttlRaw != "" {
@@ -194,6 +232,15 @@ func parseRecordLine(lineNo int, cols []string, hadTabs bool, header map[string]
 		}
 	}
 
+	if weight != "" {
+		v, err := strconv.ParseUint(weight, 10, 64)
+		if err != nil || v > 100 {
+			issues = append(issues, Issue{Line: lineNo, Level: "warn", Message: fmt.Sprintf("invalid weight %q (expected 0-100)", weight)})
+		} else {
+			rec.Weight = &v
+		}
+	}
+
 	switch t {
 	case "MX":
 		rr, warn := normalizeMXContents(rec.Contents)
@@ -215,6 +262,14 @@ func parseRecordLine(lineNo int, cols []string, hadTabs bool, header map[string]
 		}
 	case "CNAME":
 		rec.Contents = maybeEnsureTrailingDot(rec.Contents)
+	case "CAA", "NAPTR", "SSHFP", "TLSA", "HTTPS", "SVCB", "DS":
+		parsed, contents, err := config.ParseStructured(t, rec.Contents)
+		if err != nil {
+			issues = append(issues, Issue{Line: lineNo, Level: "warn", Message: err.Error()})
+			break
+		}
+		rec.Contents = contents
+		rec.Parsed = parsed
 	}
 
 	return rec, issues, true
@@ -0,0 +1,199 @@
+package dnstxt
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// maxGenerate bounds the records one $GENERATE line may produce.
+const maxGenerate = 65536
+
+func isGenerate(line string) bool {
+	directive, _, _ := strings.Cut(line, " ")
+	directive, _, _ = strings.Cut(directive, "\t")
+	return strings.EqualFold(directive, "$GENERATE")
+}
+
+// expandGenerate expands a BIND-style directive
+//
+//	$GENERATE <start>-<stop>[/<step>] <name> [<ttl>] [IN] <type> <contents>
+//
+// into one row per iteration. Name and contents are written as in the
+// Type/Name/Contents columns; in both, $ is the iterator, ${offset,width,base}
+// formats it (base d, o, x, X, n or N) and \$ is a literal dollar sign.
+func expandGenerate(line string) ([]rawFields, error) {
+	rest := strings.TrimSpace(line)
+	next := func() string {
+		rest = strings.TrimLeft(rest, " \t")
+		i := strings.IndexAny(rest, " \t")
+		if i < 0 {
+			tok := rest
+			rest = ""
+			return tok
+		}
+		tok := rest[:i]
+		rest = rest[i:]
+		return tok
+	}
+
+	next() // $GENERATE
+	start, stop, step, err := parseGenerateRange(next())
+	if err != nil {
+		return nil, err
+	}
+	lhs := next()
+
+	var ttl, typ string
+	for typ == "" {
+		tok := next()
+		switch {
+		case tok == "":
+			return nil, fmt.Errorf("$GENERATE expects: <range> <name> [<ttl>] [IN] <type> <contents>")
+		case ttl == "" && isDigits(tok):
+			ttl = tok
+		case strings.EqualFold(tok, "IN"):
+		default:
+			typ = tok
+		}
+	}
+	rhs := strings.TrimSpace(rest)
+	if rhs == "" {
+		return nil, fmt.Errorf("$GENERATE expects: <range> <name> [<ttl>] [IN] <type> <contents>")
+	}
+
+	var out []rawFields
+	for i := start; i <= stop; i += step {
+		name, err := substituteIterator(lhs, i)
+		if err != nil {
+			return nil, err
+		}
+		contents, err := substituteIterator(rhs, i)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, rawFields{Type: typ, Name: name, Contents: contents, TTL: ttl})
+	}
+	return out, nil
+}
+
+func parseGenerateRange(s string) (start, stop, step int, err error) {
+	r, stepStr, hasStep := strings.Cut(s, "/")
+	lo, hi, ok := strings.Cut(r, "-")
+	if !ok {
+		return 0, 0, 0, fmt.Errorf("invalid $GENERATE range %q (expected start-stop[/step])", s)
+	}
+	start, err1 := strconv.Atoi(lo)
+	stop, err2 := strconv.Atoi(hi)
+	step = 1
+	var err3 error
+	if hasStep {
+		step, err3 = strconv.Atoi(stepStr)
+	}
+	if err1 != nil || err2 != nil || err3 != nil || start < 0 || step <= 0 {
+		return 0, 0, 0, fmt.Errorf("invalid $GENERATE range %q (expected start-stop[/step])", s)
+	}
+	if stop < start {
+		return 0, 0, 0, fmt.Errorf("$GENERATE range %q ends before it starts", s)
+	}
+	if (stop-start)/step >= maxGenerate {
+		return 0, 0, 0, fmt.Errorf("$GENERATE range %q produces more than %d records", s, maxGenerate)
+	}
+	return start, stop, step, nil
+}
+
+// substituteIterator replaces $ and ${offset,width,base} in s with i.
+func substituteIterator(s string, i int) (string, error) {
+	var b strings.Builder
+	for pos := 0; pos < len(s); pos++ {
+		c := s[pos]
+		switch {
+		case c == '\\' && pos+1 < len(s) && s[pos+1] == '$':
+			b.WriteByte('$')
+			pos++
+		case c != '$':
+			b.WriteByte(c)
+		case pos+1 < len(s) && s[pos+1] == '{':
+			end := strings.IndexByte(s[pos:], '}')
+			if end < 0 {
+				return "", fmt.Errorf("unterminated ${...} in %q", s)
+			}
+			v, err := formatIterator(s[pos+2:pos+end], i)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(v)
+			pos += end
+		default:
+			b.WriteString(strconv.Itoa(i))
+		}
+	}
+	return b.String(), nil
+}
+
+// formatIterator applies an "offset[,width[,base]]" modifier.
+func formatIterator(mod string, i int) (string, error) {
+	parts := strings.Split(mod, ",")
+	if len(parts) > 3 {
+		return "", fmt.Errorf("invalid $GENERATE modifier ${%s}", mod)
+	}
+	offset, width, base := 0, 0, "d"
+	var err error
+	if parts[0] != "" {
+		if offset, err = strconv.Atoi(parts[0]); err != nil {
+			return "", fmt.Errorf("invalid $GENERATE offset in ${%s}", mod)
+		}
+	}
+	if len(parts) > 1 && parts[1] != "" {
+		if width, err = strconv.Atoi(parts[1]); err != nil || width < 0 {
+			return "", fmt.Errorf("invalid $GENERATE width in ${%s}", mod)
+		}
+	}
+	if len(parts) > 2 {
+		base = parts[2]
+	}
+	n := i + offset
+	if n < 0 {
+		return "", fmt.Errorf("$GENERATE value %d is negative in ${%s}", n, mod)
+	}
+
+	switch base {
+	case "d":
+		return fmt.Sprintf("%0*d", width, n), nil
+	case "o":
+		return fmt.Sprintf("%0*o", width, n), nil
+	case "x":
+		return fmt.Sprintf("%0*x", width, n), nil
+	case "X":
+		return fmt.Sprintf("%0*X", width, n), nil
+	case "n", "N":
+		// Nibble format: hex digits in reverse order separated by dots, as
+		// used in ip6.arpa names. width counts the dots too.
+		hex := strconv.FormatInt(int64(n), 16)
+		if base == "N" {
+			hex = strings.ToUpper(hex)
+		}
+		for 2*len(hex)-1 < width {
+			hex = "0" + hex
+		}
+		labels := make([]string, len(hex))
+		for j := range hex {
+			labels[len(hex)-1-j] = hex[j : j+1]
+		}
+		return strings.Join(labels, "."), nil
+	default:
+		return "", fmt.Errorf("invalid $GENERATE base %q in ${%s} (expected d, o, x, X, n or N)", base, mod)
+	}
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,69 @@
+package dnstxt
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseExpandsGenerate(t *testing.T) {
+	in := strings.Join([]string{
+		"Type\tName\tContents",
+		"$GENERATE 1-3 mx$.example.com. A 192.0.2.${10}",
+		"$GENERATE 1-4/2 example.com. 600 IN MX $0 mx$.example.com.",
+		"$GENERATE 0-1 _25._tcp.mx${1}.example.com TLSA 3 1 1 aa1$",
+		"$GENERATE 10-11 ${0,4,n}.0.8.b.d.0.1.0.0.2.ip6.arpa. PTR host-${0,3,x}.example.com.",
+		"$GENERATE 1-1 price\\$.example.com TXT cost \\$5",
+		"A\tmail.example.com\t192.0.2.1",
+	}, "\n")
+
+	records, issues, err := Parse(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 0 {
+		t.Fatalf("unexpected issues: %+v", issues)
+	}
+
+	var got []string
+	for _, r := range records {
+		line := r.Type + " " + r.Name + " " + r.Contents
+		if r.TTL != nil {
+			line += " ttl"
+		}
+		got = append(got, line)
+	}
+	want := []string{
+		"A mx1.example.com. 192.0.2.11",
+		"A mx2.example.com. 192.0.2.12",
+		"A mx3.example.com. 192.0.2.13",
+		"MX example.com. 10 mx1.example.com. ttl",
+		"MX example.com. 30 mx3.example.com. ttl",
+		"TLSA _25._tcp.mx1.example.com. 3 1 1 aa10",
+		"TLSA _25._tcp.mx2.example.com. 3 1 1 aa11",
+		"PTR a.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa. host-00a.example.com.",
+		"PTR b.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa. host-00b.example.com.",
+		"TXT price$.example.com. cost $5",
+		"A mail.example.com. 192.0.2.1",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected records:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+func TestParseGenerateErrors(t *testing.T) {
+	for _, line := range []string{
+		"$GENERATE 5-1 h$.example.com A 192.0.2.$",
+		"$GENERATE 1-2/0 h$.example.com A 192.0.2.$",
+		"$GENERATE 1-2 h$.example.com A",
+		"$GENERATE 1-2 h${0,2,z}.example.com A 192.0.2.$",
+		"$GENERATE 0-100000 h$.example.com A 192.0.2.1",
+	} {
+		records, issues, err := Parse(strings.NewReader(line))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(records) != 0 || len(issues) != 1 || issues[0].Level != "error" || issues[0].Line != 1 {
+			t.Fatalf("%q: expected one error issue, got %+v %+v", line, records, issues)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package dnstxt
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	SerialDate      = "date"
+	SerialUnixTime  = "unixtime"
+	SerialIncrement = "increment"
+)
+
+// SOA describes the apex SOA record. Zero timers take the RIPE-203
+// recommendations.
+type SOA struct {
+	// MName is the primary name server; empty uses the first NS host.
+	MName string
+	// RName is the responsible mailbox, as "hostmaster@example.com" or in
+	// zone-file form; empty uses hostmaster.<domain>.
+	RName string
+	// SerialStrategy is date (YYYYMMDDnn, the default), unixtime or
+	// increment. Each yields a serial greater than PreviousSerial.
+	SerialStrategy string
+	// PreviousSerial is the serial of the zone being replaced, if any.
+	PreviousSerial uint32
+	Refresh        uint32
+	Retry          uint32
+	Expire         uint32
+	// Minimum is the negative-caching TTL.
+	Minimum uint32
+	// Now fixes the clock for the date and unixtime strategies.
+	Now time.Time
+}
+
+func (s SOA) serial() (uint32, error) {
+	now := s.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	var next uint32
+	switch strings.ToLower(strings.TrimSpace(s.SerialStrategy)) {
+	case "", SerialDate:
+		d := now.UTC()
+		next = uint32(d.Year()*1000000 + int(d.Month())*10000 + d.Day()*100)
+	case SerialUnixTime:
+		next = uint32(now.Unix())
+	case SerialIncrement:
+		next = s.PreviousSerial + 1
+	default:
+		return 0, fmt.Errorf("unknown SOA serial strategy %q (expected date|unixtime|increment)", s.SerialStrategy)
+	}
+	if next <= s.PreviousSerial {
+		next = s.PreviousSerial + 1
+	}
+	return next, nil
+}
+
+// rdata renders the SOA RDATA for domain; ns is the apex NS host list.
+func (s SOA) rdata(domain string, ns []string) (string, error) {
+	mname := strings.TrimSpace(s.MName)
+	if mname == "" && len(ns) > 0 {
+		mname = ns[0]
+	}
+	if mname == "" {
+		return "", fmt.Errorf("SOA needs a primary name server (MName or NS)")
+	}
+	serial, err := s.serial()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s %s %d %d %d %d %d",
+		ensureFQDN(mname), mailboxName(domain, s.RName), serial,
+		orDefault(s.Refresh, 86400), orDefault(s.Retry, 7200), orDefault(s.Expire, 3600000), orDefault(s.Minimum, 3600)), nil
+}
+
+// mailboxName turns "local@host" into the RNAME form "local.host.", with
+// dots in the local part escaped.
+func mailboxName(domain, rname string) string {
+	rname = strings.TrimSpace(rname)
+	if rname == "" {
+		return "hostmaster." + domain + "."
+	}
+	if local, host, ok := strings.Cut(rname, "@"); ok {
+		rname = strings.ReplaceAll(local, ".", `\.`) + "." + host
+	}
+	if !strings.HasSuffix(rname, ".") {
+		rname += "."
+	}
+	return rname
+}
+
+func orDefault(v, def uint32) uint32 {
+	if v == 0 {
+		return def
+	}
+	return v
+}
+
+var soaSerialRe = regexp.MustCompile(`(?is)\bSOA\s+\(?\s*\S+\s+\S+\s+\(?\s*(\d+)`)
+
+// ZoneSerial returns the SOA serial of a zone file rendered earlier, so a
+// regenerated zone can be given a larger one.
+func ZoneSerial(zone string) (uint32, bool) {
+	var b strings.Builder
+	for _, line := range strings.Split(zone, "\n") {
+		if i := strings.Index(line, ";"); i >= 0 {
+			line = line[:i]
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	m := soaSerialRe.FindStringSubmatch(b.String())
+	if m == nil {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(m[1], 10, 32)
+	if err != nil {
+		return 0, false
+	}
+	return uint32(n), true
+}
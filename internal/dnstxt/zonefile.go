@@ -3,14 +3,34 @@ package dnstxt
 import (
 	"fmt"
 	"net"
+	"sort"
 	"strconv"
 	"strings"
 
 	"ddnsjx/internal/config"
+	"ddnsjx/internal/dns"
 )
 
 type ZoneOptions struct {
 	DefaultTTL uint64
+	// SOA, when set, emits the apex SOA record.
+	SOA *SOA
+	// NS lists the apex name servers. Apex NS records with the same host
+	// in the input are not repeated.
+	NS []string
+	// Canonical sorts records by owner in DNSSEC canonical order (SOA and
+	// NS first within the apex, then by type and data), writes each owner
+	// once per group and aligns the columns.
+	Canonical bool
+}
+
+// zoneRecord is one resource record line of the rendered zone.
+type zoneRecord struct {
+	owner string // relative, "@" or absolute with a trailing dot
+	fqdn  string // lowercase absolute name without the trailing dot, for sorting
+	ttl   string
+	typ   string
+	rdata string
 }
 
 func RenderZone(domain string, records []config.RawRecord, opt ZoneOptions) (string, []Issue, error) {
@@ -18,22 +38,33 @@ func RenderZone(domain string, records []config.RawRecord, opt ZoneOptions) (str
 	if domain == "" {
 		return "", nil, fmt.Errorf("domain is empty")
 	}
+	if opt.DefaultTTL == 0 {
+		opt.DefaultTTL = 300
+	}
 
 	var (
-		b      strings.Builder
+		rrs    []zoneRecord
 		issues []Issue
 	)
 
-	b.WriteString("$ORIGIN ")
-	b.WriteString(domain)
-	b.WriteString(".\n")
-	if opt.DefaultTTL == 0 {
-		opt.DefaultTTL = 300
+	ns := make([]string, 0, len(opt.NS))
+	for _, h := range opt.NS {
+		if h = strings.TrimSpace(h); h != "" {
+			ns = append(ns, ensureFQDN(h))
+		}
+	}
+	if opt.SOA != nil {
+		rdata, err := opt.SOA.rdata(domain, ns)
+		if err != nil {
+			return "", nil, err
+		}
+		rrs = append(rrs, zoneRecord{owner: "@", fqdn: strings.ToLower(domain), typ: "SOA", rdata: rdata})
+	}
+	for _, h := range ns {
+		rrs = append(rrs, zoneRecord{owner: "@", fqdn: strings.ToLower(domain), typ: "NS", rdata: h})
 	}
-	b.WriteString("$TTL ")
-	b.WriteString(strconv.FormatUint(opt.DefaultTTL, 10))
-	b.WriteString("\n\n")
 
+	apexNS := len(ns) > 0
 	for i, rr := range records {
 		lineNo := i + 1
 		t := strings.ToUpper(strings.TrimSpace(rr.Type))
@@ -61,22 +92,123 @@ func RenderZone(domain string, records []config.RawRecord, opt ZoneOptions) (str
 		if rdata == "" {
 			continue
 		}
+		if owner == "@" && t == "NS" {
+			if containsFold(ns, rdata) {
+				continue
+			}
+			apexNS = true
+		}
+
+		rrs = append(rrs, zoneRecord{owner: owner, fqdn: strings.ToLower(name), ttl: ttlStr, typ: t, rdata: rdata})
+	}
+	if opt.SOA != nil && !apexNS {
+		issues = append(issues, Issue{Level: "warn", Message: "zone has an SOA but no apex NS records; it will not load"})
+	}
+
+	var b strings.Builder
+	b.WriteString("$ORIGIN ")
+	b.WriteString(domain)
+	b.WriteString(".\n")
+	b.WriteString("$TTL ")
+	b.WriteString(strconv.FormatUint(opt.DefaultTTL, 10))
+	b.WriteString("\n\n")
 
-		b.WriteString(owner)
-		if ttlStr != "" {
+	if opt.Canonical {
+		sortCanonical(rrs)
+		writeAligned(&b, rrs)
+		return b.String(), issues, nil
+	}
+	for _, rr := range rrs {
+		b.WriteString(rr.owner)
+		if rr.ttl != "" {
 			b.WriteString(" ")
-			b.WriteString(ttlStr)
+			b.WriteString(rr.ttl)
 		}
 		b.WriteString(" IN ")
-		b.WriteString(t)
+		b.WriteString(rr.typ)
 		b.WriteString(" ")
-		b.WriteString(rdata)
+		b.WriteString(rr.rdata)
 		b.WriteString("\n")
 	}
-
 	return b.String(), issues, nil
 }
 
+// sortCanonical orders records by owner in RFC 4034 canonical name order,
+// which puts the apex first and children after their parents, then SOA and
+// NS ahead of other types, then by type and data.
+func sortCanonical(rrs []zoneRecord) {
+	sort.SliceStable(rrs, func(i, j int) bool {
+		a, b := rrs[i], rrs[j]
+		if c := compareNames(a.fqdn, b.fqdn); c != 0 {
+			return c < 0
+		}
+		if ra, rb := typeRank(a.typ), typeRank(b.typ); ra != rb {
+			return ra < rb
+		}
+		if a.typ != b.typ {
+			return a.typ < b.typ
+		}
+		return a.rdata < b.rdata
+	})
+}
+
+// compareNames compares two names label by label from the root.
+func compareNames(a, b string) int {
+	la, lb := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 1; i <= len(la) && i <= len(lb); i++ {
+		if c := strings.Compare(la[len(la)-i], lb[len(lb)-i]); c != 0 {
+			return c
+		}
+	}
+	return len(la) - len(lb)
+}
+
+func typeRank(t string) int {
+	switch t {
+	case "SOA":
+		return 0
+	case "NS":
+		return 1
+	default:
+		return 2
+	}
+}
+
+// writeAligned writes rrs in tab-free aligned columns, printing each owner
+// only on the first line of its group and separating groups with a blank
+// line.
+func writeAligned(b *strings.Builder, rrs []zoneRecord) {
+	var wOwner, wTTL, wType int
+	for _, rr := range rrs {
+		wOwner = max(wOwner, len(rr.owner))
+		wTTL = max(wTTL, len(rr.ttl))
+		wType = max(wType, len(rr.typ))
+	}
+	for i, rr := range rrs {
+		owner := rr.owner
+		if i > 0 && rrs[i-1].owner == rr.owner {
+			owner = ""
+		} else if i > 0 {
+			b.WriteString("\n")
+		}
+		line := fmt.Sprintf("%-*s  %*s  IN  %-*s  %s", wOwner, owner, wTTL, rr.ttl, wType, rr.typ, rr.rdata)
+		if wTTL == 0 {
+			line = fmt.Sprintf("%-*s  IN  %-*s  %s", wOwner, owner, wType, rr.typ, rr.rdata)
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+}
+
+func containsFold(list []string, v string) bool {
+	for _, s := range list {
+		if strings.EqualFold(s, v) {
+			return true
+		}
+	}
+	return false
+}
+
 func toRelativeOwner(domain, name string) string {
 	if name == domain {
 		return "@"
@@ -101,7 +233,7 @@ func toZoneRData(t, contents string) (string, string) {
 	switch t {
 	case "TXT":
 		return quoteTXT(contents), ""
-	case "CNAME":
+	case "CNAME", "NS", "PTR":
 		return ensureFQDN(contents), ""
 	case "MX":
 		f := strings.Fields(contents)
@@ -117,6 +249,12 @@ func toZoneRData(t, contents string) (string, string) {
 		}
 		target := ensureFQDN(f[3])
 		return strings.Join([]string{f[0], f[1], f[2], target}, " "), ""
+	case "CAA", "NAPTR", "SSHFP", "TLSA", "HTTPS", "SVCB", "DS":
+		rdata, err := dns.ParseRData(t, contents)
+		if err != nil {
+			return "", err.Error()
+		}
+		return rdata, ""
 	default:
 		return contents, ""
 	}
@@ -139,27 +277,12 @@ func ensureFQDN(v string) string {
 	return v
 }
 
+// quoteTXT renders a TXT value as quoted strings of at most 255 bytes,
+// wrapped in parentheses when there is more than one.
 func quoteTXT(v string) string {
-	v = strings.TrimSpace(v)
-	if v == "" {
-		return "\"\""
-	}
-	v = strings.ReplaceAll(v, "\\", "\\\\")
-	v = strings.ReplaceAll(v, "\"", "\\\"")
-
-	const maxChunk = 200
-	if len(v) <= maxChunk {
-		return "\"" + v + "\""
-	}
-
-	var parts []string
-	for len(v) > 0 {
-		n := maxChunk
-		if len(v) < n {
-			n = len(v)
-		}
-		parts = append(parts, "\""+v[:n]+"\"")
-		v = v[n:]
+	txt := dns.ParseTXT(v).Split()
+	if len(txt) == 1 {
+		return txt.Quoted()
 	}
-	return "(" + strings.Join(parts, " ") + ")"
+	return "(" + txt.Quoted() + ")"
 }
@@ -0,0 +1,115 @@
+package dnstxt
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"ddnsjx/internal/config"
+	"ddnsjx/internal/dns"
+)
+
+func TestRenderZoneSOAAndCanonicalOrder(t *testing.T) {
+	ttl := uint64(600)
+	records := []config.RawRecord{
+		{Type: "TXT", Name: "_dmarc.example.com", Contents: "v=DMARC1; p=none"},
+		{Type: "A", Name: "mail.example.com", Contents: "192.0.2.10"},
+		{Type: "MX", Name: "example.com", Contents: "10 mail.example.com"},
+		{Type: "NS", Name: "example.com", Contents: "ns1.example.net"},
+		{Type: "AAAA", Name: "mail.example.com", Contents: "2001:db8::10", TTL: &ttl},
+		{Type: "A", Name: "a.mail.example.com", Contents: "192.0.2.11"},
+	}
+	zone, issues, err := RenderZone("example.com", records, ZoneOptions{
+		DefaultTTL: 3600,
+		SOA:        &SOA{RName: "dns.admin@example.com", Now: time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)},
+		NS:         []string{"ns1.example.net", "ns2.example.net"},
+		Canonical:  true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 0 {
+		t.Fatalf("unexpected issues %v", issues)
+	}
+
+	want := "$ORIGIN example.com.\n$TTL 3600\n\n" +
+		"@            IN  SOA   ns1.example.net. dns\\.admin.example.com. 2026101800 86400 7200 3600000 3600\n" +
+		"             IN  NS    ns1.example.net.\n" +
+		"             IN  NS    ns2.example.net.\n" +
+		"             IN  MX    10 mail.example.com.\n" +
+		"\n" +
+		"_dmarc       IN  TXT   \"v=DMARC1; p=none\"\n" +
+		"\n" +
+		"mail         IN  A     192.0.2.10\n" +
+		"        600  IN  AAAA  2001:db8::10\n" +
+		"\n" +
+		"a.mail       IN  A     192.0.2.11\n"
+	if zone != want {
+		t.Fatalf("unexpected zone:\n%s\nwant:\n%s", zone, want)
+	}
+	if serial, ok := ZoneSerial(zone); !ok || serial != 2026101800 {
+		t.Fatalf("ZoneSerial = %d %v", serial, ok)
+	}
+}
+
+func TestSOASerialStrategies(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		strategy string
+		previous uint32
+		want     uint32
+	}{
+		{SerialDate, 0, 2026101800},
+		{SerialDate, 2026101805, 2026101806},
+		{SerialUnixTime, 0, uint32(now.Unix())},
+		{SerialIncrement, 41, 42},
+		{SerialIncrement, 0, 1},
+	} {
+		got, err := SOA{SerialStrategy: tc.strategy, PreviousSerial: tc.previous, Now: now}.serial()
+		if err != nil || got != tc.want {
+			t.Errorf("%s after %d: got %d %v, want %d", tc.strategy, tc.previous, got, err, tc.want)
+		}
+	}
+	if _, err := (SOA{SerialStrategy: "weekly"}).serial(); err == nil {
+		t.Errorf("expected an error for an unknown strategy")
+	}
+	if _, _, err := RenderZone("example.com", nil, ZoneOptions{SOA: &SOA{}}); err == nil {
+		t.Errorf("expected an error for an SOA without a primary name server")
+	}
+
+	multiline := "@ IN SOA ns1.example.net. hostmaster.example.com. (\n  2024010203 ; serial\n  7200 3600 1209600 300 )\n"
+	if serial, ok := ZoneSerial(multiline); !ok || serial != 2024010203 {
+		t.Errorf("ZoneSerial(multi-line) = %d %v", serial, ok)
+	}
+}
+
+func TestLongTXTRoundTrip(t *testing.T) {
+	key := strings.Repeat("K", 300)
+	in := "Type\tName\tContents\nTXT\tdkim._domainkey.example.com\t\"v=DKIM1; p=" + key[:100] + "\" \"" + key[100:] + "\"\n"
+	records, issues, err := Parse(strings.NewReader(in))
+	if err != nil || len(issues) != 0 {
+		t.Fatalf("parse: %v %v", err, issues)
+	}
+	if got := dns.TXTText(records[0].Contents); got != "v=DKIM1; p="+key {
+		t.Fatalf("split TXT lost its text: %q", got)
+	}
+
+	zone, _, err := RenderZone("example.com", records, ZoneOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `dkim._domainkey IN TXT ("v=DKIM1; p=` + key[:100] + `" "` + key[100:] + `")`
+	if !strings.Contains(zone, want+"\n") {
+		t.Fatalf("expected the strings as given, got:\n%s", zone)
+	}
+
+	records[0].Contents = dns.TXTText(records[0].Contents)
+	zone, _, err = RenderZone("example.com", records, ZoneOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = `dkim._domainkey IN TXT ("v=DKIM1; p=` + key[:244] + `" "` + key[244:] + `")`
+	if !strings.Contains(zone, want+"\n") {
+		t.Fatalf("expected plain text split into 255 byte strings, got:\n%s", zone)
+	}
+}
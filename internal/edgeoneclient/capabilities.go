@@ -0,0 +1,12 @@
+package edgeoneclient
+
+import "ddnsjx/internal/provider"
+
+func capabilities() provider.Capabilities {
+	return provider.Capabilities{
+		RecordTypes: []string{"A", "AAAA", "CAA", "CNAME", "MX", "NS", "SRV", "TXT"},
+		MinTTL:      60,
+		MaxTTL:      86400,
+		Weights:     true,
+	}
+}
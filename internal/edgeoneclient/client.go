@@ -0,0 +1,174 @@
+// Package edgeoneclient implements provider.Client for the DNS records of
+// Tencent EdgeOne (teo) zones. It uses the TencentCloud SDK's generic
+// CommonRequest, so no teo service package is needed.
+package edgeoneclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"ddnsjx/internal/provider"
+
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/errors"
+	tchttp "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/http"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
+)
+
+const (
+	defaultBaseURL = "https://teo.tencentcloudapi.com"
+	service        = "teo"
+	apiVersion     = "2022-09-01"
+)
+
+type NewOptions struct {
+	SecretID  string
+	SecretKey string
+	// ZoneID skips the lookup by zone name.
+	ZoneID string
+	// BaseURL overrides the API endpoint (default https://teo.tencentcloudapi.com).
+	BaseURL string
+	// Transport replaces the HTTP transport, e.g. with a cassette.
+	Transport http.RoundTripper
+}
+
+type client struct {
+	sdk    *common.Client
+	zoneID string
+}
+
+type Error struct {
+	Code    string
+	Message string
+}
+
+func (e Error) Error() string {
+	if e.Code == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("[%s] %s", e.Code, e.Message)
+}
+
+// ErrorCode returns the API error code for metrics.
+func (e Error) ErrorCode() string {
+	return e.Code
+}
+
+func (e Error) Retryable() bool {
+	switch e.Code {
+	case "RequestLimitExceeded", "InternalError", "InternalError.SystemError", "InternalError.UnknownError":
+		return true
+	default:
+		return false
+	}
+}
+
+func New(opt NewOptions) (provider.Client, error) {
+	if opt.SecretID == "" || opt.SecretKey == "" {
+		return nil, fmt.Errorf("missing credentials")
+	}
+
+	cpf := profile.NewClientProfile()
+	if err := applyBaseURL(cpf.HttpProfile, opt.BaseURL); err != nil {
+		return nil, err
+	}
+	// teo is a global service; the region is left empty.
+	sdk := common.NewCommonClient(common.NewCredential(opt.SecretID, opt.SecretKey), "", cpf)
+	if opt.Transport != nil {
+		sdk.WithHttpTransport(opt.Transport)
+	}
+	return &client{sdk: sdk, zoneID: strings.TrimSpace(opt.ZoneID)}, nil
+}
+
+// applyBaseURL points the SDK at base, given as "https://host[:port]" or a
+// bare host name.
+func applyBaseURL(hp *profile.HttpProfile, base string) error {
+	base = strings.TrimRight(strings.TrimSpace(base), "/")
+	if base == "" {
+		base = defaultBaseURL
+	}
+	if !strings.Contains(base, "://") {
+		base = "https://" + base
+	}
+	u, err := url.Parse(base)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid edgeone base url %q", base)
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+	default:
+		return fmt.Errorf("invalid edgeone base url %q: scheme must be http or https", base)
+	}
+	hp.Scheme = strings.ToUpper(u.Scheme)
+	hp.Endpoint = u.Host
+	return nil
+}
+
+// call sends action with params and decodes the "Response" object into
+// out.
+func (c *client) call(ctx context.Context, action string, params map[string]interface{}, out any) error {
+	req := tchttp.NewCommonRequest(service, apiVersion, action)
+	if err := req.SetActionParameters(params); err != nil {
+		return err
+	}
+	if ctx != nil {
+		req.SetContext(ctx)
+	}
+
+	resp := tchttp.NewCommonResponse()
+	if err := c.sdk.Send(req, resp); err != nil {
+		if sdkErr, ok := err.(*errors.TencentCloudSDKError); ok {
+			return Error{Code: sdkErr.Code, Message: sdkErr.Message}
+		}
+		return err
+	}
+	if out == nil {
+		return nil
+	}
+	var envelope struct {
+		Response json.RawMessage
+	}
+	if err := json.Unmarshal(resp.GetBody(), &envelope); err != nil {
+		return err
+	}
+	return json.Unmarshal(envelope.Response, out)
+}
+
+func (c *client) Capabilities() provider.Capabilities {
+	return capabilities()
+}
+
+func (c *client) resolveZoneID(ctx context.Context, zone string) (string, error) {
+	if c.zoneID != "" {
+		return c.zoneID, nil
+	}
+	name := strings.ToLower(strings.TrimSuffix(strings.TrimSpace(zone), "."))
+	if name == "" {
+		return "", fmt.Errorf("missing EdgeOne zone name or zone id")
+	}
+
+	var resp struct {
+		Zones []struct {
+			ZoneId   string
+			ZoneName string
+		}
+	}
+	err := c.call(ctx, "DescribeZones", map[string]interface{}{
+		"Filters": []map[string]interface{}{{"Name": "zone-name", "Values": []string{name}}},
+		"Limit":   100,
+	}, &resp)
+	if err != nil {
+		return "", err
+	}
+	for _, z := range resp.Zones {
+		if strings.EqualFold(z.ZoneName, name) {
+			c.zoneID = z.ZoneId
+			return c.zoneID, nil
+		}
+	}
+	return "", fmt.Errorf("EdgeOne zone not found: %s", name)
+}
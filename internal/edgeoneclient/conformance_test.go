@@ -0,0 +1,214 @@
+package edgeoneclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"ddnsjx/internal/dns"
+	"ddnsjx/internal/provider"
+	"ddnsjx/internal/provider/providertest"
+)
+
+func newTestClient(t *testing.T, api *fakeAPI) provider.Client {
+	t.Helper()
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+	c, err := New(NewOptions{SecretID: "id", SecretKey: "key", BaseURL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestConformance(t *testing.T) {
+	providertest.Run(t, func(t *testing.T) provider.Client {
+		return newTestClient(t, newFakeAPI("example.com"))
+	}, providertest.Options{})
+}
+
+func TestZoneLookupAndDuplicates(t *testing.T) {
+	api := newFakeAPI("example.com")
+	c := newTestClient(t, api)
+	ctx := context.Background()
+
+	rec := dns.Record{SubDomain: "www", Type: "CNAME", Value: "origin.example.net"}
+	id, status, err := c.CreateRecord(ctx, "example.com", "", rec)
+	if err != nil || status != provider.CreateStatusSuccess {
+		t.Fatalf("create: %s %v", status, err)
+	}
+	if got := api.records[id]; got.Name != "www.example.com" || got.Location != "Default" || got.ZoneId != fakeZoneID {
+		t.Fatalf("unexpected stored record %+v", got)
+	}
+
+	// EdgeOne itself accepts the duplicate, so the client must catch it.
+	if _, status, err := c.CreateRecord(ctx, "example.com", "", dns.Record{SubDomain: "WWW", Type: "CNAME", Value: "origin.example.net."}); err != nil || status != provider.CreateStatusExists {
+		t.Fatalf("expected exists for an identical record, got %s %v", status, err)
+	}
+	if len(api.records) != 1 {
+		t.Fatalf("duplicate must not be created, got %d records", len(api.records))
+	}
+
+	_, _, err = newTestClient(t, api).FindRecord(ctx, "example.org", "", rec)
+	var apiErr Error
+	if err == nil || !strings.Contains(err.Error(), "EdgeOne zone not found: example.org") {
+		t.Fatalf("expected zone lookup failure, got %v", err)
+	}
+
+	other := newTestClient(t, api).(*client)
+	other.zoneID = "zone-missing"
+	_, _, err = other.FindRecord(ctx, "example.com", "", rec)
+	if !errors.As(err, &apiErr) || apiErr.Code != "ResourceNotFound.ZoneNotFound" || apiErr.Retryable() {
+		t.Fatalf("expected ResourceNotFound.ZoneNotFound Error, got %#v", err)
+	}
+}
+
+// fakeAPI is a stand-in for the teo 2022-09-01 actions the client calls,
+// answering in the TC3 envelope. Like the real service it stores
+// duplicates without complaint and returns zones whose names only share a
+// prefix with the filter.
+type fakeAPI struct {
+	mu      sync.Mutex
+	zone    string
+	nextID  int
+	records map[string]fakeRecord
+}
+
+type fakeRecord struct {
+	ZoneId   string
+	RecordId string
+	Name     string
+	Type     string
+	Location string
+	Content  string
+	TTL      uint64
+	Weight   int64
+	Priority uint64
+}
+
+type fakeFilter struct {
+	Name   string
+	Values []string
+}
+
+type fakeParams struct {
+	ZoneId     string
+	Filters    []fakeFilter
+	RecordIds  []string
+	DnsRecords []fakeRecord
+	fakeRecord
+}
+
+const fakeZoneID = "zone-2o0i41pv2h8c"
+
+func newFakeAPI(zone string) *fakeAPI {
+	return &fakeAPI{zone: zone, records: make(map[string]fakeRecord)}
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if v := r.Header.Get("X-TC-Version"); v != apiVersion {
+		writeTC3Error(w, "InvalidParameter", "unexpected version "+v)
+		return
+	}
+	var p fakeParams
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		writeTC3Error(w, "InvalidParameter", err.Error())
+		return
+	}
+	action := r.Header.Get("X-TC-Action")
+	if action != "DescribeZones" && p.ZoneId != fakeZoneID {
+		writeTC3Error(w, "ResourceNotFound.ZoneNotFound", "站点不存在。")
+		return
+	}
+
+	switch action {
+	case "DescribeZones":
+		writeTC3(w, map[string]any{"TotalCount": 2, "Zones": []map[string]any{
+			{"ZoneId": "zone-other", "ZoneName": f.zone + ".cn"},
+			{"ZoneId": fakeZoneID, "ZoneName": f.zone},
+		}})
+	case "CreateDnsRecord":
+		rec := p.fakeRecord
+		f.nextID++
+		rec.ZoneId = p.ZoneId
+		rec.RecordId = fmt.Sprintf("record-%03d", f.nextID)
+		if rec.TTL == 0 {
+			rec.TTL = 300
+		}
+		f.records[rec.RecordId] = rec
+		writeTC3(w, map[string]any{"RecordId": rec.RecordId})
+	case "ModifyDnsRecords":
+		for _, rec := range p.DnsRecords {
+			if _, ok := f.records[rec.RecordId]; !ok {
+				writeTC3Error(w, "ResourceNotFound", "记录不存在。")
+				return
+			}
+		}
+		for _, rec := range p.DnsRecords {
+			rec.ZoneId = p.ZoneId
+			if rec.TTL == 0 {
+				rec.TTL = 300
+			}
+			f.records[rec.RecordId] = rec
+		}
+		writeTC3(w, map[string]any{})
+	case "DeleteDnsRecords":
+		for _, id := range p.RecordIds {
+			delete(f.records, id)
+		}
+		writeTC3(w, map[string]any{})
+	case "DescribeDnsRecords":
+		list := []fakeRecord{}
+		for _, rec := range f.records {
+			if matchFilters(rec, p.Filters) {
+				list = append(list, rec)
+			}
+		}
+		sort.Slice(list, func(i, j int) bool { return list[i].RecordId < list[j].RecordId })
+		writeTC3(w, map[string]any{"TotalCount": len(list), "DnsRecords": list})
+	default:
+		writeTC3Error(w, "InvalidAction", "unsupported action "+action)
+	}
+}
+
+func matchFilters(rec fakeRecord, filters []fakeFilter) bool {
+	for _, fl := range filters {
+		var v string
+		switch fl.Name {
+		case "name":
+			v = rec.Name
+		case "type":
+			v = rec.Type
+		default:
+			continue
+		}
+		ok := false
+		for _, want := range fl.Values {
+			ok = ok || strings.EqualFold(v, want)
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func writeTC3(w http.ResponseWriter, resp map[string]any) {
+	resp["RequestId"] = "fake-request"
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"Response": resp})
+}
+
+func writeTC3Error(w http.ResponseWriter, code, message string) {
+	writeTC3(w, map[string]any{"Error": map[string]string{"Code": code, "Message": message}})
+}
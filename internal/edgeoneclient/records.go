@@ -0,0 +1,192 @@
+package edgeoneclient
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"ddnsjx/internal/dns"
+	"ddnsjx/internal/provider"
+)
+
+// defaultLocation is the resolution line every record is written to.
+const defaultLocation = "Default"
+
+type dnsRecord struct {
+	RecordId string
+	Name     string
+	Type     string
+	Location string
+	Content  string
+	TTL      uint64
+	Weight   int64
+	Priority uint64
+}
+
+// CreateRecord looks for an identical record first: EdgeOne accepts
+// duplicates instead of reporting them.
+func (c *client) CreateRecord(ctx context.Context, zone string, recordLine string, record dns.Record) (string, provider.CreateStatus, error) {
+	zoneID, err := c.resolveZoneID(ctx, zone)
+	if err != nil {
+		return "", provider.CreateStatusFail, err
+	}
+	if id, found, err := c.FindRecord(ctx, zone, recordLine, record); err != nil {
+		return "", provider.CreateStatusFail, err
+	} else if found {
+		return id, provider.CreateStatusExists, nil
+	}
+
+	params := recordParams(zone, record)
+	params["ZoneId"] = zoneID
+	var resp struct {
+		RecordId string
+	}
+	if err := c.call(ctx, "CreateDnsRecord", params, &resp); err != nil {
+		return "", provider.CreateStatusFail, err
+	}
+	return resp.RecordId, provider.CreateStatusSuccess, nil
+}
+
+func (c *client) DeleteRecord(ctx context.Context, zone string, recordID string) error {
+	zoneID, err := c.resolveZoneID(ctx, zone)
+	if err != nil {
+		return err
+	}
+	return c.call(ctx, "DeleteDnsRecords", map[string]interface{}{
+		"ZoneId":    zoneID,
+		"RecordIds": []string{recordID},
+	}, nil)
+}
+
+func (c *client) FindRecord(ctx context.Context, zone string, _ string, record dns.Record) (string, bool, error) {
+	records, err := c.listAt(ctx, zone, record)
+	if err != nil {
+		return "", false, err
+	}
+	typ := strings.ToUpper(strings.TrimSpace(record.Type))
+
+	var matches []dnsRecord
+	for _, r := range records {
+		if !sameContent(typ, r.Content, record.Value) {
+			continue
+		}
+		if typ == "MX" && record.Priority != nil && r.Priority != *record.Priority {
+			continue
+		}
+		matches = append(matches, r)
+	}
+	switch len(matches) {
+	case 0:
+		return "", false, nil
+	case 1:
+		return matches[0].RecordId, true, nil
+	default:
+		return "", false, fmt.Errorf("multiple existing records found for %s %s; cannot safely update", record.Type, record.SubDomain)
+	}
+}
+
+// ListRecords returns every record at record's name and type.
+func (c *client) ListRecords(ctx context.Context, zone string, _ string, record dns.Record) ([]provider.ExistingRecord, error) {
+	records, err := c.listAt(ctx, zone, record)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]provider.ExistingRecord, 0, len(records))
+	for _, r := range records {
+		out = append(out, provider.ExistingRecord{ID: r.RecordId, Value: r.Content})
+	}
+	return out, nil
+}
+
+// listAt returns the records at record's name and type.
+func (c *client) listAt(ctx context.Context, zone string, record dns.Record) ([]dnsRecord, error) {
+	zoneID, err := c.resolveZoneID(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	name := recordName(zone, record.SubDomain)
+	typ := strings.ToUpper(strings.TrimSpace(record.Type))
+
+	var resp struct {
+		TotalCount int
+		DnsRecords []dnsRecord
+	}
+	err = c.call(ctx, "DescribeDnsRecords", map[string]interface{}{
+		"ZoneId": zoneID,
+		"Filters": []map[string]interface{}{
+			{"Name": "name", "Values": []string{name}, "Fuzzy": false},
+			{"Name": "type", "Values": []string{typ}, "Fuzzy": false},
+		},
+		"Limit": 1000,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	var found []dnsRecord
+	for _, r := range resp.DnsRecords {
+		if strings.EqualFold(strings.TrimSuffix(r.Name, "."), name) && strings.EqualFold(r.Type, typ) {
+			found = append(found, r)
+		}
+	}
+	return found, nil
+}
+
+func (c *client) UpdateRecord(ctx context.Context, zone string, _ string, recordID string, record dns.Record) error {
+	zoneID, err := c.resolveZoneID(ctx, zone)
+	if err != nil {
+		return err
+	}
+	rec := recordParams(zone, record)
+	rec["RecordId"] = recordID
+	return c.call(ctx, "ModifyDnsRecords", map[string]interface{}{
+		"ZoneId":     zoneID,
+		"DnsRecords": []map[string]interface{}{rec},
+	}, nil)
+}
+
+func recordParams(zone string, record dns.Record) map[string]interface{} {
+	typ := strings.ToUpper(strings.TrimSpace(record.Type))
+	p := map[string]interface{}{
+		"Name":     recordName(zone, record.SubDomain),
+		"Type":     typ,
+		"Content":  strings.TrimSpace(record.Value),
+		"Location": defaultLocation,
+	}
+	if typ == "TXT" {
+		p["Content"] = dns.TXTText(record.Value)
+	}
+	if record.TTL != nil && *record.TTL > 0 {
+		p["TTL"] = *record.TTL
+	}
+	if typ == "MX" && record.Priority != nil {
+		p["Priority"] = *record.Priority
+	}
+	if record.Weight != nil {
+		p["Weight"] = *record.Weight
+	}
+	return p
+}
+
+// recordName returns the full record name EdgeOne expects, without a
+// trailing dot.
+func recordName(zone, sub string) string {
+	zone = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(zone), "."))
+	sub = strings.ToLower(strings.TrimSpace(sub))
+	if sub == "" || sub == "@" {
+		return zone
+	}
+	return sub + "." + zone
+}
+
+// sameContent compares record contents, ignoring case, spacing and a
+// trailing dot; TXT values compare by their text.
+func sameContent(typ, a, b string) bool {
+	if strings.EqualFold(typ, "TXT") {
+		return dns.SameTXT(a, b)
+	}
+	norm := func(s string) string {
+		return strings.TrimSuffix(strings.Join(strings.Fields(s), " "), ".")
+	}
+	return strings.EqualFold(norm(a), norm(b))
+}
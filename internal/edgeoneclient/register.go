@@ -0,0 +1,29 @@
+package edgeoneclient
+
+import (
+	"net/http"
+
+	"ddnsjx/internal/provider"
+)
+
+func init() {
+	provider.Register(provider.Factory{
+		Name: "edgeone",
+		Help: "Tencent EdgeOne DNS records (teo API 2022-09-01)",
+		Settings: []provider.Setting{
+			{Name: "secret_id", Flag: "edgeone-secret-id", Env: "TENCENTCLOUD_SECRET_ID", Help: "TencentCloud secret id", Required: true},
+			{Name: "secret_key", Flag: "edgeone-secret-key", Env: "TENCENTCLOUD_SECRET_KEY", Help: "TencentCloud secret key", Required: true, Secret: true},
+			{Name: "zone_id", Flag: "edgeone-zone-id", Env: "EDGEONE_ZONE_ID", Help: "EdgeOne zone id, e.g. zone-2o0i41pv2h8c (empty: query by zone name)"},
+			{Name: "base_url", Flag: "edgeone-base-url", Env: "EDGEONE_BASE_URL", Help: "EdgeOne API endpoint (default " + defaultBaseURL + ")"},
+		},
+		New: func(_ string, s provider.Settings, transport http.RoundTripper) (provider.Client, error) {
+			return New(NewOptions{
+				SecretID:  s.Get("secret_id"),
+				SecretKey: s.Get("secret_key"),
+				ZoneID:    s.Get("zone_id"),
+				BaseURL:   s.Get("base_url"),
+				Transport: transport,
+			})
+		},
+	})
+}
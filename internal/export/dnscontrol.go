@@ -0,0 +1,119 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"ddnsjx/internal/dns"
+)
+
+// renderDNSControl writes a dnsconfig.js with one D() block. The registrar
+// and DNS provider names are placeholders for entries in creds.json.
+func renderDNSControl(plan dns.Plan) ([]byte, error) {
+	var b strings.Builder
+	b.WriteString("// Generated by stalwart-dns convert. Rename \"none\" and \"dns\" to the\n")
+	b.WriteString("// registrar and DNS provider entries of your creds.json.\n")
+	b.WriteString("var REG = NewRegistrar(\"none\");\n")
+	b.WriteString("var DSP = NewDnsProvider(\"dns\");\n\n")
+	fmt.Fprintf(&b, "D(%s, REG, DnsProvider(DSP),\n", jsString(plan.Domain))
+
+	for _, r := range plan.Records {
+		line, err := dnscontrolRecord(r)
+		if err != nil {
+			return nil, err
+		}
+		b.WriteString("\t")
+		b.WriteString(line)
+		b.WriteString(",\n")
+	}
+	b.WriteString(");\n")
+	return []byte(b.String()), nil
+}
+
+func dnscontrolRecord(r dns.Record) (string, error) {
+	name := jsString(r.SubDomain)
+	var args []string
+	switch r.Type {
+	case "A", "AAAA":
+		args = []string{jsString(r.Value)}
+	case "CNAME", "NS", "PTR":
+		args = []string{jsString(ensureDot(r.Value))}
+	case "TXT":
+		// dnscontrol splits strings over 255 bytes itself.
+		args = []string{jsString(dns.TXTText(r.Value))}
+	case "MX":
+		if r.Priority == nil {
+			return "", fmt.Errorf("MX %s: priority is required", r.SubDomain)
+		}
+		args = []string{strconv.FormatUint(*r.Priority, 10), jsString(ensureDot(r.Value))}
+	case "SRV":
+		f, err := fields(r, 4, "<priority> <weight> <port> <target>")
+		if err != nil {
+			return "", err
+		}
+		if _, err := uints(r, f[:3]); err != nil {
+			return "", err
+		}
+		args = []string{f[0], f[1], f[2], jsString(ensureDot(f[3]))}
+	case "CAA":
+		v, err := dns.ParseCAA(r.Value)
+		if err != nil {
+			return "", recordErr(r, err)
+		}
+		args = []string{jsString(v.Tag), jsString(v.Value)}
+		if v.Flags&128 != 0 {
+			args = append(args, "CAA_CRITICAL")
+		}
+	case "NAPTR":
+		v, err := dns.ParseNAPTR(r.Value)
+		if err != nil {
+			return "", recordErr(r, err)
+		}
+		args = []string{
+			strconv.Itoa(int(v.Order)), strconv.Itoa(int(v.Preference)),
+			jsString(v.Flags), jsString(v.Service), jsString(v.Regexp), jsString(ensureDot(v.Replacement)),
+		}
+	case "TLSA":
+		v, err := dns.ParseTLSA(r.Value)
+		if err != nil {
+			return "", recordErr(r, err)
+		}
+		args = []string{strconv.Itoa(int(v.Usage)), strconv.Itoa(int(v.Selector)), strconv.Itoa(int(v.MatchingType)), jsString(strings.ToLower(v.Certificate))}
+	case "SSHFP":
+		v, err := dns.ParseSSHFP(r.Value)
+		if err != nil {
+			return "", recordErr(r, err)
+		}
+		args = []string{strconv.Itoa(int(v.Algorithm)), strconv.Itoa(int(v.Type)), jsString(strings.ToLower(v.Fingerprint))}
+	case "DS":
+		v, err := dns.ParseDS(r.Value)
+		if err != nil {
+			return "", recordErr(r, err)
+		}
+		args = []string{strconv.Itoa(int(v.KeyTag)), strconv.Itoa(int(v.Algorithm)), strconv.Itoa(int(v.DigestType)), jsString(strings.ToLower(v.Digest))}
+	case "HTTPS", "SVCB":
+		v, err := dns.ParseSVCB(r.Value)
+		if err != nil {
+			return "", recordErr(r, err)
+		}
+		args = []string{strconv.Itoa(int(v.Priority)), jsString(ensureDot(v.Target)), jsString(strings.Join(v.Params, " "))}
+	default:
+		return "", fmt.Errorf("%s %s: record type is not supported by the dnscontrol export", r.Type, r.SubDomain)
+	}
+
+	if r.TTL != nil && *r.TTL > 0 {
+		args = append(args, fmt.Sprintf("TTL(%d)", *r.TTL))
+	}
+	if cf := r.Cloudflare; cf != nil && cf.Proxied != nil && *cf.Proxied {
+		args = append(args, "CF_PROXY_ON")
+	}
+	return fmt.Sprintf("%s(%s, %s)", r.Type, name, strings.Join(args, ", ")), nil
+}
+
+// jsString quotes s as a JavaScript string literal.
+func jsString(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
@@ -0,0 +1,138 @@
+// Package export renders a record set in the formats of other DNS
+// management tools (octoDNS, dnscontrol, Terraform), so the records can be
+// handed to teams that manage their zones that way. Every emitter starts
+// from the same normalized plan the providers use.
+package export
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"ddnsjx/internal/config"
+	"ddnsjx/internal/dns"
+)
+
+const (
+	FormatOctoDNS               = "octodns"
+	FormatDNSControl            = "dnscontrol"
+	FormatTerraformCloudflare   = "terraform-cloudflare"
+	FormatTerraformTencentCloud = "terraform-tencentcloud"
+)
+
+// Formats lists the supported export formats.
+var Formats = []string{FormatOctoDNS, FormatDNSControl, FormatTerraformCloudflare, FormatTerraformTencentCloud}
+
+// Render converts records for domain into format.
+func Render(format, domain string, records []config.RawRecord) ([]byte, error) {
+	domain = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(domain), "."))
+	plan, err := config.BuildPlan(domain, "", records)
+	if err != nil {
+		return nil, err
+	}
+	switch format {
+	case FormatOctoDNS:
+		return renderOctoDNS(plan)
+	case FormatDNSControl:
+		return renderDNSControl(plan)
+	case FormatTerraformCloudflare:
+		return renderTerraformCloudflare(plan)
+	case FormatTerraformTencentCloud:
+		return renderTerraformTencentCloud(plan)
+	default:
+		return nil, fmt.Errorf("unsupported export format %q (expected %s)", format, strings.Join(Formats, "|"))
+	}
+}
+
+// DefaultPath is the file name each tool looks for by default.
+func DefaultPath(format, domain string) string {
+	switch format {
+	case FormatOctoDNS:
+		return strings.TrimSuffix(domain, ".") + ".yaml"
+	case FormatDNSControl:
+		return "dnsconfig.js"
+	default:
+		return "dns.tf"
+	}
+}
+
+// rrset groups the records of one owner and type, in plan order.
+type rrset struct {
+	sub     string
+	typ     string
+	records []dns.Record
+}
+
+func groupRRSets(records []dns.Record) []*rrset {
+	var (
+		out   []*rrset
+		index = make(map[string]*rrset)
+	)
+	for _, r := range records {
+		key := strings.ToLower(r.SubDomain) + " " + r.Type
+		s, ok := index[key]
+		if !ok {
+			s = &rrset{sub: strings.ToLower(r.SubDomain), typ: r.Type}
+			index[key] = s
+			out = append(out, s)
+		}
+		s.records = append(s.records, r)
+	}
+	return out
+}
+
+// ttl returns the first TTL set in the RRset, or 0.
+func (s *rrset) ttl() uint64 {
+	for _, r := range s.records {
+		if r.TTL != nil && *r.TTL > 0 {
+			return *r.TTL
+		}
+	}
+	return 0
+}
+
+// fields splits a structured value into exactly n fields; the last one
+// takes the rest of the value.
+func fields(r dns.Record, n int, layout string) ([]string, error) {
+	f := strings.Fields(r.Value)
+	if len(f) < n {
+		return nil, fmt.Errorf("%s %s: value expects %q, got %q", r.Type, r.SubDomain, layout, r.Value)
+	}
+	if len(f) > n {
+		f = append(f[:n-1], strings.Join(f[n-1:], " "))
+	}
+	return f, nil
+}
+
+// recordErr prefixes a value error with the record it belongs to.
+func recordErr(r dns.Record, err error) error {
+	return fmt.Errorf("%s %s: %w", r.Type, r.SubDomain, err)
+}
+
+func uints(r dns.Record, f []string) ([]uint64, error) {
+	out := make([]uint64, len(f))
+	for i, s := range f {
+		v, err := strconv.ParseUint(s, 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: invalid number %q", r.Type, r.SubDomain, s)
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
+// fqdn returns the absolute name of sub in domain, without a trailing dot.
+func fqdn(domain, sub string) string {
+	if sub == "" || sub == "@" {
+		return domain
+	}
+	return sub + "." + domain
+}
+
+func ensureDot(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.HasSuffix(s, ".") {
+		return s
+	}
+	return s + "."
+}
@@ -0,0 +1,115 @@
+package export
+
+import (
+	"strings"
+	"testing"
+
+	"ddnsjx/internal/config"
+
+	"gopkg.in/yaml.v3"
+)
+
+func u64(v uint64) *uint64 { return &v }
+
+func mailRecords() []config.RawRecord {
+	proxied := true
+	return []config.RawRecord{
+		{Type: "MX", Name: "example.com", Contents: "10 mail.example.com"},
+		{Type: "A", Name: "mail.example.com", Contents: "192.0.2.10", TTL: u64(600)},
+		{Type: "TXT", Name: "example.com", Contents: "v=spf1 mx -all"},
+		{Type: "TXT", Name: "_dmarc.example.com", Contents: "v=DMARC1; p=none", Remark: "dmarc"},
+		{Type: "SRV", Name: "_submission._tcp.example.com", Contents: "0 1 587 mail.example.com"},
+		{Type: "TLSA", Name: "_25._tcp.mail.example.com", Contents: "3 1 1 abcd"},
+		{Type: "CAA", Name: "example.com", Contents: `0 issue "letsencrypt.org"`},
+		{Type: "CNAME", Name: "autoconfig.example.com", Contents: "mail.example.com", Cloudflare: &config.CloudflareOptions{Proxied: &proxied}},
+	}
+}
+
+func TestOctoDNS(t *testing.T) {
+	out, err := Render(FormatOctoDNS, "example.com", mailRecords())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]any
+	if err := yaml.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("invalid YAML: %v\n%s", err, out)
+	}
+
+	apex, _ := doc[""].([]any)
+	if len(apex) != 3 {
+		t.Fatalf("expected MX, TXT and CAA at the apex, got %v", doc[""])
+	}
+	for _, want := range []string{
+		"_dmarc:\n  type: TXT\n  value: v=DMARC1\\; p=none\n",
+		"exchange: mail.example.com.\n",
+		"  octodns:\n    cloudflare:\n      proxied: true\n",
+		"certificate_association_data: abcd\n",
+	} {
+		if !strings.Contains(string(out), want) {
+			t.Fatalf("missing %q in:\n%s", want, out)
+		}
+	}
+	if i, j := strings.Index(string(out), "_dmarc:"), strings.Index(string(out), "autoconfig:"); i < 0 || j < i {
+		t.Fatalf("owners must be sorted:\n%s", out)
+	}
+}
+
+func TestDNSControl(t *testing.T) {
+	out, err := Render(FormatDNSControl, "example.com", mailRecords())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`D("example.com", REG, DnsProvider(DSP),`,
+		`	MX("@", 10, "mail.example.com."),`,
+		`	A("mail", "192.0.2.10", TTL(600)),`,
+		`	SRV("_submission._tcp", 0, 1, 587, "mail.example.com."),`,
+		`	TLSA("_25._tcp.mail", 3, 1, 1, "abcd"),`,
+		`	CAA("@", "issue", "letsencrypt.org"),`,
+		`	CNAME("autoconfig", "mail.example.com.", CF_PROXY_ON),`,
+	} {
+		if !strings.Contains(string(out), want+"\n") {
+			t.Fatalf("missing %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestTerraform(t *testing.T) {
+	out, err := Render(FormatTerraformCloudflare, "example.com", mailRecords())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`resource "cloudflare_dns_record" "mx_apex" {`,
+		`  content  = "mail.example.com"`,
+		`  priority = 10`,
+		`resource "cloudflare_dns_record" "txt_apex" {`,
+		`  content = "\"v=spf1 mx -all\""`,
+		`  data    = { priority = 0, weight = 1, port = 587, target = "mail.example.com" }`,
+		`  data    = { flags = 0, tag = "issue", value = "letsencrypt.org" }`,
+		`  proxied = true`,
+	} {
+		if !strings.Contains(string(out), want+"\n") {
+			t.Fatalf("missing %q in:\n%s", want, out)
+		}
+	}
+
+	out, err = Render(FormatTerraformTencentCloud, "example.com", mailRecords())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`resource "tencentcloud_dnspod_record" "txt__dmarc" {`,
+		`  record_line = "默认"`,
+		`  value       = "v=DMARC1; p=none"`,
+		`  remark      = "dmarc"`,
+		`  mx          = 10`,
+	} {
+		if !strings.Contains(string(out), want+"\n") {
+			t.Fatalf("missing %q in:\n%s", want, out)
+		}
+	}
+	if _, err := Render("bind", "example.com", nil); err == nil {
+		t.Fatalf("expected an error for an unknown format")
+	}
+}
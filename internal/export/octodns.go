@@ -0,0 +1,141 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"ddnsjx/internal/dns"
+
+	"gopkg.in/yaml.v3"
+)
+
+// renderOctoDNS writes a zone file for octoDNS's YamlProvider: owners
+// relative to the zone (an empty key for the apex) mapping to one record
+// or a list of records. octoDNS rejects files whose keys are out of order; yaml.v3
+// sorts map keys the same natural way.
+func renderOctoDNS(plan dns.Plan) ([]byte, error) {
+	zone := make(map[string][]map[string]any)
+	for _, s := range groupRRSets(plan.Records) {
+		rec, err := octoRecord(s)
+		if err != nil {
+			return nil, err
+		}
+		name := s.sub
+		if name == "@" {
+			name = ""
+		}
+		zone[name] = append(zone[name], rec)
+	}
+
+	doc := make(map[string]any, len(zone))
+	for name, recs := range zone {
+		if len(recs) == 1 {
+			doc[name] = recs[0]
+		} else {
+			doc[name] = recs
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("---\n")
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func octoRecord(s *rrset) (map[string]any, error) {
+	rec := map[string]any{"type": s.typ}
+	if ttl := s.ttl(); ttl > 0 {
+		rec["ttl"] = ttl
+	}
+
+	var values []any
+	for _, r := range s.records {
+		v, err := octoValue(r)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+		if cf := r.Cloudflare; cf != nil && cf.Proxied != nil && *cf.Proxied {
+			rec["octodns"] = map[string]any{"cloudflare": map[string]any{"proxied": true}}
+		}
+	}
+	if len(values) == 1 || s.typ == "CNAME" {
+		rec["value"] = values[0]
+	} else {
+		rec["values"] = values
+	}
+	return rec, nil
+}
+
+func octoValue(r dns.Record) (any, error) {
+	switch r.Type {
+	case "A", "AAAA":
+		return r.Value, nil
+	case "CNAME", "NS", "PTR":
+		return ensureDot(r.Value), nil
+	case "TXT":
+		// octoDNS requires semicolons escaped in TXT values.
+		return strings.ReplaceAll(dns.TXTText(r.Value), ";", `\;`), nil
+	case "MX":
+		if r.Priority == nil {
+			return nil, fmt.Errorf("MX %s: priority is required", r.SubDomain)
+		}
+		return map[string]any{"exchange": ensureDot(r.Value), "preference": *r.Priority}, nil
+	case "SRV":
+		f, err := fields(r, 4, "<priority> <weight> <port> <target>")
+		if err != nil {
+			return nil, err
+		}
+		n, err := uints(r, f[:3])
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"priority": n[0], "weight": n[1], "port": n[2], "target": ensureDot(f[3])}, nil
+	case "CAA":
+		v, err := dns.ParseCAA(r.Value)
+		if err != nil {
+			return nil, recordErr(r, err)
+		}
+		return map[string]any{"flags": v.Flags, "tag": v.Tag, "value": v.Value}, nil
+	case "NAPTR":
+		v, err := dns.ParseNAPTR(r.Value)
+		if err != nil {
+			return nil, recordErr(r, err)
+		}
+		return map[string]any{
+			"order": v.Order, "preference": v.Preference, "flags": v.Flags,
+			"service": v.Service, "regexp": v.Regexp, "replacement": ensureDot(v.Replacement),
+		}, nil
+	case "TLSA":
+		v, err := dns.ParseTLSA(r.Value)
+		if err != nil {
+			return nil, recordErr(r, err)
+		}
+		return map[string]any{
+			"certificate_usage": v.Usage, "selector": v.Selector, "matching_type": v.MatchingType,
+			"certificate_association_data": strings.ToLower(v.Certificate),
+		}, nil
+	case "SSHFP":
+		v, err := dns.ParseSSHFP(r.Value)
+		if err != nil {
+			return nil, recordErr(r, err)
+		}
+		return map[string]any{"algorithm": v.Algorithm, "fingerprint_type": v.Type, "fingerprint": strings.ToLower(v.Fingerprint)}, nil
+	case "DS":
+		v, err := dns.ParseDS(r.Value)
+		if err != nil {
+			return nil, recordErr(r, err)
+		}
+		return map[string]any{"key_tag": v.KeyTag, "algorithm": v.Algorithm, "digest_type": v.DigestType, "digest": strings.ToLower(v.Digest)}, nil
+	default:
+		return nil, fmt.Errorf("%s %s: record type is not supported by the octodns export", r.Type, r.SubDomain)
+	}
+}
@@ -0,0 +1,248 @@
+package export
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"ddnsjx/internal/dns"
+)
+
+// hclAttr is one attribute of a resource block, with its value already
+// rendered as an HCL expression.
+type hclAttr struct {
+	key   string
+	value string
+}
+
+// hclWriter renders resource blocks with unique names and attributes
+// aligned the way terraform fmt does.
+type hclWriter struct {
+	b    strings.Builder
+	seen map[string]int
+}
+
+func (w *hclWriter) resource(kind, name string, attrs []hclAttr) {
+	name = w.uniqueName(name)
+	width := 0
+	for _, a := range attrs {
+		width = max(width, len(a.key))
+	}
+	fmt.Fprintf(&w.b, "\nresource %q %q {\n", kind, name)
+	for _, a := range attrs {
+		fmt.Fprintf(&w.b, "  %-*s = %s\n", width, a.key, a.value)
+	}
+	w.b.WriteString("}\n")
+}
+
+var nonIdent = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// uniqueName derives a Terraform identifier from name, numbering repeats.
+func (w *hclWriter) uniqueName(name string) string {
+	name = strings.Trim(nonIdent.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "r_" + name
+	}
+	if w.seen == nil {
+		w.seen = make(map[string]int)
+	}
+	w.seen[name]++
+	if n := w.seen[name]; n > 1 {
+		return name + "_" + strconv.Itoa(n)
+	}
+	return name
+}
+
+func resourceName(r dns.Record) string {
+	sub := r.SubDomain
+	if sub == "@" {
+		sub = "apex"
+	}
+	return strings.ToLower(r.Type) + "_" + sub
+}
+
+// hclString quotes s as an HCL string, escaping template sequences.
+func hclString(s string) string {
+	s = strconv.Quote(s)
+	s = strings.ReplaceAll(s, "${", "$${")
+	return strings.ReplaceAll(s, "%{", "%%{")
+}
+
+// hclObject renders an inline object; values are HCL expressions.
+func hclObject(attrs []hclAttr) string {
+	parts := make([]string, len(attrs))
+	for i, a := range attrs {
+		parts[i] = a.key + " = " + a.value
+	}
+	return "{ " + strings.Join(parts, ", ") + " }"
+}
+
+func hclList(values []string) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = hclString(v)
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+// renderTerraformCloudflare writes cloudflare_dns_record resources for the
+// Cloudflare provider v5. The zone id is a variable.
+func renderTerraformCloudflare(plan dns.Plan) ([]byte, error) {
+	var w hclWriter
+	w.b.WriteString("# Generated by stalwart-dns convert (Cloudflare provider v5).\n\n")
+	w.b.WriteString("variable \"cloudflare_zone_id\" {\n  type        = string\n  description = \"Zone id of " + plan.Domain + "\"\n}\n")
+
+	for _, r := range plan.Records {
+		attrs := []hclAttr{
+			{"zone_id", "var.cloudflare_zone_id"},
+			{"name", hclString(fqdn(plan.Domain, r.SubDomain))},
+			{"type", hclString(r.Type)},
+		}
+		ttl := uint64(1)
+		if r.TTL != nil && *r.TTL > 0 {
+			ttl = *r.TTL
+		}
+		attrs = append(attrs, hclAttr{"ttl", strconv.FormatUint(ttl, 10)})
+
+		data, err := cloudflareData(r)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case data != nil:
+			attrs = append(attrs, hclAttr{"data", hclObject(data)})
+		case r.Type == "TXT":
+			attrs = append(attrs, hclAttr{"content", hclString(dns.QuoteTXT(r.Value))})
+		default:
+			attrs = append(attrs, hclAttr{"content", hclString(strings.TrimSuffix(r.Value, "."))})
+		}
+		if r.Type == "MX" {
+			if r.Priority == nil {
+				return nil, fmt.Errorf("MX %s: priority is required", r.SubDomain)
+			}
+			attrs = append(attrs, hclAttr{"priority", strconv.FormatUint(*r.Priority, 10)})
+		}
+
+		comment := r.Remark
+		if cf := r.Cloudflare; cf != nil {
+			if cf.Proxied != nil {
+				attrs = append(attrs, hclAttr{"proxied", strconv.FormatBool(*cf.Proxied)})
+			}
+			if cf.Comment != nil {
+				comment = *cf.Comment
+			}
+			if len(cf.Tags) > 0 {
+				attrs = append(attrs, hclAttr{"tags", hclList(cf.Tags)})
+			}
+		}
+		if comment != "" {
+			attrs = append(attrs, hclAttr{"comment", hclString(comment)})
+		}
+		w.resource("cloudflare_dns_record", resourceName(r), attrs)
+	}
+	return []byte(w.b.String()), nil
+}
+
+// cloudflareData returns the structured data attribute for the types
+// Cloudflare takes field by field, or nil for content-based types.
+func cloudflareData(r dns.Record) ([]hclAttr, error) {
+	num := func(n uint64) string { return strconv.FormatUint(n, 10) }
+	switch r.Type {
+	case "SRV":
+		f, err := fields(r, 4, "<priority> <weight> <port> <target>")
+		if err != nil {
+			return nil, err
+		}
+		n, err := uints(r, f[:3])
+		if err != nil {
+			return nil, err
+		}
+		return []hclAttr{{"priority", num(n[0])}, {"weight", num(n[1])}, {"port", num(n[2])}, {"target", hclString(strings.TrimSuffix(f[3], "."))}}, nil
+	case "CAA":
+		v, err := dns.ParseCAA(r.Value)
+		if err != nil {
+			return nil, recordErr(r, err)
+		}
+		return []hclAttr{{"flags", num(uint64(v.Flags))}, {"tag", hclString(v.Tag)}, {"value", hclString(v.Value)}}, nil
+	case "NAPTR":
+		v, err := dns.ParseNAPTR(r.Value)
+		if err != nil {
+			return nil, recordErr(r, err)
+		}
+		return []hclAttr{
+			{"order", num(uint64(v.Order))}, {"preference", num(uint64(v.Preference))}, {"flags", hclString(v.Flags)},
+			{"service", hclString(v.Service)}, {"regex", hclString(v.Regexp)}, {"replacement", hclString(v.Replacement)},
+		}, nil
+	case "TLSA":
+		v, err := dns.ParseTLSA(r.Value)
+		if err != nil {
+			return nil, recordErr(r, err)
+		}
+		return []hclAttr{{"usage", num(uint64(v.Usage))}, {"selector", num(uint64(v.Selector))}, {"matching_type", num(uint64(v.MatchingType))}, {"certificate", hclString(strings.ToLower(v.Certificate))}}, nil
+	case "SSHFP":
+		v, err := dns.ParseSSHFP(r.Value)
+		if err != nil {
+			return nil, recordErr(r, err)
+		}
+		return []hclAttr{{"algorithm", num(uint64(v.Algorithm))}, {"type", num(uint64(v.Type))}, {"fingerprint", hclString(strings.ToLower(v.Fingerprint))}}, nil
+	case "DS":
+		v, err := dns.ParseDS(r.Value)
+		if err != nil {
+			return nil, recordErr(r, err)
+		}
+		return []hclAttr{{"key_tag", num(uint64(v.KeyTag))}, {"algorithm", num(uint64(v.Algorithm))}, {"digest_type", num(uint64(v.DigestType))}, {"digest", hclString(strings.ToLower(v.Digest))}}, nil
+	case "HTTPS", "SVCB":
+		v, err := dns.ParseSVCB(r.Value)
+		if err != nil {
+			return nil, recordErr(r, err)
+		}
+		return []hclAttr{{"priority", num(uint64(v.Priority))}, {"target", hclString(v.Target)}, {"value", hclString(strings.Join(v.Params, " "))}}, nil
+	case "A", "AAAA", "CNAME", "MX", "NS", "PTR", "TXT":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("%s %s: record type is not supported by the terraform-cloudflare export", r.Type, r.SubDomain)
+	}
+}
+
+// renderTerraformTencentCloud writes tencentcloud_dnspod_record resources,
+// with the same values, lines, weights and status the DNSPod provider
+// sends.
+func renderTerraformTencentCloud(plan dns.Plan) ([]byte, error) {
+	var w hclWriter
+	w.b.WriteString("# Generated by stalwart-dns convert (tencentcloudstack/tencentcloud provider).\n")
+
+	for _, r := range plan.Records {
+		value := r.Value
+		if r.Type == "TXT" {
+			value = dns.TXTText(value)
+		}
+		attrs := []hclAttr{
+			{"domain", hclString(plan.Domain)},
+			{"sub_domain", hclString(r.SubDomain)},
+			{"record_type", hclString(r.Type)},
+			{"record_line", hclString(r.LineOr("默认"))},
+			{"value", hclString(value)},
+		}
+		if r.Type == "MX" {
+			if r.Priority == nil {
+				return nil, fmt.Errorf("MX %s: priority is required", r.SubDomain)
+			}
+			attrs = append(attrs, hclAttr{"mx", strconv.FormatUint(*r.Priority, 10)})
+		}
+		if r.TTL != nil && *r.TTL > 0 {
+			attrs = append(attrs, hclAttr{"ttl", strconv.FormatUint(*r.TTL, 10)})
+		}
+		if r.Weight != nil {
+			attrs = append(attrs, hclAttr{"weight", strconv.FormatUint(*r.Weight, 10)})
+		}
+		if r.Status != "" {
+			attrs = append(attrs, hclAttr{"status", hclString(r.Status)})
+		}
+		if r.Remark != "" {
+			attrs = append(attrs, hclAttr{"remark", hclString(r.Remark)})
+		}
+		w.resource("tencentcloud_dnspod_record", resourceName(r), attrs)
+	}
+	return []byte(w.b.String()), nil
+}
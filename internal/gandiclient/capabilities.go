@@ -0,0 +1,11 @@
+package gandiclient
+
+import "ddnsjx/internal/provider"
+
+func capabilities() provider.Capabilities {
+	return provider.Capabilities{
+		RecordTypes: []string{"A", "AAAA", "CAA", "CNAME", "DS", "MX", "NAPTR", "NS", "PTR", "SRV", "SSHFP", "TLSA", "TXT"},
+		MinTTL:      300,
+		MaxTTL:      2592000,
+	}
+}
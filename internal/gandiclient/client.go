@@ -0,0 +1,178 @@
+// Package gandiclient implements provider.Client for Gandi LiveDNS (API
+// v5).
+//
+// LiveDNS stores RRsets (all values of one name and type, sharing a TTL),
+// so every per-record operation reads the RRset, edits one value and
+// writes the set back with a PUT. Record ids are synthesised from name,
+// type and value.
+package gandiclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"ddnsjx/internal/dns"
+	"ddnsjx/internal/provider"
+	"ddnsjx/internal/restclient"
+	"ddnsjx/internal/rrset"
+)
+
+const (
+	defaultBaseURL = "https://api.gandi.net/v5/livedns"
+	defaultTTL     = 10800
+)
+
+type NewOptions struct {
+	// APIToken is a personal access token.
+	APIToken string
+	// BaseURL overrides the API root (default https://api.gandi.net/v5/livedns).
+	BaseURL string
+	// Transport replaces the HTTP transport, e.g. with a cassette.
+	Transport http.RoundTripper
+}
+
+type client struct {
+	rest *restclient.Client
+	sets rrset.Engine
+}
+
+type Error struct {
+	Status  int
+	Cause   string
+	Message string
+}
+
+func (e Error) Error() string {
+	if e.Cause == "" {
+		return fmt.Sprintf("[%d] %s", e.Status, e.Message)
+	}
+	return fmt.Sprintf("[%d %s] %s", e.Status, e.Cause, e.Message)
+}
+
+// ErrorCode returns the cause reported by the API, or the HTTP status when
+// there is none, for metrics.
+func (e Error) ErrorCode() string {
+	if e.Cause == "" {
+		return strconv.Itoa(e.Status)
+	}
+	return e.Cause
+}
+
+func (e Error) Retryable() bool {
+	return restclient.RetryableStatus(e.Status)
+}
+
+func New(opt NewOptions) (provider.Client, error) {
+	if strings.TrimSpace(opt.APIToken) == "" {
+		return nil, fmt.Errorf("missing Gandi personal access token")
+	}
+	baseURL := strings.TrimSpace(opt.BaseURL)
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+	c := &client{rest: restclient.New(restclient.Options{
+		BaseURL:     baseURL,
+		Auth:        restclient.BearerToken(strings.TrimSpace(opt.APIToken)),
+		DecodeError: decodeError,
+		Transport:   opt.Transport,
+	})}
+	c.sets = rrset.Engine{Store: c, Provider: "gandi", DefaultTTL: defaultTTL}
+	return c, nil
+}
+
+func decodeError(status int, _ http.Header, b []byte) error {
+	var e struct {
+		Cause   string `json:"cause"`
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(b, &e) == nil && e.Message != "" {
+		return Error{Status: status, Cause: e.Cause, Message: e.Message}
+	}
+	return nil
+}
+
+type apiRRSet struct {
+	Name   string   `json:"rrset_name,omitempty"`
+	Type   string   `json:"rrset_type,omitempty"`
+	TTL    uint64   `json:"rrset_ttl,omitempty"`
+	Values []string `json:"rrset_values"`
+}
+
+func rrsetPath(zone, name, typ string) string {
+	return "/domains/" + url.PathEscape(zoneName(zone)) + "/records/" + url.PathEscape(name) + "/" + url.PathEscape(typ)
+}
+
+// SetName returns the set name of sub: relative to the zone, "@" for the
+// apex.
+func (c *client) SetName(_, sub string) string {
+	return rrset.Relative(sub)
+}
+
+// GetSet returns the RRset at name and type; a missing set is returned
+// empty.
+func (c *client) GetSet(ctx context.Context, zone, name, typ string) (*rrset.Set, error) {
+	var s apiRRSet
+	if _, err := c.rest.Do(ctx, "GET", rrsetPath(zone, name, typ), nil, &s); err != nil {
+		var e Error
+		if errors.As(err, &e) && e.Status == http.StatusNotFound {
+			return &rrset.Set{Name: name, Type: typ}, nil
+		}
+		return nil, err
+	}
+	return &rrset.Set{Name: name, Type: typ, TTL: s.TTL, Values: s.Values}, nil
+}
+
+// SubmitSets replaces each set with a PUT, or deletes it when no value is
+// left. LiveDNS has no transaction, so a failure leaves earlier sets
+// written.
+func (c *client) SubmitSets(ctx context.Context, zone string, updates []rrset.Update) error {
+	for _, u := range updates {
+		s := u.After
+		path := rrsetPath(zone, s.Name, s.Type)
+		if len(s.Values) == 0 {
+			if _, err := c.rest.Do(ctx, "DELETE", path, nil, nil); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := c.rest.Do(ctx, "PUT", path, apiRRSet{TTL: s.TTL, Values: s.Values}, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *client) Capabilities() provider.Capabilities {
+	return capabilities()
+}
+
+func (c *client) CreateRecord(ctx context.Context, zone string, _ string, record dns.Record) (string, provider.CreateStatus, error) {
+	return c.sets.Create(ctx, zone, record)
+}
+
+func (c *client) DeleteRecord(ctx context.Context, zone string, recordID string) error {
+	return c.sets.Delete(ctx, zone, recordID)
+}
+
+// FindRecord looks for record's value in the RRset at its name and type.
+func (c *client) FindRecord(ctx context.Context, zone string, _ string, record dns.Record) (string, bool, error) {
+	return c.sets.Find(ctx, zone, record)
+}
+
+// ListRecords returns every value of the RRset at record's name and type.
+func (c *client) ListRecords(ctx context.Context, zone string, _ string, record dns.Record) ([]provider.ExistingRecord, error) {
+	return c.sets.List(ctx, zone, record)
+}
+
+// UpdateRecord replaces the value identified by recordID with record. The
+// record's TTL applies to the whole RRset; a record moved to another name
+// or type is removed from its old RRset.
+func (c *client) UpdateRecord(ctx context.Context, zone string, _ string, recordID string, record dns.Record) error {
+	return c.sets.Update(ctx, zone, recordID, record)
+}
@@ -0,0 +1,144 @@
+package gandiclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"ddnsjx/internal/dns"
+	"ddnsjx/internal/provider"
+	"ddnsjx/internal/provider/providertest"
+)
+
+func newTestClient(t *testing.T, api *fakeAPI) provider.Client {
+	t.Helper()
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+	c, err := New(NewOptions{APIToken: "test", BaseURL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestConformance(t *testing.T) {
+	providertest.Run(t, func(t *testing.T) provider.Client {
+		return newTestClient(t, newFakeAPI("example.com"))
+	}, providertest.Options{ContentIDs: true})
+}
+
+func TestRRSetMerging(t *testing.T) {
+	api := newFakeAPI("example.com")
+	api.sets["www/A"] = &apiRRSet{Name: "www", Type: "A", TTL: 1800, Values: []string{"192.0.2.1"}}
+	c := newTestClient(t, api)
+	ctx := context.Background()
+
+	ttl := uint64(600)
+	if _, status, err := c.CreateRecord(ctx, "example.com", "", dns.Record{SubDomain: "www", Type: "A", Value: "192.0.2.2", TTL: &ttl}); err != nil || status != provider.CreateStatusSuccess {
+		t.Fatalf("create: %s %v", status, err)
+	}
+	set := api.sets["www/A"]
+	if len(set.Values) != 2 || set.TTL != 600 {
+		t.Fatalf("expected both values with the new TTL, got %+v", set)
+	}
+
+	if err := c.DeleteRecord(ctx, "example.com", "www A 192.0.2.1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.DeleteRecord(ctx, "example.com", "www A 192.0.2.2"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := api.sets["www/A"]; ok {
+		t.Fatalf("empty rrset should have been deleted")
+	}
+
+	if _, _, err := c.CreateRecord(ctx, "example.org", "", dns.Record{SubDomain: "www", Type: "A", Value: "192.0.2.1"}); err == nil {
+		t.Fatalf("expected an error for an unknown domain")
+	} else {
+		var e Error
+		if !errors.As(err, &e) || e.Status != http.StatusForbidden || e.Cause != "Forbidden" {
+			t.Fatalf("expected a decoded Gandi error, got %v", err)
+		}
+	}
+}
+
+// fakeAPI is a stand-in for the LiveDNS rrset endpoints. Like the real
+// service it answers 404 for a missing rrset and 403 for a domain the
+// token cannot manage.
+type fakeAPI struct {
+	mu     sync.Mutex
+	domain string
+	sets   map[string]*apiRRSet
+}
+
+func newFakeAPI(domain string) *fakeAPI {
+	return &fakeAPI{domain: domain, sets: make(map[string]*apiRRSet)}
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer test" {
+		writeError(w, http.StatusUnauthorized, "Unauthorized", "invalid token")
+		return
+	}
+	parts := strings.Split(strings.TrimPrefix(r.URL.EscapedPath(), "/"), "/")
+	if len(parts) != 5 || parts[0] != "domains" || parts[2] != "records" {
+		writeError(w, http.StatusNotFound, "Not Found", "unknown path "+r.URL.Path)
+		return
+	}
+	domain, _ := url.PathUnescape(parts[1])
+	name, _ := url.PathUnescape(parts[3])
+	typ, _ := url.PathUnescape(parts[4])
+	if domain != f.domain {
+		writeError(w, http.StatusForbidden, "Forbidden", "access to this domain is denied")
+		return
+	}
+	key := name + "/" + typ
+
+	switch r.Method {
+	case "GET":
+		set, ok := f.sets[key]
+		if !ok {
+			writeError(w, http.StatusNotFound, "Not Found", "the requested rrset does not exist")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(set)
+	case "PUT":
+		var body apiRRSet
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || len(body.Values) == 0 {
+			writeError(w, http.StatusBadRequest, "Bad Request", "rrset_values is required")
+			return
+		}
+		if body.TTL < 300 {
+			writeError(w, http.StatusBadRequest, "Bad Request", "rrset_ttl must be at least 300")
+			return
+		}
+		f.sets[key] = &apiRRSet{Name: name, Type: typ, TTL: body.TTL, Values: body.Values}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"message":"DNS Record Created"}`))
+	case "DELETE":
+		if _, ok := f.sets[key]; !ok {
+			writeError(w, http.StatusNotFound, "Not Found", "the requested rrset does not exist")
+			return
+		}
+		delete(f.sets, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "Method Not Allowed", r.Method)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, cause, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{"code": status, "cause": cause, "message": message, "object": "HTTPError"})
+}
@@ -0,0 +1,25 @@
+package gandiclient
+
+import (
+	"net/http"
+
+	"ddnsjx/internal/provider"
+)
+
+func init() {
+	provider.Register(provider.Factory{
+		Name: "gandi",
+		Help: "Gandi LiveDNS (API v5, personal access token)",
+		Settings: []provider.Setting{
+			{Name: "token", Flag: "gandi-token", Env: "GANDI_PAT", Help: "Gandi personal access token", Required: true, Secret: true},
+			{Name: "base_url", Flag: "gandi-base-url", Env: "GANDI_BASE_URL", Help: "Gandi LiveDNS API root (default " + defaultBaseURL + ")"},
+		},
+		New: func(_ string, s provider.Settings, transport http.RoundTripper) (provider.Client, error) {
+			return New(NewOptions{
+				APIToken:  s.Get("token"),
+				BaseURL:   s.Get("base_url"),
+				Transport: transport,
+			})
+		},
+	})
+}
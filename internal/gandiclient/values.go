@@ -0,0 +1,7 @@
+package gandiclient
+
+import "strings"
+
+func zoneName(zone string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(zone), "."))
+}
@@ -0,0 +1,9 @@
+package hetznerclient
+
+import "ddnsjx/internal/provider"
+
+func capabilities() provider.Capabilities {
+	return provider.Capabilities{
+		RecordTypes: []string{"A", "AAAA", "CAA", "CNAME", "DS", "MX", "NS", "PTR", "SRV", "TLSA", "TXT"},
+	}
+}
@@ -0,0 +1,274 @@
+// Package hetznerclient implements provider.Client for Hetzner DNS
+// (dns.hetzner.com API v1).
+package hetznerclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"ddnsjx/internal/dns"
+	"ddnsjx/internal/provider"
+	"ddnsjx/internal/restclient"
+	"ddnsjx/internal/rrset"
+)
+
+const defaultBaseURL = "https://dns.hetzner.com/api/v1"
+
+type NewOptions struct {
+	APIToken string
+	// ZoneID skips the lookup by zone name.
+	ZoneID string
+	// BaseURL overrides the API root (default https://dns.hetzner.com/api/v1).
+	BaseURL string
+	// Transport replaces the HTTP transport, e.g. with a cassette.
+	Transport http.RoundTripper
+}
+
+type client struct {
+	zoneID string
+	rest   *restclient.Client
+}
+
+type Error struct {
+	Status  int
+	Message string
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("[%d] %s", e.Status, e.Message)
+}
+
+func (e Error) Retryable() bool {
+	return restclient.RetryableStatus(e.Status)
+}
+
+type hzRecord struct {
+	ID     string  `json:"id,omitempty"`
+	ZoneID string  `json:"zone_id"`
+	Type   string  `json:"type"`
+	Name   string  `json:"name"`
+	Value  string  `json:"value"`
+	TTL    *uint64 `json:"ttl,omitempty"`
+}
+
+type pagination struct {
+	Meta struct {
+		Pagination struct {
+			Page     int `json:"page"`
+			LastPage int `json:"last_page"`
+		} `json:"pagination"`
+	} `json:"meta"`
+}
+
+func (p pagination) more() bool {
+	return p.Meta.Pagination.Page < p.Meta.Pagination.LastPage
+}
+
+func New(opt NewOptions) (provider.Client, error) {
+	if strings.TrimSpace(opt.APIToken) == "" {
+		return nil, fmt.Errorf("missing Hetzner DNS api token")
+	}
+	baseURL := strings.TrimSpace(opt.BaseURL)
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+	return &client{
+		zoneID: strings.TrimSpace(opt.ZoneID),
+		rest: restclient.New(restclient.Options{
+			BaseURL:     baseURL,
+			Auth:        restclient.Header("Auth-API-Token", strings.TrimSpace(opt.APIToken)),
+			DecodeError: decodeError,
+			Transport:   opt.Transport,
+		}),
+	}, nil
+}
+
+// decodeError reads both error shapes: {"error":{"message","code"}} from
+// the DNS API and {"message"} from the gateway.
+func decodeError(status int, _ http.Header, b []byte) error {
+	var e struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(b, &e) != nil {
+		return nil
+	}
+	switch {
+	case e.Error.Message != "":
+		return Error{Status: status, Message: e.Error.Message}
+	case e.Message != "":
+		return Error{Status: status, Message: e.Message}
+	}
+	return nil
+}
+
+func (c *client) Capabilities() provider.Capabilities {
+	return capabilities()
+}
+
+// CreateRecord looks for an identical record first, since Hetzner stores
+// duplicates.
+func (c *client) CreateRecord(ctx context.Context, zone string, recordLine string, record dns.Record) (string, provider.CreateStatus, error) {
+	if id, found, err := c.FindRecord(ctx, zone, recordLine, record); err != nil {
+		return "", provider.CreateStatusFail, err
+	} else if found {
+		return id, provider.CreateStatusExists, nil
+	}
+
+	body, err := c.recordBody(ctx, zone, record)
+	if err != nil {
+		return "", provider.CreateStatusFail, err
+	}
+	var resp struct {
+		Record hzRecord `json:"record"`
+	}
+	if _, err := c.rest.Do(ctx, "POST", "/records", body, &resp); err != nil {
+		return "", provider.CreateStatusFail, err
+	}
+	return resp.Record.ID, provider.CreateStatusSuccess, nil
+}
+
+func (c *client) DeleteRecord(ctx context.Context, _ string, recordID string) error {
+	_, err := c.rest.Do(ctx, "DELETE", "/records/"+url.PathEscape(strings.TrimSpace(recordID)), nil, nil)
+	return err
+}
+
+// FindRecord scans the zone's records: the API filters by zone only.
+func (c *client) FindRecord(ctx context.Context, zone string, _ string, record dns.Record) (string, bool, error) {
+	want, err := recordValue(record)
+	if err != nil {
+		return "", false, err
+	}
+	records, err := c.listAt(ctx, zone, record)
+	if err != nil {
+		return "", false, err
+	}
+	var found []string
+	for _, r := range records {
+		if rrset.Same(r.Type, r.Value, want) {
+			found = append(found, r.ID)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return "", false, nil
+	case 1:
+		return found[0], true, nil
+	default:
+		return "", false, fmt.Errorf("multiple existing records found for %s %s; cannot safely update", record.Type, record.SubDomain)
+	}
+}
+
+// ListRecords returns every record at record's name and type.
+func (c *client) ListRecords(ctx context.Context, zone string, _ string, record dns.Record) ([]provider.ExistingRecord, error) {
+	records, err := c.listAt(ctx, zone, record)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]provider.ExistingRecord, 0, len(records))
+	for _, r := range records {
+		out = append(out, provider.ExistingRecord{ID: r.ID, Value: r.Value})
+	}
+	return out, nil
+}
+
+// listAt pages through the zone for the records at record's name and type.
+func (c *client) listAt(ctx context.Context, zone string, record dns.Record) ([]hzRecord, error) {
+	zoneID, err := c.resolveZoneID(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	name := rrset.Relative(record.SubDomain)
+	typ := strings.ToUpper(strings.TrimSpace(record.Type))
+
+	var found []hzRecord
+	err = restclient.Paginate(ctx, func(page int) (bool, error) {
+		query := url.Values{}
+		query.Set("zone_id", zoneID)
+		query.Set("page", strconv.Itoa(page))
+		query.Set("per_page", "100")
+		var resp struct {
+			Records []hzRecord `json:"records"`
+			pagination
+		}
+		if _, err := c.rest.Do(ctx, "GET", "/records?"+query.Encode(), nil, &resp); err != nil {
+			return false, err
+		}
+		for _, r := range resp.Records {
+			if strings.EqualFold(r.Name, name) && strings.EqualFold(r.Type, typ) {
+				found = append(found, r)
+			}
+		}
+		return resp.more(), nil
+	})
+	return found, err
+}
+
+func (c *client) UpdateRecord(ctx context.Context, zone string, _ string, recordID string, record dns.Record) error {
+	body, err := c.recordBody(ctx, zone, record)
+	if err != nil {
+		return err
+	}
+	_, err = c.rest.Do(ctx, "PUT", "/records/"+url.PathEscape(strings.TrimSpace(recordID)), body, nil)
+	return err
+}
+
+func (c *client) recordBody(ctx context.Context, zone string, record dns.Record) (hzRecord, error) {
+	zoneID, err := c.resolveZoneID(ctx, zone)
+	if err != nil {
+		return hzRecord{}, err
+	}
+	value, err := recordValue(record)
+	if err != nil {
+		return hzRecord{}, err
+	}
+	body := hzRecord{
+		ZoneID: zoneID,
+		Type:   strings.ToUpper(strings.TrimSpace(record.Type)),
+		Name:   rrset.Relative(record.SubDomain),
+		Value:  value,
+	}
+	if record.TTL != nil && *record.TTL > 0 {
+		body.TTL = record.TTL
+	}
+	return body, nil
+}
+
+func (c *client) resolveZoneID(ctx context.Context, zone string) (string, error) {
+	if c.zoneID != "" {
+		return c.zoneID, nil
+	}
+	name := strings.ToLower(strings.TrimSuffix(strings.TrimSpace(zone), "."))
+	if name == "" {
+		return "", fmt.Errorf("missing Hetzner DNS zone name or zone id")
+	}
+
+	var resp struct {
+		Zones []struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"zones"`
+	}
+	if _, err := c.rest.Do(ctx, "GET", "/zones?name="+url.QueryEscape(name), nil, &resp); err != nil {
+		var e Error
+		if errors.As(err, &e) && e.Status == http.StatusNotFound {
+			return "", fmt.Errorf("Hetzner DNS zone not found: %s", name)
+		}
+		return "", err
+	}
+	for _, z := range resp.Zones {
+		if strings.EqualFold(z.Name, name) {
+			c.zoneID = z.ID
+			return c.zoneID, nil
+		}
+	}
+	return "", fmt.Errorf("Hetzner DNS zone not found: %s", name)
+}
@@ -0,0 +1,217 @@
+package hetznerclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"ddnsjx/internal/dns"
+	"ddnsjx/internal/provider"
+	"ddnsjx/internal/provider/providertest"
+)
+
+func newTestClient(t *testing.T, api *fakeAPI) provider.Client {
+	t.Helper()
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+	c, err := New(NewOptions{APIToken: "test", BaseURL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestConformance(t *testing.T) {
+	providertest.Run(t, func(t *testing.T) provider.Client {
+		return newTestClient(t, newFakeAPI("example.com"))
+	}, providertest.Options{})
+}
+
+func TestPagingAndTXT(t *testing.T) {
+	api := newFakeAPI("example.com")
+	api.pageSize = 2
+	for i := 1; i <= 5; i++ {
+		api.add(hzRecord{Type: "A", Name: "host" + strconv.Itoa(i), Value: fmt.Sprintf("192.0.2.%d", i)})
+	}
+	api.add(hzRecord{Type: "TXT", Name: "@", Value: `"v=spf1 mx -all"`})
+	c := newTestClient(t, api)
+	ctx := context.Background()
+
+	id, found, err := c.FindRecord(ctx, "example.com", "", dns.Record{SubDomain: "host5", Type: "A", Value: "192.0.2.5"})
+	if err != nil || !found || id != "rec-005" {
+		t.Fatalf("expected record on the last page, got %q %v %v", id, found, err)
+	}
+	if _, status, err := c.CreateRecord(ctx, "example.com", "", dns.Record{SubDomain: "@", Type: "TXT", Value: "v=spf1 mx -all"}); err != nil || status != provider.CreateStatusExists {
+		t.Fatalf("quoted TXT should match the unquoted value, got %s %v", status, err)
+	}
+
+	long := strings.Repeat("k", 300)
+	id, _, err = c.CreateRecord(ctx, "example.com", "", dns.Record{SubDomain: "dkim._domainkey", Type: "TXT", Value: long})
+	if err != nil {
+		t.Fatalf("create long TXT: %v", err)
+	}
+	if got := api.records[id].Value; got != `"`+long[:255]+`" "`+long[255:]+`"` {
+		t.Fatalf("expected long TXT split into strings, got %q", got)
+	}
+
+	_, _, err = newTestClient(t, api).FindRecord(ctx, "example.org", "", dns.Record{SubDomain: "www", Type: "A", Value: "192.0.2.1"})
+	if err == nil || !strings.Contains(err.Error(), "zone not found: example.org") {
+		t.Fatalf("expected zone not found, got %v", err)
+	}
+
+	bad, _ := New(NewOptions{APIToken: "wrong", ZoneID: fakeZoneID, BaseURL: api.url(t)})
+	var apiErr Error
+	if _, _, err := bad.FindRecord(ctx, "example.com", "", dns.Record{SubDomain: "www", Type: "A", Value: "192.0.2.1"}); !errors.As(err, &apiErr) || apiErr.Status != 401 || apiErr.Message != "Invalid authentication credentials" {
+		t.Fatalf("expected 401 Error, got %#v", err)
+	}
+}
+
+// fakeAPI is a stand-in for the Hetzner DNS API v1. Like the real service
+// it lists records by zone only, paginates with meta.pagination and
+// stores duplicate records without complaint.
+type fakeAPI struct {
+	mu       sync.Mutex
+	zone     string
+	nextID   int
+	pageSize int
+	records  map[string]hzRecord
+}
+
+const fakeZoneID = "rMu2waTJPbHr4"
+
+func newFakeAPI(zone string) *fakeAPI {
+	return &fakeAPI{zone: zone, records: make(map[string]hzRecord)}
+}
+
+func (f *fakeAPI) url(t *testing.T) string {
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+func (f *fakeAPI) add(r hzRecord) string {
+	f.nextID++
+	r.ID = fmt.Sprintf("rec-%03d", f.nextID)
+	r.ZoneID = fakeZoneID
+	f.records[r.ID] = r
+	return r.ID
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Header.Get("Auth-API-Token") != "test" {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"message": "Invalid authentication credentials"})
+		return
+	}
+
+	q := r.URL.Query()
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/zones":
+		if q.Get("name") != f.zone {
+			writeHzError(w, http.StatusNotFound, "zone not found")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"zones": []map[string]string{{"id": fakeZoneID, "name": f.zone}}})
+	case r.Method == http.MethodGet && r.URL.Path == "/records":
+		if q.Get("zone_id") != fakeZoneID {
+			writeHzError(w, http.StatusNotFound, "zone not found")
+			return
+		}
+		f.list(w, q)
+	case r.Method == http.MethodPost && r.URL.Path == "/records":
+		var rec hzRecord
+		if msg := decodeRecord(r, &rec); msg != "" {
+			writeHzError(w, http.StatusUnprocessableEntity, msg)
+			return
+		}
+		rec.ID = f.add(rec)
+		writeJSON(w, http.StatusOK, map[string]any{"record": f.records[rec.ID]})
+	case strings.HasPrefix(r.URL.Path, "/records/"):
+		id := strings.TrimPrefix(r.URL.Path, "/records/")
+		if _, ok := f.records[id]; !ok {
+			writeHzError(w, http.StatusNotFound, "record not found")
+			return
+		}
+		switch r.Method {
+		case http.MethodPut:
+			var rec hzRecord
+			if msg := decodeRecord(r, &rec); msg != "" {
+				writeHzError(w, http.StatusUnprocessableEntity, msg)
+				return
+			}
+			rec.ID = id
+			f.records[id] = rec
+			writeJSON(w, http.StatusOK, map[string]any{"record": rec})
+		case http.MethodDelete:
+			delete(f.records, id)
+			w.WriteHeader(http.StatusOK)
+		default:
+			http.NotFound(w, r)
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f *fakeAPI) list(w http.ResponseWriter, q url.Values) {
+	all := make([]hzRecord, 0, len(f.records))
+	for _, rec := range f.records {
+		all = append(all, rec)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].ID < all[j].ID })
+
+	perPage, _ := strconv.Atoi(q.Get("per_page"))
+	if f.pageSize > 0 && (perPage == 0 || perPage > f.pageSize) {
+		perPage = f.pageSize
+	}
+	if perPage <= 0 {
+		perPage = 100
+	}
+	page, _ := strconv.Atoi(q.Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	lastPage := max(1, (len(all)+perPage-1)/perPage)
+	lo := min(len(all), (page-1)*perPage)
+	hi := min(len(all), lo+perPage)
+	writeJSON(w, http.StatusOK, map[string]any{
+		"records": all[lo:hi],
+		"meta": map[string]any{"pagination": map[string]int{
+			"page": page, "per_page": perPage, "last_page": lastPage, "total_entries": len(all),
+		}},
+	})
+}
+
+func decodeRecord(r *http.Request, rec *hzRecord) string {
+	if err := json.NewDecoder(r.Body).Decode(rec); err != nil {
+		return err.Error()
+	}
+	switch {
+	case rec.ZoneID != fakeZoneID:
+		return "zone_id: invalid"
+	case rec.Name == "" || rec.Type == "" || rec.Value == "":
+		return "name, type and value are required"
+	}
+	return ""
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeHzError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]any{"error": map[string]any{"message": message, "code": status}})
+}
@@ -0,0 +1,27 @@
+package hetznerclient
+
+import (
+	"net/http"
+
+	"ddnsjx/internal/provider"
+)
+
+func init() {
+	provider.Register(provider.Factory{
+		Name: "hetzner",
+		Help: "Hetzner DNS (Auth-API-Token)",
+		Settings: []provider.Setting{
+			{Name: "token", Flag: "hetzner-token", Env: "HETZNER_DNS_TOKEN", Help: "Hetzner DNS API token", Required: true, Secret: true},
+			{Name: "zone_id", Flag: "hetzner-zone-id", Env: "HETZNER_DNS_ZONE_ID", Help: "Hetzner DNS zone id (empty: query by zone name)"},
+			{Name: "base_url", Flag: "hetzner-base-url", Env: "HETZNER_DNS_BASE_URL", Help: "Hetzner DNS API root (default " + defaultBaseURL + ")"},
+		},
+		New: func(_ string, s provider.Settings, transport http.RoundTripper) (provider.Client, error) {
+			return New(NewOptions{
+				APIToken:  s.Get("token"),
+				ZoneID:    s.Get("zone_id"),
+				BaseURL:   s.Get("base_url"),
+				Transport: transport,
+			})
+		},
+	})
+}
@@ -0,0 +1,22 @@
+package hetznerclient
+
+import (
+	"strings"
+
+	"ddnsjx/internal/dns"
+	"ddnsjx/internal/rrset"
+)
+
+// recordValue renders record in zone-file syntax, with absolute names for
+// every hostname field. Short TXT values are sent as is; longer ones are
+// split into quoted 255 byte strings.
+func recordValue(record dns.Record) (string, error) {
+	if !strings.EqualFold(record.Type, "TXT") {
+		return rrset.Value(record)
+	}
+	txt := dns.ParseTXT(strings.TrimSpace(record.Value)).Split()
+	if len(txt) == 1 {
+		return txt[0], nil
+	}
+	return txt.Quoted(), nil
+}
@@ -0,0 +1,176 @@
+package huaweiclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"ddnsjx/internal/rrset"
+)
+
+type recordset struct {
+	ID          string   `json:"id,omitempty"`
+	Name        string   `json:"name"`
+	Type        string   `json:"type"`
+	TTL         uint64   `json:"ttl,omitempty"`
+	Records     []string `json:"records"`
+	Description string   `json:"description,omitempty"`
+	Status      string   `json:"status,omitempty"`
+}
+
+func (s *recordset) indexOf(value string) int {
+	for i, v := range s.Records {
+		if rrset.Same(s.Type, v, value) {
+			return i
+		}
+	}
+	return -1
+}
+
+type recordsetList struct {
+	Recordsets []recordset `json:"recordsets"`
+}
+
+type zoneList struct {
+	Zones []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"zones"`
+}
+
+// errorResponse covers the DNS service shape {"code","message"} and the
+// API gateway shape {"error_code","error_msg"} used for auth failures.
+type errorResponse struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	ErrorCode string `json:"error_code"`
+	ErrorMsg  string `json:"error_msg"`
+}
+
+func (c *client) resolveZoneID(ctx context.Context, zone string) (string, error) {
+	if c.zoneID != "" {
+		return c.zoneID, nil
+	}
+	name := rrset.ZoneName(zone)
+	if name == "." {
+		return "", fmt.Errorf("missing Huawei Cloud zone name or zone id")
+	}
+
+	query := url.Values{}
+	query.Set("type", "public")
+	query.Set("name", name)
+	query.Set("search_mode", "equal")
+
+	var resp zoneList
+	if err := c.do(ctx, "GET", "/v2/zones?"+query.Encode(), nil, &resp); err != nil {
+		return "", err
+	}
+	for _, z := range resp.Zones {
+		if rrset.ZoneName(z.Name) == name {
+			c.zoneID = z.ID
+			return c.zoneID, nil
+		}
+	}
+	return "", fmt.Errorf("Huawei Cloud public zone not found: %s", name)
+}
+
+// findSet returns the recordset at name and type, or nil. The name filter
+// may match by substring, so the result is narrowed here.
+func (c *client) findSet(ctx context.Context, zoneID, name, typ string) (*recordset, error) {
+	query := url.Values{}
+	query.Set("name", name)
+	query.Set("type", typ)
+	query.Set("search_mode", "equal")
+	query.Set("limit", "500")
+
+	var resp recordsetList
+	if err := c.do(ctx, "GET", "/v2/zones/"+url.PathEscape(zoneID)+"/recordsets?"+query.Encode(), nil, &resp); err != nil {
+		return nil, err
+	}
+	for i := range resp.Recordsets {
+		s := &resp.Recordsets[i]
+		if strings.EqualFold(s.Name, name) && strings.EqualFold(s.Type, typ) {
+			return s, nil
+		}
+	}
+	return nil, nil
+}
+
+func (c *client) getSet(ctx context.Context, zoneID, setID string) (*recordset, error) {
+	var s recordset
+	if err := c.do(ctx, "GET", "/v2/zones/"+url.PathEscape(zoneID)+"/recordsets/"+url.PathEscape(setID), nil, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (c *client) createSet(ctx context.Context, zoneID string, s recordset) (*recordset, error) {
+	var out recordset
+	if err := c.do(ctx, "POST", "/v2/zones/"+url.PathEscape(zoneID)+"/recordsets", s, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *client) updateSet(ctx context.Context, zoneID string, s *recordset) error {
+	body := recordset{Name: s.Name, Type: s.Type, TTL: s.TTL, Records: s.Records, Description: s.Description}
+	return c.do(ctx, "PUT", "/v2/zones/"+url.PathEscape(zoneID)+"/recordsets/"+url.PathEscape(s.ID), body, nil)
+}
+
+func (c *client) deleteSet(ctx context.Context, zoneID, setID string) error {
+	return c.do(ctx, "DELETE", "/v2/zones/"+url.PathEscape(zoneID)+"/recordsets/"+url.PathEscape(setID), nil, nil)
+}
+
+func (c *client) do(ctx context.Context, method, path string, body any, out any) error {
+	var payload []byte
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		payload = b
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.projectID != "" {
+		req.Header.Set("X-Project-Id", c.projectID)
+	}
+	sign(req, payload, c.accessKey, c.secretKey, time.Now())
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var e errorResponse
+		if json.Unmarshal(b, &e) == nil {
+			if e.Code != "" {
+				return Error{Status: resp.StatusCode, Code: e.Code, Message: e.Message}
+			}
+			if e.ErrorCode != "" {
+				return Error{Status: resp.StatusCode, Code: e.ErrorCode, Message: e.ErrorMsg}
+			}
+		}
+		return Error{Status: resp.StatusCode, Message: string(bytes.TrimSpace(b))}
+	}
+	if out == nil || len(bytes.TrimSpace(b)) == 0 {
+		return nil
+	}
+	return json.Unmarshal(b, out)
+}
@@ -0,0 +1,11 @@
+package huaweiclient
+
+import "ddnsjx/internal/provider"
+
+func capabilities() provider.Capabilities {
+	return provider.Capabilities{
+		RecordTypes: []string{"A", "AAAA", "CAA", "CNAME", "MX", "NS", "SRV", "TXT"},
+		MinTTL:      1,
+		MaxTTL:      2147483647,
+	}
+}
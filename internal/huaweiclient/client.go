@@ -0,0 +1,257 @@
+// Package huaweiclient implements provider.Client for Huawei Cloud DNS
+// public zones.
+//
+// Huawei Cloud stores recordsets (all values of one name and type), so a
+// record id is "<recordset id> <value>" and per-record operations edit the
+// recordset's records list.
+package huaweiclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"ddnsjx/internal/dns"
+	"ddnsjx/internal/provider"
+	"ddnsjx/internal/rrset"
+)
+
+const (
+	defaultRegion = "cn-north-4"
+	defaultTTL    = 300
+)
+
+type NewOptions struct {
+	AccessKey string
+	SecretKey string
+	// Region selects the endpoint https://dns.<region>.myhuaweicloud.com
+	// (default cn-north-4).
+	Region    string
+	ProjectID string
+	// ZoneID skips the lookup by zone name.
+	ZoneID string
+	// BaseURL overrides the region endpoint.
+	BaseURL string
+	// Transport replaces the HTTP transport, e.g. with a cassette.
+	Transport http.RoundTripper
+}
+
+type client struct {
+	accessKey string
+	secretKey string
+	projectID string
+	zoneID    string
+	baseURL   string
+	http      *http.Client
+}
+
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+}
+
+func (e Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("[%d] %s", e.Status, e.Message)
+	}
+	return fmt.Sprintf("[%s] %s", e.Code, e.Message)
+}
+
+// ErrorCode returns the API error code, or the HTTP status when there is
+// none, for metrics.
+func (e Error) ErrorCode() string {
+	if e.Code == "" {
+		return strconv.Itoa(e.Status)
+	}
+	return e.Code
+}
+
+func (e Error) Retryable() bool {
+	return e.Status == http.StatusTooManyRequests || e.Status >= 500
+}
+
+// endpoint returns the DNS endpoint of region.
+func endpoint(region string) string {
+	return "https://dns." + region + ".myhuaweicloud.com"
+}
+
+func New(opt NewOptions) (provider.Client, error) {
+	if strings.TrimSpace(opt.AccessKey) == "" || strings.TrimSpace(opt.SecretKey) == "" {
+		return nil, fmt.Errorf("missing Huawei Cloud AK/SK")
+	}
+	region := strings.TrimSpace(opt.Region)
+	if region == "" {
+		region = defaultRegion
+	}
+	baseURL := strings.TrimRight(strings.TrimSpace(opt.BaseURL), "/")
+	if baseURL == "" {
+		baseURL = endpoint(region)
+	}
+	return &client{
+		accessKey: strings.TrimSpace(opt.AccessKey),
+		secretKey: strings.TrimSpace(opt.SecretKey),
+		projectID: strings.TrimSpace(opt.ProjectID),
+		zoneID:    strings.TrimSpace(opt.ZoneID),
+		baseURL:   baseURL,
+		http:      &http.Client{Timeout: 20 * time.Second, Transport: opt.Transport},
+	}, nil
+}
+
+func (c *client) Capabilities() provider.Capabilities {
+	return capabilities()
+}
+
+// CreateRecord adds record's value to the recordset at its name and type,
+// creating the recordset when there is none.
+func (c *client) CreateRecord(ctx context.Context, zone string, _ string, record dns.Record) (string, provider.CreateStatus, error) {
+	zoneID, err := c.resolveZoneID(ctx, zone)
+	if err != nil {
+		return "", provider.CreateStatusFail, err
+	}
+	name := rrset.FQDN(zone, record.SubDomain)
+	typ := strings.ToUpper(strings.TrimSpace(record.Type))
+	value, err := rrset.Value(record)
+	if err != nil {
+		return "", provider.CreateStatusFail, err
+	}
+
+	set, err := c.findSet(ctx, zoneID, name, typ)
+	if err != nil {
+		return "", provider.CreateStatusFail, err
+	}
+	if set == nil {
+		ttl := uint64(defaultTTL)
+		if record.TTL != nil && *record.TTL > 0 {
+			ttl = *record.TTL
+		}
+		created, err := c.createSet(ctx, zoneID, recordset{Name: name, Type: typ, TTL: ttl, Records: []string{value}})
+		if err != nil {
+			return "", provider.CreateStatusFail, err
+		}
+		return recordID(created.ID, value), provider.CreateStatusSuccess, nil
+	}
+	if i := set.indexOf(value); i >= 0 {
+		return recordID(set.ID, set.Records[i]), provider.CreateStatusExists, nil
+	}
+
+	set.Records = append(set.Records, value)
+	if record.TTL != nil && *record.TTL > 0 {
+		set.TTL = *record.TTL
+	}
+	if err := c.updateSet(ctx, zoneID, set); err != nil {
+		return "", provider.CreateStatusFail, err
+	}
+	return recordID(set.ID, value), provider.CreateStatusSuccess, nil
+}
+
+// DeleteRecord removes one value; the recordset is deleted with its last
+// value.
+func (c *client) DeleteRecord(ctx context.Context, zone string, recordID string) error {
+	zoneID, err := c.resolveZoneID(ctx, zone)
+	if err != nil {
+		return err
+	}
+	setID, value, err := parseRecordID(recordID)
+	if err != nil {
+		return err
+	}
+	set, err := c.getSet(ctx, zoneID, setID)
+	if err != nil {
+		return err
+	}
+	i := set.indexOf(value)
+	if i < 0 {
+		return fmt.Errorf("huaweicloud record not found: %s", recordID)
+	}
+	if len(set.Records) == 1 {
+		return c.deleteSet(ctx, zoneID, set.ID)
+	}
+	set.Records = append(set.Records[:i], set.Records[i+1:]...)
+	return c.updateSet(ctx, zoneID, set)
+}
+
+func (c *client) FindRecord(ctx context.Context, zone string, _ string, record dns.Record) (string, bool, error) {
+	zoneID, err := c.resolveZoneID(ctx, zone)
+	if err != nil {
+		return "", false, err
+	}
+	name := rrset.FQDN(zone, record.SubDomain)
+	typ := strings.ToUpper(strings.TrimSpace(record.Type))
+	value, err := rrset.Value(record)
+	if err != nil {
+		return "", false, err
+	}
+
+	set, err := c.findSet(ctx, zoneID, name, typ)
+	if err != nil || set == nil {
+		return "", false, err
+	}
+	if i := set.indexOf(value); i >= 0 {
+		return recordID(set.ID, set.Records[i]), true, nil
+	}
+	return "", false, nil
+}
+
+// ListRecords returns every value of the recordset at record's name and
+// type.
+func (c *client) ListRecords(ctx context.Context, zone string, _ string, record dns.Record) ([]provider.ExistingRecord, error) {
+	zoneID, err := c.resolveZoneID(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	set, err := c.findSet(ctx, zoneID, rrset.FQDN(zone, record.SubDomain), strings.ToUpper(strings.TrimSpace(record.Type)))
+	if err != nil || set == nil {
+		return nil, err
+	}
+	out := make([]provider.ExistingRecord, 0, len(set.Records))
+	for _, v := range set.Records {
+		out = append(out, provider.ExistingRecord{ID: recordID(set.ID, v), Value: v})
+	}
+	return out, nil
+}
+
+// UpdateRecord replaces the value identified by recordID with record. The
+// record's TTL applies to the whole recordset. A record moved to another
+// name or type is deleted and created again.
+func (c *client) UpdateRecord(ctx context.Context, zone string, _ string, recordID string, record dns.Record) error {
+	zoneID, err := c.resolveZoneID(ctx, zone)
+	if err != nil {
+		return err
+	}
+	setID, old, err := parseRecordID(recordID)
+	if err != nil {
+		return err
+	}
+	value, err := rrset.Value(record)
+	if err != nil {
+		return err
+	}
+	set, err := c.getSet(ctx, zoneID, setID)
+	if err != nil {
+		return err
+	}
+	i := set.indexOf(old)
+	if i < 0 {
+		return fmt.Errorf("huaweicloud record not found: %s", recordID)
+	}
+
+	if !strings.EqualFold(set.Name, rrset.FQDN(zone, record.SubDomain)) || !strings.EqualFold(set.Type, record.Type) {
+		if _, _, err := c.CreateRecord(ctx, zone, "", record); err != nil {
+			return err
+		}
+		return c.DeleteRecord(ctx, zone, recordID)
+	}
+
+	set.Records = append(set.Records[:i], set.Records[i+1:]...)
+	if set.indexOf(value) < 0 {
+		set.Records = append(set.Records, value)
+	}
+	if record.TTL != nil && *record.TTL > 0 {
+		set.TTL = *record.TTL
+	}
+	return c.updateSet(ctx, zoneID, set)
+}
@@ -0,0 +1,210 @@
+package huaweiclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"ddnsjx/internal/dns"
+	"ddnsjx/internal/provider"
+	"ddnsjx/internal/provider/providertest"
+)
+
+func newTestClient(t *testing.T, api *fakeAPI, sk string) provider.Client {
+	t.Helper()
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+	c, err := New(NewOptions{AccessKey: "AKTEST", SecretKey: sk, BaseURL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestConformance(t *testing.T) {
+	providertest.Run(t, func(t *testing.T) provider.Client {
+		return newTestClient(t, newFakeAPI("example.com."), "SKTEST")
+	}, providertest.Options{ContentIDs: true})
+}
+
+func TestRecordsetModel(t *testing.T) {
+	api := newFakeAPI("example.com.")
+	c := newTestClient(t, api, "SKTEST")
+	ctx := context.Background()
+
+	var ids []string
+	for _, v := range []string{"v=spf1 mx -all", "google-site-verification=abc"} {
+		id, status, err := c.CreateRecord(ctx, "example.com", "", dns.Record{SubDomain: "@", Type: "TXT", Value: v})
+		if err != nil || status != provider.CreateStatusSuccess {
+			t.Fatalf("create %s: %s %v", v, status, err)
+		}
+		ids = append(ids, id)
+	}
+	if len(api.sets) != 1 {
+		t.Fatalf("expected both TXT values in one recordset, got %+v", api.sets)
+	}
+	for _, s := range api.sets {
+		if len(s.Records) != 2 || s.Records[0] != `"v=spf1 mx -all"` {
+			t.Fatalf("unexpected recordset %+v", s)
+		}
+	}
+
+	if err := c.DeleteRecord(ctx, "example.com", ids[0]); err != nil {
+		t.Fatalf("delete first value: %v", err)
+	}
+	if len(api.sets) != 1 {
+		t.Fatalf("recordset must survive while it has values")
+	}
+	if err := c.DeleteRecord(ctx, "example.com", ids[1]); err != nil {
+		t.Fatalf("delete last value: %v", err)
+	}
+	if len(api.sets) != 0 {
+		t.Fatalf("expected recordset deleted with its last value, got %+v", api.sets)
+	}
+
+	bad := newTestClient(t, api, "wrong")
+	_, _, err := bad.FindRecord(ctx, "example.com", "", dns.Record{SubDomain: "www", Type: "A", Value: "192.0.2.1"})
+	var apiErr Error
+	if !errors.As(err, &apiErr) || apiErr.Code != "APIGW.0301" || apiErr.Retryable() {
+		t.Fatalf("expected non-retryable APIGW.0301 for a bad signature, got %#v", err)
+	}
+}
+
+// fakeAPI is a stand-in for the Huawei Cloud DNS v2 API. It verifies the
+// AK/SK signature, matches names by substring like the real list calls,
+// rejects a second recordset at one name/type, unquoted TXT and CNAME
+// conflicts, and answers with 202 for writes.
+type fakeAPI struct {
+	mu     sync.Mutex
+	zone   string
+	nextID int
+	sets   map[string]*recordset
+}
+
+const fakeZoneID = "ff8080825b8fc86c015b94bc6f8712c3"
+
+func newFakeAPI(zone string) *fakeAPI {
+	return &fakeAPI{zone: zone, sets: make(map[string]*recordset)}
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	body, _ := io.ReadAll(r.Body)
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	canonical, signed := canonicalRequest(r, body)
+	toSign := signAlgorithm + "\n" + r.Header.Get("X-Sdk-Date") + "\n" + hexSHA256([]byte(canonical))
+	want := signAlgorithm + " Access=AKTEST, SignedHeaders=" + signed + ", Signature=" + fmt.Sprintf("%x", hmacSHA256([]byte("SKTEST"), toSign))
+	if r.Header.Get("Authorization") != want {
+		writeJSON(w, http.StatusUnauthorized, errorResponse{ErrorCode: "APIGW.0301", ErrorMsg: "Incorrect IAM authentication information: verify aksk signature fail"})
+		return
+	}
+
+	setsPath := "/v2/zones/" + fakeZoneID + "/recordsets"
+	q := r.URL.Query()
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/v2/zones":
+		// The name filter matches by substring unless search_mode=equal is
+		// honoured, so an unrelated zone comes back too.
+		writeJSON(w, http.StatusOK, map[string]any{"zones": []map[string]string{
+			{"id": "other", "name": "mail." + f.zone},
+			{"id": fakeZoneID, "name": f.zone},
+		}})
+	case r.Method == http.MethodGet && r.URL.Path == setsPath:
+		out := recordsetList{Recordsets: []recordset{}}
+		for _, s := range f.sets {
+			if strings.Contains(s.Name, q.Get("name")) && (q.Get("type") == "" || s.Type == q.Get("type")) {
+				out.Recordsets = append(out.Recordsets, *s)
+			}
+		}
+		writeJSON(w, http.StatusOK, out)
+	case r.Method == http.MethodPost && r.URL.Path == setsPath:
+		var s recordset
+		if err := json.Unmarshal(body, &s); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Code: "DNS.0303", Message: err.Error()})
+			return
+		}
+		for _, cur := range f.sets {
+			if cur.Name == s.Name && cur.Type == s.Type {
+				writeJSON(w, http.StatusBadRequest, errorResponse{Code: "DNS.0312", Message: "Attribute 'name' conflicts with an existing record set."})
+				return
+			}
+		}
+		if msg := f.validate("", s); msg != "" {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Code: "DNS.0308", Message: msg})
+			return
+		}
+		f.nextID++
+		s.ID = fmt.Sprintf("rs-%03d", f.nextID)
+		s.Status = "PENDING_CREATE"
+		f.sets[s.ID] = &s
+		writeJSON(w, http.StatusAccepted, s)
+	case strings.HasPrefix(r.URL.Path, setsPath+"/"):
+		id := strings.TrimPrefix(r.URL.Path, setsPath+"/")
+		cur, ok := f.sets[id]
+		if !ok {
+			writeJSON(w, http.StatusNotFound, errorResponse{Code: "DNS.0304", Message: "The record set does not exist."})
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, cur)
+		case http.MethodPut:
+			var s recordset
+			if err := json.Unmarshal(body, &s); err != nil {
+				writeJSON(w, http.StatusBadRequest, errorResponse{Code: "DNS.0303", Message: err.Error()})
+				return
+			}
+			if msg := f.validate(id, s); msg != "" {
+				writeJSON(w, http.StatusBadRequest, errorResponse{Code: "DNS.0308", Message: msg})
+				return
+			}
+			cur.TTL, cur.Records = s.TTL, s.Records
+			writeJSON(w, http.StatusAccepted, cur)
+		case http.MethodDelete:
+			delete(f.sets, id)
+			writeJSON(w, http.StatusAccepted, cur)
+		default:
+			http.NotFound(w, r)
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f *fakeAPI) validate(id string, s recordset) string {
+	if len(s.Records) == 0 || s.TTL == 0 {
+		return "Attribute 'records' or 'ttl' is invalid."
+	}
+	seen := make(map[string]bool)
+	for _, v := range s.Records {
+		if seen[v] {
+			return "Attribute 'records' contains duplicate values."
+		}
+		seen[v] = true
+		if s.Type == "TXT" && !strings.HasPrefix(v, `"`) {
+			return "Attribute 'records' is invalid: TXT values must be quoted."
+		}
+	}
+	for curID, cur := range f.sets {
+		if curID != id && cur.Name == s.Name && (cur.Type == "CNAME") != (s.Type == "CNAME") {
+			return "The CNAME record conflicts with other records of the same name."
+		}
+	}
+	return ""
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,33 @@
+package huaweiclient
+
+import (
+	"net/http"
+
+	"ddnsjx/internal/provider"
+)
+
+func init() {
+	provider.Register(provider.Factory{
+		Name: "huaweicloud",
+		Help: "Huawei Cloud DNS (AK/SK signing, public zones)",
+		Settings: []provider.Setting{
+			{Name: "access_key", Flag: "huawei-ak", Env: "HUAWEICLOUD_ACCESS_KEY", Help: "Huawei Cloud access key (AK)", Required: true},
+			{Name: "secret_key", Flag: "huawei-sk", Env: "HUAWEICLOUD_SECRET_KEY", Help: "Huawei Cloud secret key (SK)", Required: true, Secret: true},
+			{Name: "region", Flag: "huawei-region", Env: "HUAWEICLOUD_REGION", Default: defaultRegion, Help: "Huawei Cloud region of the DNS endpoint"},
+			{Name: "project_id", Flag: "huawei-project-id", Env: "HUAWEICLOUD_PROJECT_ID", Help: "Huawei Cloud project id (X-Project-Id)"},
+			{Name: "zone_id", Flag: "huawei-zone-id", Env: "HUAWEICLOUD_ZONE_ID", Help: "Huawei Cloud zone id (empty: query by zone name)"},
+			{Name: "base_url", Flag: "huawei-base-url", Env: "HUAWEICLOUD_BASE_URL", Help: "Huawei Cloud DNS endpoint (default " + endpoint("<region>") + ")"},
+		},
+		New: func(_ string, s provider.Settings, transport http.RoundTripper) (provider.Client, error) {
+			return New(NewOptions{
+				AccessKey: s.Get("access_key"),
+				SecretKey: s.Get("secret_key"),
+				Region:    s.Get("region"),
+				ProjectID: s.Get("project_id"),
+				ZoneID:    s.Get("zone_id"),
+				BaseURL:   s.Get("base_url"),
+				Transport: transport,
+			})
+		},
+	})
+}
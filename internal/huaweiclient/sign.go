@@ -0,0 +1,109 @@
+package huaweiclient
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const signAlgorithm = "SDK-HMAC-SHA256"
+
+// sign adds Huawei Cloud AK/SK (SDK-HMAC-SHA256) headers to req. Host,
+// content-type and x-sdk-*/x-project-id headers are signed.
+func sign(req *http.Request, body []byte, ak, sk string, now time.Time) {
+	sdkDate := now.UTC().Format("20060102T150405Z")
+	req.Header.Set("X-Sdk-Date", sdkDate)
+
+	canonical, signedHeaders := canonicalRequest(req, body)
+	toSign := signAlgorithm + "\n" + sdkDate + "\n" + hexSHA256([]byte(canonical))
+	signature := hex.EncodeToString(hmacSHA256([]byte(sk), toSign))
+
+	req.Header.Set("Authorization", signAlgorithm+" Access="+ak+", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func canonicalRequest(req *http.Request, body []byte) (canonical, signedHeaders string) {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for k, v := range req.Header {
+		lk := strings.ToLower(k)
+		if strings.HasPrefix(lk, "x-sdk-") || lk == "x-project-id" || lk == "content-type" {
+			headers[lk] = strings.TrimSpace(strings.Join(v, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var canonHeaders strings.Builder
+	for _, k := range names {
+		canonHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders = strings.Join(names, ";")
+
+	canonical = strings.Join([]string{
+		req.Method,
+		canonicalURI(req.URL.Path),
+		canonicalQuery(req.URL.Query()),
+		canonHeaders.String(),
+		signedHeaders,
+		hexSHA256(body),
+	}, "\n")
+	return canonical, signedHeaders
+}
+
+// canonicalURI escapes every path segment and, unlike SigV4, always ends
+// the path with a slash.
+func canonicalURI(path string) string {
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		segments[i] = escape(s)
+	}
+	uri := strings.Join(segments, "/")
+	if !strings.HasSuffix(uri, "/") {
+		uri += "/"
+	}
+	return uri
+}
+
+func canonicalQuery(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		vals := append([]string(nil), q[k]...)
+		sort.Strings(vals)
+		for _, v := range vals {
+			parts = append(parts, escape(k)+"="+escape(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// escape percent-encodes everything except RFC 3986 unreserved characters.
+func escape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+func hexSHA256(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	m := hmac.New(sha256.New, key)
+	m.Write([]byte(data))
+	return m.Sum(nil)
+}
@@ -0,0 +1,43 @@
+package huaweiclient
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCanonicalRequest(t *testing.T) {
+	req, err := http.NewRequest("GET", "https://dns.cn-north-4.myhuaweicloud.com/v2/zones?type=public&name=example.com.", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "not-signed")
+	sign(req, nil, "AK", "SK", time.Date(2026, 10, 18, 8, 30, 0, 0, time.UTC))
+
+	canonical, signed := canonicalRequest(req, nil)
+	want := strings.Join([]string{
+		"GET",
+		"/v2/zones/",
+		"name=example.com.&type=public",
+		"content-type:application/json",
+		"host:dns.cn-north-4.myhuaweicloud.com",
+		"x-sdk-date:20261018T083000Z",
+		"",
+		"content-type;host;x-sdk-date",
+		"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+	}, "\n")
+	if canonical != want {
+		t.Fatalf("canonical request mismatch:\n%s\nwant:\n%s", canonical, want)
+	}
+	if got := req.Header.Get("Authorization"); !strings.HasPrefix(got, "SDK-HMAC-SHA256 Access=AK, SignedHeaders="+signed+", Signature=") {
+		t.Fatalf("unexpected Authorization header %q", got)
+	}
+}
+
+func TestCanonicalURIEscapesSegments(t *testing.T) {
+	if got := canonicalURI("/v2/zones/a b/recordsets"); got != "/v2/zones/a%20b/recordsets/" {
+		t.Fatalf("unexpected canonical uri %q", got)
+	}
+}
@@ -0,0 +1,19 @@
+package huaweiclient
+
+import (
+	"fmt"
+	"strings"
+)
+
+// recordID identifies one value of a recordset as "<recordset id> <value>".
+func recordID(setID, value string) string {
+	return setID + " " + value
+}
+
+func parseRecordID(id string) (setID, value string, err error) {
+	parts := strings.SplitN(strings.TrimSpace(id), " ", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return "", "", fmt.Errorf("invalid huaweicloud record id %q", id)
+	}
+	return parts[0], parts[1], nil
+}
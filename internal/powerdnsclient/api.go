@@ -0,0 +1,114 @@
+package powerdnsclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"ddnsjx/internal/rrset"
+)
+
+type apiRecord struct {
+	Content  string `json:"content"`
+	Disabled bool   `json:"disabled"`
+}
+
+type apiRRSet struct {
+	Name       string      `json:"name"`
+	Type       string      `json:"type"`
+	TTL        uint64      `json:"ttl,omitempty"`
+	ChangeType string      `json:"changetype,omitempty"`
+	Records    []apiRecord `json:"records"`
+}
+
+type zoneResponse struct {
+	RRSets []apiRRSet `json:"rrsets"`
+}
+
+type patchRequest struct {
+	RRSets []apiRRSet `json:"rrsets"`
+}
+
+type errorResponse struct {
+	Error  string   `json:"error"`
+	Errors []string `json:"errors"`
+}
+
+// zonePath returns the API path of zone. PowerDNS zone ids are the
+// canonical zone name with "/" encoded as "=2F".
+func (c *client) zonePath(zone string) string {
+	id := strings.ReplaceAll(rrset.ZoneName(zone), "/", "=2F")
+	return "/servers/" + url.PathEscape(c.serverID) + "/zones/" + url.PathEscape(id)
+}
+
+func (c *client) SetName(zone, sub string) string {
+	return rrset.FQDN(zone, sub)
+}
+
+// GetSet returns the RRset at name and type; a missing set is returned
+// empty. Disabled records are kept so writing the set back does not drop
+// them. Servers older than 4.5 ignore the rrset_* filters and return the
+// whole zone, so the response is filtered again here.
+func (c *client) GetSet(ctx context.Context, zone, name, typ string) (*rrset.Set, error) {
+	query := url.Values{}
+	query.Set("rrset_name", name)
+	query.Set("rrset_type", typ)
+
+	var resp zoneResponse
+	if err := c.do(ctx, "GET", c.zonePath(zone)+"?"+query.Encode(), nil, &resp); err != nil {
+		return nil, err
+	}
+	out := &rrset.Set{Name: name, Type: typ}
+	for _, rs := range resp.RRSets {
+		if !strings.EqualFold(rs.Name, name) || rs.Type != typ {
+			continue
+		}
+		out.TTL = rs.TTL
+		for _, r := range rs.Records {
+			out.Values = append(out.Values, r.Content)
+			if r.Disabled {
+				out.Disabled = append(out.Disabled, r.Content)
+			}
+		}
+	}
+	return out, nil
+}
+
+// SubmitSets sends one PATCH: DELETE for an emptied set and REPLACE
+// otherwise.
+func (c *client) SubmitSets(ctx context.Context, zone string, updates []rrset.Update) error {
+	var sets []apiRRSet
+	for _, u := range updates {
+		s := u.After
+		if len(s.Values) == 0 {
+			sets = append(sets, apiRRSet{Name: s.Name, Type: s.Type, ChangeType: "DELETE", Records: []apiRecord{}})
+			continue
+		}
+		e := apiRRSet{Name: s.Name, Type: s.Type, TTL: s.TTL, ChangeType: "REPLACE"}
+		for _, v := range s.Values {
+			e.Records = append(e.Records, apiRecord{Content: v, Disabled: s.IsDisabled(v)})
+		}
+		sets = append(sets, e)
+	}
+	return c.do(ctx, "PATCH", c.zonePath(zone), patchRequest{RRSets: sets}, nil)
+}
+
+func (c *client) do(ctx context.Context, method, path string, body any, out any) error {
+	_, err := c.rest.Do(ctx, method, "/api/v1"+path, body, out)
+	return err
+}
+
+func decodeError(status int, _ http.Header, b []byte) error {
+	var e errorResponse
+	if json.Unmarshal(b, &e) == nil && e.Error != "" {
+		msg := e.Error
+		if len(e.Errors) > 0 {
+			msg += ": " + strings.Join(e.Errors, "; ")
+		}
+		return Error{Status: status, Message: msg}
+	}
+	return Error{Status: status, Message: string(bytes.TrimSpace(b))}
+}
@@ -0,0 +1,131 @@
+// Package powerdnsclient implements provider.Client for the PowerDNS
+// Authoritative HTTP API.
+//
+// PowerDNS replaces whole RRsets (all records of one name and type), so
+// every per-record operation reads the RRset, edits one record and writes
+// the set back with a PATCH. Record ids are synthesised from name, type and
+// content.
+package powerdnsclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"ddnsjx/internal/dns"
+	"ddnsjx/internal/provider"
+	"ddnsjx/internal/restclient"
+	"ddnsjx/internal/rrset"
+)
+
+const (
+	defaultServerID = "localhost"
+	defaultTTL      = 3600
+)
+
+type NewOptions struct {
+	// BaseURL is the API root, e.g. http://127.0.0.1:8081.
+	BaseURL  string
+	APIKey   string
+	ServerID string
+	// Rectify and Notify request PUT .../rectify and PUT .../notify once a
+	// run has changed the zone (see Finalize).
+	Rectify bool
+	Notify  bool
+	// Transport replaces the HTTP transport, e.g. with a cassette.
+	Transport http.RoundTripper
+}
+
+type client struct {
+	serverID string
+	rectify  bool
+	notify   bool
+	rest     *restclient.Client
+	sets     rrset.Engine
+}
+
+type Error struct {
+	Status  int
+	Message string
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("[%d] %s", e.Status, e.Message)
+}
+
+func (e Error) Retryable() bool {
+	return restclient.RetryableStatus(e.Status)
+}
+
+func New(opt NewOptions) (provider.Client, error) {
+	if strings.TrimSpace(opt.APIKey) == "" {
+		return nil, fmt.Errorf("missing PowerDNS API key")
+	}
+	baseURL := strings.TrimRight(strings.TrimSpace(opt.BaseURL), "/")
+	baseURL = strings.TrimSuffix(baseURL, "/api/v1")
+	if baseURL == "" {
+		return nil, fmt.Errorf("missing PowerDNS API URL")
+	}
+	serverID := strings.TrimSpace(opt.ServerID)
+	if serverID == "" {
+		serverID = defaultServerID
+	}
+	c := &client{
+		serverID: serverID,
+		rectify:  opt.Rectify,
+		notify:   opt.Notify,
+		rest: restclient.New(restclient.Options{
+			BaseURL:     baseURL,
+			Auth:        restclient.Header("X-API-Key", strings.TrimSpace(opt.APIKey)),
+			DecodeError: decodeError,
+			Transport:   opt.Transport,
+		}),
+	}
+	c.sets = rrset.Engine{Store: c, Provider: "powerdns", DefaultTTL: defaultTTL}
+	return c, nil
+}
+
+func (c *client) Capabilities() provider.Capabilities {
+	return capabilities()
+}
+
+func (c *client) CreateRecord(ctx context.Context, zone string, _ string, record dns.Record) (string, provider.CreateStatus, error) {
+	return c.sets.Create(ctx, zone, record)
+}
+
+func (c *client) DeleteRecord(ctx context.Context, zone string, recordID string) error {
+	return c.sets.Delete(ctx, zone, recordID)
+}
+
+// FindRecord looks for record's content in the RRset at its name and type.
+func (c *client) FindRecord(ctx context.Context, zone string, _ string, record dns.Record) (string, bool, error) {
+	return c.sets.Find(ctx, zone, record)
+}
+
+// ListRecords returns every record of the RRset at record's name and type.
+func (c *client) ListRecords(ctx context.Context, zone string, _ string, record dns.Record) ([]provider.ExistingRecord, error) {
+	return c.sets.List(ctx, zone, record)
+}
+
+// UpdateRecord replaces the record identified by recordID with record. The
+// record's TTL applies to the whole RRset.
+func (c *client) UpdateRecord(ctx context.Context, zone string, _ string, recordID string, record dns.Record) error {
+	return c.sets.Update(ctx, zone, recordID, record)
+}
+
+// Finalize rectifies the zone and sends NOTIFY to its secondaries, as
+// enabled in NewOptions.
+func (c *client) Finalize(ctx context.Context, zone string) error {
+	if c.rectify {
+		if err := c.do(ctx, "PUT", c.zonePath(zone)+"/rectify", nil, nil); err != nil {
+			return fmt.Errorf("rectify %s: %w", zone, err)
+		}
+	}
+	if c.notify {
+		if err := c.do(ctx, "PUT", c.zonePath(zone)+"/notify", nil, nil); err != nil {
+			return fmt.Errorf("notify %s: %w", zone, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,254 @@
+package powerdnsclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"ddnsjx/internal/dns"
+	"ddnsjx/internal/provider"
+	"ddnsjx/internal/provider/providertest"
+	"ddnsjx/internal/rrset"
+)
+
+func newTestClient(t *testing.T, api *fakeAPI, opt NewOptions) provider.Client {
+	t.Helper()
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+	opt.BaseURL = srv.URL
+	opt.APIKey = "secret"
+	c, err := New(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestConformance(t *testing.T) {
+	providertest.Run(t, func(t *testing.T) provider.Client {
+		return newTestClient(t, newFakeAPI("example.com."), NewOptions{})
+	}, providertest.Options{ContentIDs: true})
+}
+
+func TestRRSetMerging(t *testing.T) {
+	api := newFakeAPI("example.com.")
+	tlsa := rrKey{"_25._tcp.mail.example.com.", "TLSA"}
+	api.sets[tlsa] = &fakeSet{name: tlsa.name, typ: tlsa.typ, ttl: 3600, records: []apiRecord{{Content: "3 1 1 00ff", Disabled: true}}}
+	c := newTestClient(t, api, NewOptions{})
+	ctx := context.Background()
+
+	for _, v := range []string{"3 1 1 aa11", "3 1 1 bb22"} {
+		if _, status, err := c.CreateRecord(ctx, "example.com", "", dns.Record{SubDomain: "_25._tcp.mail", Type: "TLSA", Value: v}); err != nil || status != provider.CreateStatusSuccess {
+			t.Fatalf("create %s: %s %v", v, status, err)
+		}
+	}
+	set := api.sets[tlsa]
+	if set == nil || len(set.records) != 3 || !set.records[0].Disabled {
+		t.Fatalf("expected TLSA values merged into the RRset with the disabled record kept, got %+v", set)
+	}
+
+	long := strings.Repeat("k", 300)
+	if _, _, err := c.CreateRecord(ctx, "example.com", "", dns.Record{SubDomain: "dkim._domainkey", Type: "TXT", Value: long}); err != nil {
+		t.Fatalf("create long TXT: %v", err)
+	}
+	txt := api.sets[rrKey{"dkim._domainkey.example.com.", "TXT"}]
+	if txt == nil || txt.records[0].Content != `"`+long[:255]+`" "`+long[255:]+`"` {
+		t.Fatalf("expected TXT split into 255 byte strings, got %+v", txt)
+	}
+
+	b, ok := c.(provider.Batcher)
+	if !ok {
+		t.Fatalf("powerdns client should implement provider.Batcher")
+	}
+	api.patches = nil
+	_, err := b.ApplyBatch(ctx, "example.com", "", []provider.Change{
+		{Action: provider.ChangeDelete, RecordID: rrset.ID(tlsa.name, "TLSA", "3 1 1 aa11")},
+		{Action: provider.ChangeCreate, Record: dns.Record{SubDomain: "mail", Type: "A", Value: "192.0.2.25"}},
+	})
+	if err != nil {
+		t.Fatalf("batch: %v", err)
+	}
+	if len(api.patches) != 1 || len(api.patches[0]) != 2 || len(api.sets[tlsa].records) != 2 {
+		t.Fatalf("expected one PATCH replacing two RRsets, got %v", api.patches)
+	}
+
+	// A failing RRset leaves the zone untouched.
+	_, err = b.ApplyBatch(ctx, "example.com", "", []provider.Change{
+		{Action: provider.ChangeCreate, Record: dns.Record{SubDomain: "www", Type: "A", Value: "192.0.2.80"}},
+		{Action: provider.ChangeCreate, Record: dns.Record{SubDomain: "mail", Type: "CNAME", Value: "elsewhere.example.net"}},
+	})
+	var apiErr Error
+	if err == nil || !strings.Contains(err.Error(), "Conflicts with pre-existing RRset") {
+		t.Fatalf("expected CNAME conflict, got %v", err)
+	}
+	if !errors.As(err, &apiErr) || apiErr.Status != http.StatusUnprocessableEntity || apiErr.Retryable() {
+		t.Fatalf("expected non-retryable 422 Error, got %#v", err)
+	}
+	if _, ok := api.sets[rrKey{"www.example.com.", "A"}]; ok {
+		t.Fatalf("rejected PATCH must not be applied partially")
+	}
+}
+
+func TestFinalize(t *testing.T) {
+	api := newFakeAPI("example.com.")
+	f, ok := newTestClient(t, api, NewOptions{}).(provider.Finalizer)
+	if !ok {
+		t.Fatalf("powerdns client should implement provider.Finalizer")
+	}
+	if err := f.Finalize(context.Background(), "example.com"); err != nil || len(api.puts) != 0 {
+		t.Fatalf("expected no calls without rectify/notify, got %v %v", api.puts, err)
+	}
+
+	f = newTestClient(t, api, NewOptions{Rectify: true, Notify: true}).(provider.Finalizer)
+	if err := f.Finalize(context.Background(), "example.com"); err != nil {
+		t.Fatalf("finalize: %v", err)
+	}
+	if got := strings.Join(api.puts, ","); got != "rectify,notify" {
+		t.Fatalf("expected rectify then notify, got %s", got)
+	}
+
+	api.secondary = true
+	if err := f.Finalize(context.Background(), "example.com"); err == nil || !strings.Contains(err.Error(), "notify example.com") {
+		t.Fatalf("expected notify error for a secondary zone, got %v", err)
+	}
+}
+
+type rrKey struct {
+	name string
+	typ  string
+}
+
+// fakeSet is one RRset as the fake API stores it.
+type fakeSet struct {
+	name    string
+	typ     string
+	ttl     uint64
+	records []apiRecord
+}
+
+// fakeAPI is a stand-in for the PowerDNS Authoritative HTTP API. It checks
+// the API key, ignores the rrset_* filters like servers older than 4.5,
+// validates a PATCH as a whole (duplicate contents, unquoted TXT, CNAME
+// conflicts) and answers with the JSON PowerDNS uses.
+type fakeAPI struct {
+	mu        sync.Mutex
+	zone      string
+	sets      map[rrKey]*fakeSet
+	patches   [][]apiRRSet
+	puts      []string
+	secondary bool
+}
+
+func newFakeAPI(zone string) *fakeAPI {
+	return &fakeAPI{zone: zone, sets: make(map[rrKey]*fakeSet)}
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Header.Get("X-API-Key") != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte("Unauthorized"))
+		return
+	}
+
+	zonePath := "/api/v1/servers/localhost/zones/" + f.zone
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == zonePath:
+		resp := zoneResponse{RRSets: []apiRRSet{}}
+		for _, s := range f.sets {
+			resp.RRSets = append(resp.RRSets, apiRRSet{Name: s.name, Type: s.typ, TTL: s.ttl, Records: s.records})
+		}
+		writeJSON(w, http.StatusOK, resp)
+	case r.Method == http.MethodPatch && r.URL.Path == zonePath:
+		var req patchRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+			return
+		}
+		if msg := f.applyPatch(req.RRSets); msg != "" {
+			writeJSON(w, http.StatusUnprocessableEntity, errorResponse{Error: msg})
+			return
+		}
+		f.patches = append(f.patches, req.RRSets)
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPut && r.URL.Path == zonePath+"/rectify":
+		f.puts = append(f.puts, "rectify")
+		writeJSON(w, http.StatusOK, map[string]string{"result": "Rectified"})
+	case r.Method == http.MethodPut && r.URL.Path == zonePath+"/notify":
+		if f.secondary {
+			writeJSON(w, http.StatusUnprocessableEntity, errorResponse{Error: "Domain '" + f.zone + "' is not a primary domain (or a secondary with renotify enabled)"})
+			return
+		}
+		f.puts = append(f.puts, "notify")
+		writeJSON(w, http.StatusOK, map[string]string{"result": "Notification queued"})
+	case strings.HasPrefix(r.URL.Path, "/api/v1/servers/localhost/zones/"):
+		writeJSON(w, http.StatusNotFound, errorResponse{Error: "Could not find domain '" + strings.TrimPrefix(r.URL.Path, "/api/v1/servers/localhost/zones/") + "'"})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// applyPatch validates every RRset against a copy of the zone and only
+// commits when all of them are valid, like the single PowerDNS transaction.
+func (f *fakeAPI) applyPatch(sets []apiRRSet) string {
+	next := make(map[rrKey]*fakeSet, len(f.sets))
+	for k, v := range f.sets {
+		next[k] = v
+	}
+
+	for _, rs := range sets {
+		k := rrKey{rs.Name, rs.Type}
+		if rs.Name != strings.ToLower(rs.Name) || !strings.HasSuffix(rs.Name, "."+f.zone) && rs.Name != f.zone {
+			return fmt.Sprintf("RRset %s IN %s: Name is out of zone", rs.Name, rs.Type)
+		}
+		switch rs.ChangeType {
+		case "DELETE":
+			delete(next, k)
+			continue
+		case "REPLACE":
+		default:
+			return "Changetype not understood"
+		}
+		if rs.TTL == 0 {
+			return fmt.Sprintf("RRset %s IN %s: TTL is missing", rs.Name, rs.Type)
+		}
+		seen := make(map[string]bool)
+		for _, rec := range rs.Records {
+			if seen[rec.Content] {
+				return fmt.Sprintf("RRset %s IN %s has duplicate record %q", rs.Name, rs.Type, rec.Content)
+			}
+			seen[rec.Content] = true
+			if rs.Type == "TXT" && !strings.HasPrefix(rec.Content, `"`) {
+				return fmt.Sprintf("Record %s/%s '%s': Parsing record content: Data field in DNS should start with quote (\") at position 0", rs.Name, rs.Type, rec.Content)
+			}
+		}
+		next[k] = &fakeSet{name: rs.Name, typ: rs.Type, ttl: rs.TTL, records: rs.Records}
+	}
+	for k := range next {
+		if k.typ != "CNAME" {
+			continue
+		}
+		for other := range next {
+			if other.name == k.name && other.typ != "CNAME" {
+				return fmt.Sprintf("RRset %s IN CNAME: Conflicts with pre-existing RRset", k.name)
+			}
+		}
+	}
+
+	f.sets = next
+	return ""
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
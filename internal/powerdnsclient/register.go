@@ -0,0 +1,39 @@
+package powerdnsclient
+
+import (
+	"net/http"
+
+	"ddnsjx/internal/provider"
+)
+
+func init() {
+	provider.Register(provider.Factory{
+		Name: "powerdns",
+		Help: "PowerDNS Authoritative HTTP API (X-API-Key)",
+		Settings: []provider.Setting{
+			{Name: "base_url", Flag: "pdns-url", Env: "PDNS_API_URL", Help: "PowerDNS API root, e.g. http://127.0.0.1:8081", Required: true},
+			{Name: "api_key", Flag: "pdns-api-key", Env: "PDNS_API_KEY", Help: "PowerDNS API key", Required: true, Secret: true},
+			{Name: "server_id", Flag: "pdns-server-id", Env: "PDNS_SERVER_ID", Default: defaultServerID, Help: "PowerDNS server id"},
+			{Name: "rectify", Flag: "pdns-rectify", Env: "PDNS_RECTIFY", Help: "rectify the zone after changes (true|false)"},
+			{Name: "notify", Flag: "pdns-notify", Env: "PDNS_NOTIFY", Help: "send NOTIFY to secondaries after changes (true|false)"},
+		},
+		New: func(_ string, s provider.Settings, transport http.RoundTripper) (provider.Client, error) {
+			rectify, err := s.Bool("rectify")
+			if err != nil {
+				return nil, err
+			}
+			notify, err := s.Bool("notify")
+			if err != nil {
+				return nil, err
+			}
+			return New(NewOptions{
+				BaseURL:   s.Get("base_url"),
+				APIKey:    s.Get("api_key"),
+				ServerID:  s.Get("server_id"),
+				Rectify:   rectify,
+				Notify:    notify,
+				Transport: transport,
+			})
+		},
+	})
+}
@@ -0,0 +1,34 @@
+package provider
+
+import (
+	"context"
+
+	"ddnsjx/internal/dns"
+)
+
+type ChangeAction string
+
+const (
+	ChangeCreate ChangeAction = "create"
+	ChangeUpdate ChangeAction = "update"
+	ChangeDelete ChangeAction = "delete"
+)
+
+// Change is one entry of a batch. RecordID is required for updates and
+// deletes; Record is ignored for deletes.
+type Change struct {
+	Action   ChangeAction
+	RecordID string
+	Record   dns.Record
+}
+
+// ChangeResult is the outcome of the Change at the same index.
+type ChangeResult struct {
+	RecordID string
+}
+
+// Batcher is implemented by clients that can apply a whole changeset as a
+// single transaction: either every change is applied or none is.
+type Batcher interface {
+	ApplyBatch(ctx context.Context, zone string, recordLine string, changes []Change) ([]ChangeResult, error)
+}
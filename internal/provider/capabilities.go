@@ -0,0 +1,86 @@
+package provider
+
+import (
+	"fmt"
+	"strings"
+
+	"ddnsjx/internal/dns"
+)
+
+// Capabilities describes what a provider can represent, so a plan can be
+// checked before any API call is made. Zero limits mean "no limit".
+type Capabilities struct {
+	RecordTypes []string
+	MinTTL      uint64
+	MaxTTL      uint64
+	// AutoTTL is a TTL value the provider reads as "automatic" and accepts
+	// outside MinTTL/MaxTTL, such as Cloudflare's 1. Zero means none.
+	AutoTTL uint64
+	// MaxTXTLength is the longest TXT text accepted, in bytes, counted
+	// before it is split into character-strings.
+	MaxTXTLength int
+
+	// Settings the provider can store with a record. Unsupported ones are
+	// dropped by Strip rather than rejected.
+	Lines    bool // per-record resolution lines (dns.Record.Line/LineID)
+	Weights  bool // per-record weights
+	Status   bool // per-record enable/disable
+	Comments bool // remarks/comments stored with the record
+	Proxy    bool // proxying and other Cloudflare settings (dns.Record.Cloudflare)
+}
+
+func (c Capabilities) SupportsType(t string) bool {
+	t = strings.ToUpper(strings.TrimSpace(t))
+	for _, s := range c.RecordTypes {
+		if s == t {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckRecord returns every reason rec cannot be published as is. Type
+// support is reported separately through SupportsType, and settings the
+// provider cannot store are dropped by Strip.
+func (c Capabilities) CheckRecord(rec dns.Record) []string {
+	var problems []string
+	if rec.TTL != nil && *rec.TTL > 0 && (c.AutoTTL == 0 || *rec.TTL != c.AutoTTL) {
+		if c.MinTTL > 0 && *rec.TTL < c.MinTTL {
+			problems = append(problems, fmt.Sprintf("ttl %d is below provider minimum %d", *rec.TTL, c.MinTTL))
+		}
+		if c.MaxTTL > 0 && *rec.TTL > c.MaxTTL {
+			problems = append(problems, fmt.Sprintf("ttl %d is above provider maximum %d", *rec.TTL, c.MaxTTL))
+		}
+	}
+	if c.MaxTXTLength > 0 && strings.EqualFold(rec.Type, "TXT") {
+		if n := len(dns.TXTText(rec.Value)); n > c.MaxTXTLength {
+			problems = append(problems, fmt.Sprintf("TXT value is %d bytes, provider maximum is %d", n, c.MaxTXTLength))
+		}
+	}
+	return problems
+}
+
+// Strip clears the settings of rec the provider cannot store and returns
+// the config keys that were dropped.
+func (c Capabilities) Strip(rec dns.Record) (dns.Record, []string) {
+	var dropped []string
+	if !c.Lines && rec.Line != "" {
+		rec.Line, dropped = "", append(dropped, "line")
+	}
+	if !c.Lines && rec.LineID != "" {
+		rec.LineID, dropped = "", append(dropped, "line_id")
+	}
+	if !c.Weights && rec.Weight != nil {
+		rec.Weight, dropped = nil, append(dropped, "weight")
+	}
+	if !c.Status && rec.Status != "" {
+		rec.Status, dropped = "", append(dropped, "status")
+	}
+	if !c.Comments && rec.Remark != "" {
+		rec.Remark, dropped = "", append(dropped, "remark")
+	}
+	if !c.Proxy && rec.Cloudflare != nil {
+		rec.Cloudflare, dropped = nil, append(dropped, "cloudflare")
+	}
+	return rec, dropped
+}
@@ -0,0 +1,52 @@
+package provider
+
+import (
+	"strings"
+	"testing"
+
+	"ddnsjx/internal/dns"
+)
+
+func TestCapabilitiesCheckRecord(t *testing.T) {
+	caps := Capabilities{RecordTypes: []string{"TXT", "A"}, MinTTL: 600, MaxTTL: 86400, MaxTXTLength: 10}
+
+	if !caps.SupportsType(" txt ") || caps.SupportsType("TLSA") {
+		t.Fatalf("unexpected SupportsType result")
+	}
+
+	ttl := uint64(300)
+	weight := uint64(10)
+	problems := caps.CheckRecord(dns.Record{Type: "TXT", Value: strings.Repeat("x", 11), TTL: &ttl, Line: "电信", Weight: &weight})
+	if len(problems) != 2 {
+		t.Fatalf("expected ttl/length problems, got %v", problems)
+	}
+
+	auto := uint64(1)
+	caps.AutoTTL = 1
+	if problems := caps.CheckRecord(dns.Record{Type: "A", Value: "192.0.2.1", TTL: &auto}); len(problems) != 0 {
+		t.Fatalf("expected the auto TTL to be accepted, got %v", problems)
+	}
+
+	if problems := caps.CheckRecord(dns.Record{Type: "A", Value: "192.0.2.1"}); len(problems) != 0 {
+		t.Fatalf("expected no problems, got %v", problems)
+	}
+}
+
+func TestCapabilitiesStrip(t *testing.T) {
+	weight := uint64(10)
+	proxied := true
+	rec := dns.Record{Type: "A", Value: "192.0.2.1", Line: "电信", Weight: &weight, Remark: "web",
+		Cloudflare: &dns.CloudflareOptions{Proxied: &proxied}}
+
+	got, dropped := Capabilities{Comments: true}.Strip(rec)
+	if strings.Join(dropped, ",") != "line,weight,cloudflare" {
+		t.Fatalf("unexpected dropped settings: %v", dropped)
+	}
+	if got.Line != "" || got.Weight != nil || got.Cloudflare != nil || got.Remark != "web" {
+		t.Fatalf("unexpected stripped record: %+v", got)
+	}
+
+	if _, dropped := (Capabilities{Lines: true, Weights: true, Comments: true, Proxy: true}).Strip(rec); len(dropped) != 0 {
+		t.Fatalf("expected nothing dropped, got %v", dropped)
+	}
+}
@@ -0,0 +1,10 @@
+package provider
+
+import "context"
+
+// DSReader is implemented by clients whose platform signs the zone itself,
+// such as deSEC. DSRecords returns the RDATA ("<key tag> <algorithm>
+// <digest type> <digest>") of the DS records to register at the parent.
+type DSReader interface {
+	DSRecords(ctx context.Context, zone string) ([]string, error)
+}
@@ -0,0 +1,10 @@
+package provider
+
+import "context"
+
+// Finalizer is implemented by clients that need a zone-wide step once a run
+// has changed records, such as PowerDNS rectify and NOTIFY. It is called at
+// most once per run, after every change has been applied.
+type Finalizer interface {
+	Finalize(ctx context.Context, zone string) error
+}
@@ -0,0 +1,22 @@
+package provider
+
+import (
+	"context"
+
+	"ddnsjx/internal/dns"
+)
+
+// ExistingRecord is one record returned by RecordLister, with its value as
+// the provider stores it.
+type ExistingRecord struct {
+	ID    string
+	Value string
+}
+
+// RecordLister is implemented by clients that can list the records at
+// record's name and type (and line) whatever their value. FindRecord only
+// finds an exact value, so callers that move a name to a new value, such
+// as the ddns updater, use it to update in place and remove stale values.
+type RecordLister interface {
+	ListRecords(ctx context.Context, zone string, recordLine string, record dns.Record) ([]ExistingRecord, error)
+}
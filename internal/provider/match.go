@@ -0,0 +1,16 @@
+package provider
+
+import (
+	"context"
+
+	"ddnsjx/internal/dns"
+)
+
+// RecordMatcher is implemented by clients that can tell whether the
+// existing record recordID already carries every setting of record (TTL,
+// proxying, comment, ...), such as Cloudflare. Upserts skip matching
+// records instead of rewriting them; without it a found record is always
+// updated.
+type RecordMatcher interface {
+	RecordUpToDate(ctx context.Context, zone string, recordID string, record dns.Record) (bool, error)
+}
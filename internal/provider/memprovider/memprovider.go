@@ -0,0 +1,287 @@
+// Package memprovider is an in-memory provider.Client used as the reference
+// implementation in tests. It mirrors what real providers enforce: identical
+// records are reported as existing, several values may share a name and type
+// (an RRset) and all members of an RRset carry one TTL. A CNAME cannot share
+// its name with any other record.
+package memprovider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"ddnsjx/internal/dns"
+	"ddnsjx/internal/provider"
+)
+
+// Options configures a Client. A zero MinTTL accepts any TTL.
+type Options struct {
+	Zones        []string
+	MinTTL       uint64
+	MaxTTL       uint64
+	MaxTXTLength int
+}
+
+type Client struct {
+	mu     sync.Mutex
+	opt    Options
+	nextID int
+	zones  map[string]map[string]stored
+}
+
+type stored struct {
+	line   string
+	record dns.Record
+}
+
+// Error is returned for requests a real provider would reject.
+type Error struct {
+	Message string
+}
+
+func (e Error) Error() string { return e.Message }
+
+func New(opt Options) *Client {
+	c := &Client{opt: opt, zones: make(map[string]map[string]stored)}
+	for _, z := range opt.Zones {
+		c.zones[normalizeZone(z)] = make(map[string]stored)
+	}
+	return c
+}
+
+func (c *Client) Capabilities() provider.Capabilities {
+	return provider.Capabilities{
+		RecordTypes:  []string{"A", "AAAA", "CAA", "CNAME", "DS", "HTTPS", "MX", "NAPTR", "NS", "PTR", "SRV", "SSHFP", "SVCB", "TLSA", "TXT"},
+		MinTTL:       c.opt.MinTTL,
+		MaxTTL:       c.opt.MaxTTL,
+		MaxTXTLength: c.opt.MaxTXTLength,
+		Lines:        true,
+		Weights:      true,
+		Status:       true,
+		Comments:     true,
+	}
+}
+
+func (c *Client) CreateRecord(ctx context.Context, zone string, recordLine string, record dns.Record) (string, provider.CreateStatus, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	records, err := c.zone(zone)
+	if err != nil {
+		return "", provider.CreateStatusFail, err
+	}
+	record = normalize(record)
+	line := record.LineOr(recordLine)
+
+	if err := c.checkTTL(record); err != nil {
+		return "", provider.CreateStatusFail, err
+	}
+	for _, s := range records {
+		if !strings.EqualFold(s.record.SubDomain, record.SubDomain) || s.line != line {
+			continue
+		}
+		if s.record.Type == record.Type && sameData(s.record, record) {
+			return "", provider.CreateStatusExists, nil
+		}
+		if s.record.Type == "CNAME" || record.Type == "CNAME" {
+			return "", provider.CreateStatusFail, Error{Message: fmt.Sprintf("CNAME at %s cannot coexist with other records", record.SubDomain)}
+		}
+		if s.record.Type == record.Type && !sameTTL(s.record.TTL, record.TTL) {
+			return "", provider.CreateStatusFail, Error{Message: fmt.Sprintf("ttl of %s %s must match the existing RRset", record.Type, record.SubDomain)}
+		}
+	}
+
+	c.nextID++
+	id := strconv.Itoa(c.nextID)
+	records[id] = stored{line: line, record: record}
+	return id, provider.CreateStatusSuccess, nil
+}
+
+func (c *Client) DeleteRecord(ctx context.Context, zone string, recordID string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	records, err := c.zone(zone)
+	if err != nil {
+		return err
+	}
+	if _, ok := records[recordID]; !ok {
+		return Error{Message: "record not found: " + recordID}
+	}
+	delete(records, recordID)
+	return nil
+}
+
+// FindRecord returns the record with the same name, type, line and data.
+// Another value at the same name and type is a different record; callers
+// that need every value use ListRecords.
+func (c *Client) FindRecord(ctx context.Context, zone string, recordLine string, record dns.Record) (string, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	records, err := c.zone(zone)
+	if err != nil {
+		return "", false, err
+	}
+	record = normalize(record)
+	line := record.LineOr(recordLine)
+
+	for _, id := range sortedIDs(records) {
+		s := records[id]
+		if strings.EqualFold(s.record.SubDomain, record.SubDomain) && s.record.Type == record.Type && s.line == line && sameData(s.record, record) {
+			return id, true, nil
+		}
+	}
+	return "", false, nil
+}
+
+func (c *Client) UpdateRecord(ctx context.Context, zone string, recordLine string, recordID string, record dns.Record) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	records, err := c.zone(zone)
+	if err != nil {
+		return err
+	}
+	if _, ok := records[recordID]; !ok {
+		return Error{Message: "record not found: " + recordID}
+	}
+	record = normalize(record)
+	if err := c.checkTTL(record); err != nil {
+		return err
+	}
+	records[recordID] = stored{line: record.LineOr(recordLine), record: record}
+	return nil
+}
+
+// RecordOwner implements provider.OwnerReader.
+func (c *Client) RecordOwner(ctx context.Context, zone string, recordID string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	records, err := c.zone(zone)
+	if err != nil {
+		return "", err
+	}
+	s, ok := records[recordID]
+	if !ok {
+		return "", Error{Message: "record not found: " + recordID}
+	}
+	return s.record.Owner, nil
+}
+
+// ListRecords implements provider.RecordLister.
+func (c *Client) ListRecords(ctx context.Context, zone string, recordLine string, record dns.Record) ([]provider.ExistingRecord, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	records, err := c.zone(zone)
+	if err != nil {
+		return nil, err
+	}
+	record = normalize(record)
+	line := record.LineOr(recordLine)
+
+	var out []provider.ExistingRecord
+	for _, id := range sortedIDs(records) {
+		s := records[id]
+		if strings.EqualFold(s.record.SubDomain, record.SubDomain) && s.record.Type == record.Type && s.line == line {
+			out = append(out, provider.ExistingRecord{ID: id, Value: s.record.Value})
+		}
+	}
+	return out, nil
+}
+
+// Records returns a snapshot of zone sorted by record id.
+func (c *Client) Records(zone string) []dns.Record {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	records := c.zones[normalizeZone(zone)]
+	out := make([]dns.Record, 0, len(records))
+	for _, id := range sortedIDs(records) {
+		out = append(out, records[id].record)
+	}
+	return out
+}
+
+func (c *Client) zone(zone string) (map[string]stored, error) {
+	z := normalizeZone(zone)
+	records, ok := c.zones[z]
+	if !ok {
+		if len(c.opt.Zones) > 0 {
+			return nil, Error{Message: "zone not found: " + z}
+		}
+		records = make(map[string]stored)
+		c.zones[z] = records
+	}
+	return records, nil
+}
+
+func (c *Client) checkTTL(record dns.Record) error {
+	if record.TTL == nil || *record.TTL == 0 {
+		return nil
+	}
+	if c.opt.MinTTL > 0 && *record.TTL < c.opt.MinTTL {
+		return Error{Message: fmt.Sprintf("ttl %d is below minimum %d", *record.TTL, c.opt.MinTTL)}
+	}
+	if c.opt.MaxTTL > 0 && *record.TTL > c.opt.MaxTTL {
+		return Error{Message: fmt.Sprintf("ttl %d is above maximum %d", *record.TTL, c.opt.MaxTTL)}
+	}
+	return nil
+}
+
+func normalize(r dns.Record) dns.Record {
+	r.Type = strings.ToUpper(strings.TrimSpace(r.Type))
+	r.SubDomain = strings.TrimSpace(r.SubDomain)
+	if r.SubDomain == "" {
+		r.SubDomain = "@"
+	}
+	r.Value = strings.TrimSpace(r.Value)
+	return r
+}
+
+func sameData(a, b dns.Record) bool {
+	if a.Type == "TXT" {
+		if !dns.SameTXT(a.Value, b.Value) {
+			return false
+		}
+	} else if strings.TrimSuffix(a.Value, ".") != strings.TrimSuffix(b.Value, ".") {
+		return false
+	}
+	if (a.Priority == nil) != (b.Priority == nil) {
+		return false
+	}
+	return a.Priority == nil || *a.Priority == *b.Priority
+}
+
+func sameTTL(a, b *uint64) bool {
+	av, bv := uint64(0), uint64(0)
+	if a != nil {
+		av = *a
+	}
+	if b != nil {
+		bv = *b
+	}
+	return av == bv
+}
+
+func normalizeZone(z string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(z), "."))
+}
+
+func sortedIDs(records map[string]stored) []string {
+	ids := make([]string, 0, len(records))
+	for id := range records {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, _ := strconv.Atoi(ids[i])
+		b, _ := strconv.Atoi(ids[j])
+		return a < b
+	})
+	return ids
+}
@@ -0,0 +1,42 @@
+package memprovider
+
+import (
+	"context"
+	"testing"
+
+	"ddnsjx/internal/dns"
+	"ddnsjx/internal/provider"
+	"ddnsjx/internal/provider/providertest"
+)
+
+func TestConformance(t *testing.T) {
+	providertest.Run(t, func(t *testing.T) provider.Client {
+		return New(Options{MinTTL: 60})
+	}, providertest.Options{})
+}
+
+func TestRRsetRules(t *testing.T) {
+	ctx := context.Background()
+	c := New(Options{Zones: []string{"example.com."}})
+	ttl := uint64(300)
+	other := uint64(600)
+
+	if _, status, err := c.CreateRecord(ctx, "example.com", "默认", dns.Record{SubDomain: "www", Type: "A", Value: "192.0.2.1", TTL: &ttl}); err != nil || status != provider.CreateStatusSuccess {
+		t.Fatalf("create: %s %v", status, err)
+	}
+	if _, _, err := c.CreateRecord(ctx, "example.com", "默认", dns.Record{SubDomain: "www", Type: "A", Value: "192.0.2.2", TTL: &other}); err == nil {
+		t.Fatalf("expected ttl mismatch error")
+	}
+	if _, _, err := c.CreateRecord(ctx, "example.com", "默认", dns.Record{SubDomain: "www", Type: "CNAME", Value: "example.net"}); err == nil {
+		t.Fatalf("expected CNAME conflict error")
+	}
+	if _, status, err := c.CreateRecord(ctx, "example.com", "电信", dns.Record{SubDomain: "www", Type: "A", Value: "192.0.2.1", TTL: &other}); err != nil || status != provider.CreateStatusSuccess {
+		t.Fatalf("create on another line: %s %v", status, err)
+	}
+	if _, _, err := c.CreateRecord(ctx, "example.org", "默认", dns.Record{SubDomain: "www", Type: "A", Value: "192.0.2.1"}); err == nil {
+		t.Fatalf("expected unknown zone error")
+	}
+	if got := len(c.Records("example.com")); got != 2 {
+		t.Fatalf("expected 2 records, got %d", got)
+	}
+}
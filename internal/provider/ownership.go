@@ -0,0 +1,57 @@
+package provider
+
+import (
+	"context"
+	"strings"
+)
+
+// OwnerReader is implemented by clients that store dns.Record.Owner on the
+// record itself (DNSPod remark, Cloudflare comment). Clients without it are
+// tracked through companion TXT registry records instead.
+type OwnerReader interface {
+	RecordOwner(ctx context.Context, zone string, recordID string) (owner string, err error)
+}
+
+const ownerMarkerPrefix = "[stalwart-dns:owner="
+
+// WithOwnerMarker appends the ownership marker for owner to a free-text
+// field. An empty owner leaves text unchanged.
+func WithOwnerMarker(text, owner string) string {
+	text = StripOwnerMarker(text)
+	owner = strings.TrimSpace(owner)
+	if owner == "" {
+		return text
+	}
+	marker := ownerMarkerPrefix + owner + "]"
+	if text == "" {
+		return marker
+	}
+	return text + " " + marker
+}
+
+// ParseOwnerMarker extracts the owner written by WithOwnerMarker.
+func ParseOwnerMarker(text string) (string, bool) {
+	i := strings.Index(text, ownerMarkerPrefix)
+	if i < 0 {
+		return "", false
+	}
+	rest := text[i+len(ownerMarkerPrefix):]
+	end := strings.IndexByte(rest, ']')
+	if end < 0 {
+		return "", false
+	}
+	return rest[:end], true
+}
+
+func StripOwnerMarker(text string) string {
+	i := strings.Index(text, ownerMarkerPrefix)
+	if i < 0 {
+		return strings.TrimSpace(text)
+	}
+	rest := text[i:]
+	end := strings.IndexByte(rest, ']')
+	if end < 0 {
+		return strings.TrimSpace(text)
+	}
+	return strings.TrimSpace(text[:i] + rest[end+1:])
+}
@@ -17,8 +17,9 @@ const (
 type Client interface {
 	CreateRecord(ctx context.Context, zone string, recordLine string, record dns.Record) (recordID string, status CreateStatus, err error)
 	DeleteRecord(ctx context.Context, zone string, recordID string) error
+	// FindRecord matches name, type, line and value; another value at the
+	// same name and type is not found. See RecordLister.
 	FindRecord(ctx context.Context, zone string, recordLine string, record dns.Record) (recordID string, found bool, err error)
 	UpdateRecord(ctx context.Context, zone string, recordLine string, recordID string, record dns.Record) error
-	IsSupportedRecordType(t string) bool
+	Capabilities() Capabilities
 }
-
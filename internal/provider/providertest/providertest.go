@@ -0,0 +1,188 @@
+// Package providertest is a conformance suite for provider.Client
+// implementations. Each client runs it against a stand-in of its API (an
+// httptest server or an in-memory fake) so every supported record type is
+// created, found, updated and deleted the same way the runner uses them.
+package providertest
+
+import (
+	"context"
+	"testing"
+
+	"ddnsjx/internal/dns"
+	"ddnsjx/internal/provider"
+)
+
+type Options struct {
+	// Zone defaults to "example.com".
+	Zone string
+	// RecordLine defaults to "默认".
+	RecordLine string
+	// Types limits the suite to these record types; empty runs every type
+	// the client reports in Capabilities.
+	Types []string
+	// ContentIDs is set for providers whose record ids are derived from the
+	// record data (Route 53), so an update changes the id.
+	ContentIDs bool
+}
+
+// sample holds three distinct values for one record type. The first two
+// build an RRset, the third replaces the first on update.
+type sample struct {
+	sub      string
+	values   [3]string
+	priority *uint64
+}
+
+var samples = map[string]sample{
+	"A":     {sub: "conf-a", values: [3]string{"192.0.2.1", "192.0.2.2", "192.0.2.3"}},
+	"AAAA":  {sub: "conf-aaaa", values: [3]string{"2001:db8::1", "2001:db8::2", "2001:db8::3"}},
+	"CNAME": {sub: "conf-cname", values: [3]string{"one.example.net", "two.example.net", "three.example.net"}},
+	"MX":    {sub: "conf-mx", values: [3]string{"mx1.example.net", "mx2.example.net", "mx3.example.net"}, priority: uint64Ptr(10)},
+	"TXT":   {sub: "conf-txt", values: [3]string{"v=conf1", "v=conf2", "v=conf3"}},
+	"SRV":   {sub: "_conf._tcp", values: [3]string{"10 5 443 one.example.net.", "10 5 443 two.example.net.", "20 5 8443 three.example.net."}},
+	"NS":    {sub: "conf-ns", values: [3]string{"ns1.example.net", "ns2.example.net", "ns3.example.net"}},
+	"CAA":   {sub: "conf-caa", values: [3]string{`0 issue "letsencrypt.org"`, `0 issue "pki.goog"`, `0 iodef "mailto:ca@example.com"`}},
+	"PTR":   {sub: "conf-ptr", values: [3]string{"host1.example.net", "host2.example.net", "host3.example.net"}},
+	"NAPTR": {sub: "conf-naptr", values: [3]string{`100 10 "S" "SIP+D2U" "" _sip._udp.example.com.`, `100 20 "S" "SIP+D2T" "" _sip._tcp.example.com.`, `200 10 "S" "SIPS+D2T" "" _sips._tcp.example.com.`}},
+	"TLSA":  {sub: "_443._tcp.conf", values: [3]string{"3 1 1 aa11", "3 1 1 bb22", "2 0 1 cc33"}},
+	"SSHFP": {sub: "conf-sshfp", values: [3]string{"1 1 aa11", "4 2 bb22", "3 2 cc33"}},
+	"DS":    {sub: "conf-ds", values: [3]string{"12345 13 2 aa11", "12345 13 2 bb22", "23456 8 2 cc33"}},
+	"HTTPS": {sub: "conf-https", values: [3]string{`1 . alpn="h2"`, `2 . alpn="h3"`, `1 . alpn="h2,h3"`}},
+	"SVCB":  {sub: "_conf.svcb", values: [3]string{"1 one.example.net.", "2 two.example.net.", "1 three.example.net."}},
+}
+
+// Run executes the suite. newClient is called once per subtest and must
+// return a client backed by an empty zone.
+func Run(t *testing.T, newClient func(t *testing.T) provider.Client, opt Options) {
+	t.Helper()
+	if opt.Zone == "" {
+		opt.Zone = "example.com"
+	}
+	if opt.RecordLine == "" {
+		opt.RecordLine = "默认"
+	}
+
+	types := opt.Types
+	if len(types) == 0 {
+		types = newClient(t).Capabilities().RecordTypes
+	}
+	for _, typ := range types {
+		t.Run(typ, func(t *testing.T) {
+			s, ok := samples[typ]
+			if !ok {
+				t.Fatalf("providertest has no sample values for %s; add them to samples", typ)
+			}
+			runType(t, newClient(t), opt, typ, s)
+		})
+	}
+}
+
+func runType(t *testing.T, c provider.Client, opt Options, typ string, s sample) {
+	ctx := context.Background()
+	caps := c.Capabilities()
+	if !caps.SupportsType(typ) {
+		t.Fatalf("Capabilities does not list %s", typ)
+	}
+
+	ttl := caps.MinTTL
+	if ttl == 0 {
+		ttl = 300
+	}
+	rec := func(i int) dns.Record {
+		return dns.Record{SubDomain: s.sub, Type: typ, Value: s.values[i], Priority: s.priority, TTL: &ttl}
+	}
+
+	if _, found, err := c.FindRecord(ctx, opt.Zone, opt.RecordLine, rec(0)); err != nil || found {
+		t.Fatalf("find in empty zone: found=%v err=%v", found, err)
+	}
+
+	id1, status, err := c.CreateRecord(ctx, opt.Zone, opt.RecordLine, rec(0))
+	if err != nil || status != provider.CreateStatusSuccess || id1 == "" {
+		t.Fatalf("create: id=%q status=%s err=%v", id1, status, err)
+	}
+
+	_, status, err = c.CreateRecord(ctx, opt.Zone, opt.RecordLine, rec(0))
+	if err != nil || status != provider.CreateStatusExists {
+		t.Fatalf("create duplicate: status=%s err=%v", status, err)
+	}
+
+	expectFound(t, c, opt, rec(0), id1, "after create")
+	// FindRecord matches the value too: another value at the same name
+	// and type is a different record, even when it is the only one.
+	if id, found, err := c.FindRecord(ctx, opt.Zone, opt.RecordLine, rec(1)); err != nil || found {
+		t.Fatalf("find other value %q: id=%q found=%v err=%v", rec(1).Value, id, found, err)
+	}
+
+	if err := c.UpdateRecord(ctx, opt.Zone, opt.RecordLine, id1, rec(2)); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if opt.ContentIDs {
+		if id1, _, err = c.FindRecord(ctx, opt.Zone, opt.RecordLine, rec(2)); err != nil || id1 == "" {
+			t.Fatalf("find after update: id=%q err=%v", id1, err)
+		}
+	}
+	expectFound(t, c, opt, rec(2), id1, "after update")
+
+	ids := []string{id1}
+	if typ != "CNAME" {
+		id2, status, err := c.CreateRecord(ctx, opt.Zone, opt.RecordLine, rec(1))
+		if err != nil || status != provider.CreateStatusSuccess {
+			t.Fatalf("create second value: status=%s err=%v", status, err)
+		}
+		if id2 == id1 {
+			t.Fatalf("second value reused id %s", id1)
+		}
+		expectFound(t, c, opt, rec(1), id2, "second value")
+		expectFound(t, c, opt, rec(2), id1, "first value with second present")
+		ids = append(ids, id2)
+	}
+
+	expectListed(t, c, opt, rec(0), ids, "before delete")
+
+	for _, id := range ids {
+		if err := c.DeleteRecord(ctx, opt.Zone, id); err != nil {
+			t.Fatalf("delete %s: %v", id, err)
+		}
+	}
+	for _, r := range []dns.Record{rec(1), rec(2)} {
+		if _, found, err := c.FindRecord(ctx, opt.Zone, opt.RecordLine, r); err != nil || found {
+			t.Fatalf("find after delete %q: found=%v err=%v", r.Value, found, err)
+		}
+	}
+}
+
+func expectFound(t *testing.T, c provider.Client, opt Options, r dns.Record, want string, step string) {
+	t.Helper()
+	id, found, err := c.FindRecord(context.Background(), opt.Zone, opt.RecordLine, r)
+	if err != nil || !found || id != want {
+		t.Fatalf("find %s %q: id=%q found=%v err=%v, want id %q", step, r.Value, id, found, err, want)
+	}
+}
+
+// expectListed checks that a RecordLister returns exactly want at r's name
+// and type, whatever r's value.
+func expectListed(t *testing.T, c provider.Client, opt Options, r dns.Record, want []string, step string) {
+	t.Helper()
+	l, ok := provider.As[provider.RecordLister](c)
+	if !ok {
+		return
+	}
+	got, err := l.ListRecords(context.Background(), opt.Zone, opt.RecordLine, r)
+	if err != nil {
+		t.Fatalf("list %s: %v", step, err)
+	}
+	ids := make(map[string]bool, len(got))
+	for _, e := range got {
+		ids[e.ID] = true
+	}
+	for _, id := range want {
+		if !ids[id] {
+			t.Fatalf("list %s: got %+v, want ids %v", step, got, want)
+		}
+	}
+	if len(got) != len(want) {
+		t.Fatalf("list %s: got %+v, want ids %v", step, got, want)
+	}
+}
+
+func uint64Ptr(v uint64) *uint64 { return &v }
@@ -0,0 +1,172 @@
+package provider
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Setting describes one credential or option a provider accepts. Values
+// come from the CLI flag, then the environment variable, then Default.
+// Secret values are masked by Factory.Redact and should come from the
+// environment, since flags show up in the process list.
+type Setting struct {
+	Name     string
+	Flag     string
+	Env      string
+	Default  string
+	Help     string
+	Required bool
+	Secret   bool
+}
+
+// Settings holds resolved values keyed by Setting.Name.
+type Settings map[string]string
+
+func (s Settings) Get(name string) string {
+	return strings.TrimSpace(s[name])
+}
+
+func (s Settings) Uint64(name string) (uint64, error) {
+	v := s.Get(name)
+	if v == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", name, v, err)
+	}
+	return n, nil
+}
+
+func (s Settings) Bool(name string) (bool, error) {
+	v := s.Get(name)
+	if v == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s %q: %w", name, v, err)
+	}
+	return b, nil
+}
+
+// Factory constructs a provider client for zone from resolved settings.
+// A non-nil transport replaces the client's HTTP transport (see the
+// cassette package).
+type Factory struct {
+	Name     string
+	Help     string
+	Settings []Setting
+	New      func(zone string, s Settings, transport http.RoundTripper) (Client, error)
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Factory)
+)
+
+// Register makes a provider available by name. Client packages call it
+// from init; registering the same name twice panics.
+func Register(f Factory) {
+	name := strings.ToLower(strings.TrimSpace(f.Name))
+	if name == "" || f.New == nil {
+		panic("provider: Register requires a name and a constructor")
+	}
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, dup := registry[name]; dup {
+		panic("provider: Register called twice for " + name)
+	}
+	f.Name = name
+	registry[name] = f
+}
+
+func Lookup(name string) (Factory, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	f, ok := registry[strings.ToLower(strings.TrimSpace(name))]
+	return f, ok
+}
+
+// Factories returns every registered provider sorted by name.
+func Factories() []Factory {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	out := make([]Factory, 0, len(registry))
+	for _, f := range registry {
+		out = append(out, f)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Names returns the registered provider names sorted.
+func Names() []string {
+	var names []string
+	for _, f := range Factories() {
+		names = append(names, f.Name)
+	}
+	return names
+}
+
+// ResolveSettings fills every setting of f from flags (keyed by flag name),
+// then getenv, then defaults, and reports all missing required values at
+// once.
+func (f Factory) ResolveSettings(flags map[string]string, getenv func(string) string) (Settings, error) {
+	out := make(Settings, len(f.Settings))
+	var missing []string
+	for _, s := range f.Settings {
+		v := strings.TrimSpace(flags[s.Flag])
+		if v == "" && s.Env != "" && getenv != nil {
+			v = strings.TrimSpace(getenv(s.Env))
+		}
+		if v == "" {
+			v = s.Default
+		}
+		if v == "" && s.Required {
+			missing = append(missing, describeSource(s))
+		}
+		out[s.Name] = v
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing credentials for %s: set %s", f.Name, strings.Join(missing, ", "))
+	}
+	return out, nil
+}
+
+func describeSource(s Setting) string {
+	switch {
+	case s.Env != "" && s.Flag != "":
+		return fmt.Sprintf("%s (or --%s)", s.Env, s.Flag)
+	case s.Env != "":
+		return s.Env
+	default:
+		return "--" + s.Flag
+	}
+}
+
+// SecretFlags returns the flags of secret settings that are set in flags.
+func (f Factory) SecretFlags(flags map[string]string) []string {
+	var out []string
+	for _, s := range f.Settings {
+		if s.Secret && s.Flag != "" && strings.TrimSpace(flags[s.Flag]) != "" {
+			out = append(out, s.Flag)
+		}
+	}
+	return out
+}
+
+// Redact replaces the values of secret settings in text with "***", for
+// error messages that may echo a credential.
+func (f Factory) Redact(s Settings, text string) string {
+	for _, st := range f.Settings {
+		if v := s.Get(st.Name); st.Secret && v != "" {
+			text = strings.ReplaceAll(text, v, "***")
+		}
+	}
+	return text
+}
@@ -0,0 +1,56 @@
+package provider
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFactoryResolveSettings(t *testing.T) {
+	f := Factory{
+		Name: "example",
+		Settings: []Setting{
+			{Name: "token", Flag: "ex-token", Env: "EX_TOKEN", Required: true},
+			{Name: "secret", Flag: "ex-secret", Env: "EX_SECRET", Required: true, Secret: true},
+			{Name: "region", Flag: "ex-region", Default: "eu"},
+		},
+	}
+	env := map[string]string{"EX_TOKEN": "from-env"}
+
+	_, err := f.ResolveSettings(nil, func(k string) string { return env[k] })
+	if err == nil || !strings.Contains(err.Error(), "EX_SECRET (or --ex-secret)") || strings.Contains(err.Error(), "EX_TOKEN") {
+		t.Fatalf("expected only the secret to be reported missing, got %v", err)
+	}
+
+	s, err := f.ResolveSettings(map[string]string{"ex-secret": "s", "ex-token": "from-flag"}, func(k string) string { return env[k] })
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if s.Get("token") != "from-flag" || s.Get("secret") != "s" || s.Get("region") != "eu" {
+		t.Fatalf("unexpected settings: %v", s)
+	}
+}
+
+func TestFactorySecrets(t *testing.T) {
+	f := Factory{
+		Name: "example",
+		Settings: []Setting{
+			{Name: "id", Flag: "ex-id"},
+			{Name: "secret", Flag: "ex-secret", Env: "EX_SECRET", Secret: true},
+		},
+	}
+	flags := map[string]string{"ex-id": "AKID", "ex-secret": "hunter2"}
+	if got := f.SecretFlags(flags); len(got) != 1 || got[0] != "ex-secret" {
+		t.Fatalf("unexpected secret flags: %v", got)
+	}
+	if got := f.SecretFlags(map[string]string{"ex-id": "AKID"}); len(got) != 0 {
+		t.Fatalf("expected no secret flags, got %v", got)
+	}
+
+	s, err := f.ResolveSettings(flags, nil)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if got := f.Redact(s, "bad key AKID:hunter2"); got != "bad key AKID:***" {
+		t.Fatalf("unexpected redaction: %q", got)
+	}
+}
@@ -0,0 +1,28 @@
+package provider
+
+// Wrapper is implemented by clients that decorate another client, such as
+// the telemetry wrapper.
+type Wrapper interface {
+	Unwrap() Client
+}
+
+// As finds the optional interface T (Batcher, OwnerReader, Finalizer,
+// DSReader, ...) on c. Use it instead of a type assertion: a wrapper may
+// implement every optional interface and forward the calls, so it only
+// counts when the client it wraps has T too. The outermost implementation
+// is returned, so calls still go through the wrappers.
+func As[T any](c Client) (T, bool) {
+	w, ok := c.(Wrapper)
+	if !ok {
+		t, ok := c.(T)
+		return t, ok
+	}
+	inner, ok := As[T](w.Unwrap())
+	if !ok {
+		return inner, false
+	}
+	if t, ok := c.(T); ok {
+		return t, true
+	}
+	return inner, true
+}
@@ -0,0 +1,181 @@
+// Package restclient is the shared plumbing of the token-based JSON REST
+// providers: base URL handling, authentication, JSON encoding, error
+// mapping and pagination. Each provider keeps its own envelope and error
+// types and plugs them in through Options.
+package restclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultTimeout = 20 * time.Second
+
+// maxPages bounds Paginate so a server that always reports another page
+// cannot loop forever.
+const maxPages = 1000
+
+type Options struct {
+	// BaseURL is the API root every request path is appended to.
+	BaseURL string
+	// Auth adds credentials to each request, see BearerToken and Header.
+	// body is the encoded request body, for schemes that sign it.
+	Auth func(req *http.Request, body []byte)
+	// DecodeError turns a non-2xx response into an error. Nil reports a
+	// StatusError with the raw body.
+	DecodeError func(status int, header http.Header, body []byte) error
+	Timeout     time.Duration
+	// Transport replaces the HTTP transport, e.g. with a cassette.
+	Transport http.RoundTripper
+}
+
+type Client struct {
+	baseURL     string
+	auth        func(*http.Request, []byte)
+	decodeError func(int, http.Header, []byte) error
+	http        *http.Client
+}
+
+// StatusError is the fallback error for responses DecodeError does not
+// recognise.
+type StatusError struct {
+	Status  int
+	Message string
+}
+
+func (e StatusError) Error() string {
+	return fmt.Sprintf("[%d] %s", e.Status, e.Message)
+}
+
+// ErrorCode returns the HTTP status for metrics.
+func (e StatusError) ErrorCode() string {
+	return strconv.Itoa(e.Status)
+}
+
+func (e StatusError) Retryable() bool {
+	return RetryableStatus(e.Status)
+}
+
+// RetryableStatus reports whether an HTTP status is worth retrying: rate
+// limiting and server errors.
+func RetryableStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+// RetryAfter parses the Retry-After header of a throttled response, given
+// either in seconds or as an HTTP date. It returns 0 when the header is
+// missing or invalid.
+func RetryAfter(h http.Header) time.Duration {
+	v := strings.TrimSpace(h.Get("Retry-After"))
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+func New(opt Options) *Client {
+	timeout := opt.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	return &Client{
+		baseURL:     strings.TrimRight(strings.TrimSpace(opt.BaseURL), "/"),
+		auth:        opt.Auth,
+		decodeError: opt.DecodeError,
+		http:        &http.Client{Timeout: timeout, Transport: opt.Transport},
+	}
+}
+
+// BearerToken authenticates with "Authorization: Bearer <token>".
+func BearerToken(token string) func(*http.Request, []byte) {
+	return Header("Authorization", "Bearer "+token)
+}
+
+// Header authenticates with a fixed header, e.g. X-API-Key.
+func Header(name, value string) func(*http.Request, []byte) {
+	return func(req *http.Request, _ []byte) {
+		req.Header.Set(name, value)
+	}
+}
+
+// Do sends body as JSON to method path and decodes a 2xx response into
+// out. Empty responses (204) leave out untouched. The response headers are
+// returned for callers that paginate or read rate limits.
+func (c *Client) Do(ctx context.Context, method, path string, body any, out any) (http.Header, error) {
+	var payload []byte
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		payload = b
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.auth != nil {
+		c.auth(req, payload)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.Header, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		if c.decodeError != nil {
+			if err := c.decodeError(resp.StatusCode, resp.Header, b); err != nil {
+				return resp.Header, err
+			}
+		}
+		return resp.Header, StatusError{Status: resp.StatusCode, Message: string(bytes.TrimSpace(b))}
+	}
+	if out == nil || len(bytes.TrimSpace(b)) == 0 {
+		return resp.Header, nil
+	}
+	return resp.Header, json.Unmarshal(b, out)
+}
+
+// Paginate calls fetch with page numbers starting at 1 until it reports no
+// further page.
+func Paginate(ctx context.Context, fetch func(page int) (more bool, err error)) error {
+	for page := 1; page <= maxPages; page++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		more, err := fetch(page)
+		if err != nil || !more {
+			return err
+		}
+	}
+	return fmt.Errorf("restclient: more than %d pages", maxPages)
+}
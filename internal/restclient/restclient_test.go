@@ -0,0 +1,108 @@
+package restclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type apiError struct{ Code string }
+
+func (e apiError) Error() string { return e.Code }
+
+func TestDo(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"code":"unauthorized"}`))
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/echo":
+			var in map[string]string
+			_ = json.NewDecoder(r.Body).Decode(&in)
+			w.Header().Set("X-Total", "1")
+			_ = json.NewEncoder(w).Encode(map[string]string{"got": in["name"], "type": r.Header.Get("Content-Type")})
+		case "/v1/empty":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusBadGateway)
+			_, _ = w.Write([]byte("upstream down\n"))
+		}
+	}))
+	defer srv.Close()
+
+	decode := func(status int, _ http.Header, body []byte) error {
+		var e apiError
+		if json.Unmarshal(body, &e) == nil && e.Code != "" {
+			return e
+		}
+		return nil
+	}
+	c := New(Options{BaseURL: srv.URL + "/v1/", Auth: BearerToken("tok"), DecodeError: decode})
+	ctx := context.Background()
+
+	var out map[string]string
+	h, err := c.Do(ctx, "POST", "/echo", map[string]string{"name": "www"}, &out)
+	if err != nil || out["got"] != "www" || out["type"] != "application/json" || h.Get("X-Total") != "1" {
+		t.Fatalf("unexpected echo result %v %v %v", out, h, err)
+	}
+	if _, err := c.Do(ctx, "DELETE", "/empty", nil, &out); err != nil {
+		t.Fatalf("204 should not fail: %v", err)
+	}
+
+	var se StatusError
+	if _, err := c.Do(ctx, "GET", "/broken", nil, nil); !errors.As(err, &se) || se.Status != 502 || se.Message != "upstream down" || !se.Retryable() {
+		t.Fatalf("expected retryable StatusError, got %#v", err)
+	}
+
+	var ae apiError
+	bad := New(Options{BaseURL: srv.URL + "/v1", Auth: BearerToken("nope"), DecodeError: decode})
+	if _, err := bad.Do(ctx, "GET", "/echo", nil, nil); !errors.As(err, &ae) || ae.Code != "unauthorized" {
+		t.Fatalf("expected decoded apiError, got %#v", err)
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	var pages []int
+	err := Paginate(context.Background(), func(page int) (bool, error) {
+		pages = append(pages, page)
+		return page < 3, nil
+	})
+	if err != nil || len(pages) != 3 || pages[2] != 3 {
+		t.Fatalf("unexpected pages %v %v", pages, err)
+	}
+	if err := Paginate(context.Background(), func(int) (bool, error) { return true, nil }); err == nil {
+		t.Fatalf("expected runaway pagination to stop")
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	for _, tc := range []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"7", 7 * time.Second},
+		{"-1", 0},
+		{"soon", 0},
+		{time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat), 0},
+	} {
+		h := http.Header{}
+		if tc.value != "" {
+			h.Set("Retry-After", tc.value)
+		}
+		if got := RetryAfter(h); got != tc.want {
+			t.Errorf("RetryAfter(%q) = %v, want %v", tc.value, got, tc.want)
+		}
+	}
+
+	h := http.Header{"Retry-After": {time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)}}
+	if got := RetryAfter(h); got < 58*time.Minute || got > time.Hour {
+		t.Errorf("RetryAfter(date) = %v, want about an hour", got)
+	}
+}
@@ -0,0 +1,61 @@
+package reverse
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+)
+
+// Resolver is the subset of *net.Resolver used by Check.
+type Resolver interface {
+	LookupAddr(ctx context.Context, addr string) ([]string, error)
+	LookupNetIP(ctx context.Context, network, host string) ([]netip.Addr, error)
+}
+
+// Check resolves every mapping in both directions and returns one problem
+// per disagreement: the PTR must name the host (and only it), and the host
+// must resolve back to the address (forward-confirmed reverse DNS).
+func Check(ctx context.Context, r Resolver, mappings []Mapping) []string {
+	var problems []string
+	for _, m := range mappings {
+		ip := m.IP.Unmap()
+		host := normalizeHost(m.Host)
+
+		names, err := r.LookupAddr(ctx, ip.String())
+		switch {
+		case err != nil:
+			problems = append(problems, fmt.Sprintf("%s: PTR lookup failed: %v", ip, err))
+		case len(names) != 1 || normalizeHost(names[0]) != host:
+			problems = append(problems, fmt.Sprintf("%s: PTR is %v, expected %s", ip, trimDots(names), host))
+		}
+
+		network := "ip6"
+		if ip.Is4() {
+			network = "ip4"
+		}
+		addrs, err := r.LookupNetIP(ctx, network, host)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: forward lookup failed: %v", host, err))
+			continue
+		}
+		found := false
+		for _, a := range addrs {
+			if a.Unmap() == ip {
+				found = true
+				break
+			}
+		}
+		if !found {
+			problems = append(problems, fmt.Sprintf("%s: resolves to %v, not %s", host, addrs, ip))
+		}
+	}
+	return problems
+}
+
+func trimDots(names []string) []string {
+	out := make([]string, len(names))
+	for i, n := range names {
+		out[i] = normalizeHost(n)
+	}
+	return out
+}
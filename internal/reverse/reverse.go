@@ -0,0 +1,205 @@
+// Package reverse derives PTR records for forward A/AAAA records and checks
+// that forward and reverse DNS agree.
+package reverse
+
+import (
+	"fmt"
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+
+	"ddnsjx/internal/dns"
+)
+
+// Mapping pairs an address with the host name its PTR should point at.
+type Mapping struct {
+	IP   netip.Addr
+	Host string
+}
+
+// Name returns the in-addr.arpa or ip6.arpa name of ip, without a trailing
+// dot.
+func Name(ip netip.Addr) string {
+	ip = ip.Unmap()
+	if ip.Is4() {
+		b := ip.As4()
+		return fmt.Sprintf("%d.%d.%d.%d.in-addr.arpa", b[3], b[2], b[1], b[0])
+	}
+	b := ip.As16()
+	labels := make([]string, 0, 34)
+	for i := len(b) - 1; i >= 0; i-- {
+		labels = append(labels, strconv.FormatUint(uint64(b[i]&0x0f), 16), strconv.FormatUint(uint64(b[i]>>4), 16))
+	}
+	return strings.Join(append(labels, "ip6", "arpa"), ".")
+}
+
+// Zone returns the reverse zone delegated for prefix. IPv4 prefixes up to
+// /24 must fall on an octet boundary; longer ones use RFC 2317 classless
+// names such as "64/26.2.0.192.in-addr.arpa". IPv6 prefixes must fall on a
+// nibble boundary.
+func Zone(prefix netip.Prefix) (string, error) {
+	if !prefix.IsValid() {
+		return "", fmt.Errorf("invalid prefix")
+	}
+	prefix = prefix.Masked()
+	bits := prefix.Bits()
+	name := Name(prefix.Addr())
+	if prefix.Addr().Is4() {
+		switch {
+		case bits == 32:
+			return "", fmt.Errorf("prefix %s is a single address; use /24 or a classless prefix (/25-/31)", prefix)
+		case bits > 24:
+			b := prefix.Addr().As4()
+			return fmt.Sprintf("%d/%d.%s", b[3], bits, parentLabels(name, 1)), nil
+		case bits%8 != 0:
+			return "", fmt.Errorf("prefix %s: IPv4 reverse zones need /8, /16, /24 or a classless /25-/31", prefix)
+		}
+		return parentLabels(name, 4-bits/8), nil
+	}
+	if bits%4 != 0 || bits == 128 {
+		return "", fmt.Errorf("prefix %s: IPv6 reverse zones need a nibble-aligned prefix shorter than /128", prefix)
+	}
+	return parentLabels(name, 32-bits/4), nil
+}
+
+// parentLabels drops the first n labels of name.
+func parentLabels(name string, n int) string {
+	labels := strings.Split(name, ".")
+	return strings.Join(labels[n:], ".")
+}
+
+// Owner returns the PTR owner name of ip inside the zone for prefix. For
+// classless zones this is "<last octet>.<zone>", the target of the CNAME
+// the parent /24 zone publishes (see Delegation).
+func Owner(ip netip.Addr, prefix netip.Prefix) (string, error) {
+	ip = ip.Unmap()
+	if !prefix.Contains(ip) {
+		return "", fmt.Errorf("%s is not in %s", ip, prefix)
+	}
+	zone, err := Zone(prefix)
+	if err != nil {
+		return "", err
+	}
+	if ip.Is4() && prefix.Bits() > 24 {
+		return fmt.Sprintf("%d.%s", ip.As4()[3], zone), nil
+	}
+	return Name(ip), nil
+}
+
+// Delegation returns the RFC 2317 CNAME records the parent /24 zone must
+// publish so that lookups for ips reach the classless zone for prefix.
+// Non-classless prefixes need none.
+func Delegation(prefix netip.Prefix, ips []netip.Addr) ([]dns.Record, error) {
+	if !prefix.Addr().Is4() || prefix.Bits() <= 24 {
+		return nil, nil
+	}
+	var out []dns.Record
+	for _, ip := range ips {
+		owner, err := Owner(ip, prefix)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, dns.Record{
+			SubDomain: strconv.Itoa(int(ip.Unmap().As4()[3])),
+			Type:      "CNAME",
+			Value:     owner,
+		})
+	}
+	return out, nil
+}
+
+// FromPlan collects the addresses of the plan's A and AAAA records. When
+// hosts is non-empty only records for those names (FQDNs) are used.
+func FromPlan(plan dns.Plan, hosts ...string) ([]Mapping, error) {
+	want := make(map[string]bool, len(hosts))
+	for _, h := range hosts {
+		want[normalizeHost(h)] = true
+	}
+	var out []Mapping
+	for _, r := range plan.Records {
+		t := strings.ToUpper(r.Type)
+		if t != "A" && t != "AAAA" {
+			continue
+		}
+		host := normalizeHost(plan.Domain)
+		if r.SubDomain != "@" {
+			host = normalizeHost(r.SubDomain + "." + plan.Domain)
+		}
+		if len(want) > 0 && !want[host] {
+			continue
+		}
+		ip, err := netip.ParseAddr(strings.TrimSpace(r.Value))
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: invalid address %q", t, host, r.Value)
+		}
+		out = append(out, Mapping{IP: ip.Unmap(), Host: host})
+	}
+	return out, nil
+}
+
+// Group assigns every mapping to the reverse zone of the first prefix that
+// contains it, falling back to the /24 (IPv4) or /48 (IPv6) around the
+// address. An address mapped to two different hosts is an error, since a
+// PTR should name exactly one host.
+func Group(mappings []Mapping, prefixes []netip.Prefix) (map[netip.Prefix][]Mapping, error) {
+	seen := make(map[netip.Addr]string, len(mappings))
+	out := make(map[netip.Prefix][]Mapping)
+	for _, m := range mappings {
+		m.IP = m.IP.Unmap()
+		m.Host = normalizeHost(m.Host)
+		if prev, ok := seen[m.IP]; ok {
+			if prev != m.Host {
+				return nil, fmt.Errorf("%s is used by both %s and %s; select one with --host", m.IP, prev, m.Host)
+			}
+			continue
+		}
+		seen[m.IP] = m.Host
+
+		var zone netip.Prefix
+		for _, p := range prefixes {
+			if p.Masked().Contains(m.IP) {
+				zone = p.Masked()
+				break
+			}
+		}
+		if !zone.IsValid() {
+			bits := 48
+			if m.IP.Is4() {
+				bits = 24
+			}
+			zone, _ = m.IP.Prefix(bits)
+		}
+		out[zone] = append(out[zone], m)
+	}
+	return out, nil
+}
+
+// BuildPlan plans one PTR record per mapping in the reverse zone for prefix.
+func BuildPlan(prefix netip.Prefix, mappings []Mapping, ttl *uint64) (dns.Plan, error) {
+	zone, err := Zone(prefix)
+	if err != nil {
+		return dns.Plan{}, err
+	}
+	sorted := append([]Mapping(nil), mappings...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].IP.Less(sorted[j].IP) })
+
+	plan := dns.Plan{Domain: zone}
+	for _, m := range sorted {
+		owner, err := Owner(m.IP, prefix)
+		if err != nil {
+			return dns.Plan{}, err
+		}
+		plan.Records = append(plan.Records, dns.Record{
+			SubDomain: strings.TrimSuffix(owner, "."+zone),
+			Type:      "PTR",
+			Value:     normalizeHost(m.Host),
+			TTL:       ttl,
+		})
+	}
+	return plan, nil
+}
+
+func normalizeHost(h string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(h), "."))
+}
@@ -0,0 +1,154 @@
+package reverse
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"ddnsjx/internal/dns"
+)
+
+func TestZoneAndOwner(t *testing.T) {
+	cases := []struct {
+		ip, prefix, zone, owner string
+	}{
+		{"192.0.2.25", "192.0.2.0/24", "2.0.192.in-addr.arpa", "25.2.0.192.in-addr.arpa"},
+		{"10.1.2.3", "10.1.0.0/16", "1.10.in-addr.arpa", "3.2.1.10.in-addr.arpa"},
+		{"192.0.2.70", "192.0.2.64/26", "64/26.2.0.192.in-addr.arpa", "70.64/26.2.0.192.in-addr.arpa"},
+		{"2001:db8::25", "2001:db8::/48", "0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa",
+			"5.2.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa"},
+	}
+	for _, c := range cases {
+		prefix := netip.MustParsePrefix(c.prefix)
+		zone, err := Zone(prefix)
+		if err != nil {
+			t.Fatalf("%s: %v", c.prefix, err)
+		}
+		if zone != c.zone {
+			t.Fatalf("%s: expected zone %q, got %q", c.prefix, c.zone, zone)
+		}
+		owner, err := Owner(netip.MustParseAddr(c.ip), prefix)
+		if err != nil {
+			t.Fatalf("%s: %v", c.ip, err)
+		}
+		if owner != c.owner {
+			t.Fatalf("%s: expected owner %q, got %q", c.ip, c.owner, owner)
+		}
+	}
+
+	for _, p := range []string{"192.0.2.0/20", "192.0.2.1/32", "2001:db8::/47"} {
+		if _, err := Zone(netip.MustParsePrefix(p)); err == nil {
+			t.Fatalf("%s: expected an error", p)
+		}
+	}
+	if _, err := Owner(netip.MustParseAddr("192.0.2.1"), netip.MustParsePrefix("192.0.2.64/26")); err == nil {
+		t.Fatalf("expected an error for an address outside the prefix")
+	}
+}
+
+func TestBuildPlanFromForwardPlan(t *testing.T) {
+	forward := dns.Plan{Domain: "example.com", Records: []dns.Record{
+		{SubDomain: "mail", Type: "A", Value: "192.0.2.70"},
+		{SubDomain: "mail", Type: "AAAA", Value: "2001:db8::25"},
+		{SubDomain: "www", Type: "A", Value: "198.51.100.7"},
+		{SubDomain: "@", Type: "MX", Value: "mail.example.com"},
+	}}
+	mappings, err := FromPlan(forward, "mail.example.com.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	groups, err := Group(mappings, []netip.Prefix{netip.MustParsePrefix("192.0.2.64/26")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 2 {
+		t.Fatalf("expected 2 reverse zones, got %v", groups)
+	}
+
+	plan, err := BuildPlan(netip.MustParsePrefix("192.0.2.64/26"), groups[netip.MustParsePrefix("192.0.2.64/26")], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Domain != "64/26.2.0.192.in-addr.arpa" || len(plan.Records) != 1 ||
+		plan.Records[0].SubDomain != "70" || plan.Records[0].Type != "PTR" || plan.Records[0].Value != "mail.example.com" {
+		t.Fatalf("unexpected plan: %+v", plan)
+	}
+
+	v6 := netip.MustParsePrefix("2001:db8::/48")
+	plan, err = BuildPlan(v6, groups[v6], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Domain != "0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa" || len(plan.Records) != 1 ||
+		!strings.HasPrefix(plan.Records[0].SubDomain, "5.2.0.0.") || strings.HasSuffix(plan.Records[0].SubDomain, "arpa") {
+		t.Fatalf("unexpected plan: %+v", plan)
+	}
+
+	del, err := Delegation(netip.MustParsePrefix("192.0.2.64/26"), []netip.Addr{netip.MustParseAddr("192.0.2.70")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(del) != 1 || del[0].SubDomain != "70" || del[0].Value != "70.64/26.2.0.192.in-addr.arpa" {
+		t.Fatalf("unexpected delegation: %+v", del)
+	}
+
+	if _, err := Group([]Mapping{
+		{IP: netip.MustParseAddr("192.0.2.1"), Host: "a.example.com"},
+		{IP: netip.MustParseAddr("192.0.2.1"), Host: "b.example.com"},
+	}, nil); err == nil {
+		t.Fatalf("expected an error for an address with two hosts")
+	}
+}
+
+type fakeResolver struct {
+	ptr     map[string][]string
+	forward map[string][]netip.Addr
+}
+
+func (f fakeResolver) LookupAddr(ctx context.Context, addr string) ([]string, error) {
+	if names, ok := f.ptr[addr]; ok {
+		return names, nil
+	}
+	return nil, errors.New("no such host")
+}
+
+func (f fakeResolver) LookupNetIP(ctx context.Context, network, host string) ([]netip.Addr, error) {
+	var out []netip.Addr
+	for _, a := range f.forward[host] {
+		if a.Is4() == (network == "ip4") {
+			out = append(out, a)
+		}
+	}
+	return out, nil
+}
+
+func TestCheck(t *testing.T) {
+	r := fakeResolver{
+		ptr: map[string][]string{
+			"192.0.2.70":  {"Mail.Example.com."},
+			"192.0.2.71":  {"other.example.net."},
+			"2001:db8::1": {"mail.example.com."},
+		},
+		forward: map[string][]netip.Addr{
+			"mail.example.com": {netip.MustParseAddr("192.0.2.70")},
+		},
+	}
+	problems := Check(context.Background(), r, []Mapping{
+		{IP: netip.MustParseAddr("192.0.2.70"), Host: "mail.example.com"},
+		{IP: netip.MustParseAddr("192.0.2.71"), Host: "mail.example.com"},
+		{IP: netip.MustParseAddr("2001:db8::1"), Host: "mail.example.com"},
+		{IP: netip.MustParseAddr("192.0.2.99"), Host: "mail.example.com"},
+	})
+	want := []string{
+		"192.0.2.71: PTR is [other.example.net], expected mail.example.com",
+		"mail.example.com: resolves to [192.0.2.70], not 192.0.2.71",
+		"mail.example.com: resolves to [], not 2001:db8::1",
+		"192.0.2.99: PTR lookup failed: no such host",
+		"mail.example.com: resolves to [192.0.2.70], not 192.0.2.99",
+	}
+	if strings.Join(problems, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected problems:\n%s", strings.Join(problems, "\n"))
+	}
+}
@@ -0,0 +1,224 @@
+package route53client
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"ddnsjx/internal/rrset"
+)
+
+const apiVersion = "2013-04-01"
+
+type resourceRecord struct {
+	Value string `xml:"Value"`
+}
+
+type resourceRecordSet struct {
+	Name            string           `xml:"Name"`
+	Type            string           `xml:"Type"`
+	SetIdentifier   string           `xml:"SetIdentifier,omitempty"`
+	TTL             *uint64          `xml:"TTL,omitempty"`
+	ResourceRecords []resourceRecord `xml:"ResourceRecords>ResourceRecord,omitempty"`
+	AliasTarget     *struct {
+		DNSName string `xml:"DNSName"`
+	} `xml:"AliasTarget,omitempty"`
+}
+
+type change struct {
+	Action            string            `xml:"Action"`
+	ResourceRecordSet resourceRecordSet `xml:"ResourceRecordSet"`
+}
+
+type changeRequest struct {
+	XMLName xml.Name `xml:"https://route53.amazonaws.com/doc/2013-04-01/ ChangeResourceRecordSetsRequest"`
+	Comment string   `xml:"ChangeBatch>Comment,omitempty"`
+	Changes []change `xml:"ChangeBatch>Changes>Change"`
+}
+
+type changeResponse struct {
+	ChangeInfo struct {
+		ID     string `xml:"Id"`
+		Status string `xml:"Status"`
+	} `xml:"ChangeInfo"`
+}
+
+type listRRSetsResponse struct {
+	ResourceRecordSets []resourceRecordSet `xml:"ResourceRecordSets>ResourceRecordSet"`
+}
+
+type hostedZonesResponse struct {
+	HostedZones []struct {
+		ID   string `xml:"Id"`
+		Name string `xml:"Name"`
+	} `xml:"HostedZones>HostedZone"`
+}
+
+// errorResponse covers both error shapes: <ErrorResponse><Error>... and
+// <InvalidChangeBatch><Messages>... returned by ChangeResourceRecordSets.
+type errorResponse struct {
+	XMLName  xml.Name
+	Code     string   `xml:"Error>Code"`
+	Message  string   `xml:"Error>Message"`
+	Messages []string `xml:"Messages>Message"`
+}
+
+func toXML(s *rrset.Set) resourceRecordSet {
+	ttl := s.TTL
+	out := resourceRecordSet{Name: s.Name, Type: s.Type, TTL: &ttl}
+	for _, v := range s.Values {
+		out.ResourceRecords = append(out.ResourceRecords, resourceRecord{Value: v})
+	}
+	return out
+}
+
+func (c *client) SetName(zone, sub string) string {
+	return rrset.FQDN(zone, sub)
+}
+
+// GetSet returns the simple (non-weighted) RRset at name and type; a
+// missing set is returned empty. Alias records are refused.
+func (c *client) GetSet(ctx context.Context, zone, name, typ string) (*rrset.Set, error) {
+	zoneID, err := c.resolveZoneID(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	query := url.Values{}
+	query.Set("name", name)
+	query.Set("type", typ)
+	query.Set("maxitems", "100")
+
+	var resp listRRSetsResponse
+	if err := c.do(ctx, "GET", "/hostedzone/"+url.PathEscape(zoneID)+"/rrset?"+query.Encode(), nil, &resp); err != nil {
+		return nil, err
+	}
+	out := &rrset.Set{Name: name, Type: typ}
+	for _, rs := range resp.ResourceRecordSets {
+		if normalizeName(rs.Name) != name || rs.Type != typ {
+			continue
+		}
+		if rs.SetIdentifier != "" {
+			continue
+		}
+		if rs.AliasTarget != nil {
+			return nil, fmt.Errorf("%s %s is a Route 53 alias record and is not managed here", typ, name)
+		}
+		if rs.TTL != nil {
+			out.TTL = *rs.TTL
+		}
+		for _, rr := range rs.ResourceRecords {
+			out.Values = append(out.Values, rr.Value)
+		}
+	}
+	return out, nil
+}
+
+// SubmitSets sends one ChangeResourceRecordSets call: CREATE for a new
+// set, DELETE for an emptied one and UPSERT otherwise.
+func (c *client) SubmitSets(ctx context.Context, zone string, updates []rrset.Update) error {
+	zoneID, err := c.resolveZoneID(ctx, zone)
+	if err != nil {
+		return err
+	}
+	var changes []change
+	for _, u := range updates {
+		switch {
+		case len(u.Before.Values) == 0:
+			changes = append(changes, change{Action: "CREATE", ResourceRecordSet: toXML(u.After)})
+		case len(u.After.Values) == 0:
+			changes = append(changes, change{Action: "DELETE", ResourceRecordSet: toXML(u.Before)})
+		default:
+			changes = append(changes, change{Action: "UPSERT", ResourceRecordSet: toXML(u.After)})
+		}
+	}
+	req := changeRequest{Comment: "stalwart-dns", Changes: changes}
+	var resp changeResponse
+	return c.do(ctx, "POST", "/hostedzone/"+url.PathEscape(zoneID)+"/rrset/", req, &resp)
+}
+
+func (c *client) resolveZoneID(ctx context.Context, zone string) (string, error) {
+	if c.zoneID != "" {
+		return c.zoneID, nil
+	}
+	name := normalizeName(strings.TrimSuffix(strings.TrimSpace(zone), "."))
+	if name == "" {
+		return "", fmt.Errorf("missing Route 53 zone name or hosted zone id")
+	}
+
+	query := url.Values{}
+	query.Set("dnsname", name)
+	query.Set("maxitems", "10")
+
+	var resp hostedZonesResponse
+	if err := c.do(ctx, "GET", "/hostedzonesbyname?"+query.Encode(), nil, &resp); err != nil {
+		return "", err
+	}
+	var ids []string
+	for _, z := range resp.HostedZones {
+		if normalizeName(z.Name) == name {
+			ids = append(ids, strings.TrimPrefix(z.ID, "/hostedzone/"))
+		}
+	}
+	switch len(ids) {
+	case 0:
+		return "", fmt.Errorf("Route 53 hosted zone not found: %s", name)
+	case 1:
+		c.zoneID = ids[0]
+		return c.zoneID, nil
+	default:
+		return "", fmt.Errorf("several Route 53 hosted zones are named %s (%s); set the hosted zone id", name, strings.Join(ids, ", "))
+	}
+}
+
+func (c *client) do(ctx context.Context, method, path string, body any, out any) error {
+	var payload []byte
+	if body != nil {
+		b, err := xml.Marshal(body)
+		if err != nil {
+			return err
+		}
+		payload = append([]byte(xml.Header), b...)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+"/"+apiVersion+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "text/xml")
+	}
+	signV4(req, payload, c.cred, "us-east-1", "route53", time.Now())
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var e errorResponse
+		if xml.Unmarshal(b, &e) == nil {
+			if e.XMLName.Local == "InvalidChangeBatch" {
+				return Error{Status: resp.StatusCode, Code: "InvalidChangeBatch", Message: strings.Join(e.Messages, "; ")}
+			}
+			if e.Code != "" {
+				return Error{Status: resp.StatusCode, Code: e.Code, Message: e.Message}
+			}
+		}
+		return Error{Status: resp.StatusCode, Message: string(bytes.TrimSpace(b))}
+	}
+	if out == nil {
+		return nil
+	}
+	return xml.Unmarshal(b, out)
+}
@@ -0,0 +1,13 @@
+package route53client
+
+import (
+	"context"
+
+	"ddnsjx/internal/provider"
+)
+
+// ApplyBatch folds changes into the RRsets they touch and submits them in
+// one ChangeResourceRecordSets call, which Route 53 applies atomically.
+func (c *client) ApplyBatch(ctx context.Context, zone string, _ string, changes []provider.Change) ([]provider.ChangeResult, error) {
+	return c.sets.Apply(ctx, zone, changes)
+}
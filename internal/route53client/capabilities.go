@@ -0,0 +1,13 @@
+package route53client
+
+import "ddnsjx/internal/provider"
+
+func capabilities() provider.Capabilities {
+	return provider.Capabilities{
+		RecordTypes: []string{"A", "AAAA", "CAA", "CNAME", "DS", "HTTPS", "MX", "NAPTR", "NS", "PTR", "SRV", "SSHFP", "SVCB", "TLSA", "TXT"},
+		MaxTTL:      2147483647,
+		// A TXT value may hold 4000 characters including the quotes added
+		// around every 255 byte string.
+		MaxTXTLength: 3900,
+	}
+}
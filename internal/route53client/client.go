@@ -0,0 +1,127 @@
+// Package route53client implements provider.Client for Amazon Route 53.
+//
+// Route 53 stores RRsets (all values of one name and type, sharing a TTL)
+// rather than individual records, so every per-record operation reads the
+// RRset, edits one value and writes the set back through
+// ChangeResourceRecordSets. Record ids are synthesised from name, type and
+// value.
+package route53client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"ddnsjx/internal/dns"
+	"ddnsjx/internal/provider"
+	"ddnsjx/internal/rrset"
+)
+
+const (
+	defaultBaseURL = "https://route53.amazonaws.com"
+	defaultTTL     = 300
+)
+
+type NewOptions struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	// HostedZoneID skips the lookup by zone name.
+	HostedZoneID string
+	// BaseURL overrides the API root (default https://route53.amazonaws.com).
+	BaseURL string
+	// Transport replaces the HTTP transport, e.g. with a cassette.
+	Transport http.RoundTripper
+}
+
+type client struct {
+	cred    credentials
+	zoneID  string
+	baseURL string
+	http    *http.Client
+	sets    rrset.Engine
+}
+
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+}
+
+func (e Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("[%d] %s", e.Status, e.Message)
+	}
+	return fmt.Sprintf("[%s] %s", e.Code, e.Message)
+}
+
+// ErrorCode returns the API error code, or the HTTP status when there is
+// none, for metrics.
+func (e Error) ErrorCode() string {
+	if e.Code == "" {
+		return strconv.Itoa(e.Status)
+	}
+	return e.Code
+}
+
+func (e Error) Retryable() bool {
+	switch e.Code {
+	case "Throttling", "PriorRequestNotComplete", "ServiceUnavailable", "InternalFailure":
+		return true
+	default:
+		return e.Status >= 500
+	}
+}
+
+func New(opt NewOptions) (provider.Client, error) {
+	if strings.TrimSpace(opt.AccessKeyID) == "" || strings.TrimSpace(opt.SecretAccessKey) == "" {
+		return nil, fmt.Errorf("missing AWS credentials")
+	}
+	baseURL := strings.TrimRight(strings.TrimSpace(opt.BaseURL), "/")
+	if baseURL == "" {
+		baseURL = defaultBaseURL
+	}
+	c := &client{
+		cred: credentials{
+			accessKeyID:     strings.TrimSpace(opt.AccessKeyID),
+			secretAccessKey: strings.TrimSpace(opt.SecretAccessKey),
+			sessionToken:    strings.TrimSpace(opt.SessionToken),
+		},
+		zoneID:  strings.TrimPrefix(strings.TrimSpace(opt.HostedZoneID), "/hostedzone/"),
+		baseURL: baseURL,
+		http:    &http.Client{Timeout: 20 * time.Second, Transport: opt.Transport},
+	}
+	c.sets = rrset.Engine{Store: c, Provider: "route53", DefaultTTL: defaultTTL}
+	return c, nil
+}
+
+func (c *client) Capabilities() provider.Capabilities {
+	return capabilities()
+}
+
+func (c *client) CreateRecord(ctx context.Context, zone string, _ string, record dns.Record) (string, provider.CreateStatus, error) {
+	return c.sets.Create(ctx, zone, record)
+}
+
+func (c *client) DeleteRecord(ctx context.Context, zone string, recordID string) error {
+	return c.sets.Delete(ctx, zone, recordID)
+}
+
+// FindRecord looks for record's value in the RRset at its name and type.
+func (c *client) FindRecord(ctx context.Context, zone string, _ string, record dns.Record) (string, bool, error) {
+	return c.sets.Find(ctx, zone, record)
+}
+
+// ListRecords returns every value of the RRset at record's name and type.
+func (c *client) ListRecords(ctx context.Context, zone string, _ string, record dns.Record) ([]provider.ExistingRecord, error) {
+	return c.sets.List(ctx, zone, record)
+}
+
+// UpdateRecord replaces the value identified by recordID with record. The
+// record's TTL applies to the whole RRset.
+func (c *client) UpdateRecord(ctx context.Context, zone string, _ string, recordID string, record dns.Record) error {
+	return c.sets.Update(ctx, zone, recordID, record)
+}
@@ -0,0 +1,323 @@
+package route53client
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"ddnsjx/internal/dns"
+	"ddnsjx/internal/provider"
+	"ddnsjx/internal/provider/providertest"
+	"ddnsjx/internal/rrset"
+)
+
+func newTestClient(t *testing.T, api *fakeAPI) provider.Client {
+	t.Helper()
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+	c, err := New(NewOptions{AccessKeyID: "AKIDTEST", SecretAccessKey: "secret", BaseURL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestConformance(t *testing.T) {
+	providertest.Run(t, func(t *testing.T) provider.Client {
+		return newTestClient(t, newFakeAPI("example.com."))
+	}, providertest.Options{ContentIDs: true})
+}
+
+func TestRRSetModel(t *testing.T) {
+	api := newFakeAPI("example.com.")
+	c := newTestClient(t, api)
+	ctx := context.Background()
+	ttl := uint64(3600)
+
+	for _, v := range []string{"3 1 1 aa11", "3 1 1 bb22"} {
+		if _, status, err := c.CreateRecord(ctx, "example.com", "", dns.Record{SubDomain: "_25._tcp.mail", Type: "TLSA", Value: v, TTL: &ttl}); err != nil || status != provider.CreateStatusSuccess {
+			t.Fatalf("create %s: %s %v", v, status, err)
+		}
+	}
+	set := api.sets[rrKey{"_25._tcp.mail.example.com.", "TLSA"}]
+	if set == nil || len(set.values) != 2 || set.ttl != 3600 {
+		t.Fatalf("expected one TLSA RRset with two values, got %+v", set)
+	}
+	if got := api.actions; strings.Join(got, ",") != "CREATE,UPSERT" {
+		t.Fatalf("unexpected change actions %v", got)
+	}
+
+	wildcard := dns.Record{SubDomain: "*.mta-sts", Type: "A", Value: "192.0.2.7"}
+	if _, _, err := c.CreateRecord(ctx, "example.com", "", wildcard); err != nil {
+		t.Fatalf("create wildcard: %v", err)
+	}
+	if _, found, err := c.FindRecord(ctx, "example.com", "", wildcard); err != nil || !found {
+		t.Fatalf("wildcard returned as \\052 should be found: %v %v", found, err)
+	}
+
+	long := strings.Repeat("k", 300)
+	if _, _, err := c.CreateRecord(ctx, "example.com", "", dns.Record{SubDomain: "dkim._domainkey", Type: "TXT", Value: long}); err != nil {
+		t.Fatalf("create long TXT: %v", err)
+	}
+	txt := api.sets[rrKey{"dkim._domainkey.example.com.", "TXT"}]
+	if txt == nil || txt.values[0] != `"`+long[:255]+`" "`+long[255:]+`"` {
+		t.Fatalf("expected TXT split into 255 byte strings, got %+v", txt)
+	}
+
+	b, ok := c.(provider.Batcher)
+	if !ok {
+		t.Fatalf("route53 client should implement provider.Batcher")
+	}
+	api.actions = nil
+	tlsaID := rrset.ID("_25._tcp.mail.example.com.", "TLSA", "3 1 1 aa11")
+	_, err := b.ApplyBatch(ctx, "example.com", "", []provider.Change{
+		{Action: provider.ChangeDelete, RecordID: tlsaID},
+		{Action: provider.ChangeDelete, RecordID: rrset.ID("_25._tcp.mail.example.com.", "TLSA", "3 1 1 bb22")},
+		{Action: provider.ChangeCreate, Record: dns.Record{SubDomain: "mail", Type: "A", Value: "192.0.2.25"}},
+	})
+	if err != nil {
+		t.Fatalf("batch: %v", err)
+	}
+	if got := strings.Join(api.actions, ","); got != "DELETE,CREATE" {
+		t.Fatalf("expected one DELETE and one CREATE, got %v", got)
+	}
+
+	// A failing change leaves the zone untouched.
+	_, err = b.ApplyBatch(ctx, "example.com", "", []provider.Change{
+		{Action: provider.ChangeCreate, Record: dns.Record{SubDomain: "www", Type: "A", Value: "192.0.2.80"}},
+		{Action: provider.ChangeCreate, Record: dns.Record{SubDomain: "mail", Type: "CNAME", Value: "elsewhere.example.net"}},
+	})
+	if err == nil {
+		t.Fatalf("expected CNAME conflict")
+	}
+	if _, ok := api.sets[rrKey{"www.example.com.", "A"}]; ok {
+		t.Fatalf("rejected batch must not be applied partially")
+	}
+}
+
+type rrKey struct {
+	name string
+	typ  string
+}
+
+// fakeSet is one RRset as the fake API stores it.
+type fakeSet struct {
+	name   string
+	typ    string
+	ttl    uint64
+	values []string
+}
+
+// matches reports whether b holds the same TTL and values as s, as Route 53
+// requires for a DELETE.
+func (s *fakeSet) matches(b *fakeSet) bool {
+	if s.ttl != b.ttl || len(s.values) != len(b.values) {
+		return false
+	}
+	for _, v := range b.values {
+		found := false
+		for _, cur := range s.values {
+			found = found || rrset.Same(s.typ, cur, v)
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// fakeAPI is a stand-in for the Route 53 REST API. It checks that requests
+// are SigV4 signed, validates change batches as a whole (CREATE of an
+// existing set, DELETE that does not match, CNAME conflicts, unquoted TXT)
+// and answers with the XML documents Route 53 uses, escaping wildcards as
+// \052 in names.
+type fakeAPI struct {
+	mu      sync.Mutex
+	zone    string
+	sets    map[rrKey]*fakeSet
+	actions []string
+}
+
+func newFakeAPI(zone string) *fakeAPI {
+	return &fakeAPI{zone: zone, sets: make(map[rrKey]*fakeSet)}
+}
+
+const fakeZoneID = "Z0123456789ABCDEFGHIJ"
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKIDTEST/") || r.Header.Get("X-Amz-Date") == "" {
+		writeR53Error(w, http.StatusForbidden, "SignatureDoesNotMatch", "missing or invalid signature")
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/"+apiVersion)
+	switch {
+	case r.Method == http.MethodGet && path == "/hostedzonesbyname":
+		// Route 53 lists zones in order starting at dnsname, not only exact
+		// matches.
+		writeXML(w, http.StatusOK, fmt.Sprintf(`<ListHostedZonesByNameResponse xmlns="https://route53.amazonaws.com/doc/2013-04-01/"><HostedZones>
+<HostedZone><Id>/hostedzone/%s</Id><Name>%s</Name><Config><PrivateZone>false</PrivateZone></Config></HostedZone>
+<HostedZone><Id>/hostedzone/ZOTHER</Id><Name>example.org.</Name></HostedZone>
+</HostedZones><IsTruncated>false</IsTruncated><MaxItems>10</MaxItems></ListHostedZonesByNameResponse>`, fakeZoneID, f.zone))
+	case r.Method == http.MethodGet && path == "/hostedzone/"+fakeZoneID+"/rrset":
+		f.list(w, r.URL.Query().Get("name"), r.URL.Query().Get("type"))
+	case r.Method == http.MethodPost && path == "/hostedzone/"+fakeZoneID+"/rrset/":
+		var req struct {
+			Changes []change `xml:"ChangeBatch>Changes>Change"`
+		}
+		if err := xml.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeR53Error(w, http.StatusBadRequest, "InvalidInput", err.Error())
+			return
+		}
+		if msgs := f.applyChanges(req.Changes); len(msgs) > 0 {
+			var b strings.Builder
+			for _, m := range msgs {
+				b.WriteString("<Message>")
+				_ = xml.EscapeText(&b, []byte(m))
+				b.WriteString("</Message>")
+			}
+			writeXML(w, http.StatusBadRequest, `<InvalidChangeBatch xmlns="https://route53.amazonaws.com/doc/2013-04-01/"><Messages>`+b.String()+`</Messages><RequestId>req-1</RequestId></InvalidChangeBatch>`)
+			return
+		}
+		writeXML(w, http.StatusOK, `<ChangeResourceRecordSetsResponse xmlns="https://route53.amazonaws.com/doc/2013-04-01/"><ChangeInfo><Id>/change/C1</Id><Status>PENDING</Status><SubmittedAt>2026-10-18T00:00:00Z</SubmittedAt></ChangeInfo></ChangeResourceRecordSetsResponse>`)
+	case strings.HasPrefix(path, "/hostedzone/"):
+		writeR53Error(w, http.StatusNotFound, "NoSuchHostedZone", "No hosted zone found with ID: "+path)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// list returns every set from name/type onwards in Route 53 order, so the
+// client has to pick the exact match itself.
+func (f *fakeAPI) list(w http.ResponseWriter, name, typ string) {
+	keys := make([]rrKey, 0, len(f.sets))
+	for k := range f.sets {
+		if k.name > name || (k.name == name && k.typ >= typ) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].name != keys[j].name {
+			return keys[i].name < keys[j].name
+		}
+		return keys[i].typ < keys[j].typ
+	})
+
+	var b strings.Builder
+	b.WriteString(`<ListResourceRecordSetsResponse xmlns="https://route53.amazonaws.com/doc/2013-04-01/"><ResourceRecordSets>`)
+	for _, k := range keys {
+		s := f.sets[k]
+		fmt.Fprintf(&b, "<ResourceRecordSet><Name>%s</Name><Type>%s</Type><TTL>%d</TTL><ResourceRecords>", strings.ReplaceAll(s.name, "*", `\052`), s.typ, s.ttl)
+		for _, v := range s.values {
+			b.WriteString("<ResourceRecord><Value>")
+			_ = xml.EscapeText(&b, []byte(v))
+			b.WriteString("</Value></ResourceRecord>")
+		}
+		b.WriteString("</ResourceRecords></ResourceRecordSet>")
+	}
+	b.WriteString(`</ResourceRecordSets><IsTruncated>false</IsTruncated><MaxItems>100</MaxItems></ListResourceRecordSetsResponse>`)
+	writeXML(w, http.StatusOK, b.String())
+}
+
+// applyChanges validates the whole batch against a copy of the zone and
+// only commits it when every change is valid.
+func (f *fakeAPI) applyChanges(changes []change) []string {
+	next := make(map[rrKey]*fakeSet, len(f.sets))
+	for k, v := range f.sets {
+		next[k] = v
+	}
+
+	var msgs []string
+	for _, ch := range changes {
+		rs := ch.ResourceRecordSet
+		k := rrKey{strings.ToLower(rs.Name), rs.Type}
+		desc := fmt.Sprintf("[name='%s', type='%s']", rs.Name, rs.Type)
+		set := &fakeSet{name: k.name, typ: k.typ}
+		if rs.TTL != nil {
+			set.ttl = *rs.TTL
+		}
+		for _, rr := range rs.ResourceRecords {
+			set.values = append(set.values, rr.Value)
+		}
+
+		switch ch.Action {
+		case "CREATE":
+			if _, ok := next[k]; ok {
+				msgs = append(msgs, "Tried to create resource record set "+desc+" but it already exists")
+				continue
+			}
+			next[k] = set
+		case "UPSERT":
+			next[k] = set
+		case "DELETE":
+			cur, ok := next[k]
+			if !ok {
+				msgs = append(msgs, "Tried to delete resource record set "+desc+" but it was not found")
+				continue
+			}
+			if !cur.matches(set) {
+				msgs = append(msgs, "Tried to delete resource record set "+desc+" but the values provided do not match the current values")
+				continue
+			}
+			delete(next, k)
+			continue
+		default:
+			msgs = append(msgs, "Invalid action "+ch.Action)
+			continue
+		}
+
+		seen := make(map[string]bool)
+		for _, v := range set.values {
+			if seen[v] {
+				msgs = append(msgs, "Duplicate Resource Record: '"+v+"'")
+			}
+			seen[v] = true
+			if set.typ == "TXT" && !strings.HasPrefix(v, `"`) {
+				msgs = append(msgs, "Invalid Resource Record: 'FATAL problem: InvalidCharacterString (Value should be enclosed in quotation marks) encountered with '"+v+"''")
+			}
+		}
+		if set.typ == "CNAME" && len(set.values) > 1 {
+			msgs = append(msgs, "RRSet of type CNAME with DNS name "+set.name+" contains more than one value")
+		}
+	}
+	for k := range next {
+		if k.typ != "CNAME" {
+			continue
+		}
+		for other := range next {
+			if other.name == k.name && other.typ != "CNAME" {
+				msgs = append(msgs, "RRSet of type CNAME with DNS name "+k.name+" is not permitted as it conflicts with other records with the same DNS name in zone "+f.zone)
+			}
+		}
+	}
+	if len(msgs) > 0 {
+		return msgs
+	}
+
+	f.sets = next
+	for _, ch := range changes {
+		f.actions = append(f.actions, ch.Action)
+	}
+	return nil
+}
+
+func writeXML(w http.ResponseWriter, status int, body string) {
+	w.Header().Set("Content-Type", "text/xml")
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte(xml.Header + body))
+}
+
+func writeR53Error(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "text/xml")
+	w.WriteHeader(status)
+	_, _ = fmt.Fprintf(w, `<ErrorResponse xmlns="https://route53.amazonaws.com/doc/2013-04-01/"><Error><Type>Sender</Type><Code>%s</Code><Message>%s</Message></Error><RequestId>req-1</RequestId></ErrorResponse>`, code, message)
+}
@@ -0,0 +1,31 @@
+package route53client
+
+import (
+	"net/http"
+
+	"ddnsjx/internal/provider"
+)
+
+func init() {
+	provider.Register(provider.Factory{
+		Name: "route53",
+		Help: "Amazon Route 53 (SigV4, ChangeResourceRecordSets)",
+		Settings: []provider.Setting{
+			{Name: "access_key_id", Flag: "aws-access-key-id", Env: "AWS_ACCESS_KEY_ID", Help: "AWS access key id", Required: true},
+			{Name: "secret_access_key", Flag: "aws-secret-access-key", Env: "AWS_SECRET_ACCESS_KEY", Help: "AWS secret access key", Required: true, Secret: true},
+			{Name: "session_token", Flag: "aws-session-token", Env: "AWS_SESSION_TOKEN", Help: "AWS session token for temporary credentials", Secret: true},
+			{Name: "hosted_zone_id", Flag: "route53-zone-id", Env: "ROUTE53_HOSTED_ZONE_ID", Help: "Route 53 hosted zone id (empty: query by zone name)"},
+			{Name: "base_url", Flag: "route53-base-url", Env: "ROUTE53_BASE_URL", Help: "Route 53 API root (default " + defaultBaseURL + ")"},
+		},
+		New: func(_ string, s provider.Settings, transport http.RoundTripper) (provider.Client, error) {
+			return New(NewOptions{
+				AccessKeyID:     s.Get("access_key_id"),
+				SecretAccessKey: s.Get("secret_access_key"),
+				SessionToken:    s.Get("session_token"),
+				HostedZoneID:    s.Get("hosted_zone_id"),
+				BaseURL:         s.Get("base_url"),
+				Transport:       transport,
+			})
+		},
+	})
+}
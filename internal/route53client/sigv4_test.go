@@ -0,0 +1,28 @@
+package route53client
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+// Vectors from the AWS Signature Version 4 test suite.
+func TestSignV4(t *testing.T) {
+	cred := credentials{accessKeyID: "AKIDEXAMPLE", secretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	now := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+
+	cases := []struct {
+		name, url, want string
+	}{
+		{"get-vanilla", "https://example.amazonaws.com/", "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"},
+		{"get-vanilla-query-order-key-case", "https://example.amazonaws.com/?Param2=value2&Param1=value1", "b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500"},
+	}
+	for _, tc := range cases {
+		req, _ := http.NewRequest("GET", tc.url, nil)
+		signV4(req, nil, cred, "us-east-1", "service", now)
+		want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=" + tc.want
+		if got := req.Header.Get("Authorization"); got != want {
+			t.Errorf("%s:\n got  %s\n want %s", tc.name, got, want)
+		}
+	}
+}
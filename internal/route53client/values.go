@@ -0,0 +1,25 @@
+package route53client
+
+import (
+	"strconv"
+	"strings"
+
+	"ddnsjx/internal/rrset"
+)
+
+// normalizeName lowercases name and decodes the \DDD escapes Route 53
+// uses in responses (for example \052 for a wildcard).
+func normalizeName(name string) string {
+	var b strings.Builder
+	for i := 0; i < len(name); i++ {
+		if name[i] == '\\' && i+4 <= len(name) {
+			if n, err := strconv.ParseUint(name[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(n))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(name[i])
+	}
+	return rrset.EnsureDot(strings.ToLower(b.String()))
+}
@@ -0,0 +1,257 @@
+// Package rrset is the shared engine of the providers that store RRsets
+// (all values of one name and type, sharing a TTL) instead of individual
+// records: Route 53, PowerDNS, deSEC and Gandi. Record ids are synthesised
+// as "<name> <type> <value>", and every operation reads the sets it
+// touches, edits them in memory and writes back the ones that changed.
+// Each provider only supplies a Store that reads and writes whole sets.
+package rrset
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"ddnsjx/internal/dns"
+	"ddnsjx/internal/provider"
+)
+
+// Set is the content of one name and type.
+type Set struct {
+	Name   string
+	Type   string
+	TTL    uint64
+	Values []string
+	// Disabled lists values the provider stores but does not serve
+	// (PowerDNS). They stay in Values so they are carried over.
+	Disabled []string
+}
+
+// IndexOf returns the position of value in s, compared with Same.
+func (s *Set) IndexOf(value string) int {
+	for i, v := range s.Values {
+		if Same(s.Type, v, value) {
+			return i
+		}
+	}
+	return -1
+}
+
+// IsDisabled reports whether value is one of s.Disabled.
+func (s *Set) IsDisabled(value string) bool {
+	for _, v := range s.Disabled {
+		if Same(s.Type, v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Set) clone() *Set {
+	next := *s
+	next.Values = append([]string(nil), s.Values...)
+	return &next
+}
+
+func sameSet(a, b *Set) bool {
+	if a.TTL != b.TTL || len(a.Values) != len(b.Values) {
+		return false
+	}
+	for _, v := range b.Values {
+		if a.IndexOf(v) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Update is one set that changed. Before has no values for a new set and
+// After has none for a set to delete.
+type Update struct {
+	Before *Set
+	After  *Set
+}
+
+// Store reads and writes the RRsets of one provider.
+type Store interface {
+	// SetName returns the name of the set holding sub in zone, as used in
+	// record ids.
+	SetName(zone, sub string) string
+	// GetSet returns the set at name and type; a missing set is returned
+	// empty.
+	GetSet(ctx context.Context, zone, name, typ string) (*Set, error)
+	// SubmitSets writes updates back, atomically where the provider can.
+	SubmitSets(ctx context.Context, zone string, updates []Update) error
+}
+
+// Engine implements the provider.Client record operations on top of a
+// Store.
+type Engine struct {
+	Store Store
+	// Provider names the provider in errors.
+	Provider string
+	// DefaultTTL is given to new sets created without a TTL.
+	DefaultTTL uint64
+}
+
+// Create adds record's value to its set, or reports the existing value.
+func (e Engine) Create(ctx context.Context, zone string, record dns.Record) (string, provider.CreateStatus, error) {
+	name, typ, value, err := e.locate(zone, record)
+	if err != nil {
+		return "", provider.CreateStatusFail, err
+	}
+	set, err := e.Store.GetSet(ctx, zone, name, typ)
+	if err != nil {
+		return "", provider.CreateStatusFail, err
+	}
+	if i := set.IndexOf(value); i >= 0 {
+		return ID(name, typ, set.Values[i]), provider.CreateStatusExists, nil
+	}
+	results, err := e.Apply(ctx, zone, []provider.Change{{Action: provider.ChangeCreate, Record: record}})
+	if err != nil {
+		return "", provider.CreateStatusFail, err
+	}
+	return results[0].RecordID, provider.CreateStatusSuccess, nil
+}
+
+// Find looks for record's value in the set at its name and type.
+func (e Engine) Find(ctx context.Context, zone string, record dns.Record) (string, bool, error) {
+	name, typ, value, err := e.locate(zone, record)
+	if err != nil {
+		return "", false, err
+	}
+	set, err := e.Store.GetSet(ctx, zone, name, typ)
+	if err != nil {
+		return "", false, err
+	}
+	if i := set.IndexOf(value); i >= 0 {
+		return ID(name, typ, set.Values[i]), true, nil
+	}
+	return "", false, nil
+}
+
+// List returns every value in the set at record's name and type.
+func (e Engine) List(ctx context.Context, zone string, record dns.Record) ([]provider.ExistingRecord, error) {
+	name, typ := e.Store.SetName(zone, record.SubDomain), strings.ToUpper(strings.TrimSpace(record.Type))
+	set, err := e.Store.GetSet(ctx, zone, name, typ)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]provider.ExistingRecord, 0, len(set.Values))
+	for _, v := range set.Values {
+		out = append(out, provider.ExistingRecord{ID: ID(name, typ, v), Value: v})
+	}
+	return out, nil
+}
+
+// Delete removes one value; the set is deleted with its last value.
+func (e Engine) Delete(ctx context.Context, zone string, recordID string) error {
+	_, err := e.Apply(ctx, zone, []provider.Change{{Action: provider.ChangeDelete, RecordID: recordID}})
+	return err
+}
+
+// Update replaces the value identified by recordID with record. The
+// record's TTL applies to the whole set; a record moved to another name or
+// type leaves its old set.
+func (e Engine) Update(ctx context.Context, zone string, recordID string, record dns.Record) error {
+	_, err := e.Apply(ctx, zone, []provider.Change{{Action: provider.ChangeUpdate, RecordID: recordID, Record: record}})
+	return err
+}
+
+type key struct {
+	name string
+	typ  string
+}
+
+// Apply loads every set named by changes, edits the values in memory and
+// submits each set that changed in one SubmitSets call.
+func (e Engine) Apply(ctx context.Context, zone string, changes []provider.Change) ([]provider.ChangeResult, error) {
+	var (
+		order  []key
+		before = make(map[key]*Set)
+		after  = make(map[key]*Set)
+	)
+	load := func(k key) (*Set, error) {
+		if s, ok := after[k]; ok {
+			return s, nil
+		}
+		s, err := e.Store.GetSet(ctx, zone, k.name, k.typ)
+		if err != nil {
+			return nil, err
+		}
+		before[k], after[k] = s, s.clone()
+		order = append(order, k)
+		return after[k], nil
+	}
+
+	results := make([]provider.ChangeResult, len(changes))
+	for i, ch := range changes {
+		switch ch.Action {
+		case provider.ChangeCreate, provider.ChangeUpdate, provider.ChangeDelete:
+		default:
+			return nil, fmt.Errorf("unsupported batch action: %s", ch.Action)
+		}
+
+		if ch.Action != provider.ChangeCreate {
+			name, typ, value, err := ParseID(ch.RecordID)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", e.Provider, err)
+			}
+			s, err := load(key{name, typ})
+			if err != nil {
+				return nil, err
+			}
+			j := s.IndexOf(value)
+			if j < 0 {
+				return nil, fmt.Errorf("%s record not found: %s", e.Provider, ch.RecordID)
+			}
+			s.Values = append(s.Values[:j], s.Values[j+1:]...)
+			if ch.Action == provider.ChangeDelete {
+				results[i] = provider.ChangeResult{RecordID: ch.RecordID}
+				continue
+			}
+		}
+
+		name, typ, value, err := e.locate(zone, ch.Record)
+		if err != nil {
+			return nil, err
+		}
+		s, err := load(key{name, typ})
+		if err != nil {
+			return nil, err
+		}
+		if s.IndexOf(value) < 0 {
+			s.Values = append(s.Values, value)
+		}
+		switch {
+		case ch.Record.TTL != nil && *ch.Record.TTL > 0:
+			s.TTL = *ch.Record.TTL
+		case s.TTL == 0:
+			s.TTL = e.DefaultTTL
+		}
+		results[i] = provider.ChangeResult{RecordID: ID(name, typ, value)}
+	}
+
+	var updates []Update
+	for _, k := range order {
+		b, a := before[k], after[k]
+		if len(b.Values) == 0 && len(a.Values) == 0 || sameSet(b, a) {
+			continue
+		}
+		updates = append(updates, Update{Before: b, After: a})
+	}
+	if len(updates) > 0 {
+		if err := e.Store.SubmitSets(ctx, zone, updates); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// locate returns the set name, type and rendered value of record.
+func (e Engine) locate(zone string, record dns.Record) (name, typ, value string, err error) {
+	value, err = Value(record)
+	if err != nil {
+		return "", "", "", err
+	}
+	return e.Store.SetName(zone, record.SubDomain), strings.ToUpper(strings.TrimSpace(record.Type)), value, nil
+}
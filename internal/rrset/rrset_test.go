@@ -0,0 +1,128 @@
+package rrset
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"ddnsjx/internal/dns"
+	"ddnsjx/internal/provider"
+)
+
+// memStore keeps sets by "<name> <type>" and records every submit.
+type memStore struct {
+	sets    map[string]*Set
+	submits [][]Update
+}
+
+func (m *memStore) SetName(zone, sub string) string {
+	return FQDN(zone, sub)
+}
+
+func (m *memStore) GetSet(ctx context.Context, zone, name, typ string) (*Set, error) {
+	if s, ok := m.sets[name+" "+typ]; ok {
+		return s.clone(), nil
+	}
+	return &Set{Name: name, Type: typ}, nil
+}
+
+func (m *memStore) SubmitSets(ctx context.Context, zone string, updates []Update) error {
+	m.submits = append(m.submits, updates)
+	for _, u := range updates {
+		if len(u.After.Values) == 0 {
+			delete(m.sets, u.After.Name+" "+u.After.Type)
+			continue
+		}
+		m.sets[u.After.Name+" "+u.After.Type] = u.After
+	}
+	return nil
+}
+
+func TestEngineMergesIntoSets(t *testing.T) {
+	store := &memStore{sets: map[string]*Set{
+		"www.example.com. A": {Name: "www.example.com.", Type: "A", TTL: 600, Values: []string{"192.0.2.1", "192.0.2.9"}, Disabled: []string{"192.0.2.9"}},
+	}}
+	e := Engine{Store: store, Provider: "mem", DefaultTTL: 300}
+	ctx := context.Background()
+
+	id, status, err := e.Create(ctx, "example.com", dns.Record{SubDomain: "www", Type: "A", Value: "192.0.2.2"})
+	if err != nil || status != provider.CreateStatusSuccess || id != "www.example.com. A 192.0.2.2" {
+		t.Fatalf("create: %q %s %v", id, status, err)
+	}
+	listed, err := e.List(ctx, "example.com", dns.Record{SubDomain: "www", Type: "a"})
+	if err != nil || len(listed) != 3 || listed[2].ID != id || listed[2].Value != "192.0.2.2" {
+		t.Fatalf("list: %+v %v", listed, err)
+	}
+	set := store.sets["www.example.com. A"]
+	if len(set.Values) != 3 || set.TTL != 600 || !set.IsDisabled("192.0.2.9") {
+		t.Fatalf("expected the value appended with TTL and disabled value kept, got %+v", set)
+	}
+	if _, status, _ := e.Create(ctx, "example.com", dns.Record{SubDomain: "WWW", Type: "a", Value: "192.0.2.2"}); status != provider.CreateStatusExists {
+		t.Fatalf("expected exists, got %s", status)
+	}
+
+	// Moving a value to another name empties nothing but touches two sets
+	// in one submit.
+	store.submits = nil
+	if err := e.Update(ctx, "example.com", id, dns.Record{SubDomain: "mail", Type: "A", Value: "192.0.2.2"}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if len(store.submits) != 1 || len(store.submits[0]) != 2 {
+		t.Fatalf("expected one submit of two sets, got %+v", store.submits)
+	}
+	if got := store.sets["mail.example.com. A"]; got == nil || got.TTL != 300 || got.Values[0] != "192.0.2.2" {
+		t.Fatalf("expected new set with the default TTL, got %+v", got)
+	}
+
+	// A change that leaves the set as it was is not submitted.
+	store.submits = nil
+	results, err := e.Apply(ctx, "example.com", []provider.Change{
+		{Action: provider.ChangeUpdate, RecordID: "mail.example.com. A 192.0.2.2", Record: dns.Record{SubDomain: "mail", Type: "A", Value: "192.0.2.2"}},
+	})
+	if err != nil || len(results) != 1 || len(store.submits) != 0 {
+		t.Fatalf("expected no submit for an unchanged set: %v %v", store.submits, err)
+	}
+
+	if err := e.Delete(ctx, "example.com", "mail.example.com. A 192.0.2.2"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, ok := store.sets["mail.example.com. A"]; ok {
+		t.Fatalf("set should be deleted with its last value")
+	}
+	if err := e.Delete(ctx, "example.com", "mail.example.com. A 192.0.2.2"); err == nil || !strings.Contains(err.Error(), "mem record not found") {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	if err := e.Delete(ctx, "example.com", "bogus"); err == nil || !strings.Contains(err.Error(), `mem: invalid record id "bogus"`) {
+		t.Fatalf("expected invalid id, got %v", err)
+	}
+}
+
+func TestValueAndSame(t *testing.T) {
+	prio := uint64(10)
+	cases := []struct {
+		rec  dns.Record
+		want string
+	}{
+		{dns.Record{Type: "MX", Value: "mx.example.net", Priority: &prio}, "10 mx.example.net."},
+		{dns.Record{Type: "CNAME", Value: "target.example.net"}, "target.example.net."},
+		{dns.Record{Type: "SRV", Value: "10 5 443 sip.example.net"}, "10 5 443 sip.example.net."},
+		{dns.Record{Type: "TXT", Value: "v=spf1 -all"}, `"v=spf1 -all"`},
+		{dns.Record{Type: "A", Value: " 192.0.2.1 "}, "192.0.2.1"},
+	}
+	for _, c := range cases {
+		got, err := Value(c.rec)
+		if err != nil || got != c.want {
+			t.Fatalf("Value(%s %q) = %q, %v; want %q", c.rec.Type, c.rec.Value, got, err, c.want)
+		}
+	}
+	if _, err := Value(dns.Record{Type: "MX", Value: "mx.example.net"}); err == nil {
+		t.Fatalf("expected MX without priority to fail")
+	}
+
+	if !Same("TXT", `"v=spf1" " -all"`, `"v=spf1 -all"`) || !Same("CAA", `0 issue  "CA.example"`, `0 issue "ca.example"`) || Same("A", "192.0.2.1", "192.0.2.2") {
+		t.Fatalf("unexpected Same result")
+	}
+	if FQDN("Example.com.", "@") != "example.com." || FQDN("example.com", "WWW") != "www.example.com." || Relative("") != "@" {
+		t.Fatalf("unexpected name helpers")
+	}
+}
@@ -0,0 +1,86 @@
+package rrset
+
+import (
+	"fmt"
+	"strings"
+
+	"ddnsjx/internal/dns"
+)
+
+// Value renders record as one RRset value in zone-file syntax, with
+// absolute names for every hostname field and TXT quoted.
+func Value(record dns.Record) (string, error) {
+	v := strings.TrimSpace(record.Value)
+	switch strings.ToUpper(record.Type) {
+	case "MX":
+		if record.Priority == nil {
+			return "", fmt.Errorf("MX priority is required for %s", record.SubDomain)
+		}
+		return fmt.Sprintf("%d %s", *record.Priority, EnsureDot(v)), nil
+	case "CNAME", "NS", "PTR":
+		return EnsureDot(v), nil
+	case "SRV":
+		f := strings.Fields(v)
+		if len(f) != 4 {
+			return "", fmt.Errorf("SRV value expects: \"<priority> <weight> <port> <target>\", got %q", v)
+		}
+		f[3] = EnsureDot(f[3])
+		return strings.Join(f, " "), nil
+	case "TXT":
+		return dns.QuoteTXT(v), nil
+	default:
+		return v, nil
+	}
+}
+
+// Same compares values, ignoring case and spacing; TXT values compare by
+// their text, however they are quoted or split.
+func Same(typ, a, b string) bool {
+	if strings.EqualFold(typ, "TXT") {
+		return dns.SameTXT(a, b)
+	}
+	return strings.EqualFold(strings.Join(strings.Fields(a), " "), strings.Join(strings.Fields(b), " "))
+}
+
+func EnsureDot(s string) string {
+	if s == "" || strings.HasSuffix(s, ".") {
+		return s
+	}
+	return s + "."
+}
+
+// ZoneName returns the canonical (lowercase, dot-terminated) zone name.
+func ZoneName(zone string) string {
+	return EnsureDot(strings.ToLower(strings.TrimSuffix(strings.TrimSpace(zone), ".")))
+}
+
+// FQDN returns the canonical owner name for sub in zone.
+func FQDN(zone, sub string) string {
+	sub = strings.ToLower(strings.TrimSpace(sub))
+	if sub == "" || sub == "@" {
+		return ZoneName(zone)
+	}
+	return sub + "." + ZoneName(zone)
+}
+
+// Relative returns the owner name relative to the zone, "@" for the apex.
+func Relative(sub string) string {
+	sub = strings.ToLower(strings.TrimSpace(sub))
+	if sub == "" {
+		return "@"
+	}
+	return sub
+}
+
+// ID identifies one value of a set as "<name> <type> <value>".
+func ID(name, typ, value string) string {
+	return name + " " + typ + " " + value
+}
+
+func ParseID(id string) (name, typ, value string, err error) {
+	parts := strings.SplitN(strings.TrimSpace(id), " ", 3)
+	if len(parts) != 3 {
+		return "", "", "", fmt.Errorf("invalid record id %q", id)
+	}
+	return strings.ToLower(parts[0]), strings.ToUpper(parts[1]), parts[2], nil
+}
@@ -0,0 +1,172 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+)
+
+// maxJobs bounds how many jobs are remembered; the oldest finished jobs
+// are forgotten first.
+const maxJobs = 1000
+
+// Job is an apply request queued behind earlier jobs for the same zone.
+type Job struct {
+	ID       string     `json:"id"`
+	Zone     string     `json:"zone"`
+	Status   string     `json:"status"`
+	Error    string     `json:"error,omitempty"`
+	Created  time.Time  `json:"created"`
+	Started  *time.Time `json:"started,omitempty"`
+	Finished *time.Time `json:"finished,omitempty"`
+	Log      string     `json:"log"`
+}
+
+type job struct {
+	mu   sync.Mutex
+	info Job
+	log  bytes.Buffer
+	run  func(ctx context.Context, j *job) error
+}
+
+// Write appends runner output to the job log.
+func (j *job) Write(p []byte) (int, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.log.Write(p)
+}
+
+func (j *job) snapshot() Job {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	out := j.info
+	out.Log = j.log.String()
+	return out
+}
+
+func (j *job) finished() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.info.Finished != nil
+}
+
+// queue runs jobs one at a time per zone, in submission order, and jobs of
+// different zones concurrently. Jobs run on ctx, so cancelling it stops
+// them.
+type queue struct {
+	ctx     context.Context
+	mu      sync.Mutex
+	jobs    map[string]*job
+	order   []string
+	pending map[string][]*job // zone -> jobs waiting; present while a worker runs
+	wg      sync.WaitGroup
+}
+
+func newQueue(ctx context.Context) *queue {
+	return &queue{ctx: ctx, jobs: make(map[string]*job), pending: make(map[string][]*job)}
+}
+
+func (q *queue) submit(zone string, run func(ctx context.Context, j *job) error) Job {
+	j := &job{info: Job{ID: newJobID(), Zone: zone, Status: JobQueued, Created: time.Now().UTC()}, run: run}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.jobs[j.info.ID] = j
+	q.order = append(q.order, j.info.ID)
+	q.evict()
+
+	waiting, busy := q.pending[zone]
+	q.pending[zone] = append(waiting, j)
+	if !busy {
+		q.wg.Add(1)
+		go q.work(zone)
+	}
+	return j.snapshot()
+}
+
+func (q *queue) work(zone string) {
+	defer q.wg.Done()
+	for {
+		q.mu.Lock()
+		waiting := q.pending[zone]
+		if len(waiting) == 0 {
+			delete(q.pending, zone)
+			q.mu.Unlock()
+			return
+		}
+		j := waiting[0]
+		q.pending[zone] = waiting[1:]
+		q.mu.Unlock()
+
+		now := time.Now().UTC()
+		j.mu.Lock()
+		j.info.Status, j.info.Started = JobRunning, &now
+		j.mu.Unlock()
+
+		err := q.run(j)
+
+		now = time.Now().UTC()
+		j.mu.Lock()
+		j.info.Status, j.info.Finished = JobSucceeded, &now
+		if err != nil {
+			j.info.Status, j.info.Error = JobFailed, err.Error()
+		}
+		j.mu.Unlock()
+	}
+}
+
+// run calls j.run, turning a panic into a failed job so the worker keeps
+// serving the zone.
+func (q *queue) run(j *job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return j.run(q.ctx, j)
+}
+
+func (q *queue) get(id string) (Job, bool) {
+	q.mu.Lock()
+	j, ok := q.jobs[id]
+	q.mu.Unlock()
+	if !ok {
+		return Job{}, false
+	}
+	return j.snapshot(), true
+}
+
+// evict drops the oldest finished jobs beyond maxJobs. Callers hold q.mu.
+func (q *queue) evict() {
+	for i := 0; len(q.jobs) > maxJobs && i < len(q.order); {
+		id := q.order[i]
+		if !q.jobs[id].finished() {
+			i++
+			continue
+		}
+		delete(q.jobs, id)
+		q.order = append(q.order[:i], q.order[i+1:]...)
+	}
+}
+
+// wait blocks until every submitted job has finished.
+func (q *queue) wait() {
+	q.wg.Wait()
+}
+
+func newJobID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,328 @@
+// Package server exposes plan, diff and apply over a small authenticated
+// REST API. Applies run as jobs, serialized per zone.
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"ddnsjx/internal/app"
+	"ddnsjx/internal/config"
+	"ddnsjx/internal/dns"
+	"ddnsjx/internal/provider"
+	"ddnsjx/internal/telemetry"
+)
+
+// Options configures a Server.
+type Options struct {
+	// Token is the bearer token every /v1 request must carry.
+	Token string
+
+	// Config is the default config (usually a template) used by requests
+	// that do not send their own; its vars are overridden per request.
+	Config *config.FileConfig
+
+	// Provider is the default provider name; NewClient builds a client for
+	// a provider and zone.
+	Provider  string
+	NewClient func(providerName, zone string) (provider.Client, error)
+
+	// FitPlan checks a plan against the provider's capabilities before any
+	// call, writing warnings to warn (the job log for applies); nil skips
+	// the check.
+	FitPlan func(plan dns.Plan, caps provider.Capabilities, warn io.Writer) (dns.Plan, error)
+
+	// Runner holds the defaults for diff and apply; requests may only
+	// switch on Upsert.
+	Runner     app.RunnerOptions
+	RecordLine string
+
+	// Metrics, if set, is served unauthenticated on /metrics and counts
+	// the runner's retries.
+	Metrics *telemetry.Metrics
+}
+
+type Server struct {
+	opt    Options
+	queue  *queue
+	mux    *http.ServeMux
+	cancel context.CancelFunc
+}
+
+func New(opt Options) (*Server, error) {
+	if strings.TrimSpace(opt.Token) == "" {
+		return nil, errors.New("server: a bearer token is required")
+	}
+	if opt.NewClient == nil {
+		return nil, errors.New("server: NewClient is required")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Server{opt: opt, queue: newQueue(ctx), mux: http.NewServeMux(), cancel: cancel}
+	s.mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "ok\n")
+	})
+	if opt.Metrics != nil {
+		s.mux.Handle("GET /metrics", opt.Metrics.Handler())
+	}
+	s.mux.Handle("POST /v1/plan", s.auth(s.handlePlan))
+	s.mux.Handle("POST /v1/diff", s.auth(s.handleDiff))
+	s.mux.Handle("POST /v1/apply", s.auth(s.handleApply))
+	s.mux.Handle("GET /v1/jobs/{id}", s.auth(s.handleJob))
+	s.mux.Handle("GET /v1/jobs/{id}/log", s.auth(s.handleJobLog))
+	return s, nil
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Wait blocks until every queued and running job has finished.
+func (s *Server) Wait() {
+	s.queue.wait()
+}
+
+// Shutdown waits for queued and running jobs like Wait. If ctx is done
+// first, the remaining jobs are cancelled and Shutdown returns ctx's error
+// once they have stopped.
+func (s *Server) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.queue.wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+func (s *Server) auth(h http.HandlerFunc) http.Handler {
+	want := []byte("Bearer " + s.opt.Token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got := []byte(r.Header.Get("Authorization"))
+		if subtle.ConstantTimeCompare(got, want) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="stalwart-dns"`)
+			writeError(w, http.StatusUnauthorized, errors.New("missing or invalid bearer token"))
+			return
+		}
+		h(w, r)
+	})
+}
+
+// Request is the body of plan, diff and apply. Config replaces the
+// server's default config; Vars override its template variables.
+type Request struct {
+	Domain     string             `json:"domain"`
+	RecordLine string             `json:"record_line,omitempty"`
+	Provider   string             `json:"provider,omitempty"`
+	Vars       map[string]string  `json:"vars,omitempty"`
+	Config     *config.FileConfig `json:"config,omitempty"`
+	Upsert     bool               `json:"upsert,omitempty"`
+}
+
+// PlanRecord is the JSON form of a planned record.
+type PlanRecord struct {
+	Type     string  `json:"type"`
+	Name     string  `json:"name"`
+	Value    string  `json:"value"`
+	Priority *uint64 `json:"priority,omitempty"`
+	TTL      *uint64 `json:"ttl,omitempty"`
+	Line     string  `json:"line,omitempty"`
+	Remark   string  `json:"remark,omitempty"`
+}
+
+type planResponse struct {
+	Domain     string       `json:"domain"`
+	RecordLine string       `json:"record_line"`
+	Protected  []string     `json:"protected,omitempty"`
+	Records    []PlanRecord `json:"records"`
+}
+
+func (s *Server) handlePlan(w http.ResponseWriter, r *http.Request) {
+	_, plan, err := s.buildPlan(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toPlanResponse(plan))
+}
+
+func (s *Server) handleDiff(w http.ResponseWriter, r *http.Request) {
+	req, plan, err := s.buildPlan(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	var warnings bytes.Buffer
+	name, client, plan, err := s.client(req, plan, &warnings)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	entries, err := s.runner(name, client, req, io.Discard).Diff(r.Context(), plan)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	resp := map[string]any{"domain": plan.Domain, "changes": entries}
+	if warnings.Len() > 0 {
+		resp["warnings"] = strings.Split(strings.TrimSpace(warnings.String()), "\n")
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleApply(w http.ResponseWriter, r *http.Request) {
+	req, plan, err := s.buildPlan(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	// The plan is fitted now so a bad request fails with 400; its warnings
+	// go to the head of the job log.
+	var warnings bytes.Buffer
+	name, client, plan, err := s.client(req, plan, &warnings)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	zone := strings.ToLower(plan.Domain)
+	j := s.queue.submit(zone, func(ctx context.Context, j *job) (err error) {
+		ctx, span := telemetry.StartSpan(ctx, "server.job", telemetry.String("job.id", j.info.ID), telemetry.String("dns.zone", zone))
+		defer func() { span.End(err) }()
+
+		_, _ = j.Write(warnings.Bytes())
+		app.PrintPlan(j, plan)
+		return s.runner(name, client, req, j).Apply(ctx, plan)
+	})
+	w.Header().Set("Location", "/v1/jobs/"+j.ID)
+	writeJSON(w, http.StatusAccepted, j)
+}
+
+func (s *Server) handleJob(w http.ResponseWriter, r *http.Request) {
+	j, ok := s.queue.get(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, errors.New("job not found"))
+		return
+	}
+	writeJSON(w, http.StatusOK, j)
+}
+
+func (s *Server) handleJobLog(w http.ResponseWriter, r *http.Request) {
+	j, ok := s.queue.get(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, errors.New("job not found"))
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = io.WriteString(w, j.Log)
+}
+
+// maxBody bounds request bodies.
+const maxBody = 1 << 20
+
+func (s *Server) buildPlan(r *http.Request) (Request, dns.Plan, error) {
+	var req Request
+	dec := json.NewDecoder(http.MaxBytesReader(nil, r.Body, maxBody))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		return req, dns.Plan{}, fmt.Errorf("invalid request body: %w", err)
+	}
+
+	base := req.Config
+	if base == nil {
+		if s.opt.Config == nil {
+			return req, dns.Plan{}, errors.New("config is required (the server has no default config)")
+		}
+		base = s.opt.Config
+	}
+	cfg, err := config.Resolve(*base, req.Vars)
+	if err != nil {
+		return req, dns.Plan{}, err
+	}
+
+	domain := strings.TrimSuffix(strings.TrimSpace(req.Domain), ".")
+	if domain == "" {
+		domain = config.InferDomain(cfg.Records)
+	}
+	if domain == "" {
+		return req, dns.Plan{}, errors.New("domain is required or inferable from config")
+	}
+	line := req.RecordLine
+	if line == "" {
+		line = s.opt.RecordLine
+	}
+	plan, err := config.BuildPlanFromFile(domain, line, cfg)
+	if err != nil {
+		return req, dns.Plan{}, err
+	}
+	return req, plan, nil
+}
+
+// client builds the client for req's provider and fits plan to its
+// capabilities, writing warnings to warn. It also returns the provider
+// name.
+func (s *Server) client(req Request, plan dns.Plan, warn io.Writer) (string, provider.Client, dns.Plan, error) {
+	name := req.Provider
+	if name == "" {
+		name = s.opt.Provider
+	}
+	client, err := s.opt.NewClient(name, plan.Domain)
+	if err != nil {
+		return name, nil, plan, err
+	}
+	if s.opt.FitPlan != nil {
+		if plan, err = s.opt.FitPlan(plan, client.Capabilities(), warn); err != nil {
+			return name, nil, plan, err
+		}
+	}
+	return name, client, plan, nil
+}
+
+// runner returns a runner for client with the server defaults and req's
+// options, printing to out.
+func (s *Server) runner(name string, client provider.Client, req Request, out io.Writer) *app.Runner {
+	opt := s.opt.Runner
+	opt.Upsert = opt.Upsert || req.Upsert
+	opt.Out = out
+	if s.opt.Metrics != nil {
+		opt.OnRetry = func(operation string, err error) {
+			s.opt.Metrics.Retry(name, operation)
+		}
+	}
+	return app.NewRunner(client, opt)
+}
+
+func toPlanResponse(plan dns.Plan) planResponse {
+	out := planResponse{Domain: plan.Domain, RecordLine: plan.RecordLine, Protected: plan.Protected, Records: []PlanRecord{}}
+	for _, r := range plan.Records {
+		out.Records = append(out.Records, PlanRecord{
+			Type: r.Type, Name: r.SubDomain, Value: r.Value, Priority: r.Priority,
+			TTL: r.TTL, Line: r.Line, Remark: r.Remark,
+		})
+	}
+	return out
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,250 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"ddnsjx/internal/app"
+	"ddnsjx/internal/config"
+	"ddnsjx/internal/dns"
+	"ddnsjx/internal/provider"
+	"ddnsjx/internal/provider/memprovider"
+)
+
+// slowClient delays creates and records how many run at once per zone.
+type slowClient struct {
+	*memprovider.Client
+	active  map[string]*int32
+	maxSeen *int32
+	mu      *sync.Mutex
+}
+
+func (c slowClient) CreateRecord(ctx context.Context, zone, line string, rec dns.Record) (string, provider.CreateStatus, error) {
+	c.mu.Lock()
+	n, ok := c.active[zone]
+	if !ok {
+		n = new(int32)
+		c.active[zone] = n
+	}
+	c.mu.Unlock()
+	cur := atomic.AddInt32(n, 1)
+	defer atomic.AddInt32(n, -1)
+	for {
+		old := atomic.LoadInt32(c.maxSeen)
+		if cur <= old || atomic.CompareAndSwapInt32(c.maxSeen, old, cur) {
+			break
+		}
+	}
+	time.Sleep(5 * time.Millisecond)
+	return c.Client.CreateRecord(ctx, zone, line, rec)
+}
+
+func newTestServer(t *testing.T) (*Server, *memprovider.Client, *int32) {
+	t.Helper()
+	mem := memprovider.New(memprovider.Options{Zones: []string{"a.example", "b.example"}})
+	maxSeen := new(int32)
+	client := slowClient{Client: mem, active: map[string]*int32{}, maxSeen: maxSeen, mu: &sync.Mutex{}}
+	tpl := &config.FileConfig{Records: []config.RawRecord{
+		{Type: "MX", Name: "{{ .Domain }}.", Contents: "10 mail.{{ .Domain }}."},
+		{Type: "TXT", Name: "{{ .Domain }}.", Contents: "v=spf1 mx -all"},
+	}}
+	s, err := New(Options{
+		Token:     "secret",
+		Config:    tpl,
+		Provider:  "mem",
+		NewClient: func(name, zone string) (provider.Client, error) { return client, nil },
+		Runner:    app.RunnerOptions{Ownership: app.OwnershipOff},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, mem, maxSeen
+}
+
+func do(t *testing.T, s *Server, method, path, token, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestServerAuthAndPlan(t *testing.T) {
+	s, _, _ := newTestServer(t)
+
+	if rec := do(t, s, "POST", "/v1/plan", "", `{}`); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without token, got %d", rec.Code)
+	}
+	if rec := do(t, s, "POST", "/v1/plan", "wrong", `{}`); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 with a wrong token, got %d", rec.Code)
+	}
+
+	rec := do(t, s, "POST", "/v1/plan", "secret", `{"domain":"a.example","vars":{"Domain":"a.example"}}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("plan: %d %s", rec.Code, rec.Body)
+	}
+	var plan planResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &plan); err != nil {
+		t.Fatal(err)
+	}
+	if plan.Domain != "a.example" || len(plan.Records) != 2 || plan.Records[0].Value != "mail.a.example" {
+		t.Fatalf("unexpected plan: %+v", plan)
+	}
+
+	if rec := do(t, s, "POST", "/v1/plan", "secret", `{"domain":"a.example"}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a missing template variable, got %d", rec.Code)
+	}
+
+	rec = do(t, s, "POST", "/v1/diff", "secret", `{"domain":"a.example","vars":{"Domain":"a.example"}}`)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"action": "create"`) {
+		t.Fatalf("diff: %d %s", rec.Code, rec.Body)
+	}
+}
+
+func TestServerApplyJobsSerializedPerZone(t *testing.T) {
+	s, mem, maxSeen := newTestServer(t)
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		for _, zone := range []string{"a.example", "b.example"} {
+			rec := do(t, s, "POST", "/v1/apply", "secret", `{"domain":"`+zone+`","vars":{"Domain":"`+zone+`"}}`)
+			if rec.Code != http.StatusAccepted {
+				t.Fatalf("apply: %d %s", rec.Code, rec.Body)
+			}
+			var j Job
+			if err := json.Unmarshal(rec.Body.Bytes(), &j); err != nil {
+				t.Fatal(err)
+			}
+			if rec.Header().Get("Location") != "/v1/jobs/"+j.ID {
+				t.Fatalf("unexpected Location %q", rec.Header().Get("Location"))
+			}
+			ids = append(ids, j.ID)
+		}
+	}
+	s.Wait()
+
+	if *maxSeen != 1 {
+		t.Fatalf("expected at most one running job per zone, saw %d", *maxSeen)
+	}
+	for _, zone := range []string{"a.example", "b.example"} {
+		if n := len(mem.Records(zone)); n != 2 {
+			t.Fatalf("%s: expected 2 records, got %d", zone, n)
+		}
+	}
+
+	rec := do(t, s, "GET", "/v1/jobs/"+ids[0], "secret", "")
+	var j Job
+	if err := json.Unmarshal(rec.Body.Bytes(), &j); err != nil {
+		t.Fatal(err)
+	}
+	if j.Status != JobSucceeded || j.Started == nil || j.Finished == nil || !strings.Contains(j.Log, "done") {
+		t.Fatalf("unexpected job: %+v", j)
+	}
+	rec = do(t, s, "GET", "/v1/jobs/"+ids[2]+"/log", "secret", "")
+	if !strings.Contains(rec.Body.String(), "exists (skip)") {
+		t.Fatalf("later job should see the records created by the first:\n%s", rec.Body)
+	}
+	if rec := do(t, s, "GET", "/v1/jobs/nope", "secret", ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown job, got %d", rec.Code)
+	}
+}
+
+func TestServerApplyBuildsClientOnce(t *testing.T) {
+	mem := memprovider.New(memprovider.Options{})
+	var built int32
+	s, err := New(Options{
+		Token:    "secret",
+		Config:   &config.FileConfig{Records: []config.RawRecord{{Type: "TXT", Name: "a.example.", Contents: "v=spf1 -all"}}},
+		Provider: "mem",
+		NewClient: func(name, zone string) (provider.Client, error) {
+			atomic.AddInt32(&built, 1)
+			return mem, nil
+		},
+		Runner: app.RunnerOptions{Ownership: app.OwnershipOff},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec := do(t, s, "POST", "/v1/apply", "secret", `{"domain":"a.example"}`); rec.Code != http.StatusAccepted {
+		t.Fatalf("apply: %d %s", rec.Code, rec.Body)
+	}
+	s.Wait()
+	if built != 1 || len(mem.Records("a.example")) != 1 {
+		t.Fatalf("expected one client for one apply, built %d", built)
+	}
+}
+
+func TestServerFitPlanWarningsGoToJobLog(t *testing.T) {
+	mem := memprovider.New(memprovider.Options{})
+	s, err := New(Options{
+		Token:     "secret",
+		Config:    &config.FileConfig{Records: []config.RawRecord{{Type: "TXT", Name: "a.example.", Contents: "v=spf1 -all"}}},
+		Provider:  "mem",
+		NewClient: func(name, zone string) (provider.Client, error) { return mem, nil },
+		FitPlan: func(plan dns.Plan, caps provider.Capabilities, warn io.Writer) (dns.Plan, error) {
+			fmt.Fprintln(warn, "warning: dropped comment")
+			return plan, nil
+		},
+		Runner: app.RunnerOptions{Ownership: app.OwnershipOff},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := do(t, s, "POST", "/v1/diff", "secret", `{"domain":"a.example"}`)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"warning: dropped comment"`) {
+		t.Fatalf("diff should report the warning: %d %s", rec.Code, rec.Body)
+	}
+
+	rec = do(t, s, "POST", "/v1/apply", "secret", `{"domain":"a.example"}`)
+	var j Job
+	if err := json.Unmarshal(rec.Body.Bytes(), &j); err != nil {
+		t.Fatal(err)
+	}
+	s.Wait()
+	rec = do(t, s, "GET", "/v1/jobs/"+j.ID+"/log", "secret", "")
+	if !strings.HasPrefix(rec.Body.String(), "warning: dropped comment\n") {
+		t.Fatalf("job log should start with the warning:\n%s", rec.Body)
+	}
+}
+
+func TestServerJobsRecoverAndCancel(t *testing.T) {
+	s, _, _ := newTestServer(t)
+
+	panicked := s.queue.submit("a.example", func(ctx context.Context, j *job) error {
+		panic("boom")
+	})
+	after := s.queue.submit("a.example", func(ctx context.Context, j *job) error { return nil })
+	s.Wait()
+	if j, _ := s.queue.get(panicked.ID); j.Status != JobFailed || !strings.Contains(j.Error, "boom") {
+		t.Fatalf("expected the panic to fail the job, got %+v", j)
+	}
+	if j, _ := s.queue.get(after.ID); j.Status != JobSucceeded {
+		t.Fatalf("expected the zone to keep serving jobs, got %+v", j)
+	}
+
+	blocked := s.queue.submit("b.example", func(ctx context.Context, j *job) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := s.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected shutdown to time out, got %v", err)
+	}
+	if j, _ := s.queue.get(blocked.ID); j.Status != JobFailed || !strings.Contains(j.Error, "canceled") {
+		t.Fatalf("expected the running job to be cancelled, got %+v", j)
+	}
+}
@@ -0,0 +1,158 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"ddnsjx/internal/dns"
+	"ddnsjx/internal/provider"
+)
+
+// Instrument wraps client so every call is timed into m and traced as a
+// client span. The wrapper implements every optional provider interface;
+// callers find out which ones client really has with provider.As, which
+// looks through Unwrap.
+func Instrument(client provider.Client, providerName string, m *Metrics) provider.Client {
+	return &instrumented{inner: client, provider: providerName, metrics: m}
+}
+
+type instrumented struct {
+	inner    provider.Client
+	provider string
+	metrics  *Metrics
+}
+
+func (c *instrumented) Unwrap() provider.Client {
+	return c.inner
+}
+
+// call times fn and records it as operation.
+func (c *instrumented) call(ctx context.Context, operation, zone string, rec *dns.Record, fn func(ctx context.Context) error) error {
+	attrs := []Attr{String("dns.provider", c.provider), String("dns.operation", operation), String("dns.zone", zone)}
+	if rec != nil {
+		attrs = append(attrs, String("dns.record.type", rec.Type), String("dns.record.name", rec.SubDomain))
+	}
+	ctx, span := StartClientSpan(ctx, "provider."+operation, attrs...)
+	start := time.Now()
+	err := fn(ctx)
+	c.metrics.ObserveCall(c.provider, operation, time.Since(start), err)
+	if err != nil {
+		span.SetAttr("dns.error_code", ErrorCode(err))
+	}
+	span.End(err)
+	return err
+}
+
+// optional returns the inner client's T, or an error for a caller that
+// skipped provider.As.
+func optional[T any](c *instrumented) (T, error) {
+	t, ok := provider.As[T](c.inner)
+	if !ok {
+		var zero T
+		return zero, fmt.Errorf("%s client does not implement %T", c.provider, (*T)(nil))
+	}
+	return t, nil
+}
+
+func (c *instrumented) Capabilities() provider.Capabilities {
+	return c.inner.Capabilities()
+}
+
+func (c *instrumented) CreateRecord(ctx context.Context, zone string, recordLine string, record dns.Record) (id string, status provider.CreateStatus, err error) {
+	err = c.call(ctx, "create", zone, &record, func(ctx context.Context) error {
+		id, status, err = c.inner.CreateRecord(ctx, zone, recordLine, record)
+		return err
+	})
+	return id, status, err
+}
+
+func (c *instrumented) DeleteRecord(ctx context.Context, zone string, recordID string) error {
+	return c.call(ctx, "delete", zone, nil, func(ctx context.Context) error {
+		return c.inner.DeleteRecord(ctx, zone, recordID)
+	})
+}
+
+func (c *instrumented) FindRecord(ctx context.Context, zone string, recordLine string, record dns.Record) (id string, found bool, err error) {
+	err = c.call(ctx, "find", zone, &record, func(ctx context.Context) error {
+		id, found, err = c.inner.FindRecord(ctx, zone, recordLine, record)
+		return err
+	})
+	return id, found, err
+}
+
+func (c *instrumented) UpdateRecord(ctx context.Context, zone string, recordLine string, recordID string, record dns.Record) error {
+	return c.call(ctx, "update", zone, &record, func(ctx context.Context) error {
+		return c.inner.UpdateRecord(ctx, zone, recordLine, recordID, record)
+	})
+}
+
+func (c *instrumented) ApplyBatch(ctx context.Context, zone string, recordLine string, changes []provider.Change) (results []provider.ChangeResult, err error) {
+	b, err := optional[provider.Batcher](c)
+	if err != nil {
+		return nil, err
+	}
+	err = c.call(ctx, "batch", zone, nil, func(ctx context.Context) error {
+		results, err = b.ApplyBatch(ctx, zone, recordLine, changes)
+		return err
+	})
+	return results, err
+}
+
+func (c *instrumented) RecordOwner(ctx context.Context, zone string, recordID string) (owner string, err error) {
+	o, err := optional[provider.OwnerReader](c)
+	if err != nil {
+		return "", err
+	}
+	err = c.call(ctx, "owner", zone, nil, func(ctx context.Context) error {
+		owner, err = o.RecordOwner(ctx, zone, recordID)
+		return err
+	})
+	return owner, err
+}
+
+func (c *instrumented) Finalize(ctx context.Context, zone string) error {
+	f, err := optional[provider.Finalizer](c)
+	if err != nil {
+		return err
+	}
+	return c.call(ctx, "finalize", zone, nil, func(ctx context.Context) error {
+		return f.Finalize(ctx, zone)
+	})
+}
+
+func (c *instrumented) DSRecords(ctx context.Context, zone string) (ds []string, err error) {
+	r, err := optional[provider.DSReader](c)
+	if err != nil {
+		return nil, err
+	}
+	err = c.call(ctx, "ds", zone, nil, func(ctx context.Context) error {
+		ds, err = r.DSRecords(ctx, zone)
+		return err
+	})
+	return ds, err
+}
+
+func (c *instrumented) RecordUpToDate(ctx context.Context, zone string, recordID string, record dns.Record) (ok bool, err error) {
+	m, err := optional[provider.RecordMatcher](c)
+	if err != nil {
+		return false, err
+	}
+	err = c.call(ctx, "match", zone, &record, func(ctx context.Context) error {
+		ok, err = m.RecordUpToDate(ctx, zone, recordID, record)
+		return err
+	})
+	return ok, err
+}
+
+func (c *instrumented) ListRecords(ctx context.Context, zone string, recordLine string, record dns.Record) (records []provider.ExistingRecord, err error) {
+	l, err := optional[provider.RecordLister](c)
+	if err != nil {
+		return nil, err
+	}
+	err = c.call(ctx, "list", zone, &record, func(ctx context.Context) error {
+		records, err = l.ListRecords(ctx, zone, recordLine, record)
+		return err
+	})
+	return records, err
+}
@@ -0,0 +1,200 @@
+// Package telemetry records provider call metrics in the Prometheus text
+// format and traces runs as OpenTelemetry spans exported over OTLP/HTTP.
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// durationBuckets are the upper bounds, in seconds, of the latency
+// histogram. DNS APIs usually answer within a second; batches and
+// throttled calls take longer.
+var durationBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+type callKey struct {
+	provider, operation string
+}
+
+type errorKey struct {
+	provider, operation, code string
+}
+
+type histogram struct {
+	counts []uint64 // per bucket, not cumulative
+	sum    float64
+	count  uint64
+}
+
+// Metrics counts provider calls. The zero value is not usable; use
+// NewMetrics.
+type Metrics struct {
+	mu        sync.Mutex
+	durations map[callKey]*histogram
+	errors    map[errorKey]uint64
+	retries   map[callKey]uint64
+}
+
+func NewMetrics() *Metrics {
+	return &Metrics{
+		durations: make(map[callKey]*histogram),
+		errors:    make(map[errorKey]uint64),
+		retries:   make(map[callKey]uint64),
+	}
+}
+
+// ObserveCall records one call's latency and, when err is non-nil, an
+// error labelled with ErrorCode(err).
+func (m *Metrics) ObserveCall(provider, operation string, d time.Duration, err error) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	k := callKey{provider, operation}
+	h, ok := m.durations[k]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(durationBuckets))}
+		m.durations[k] = h
+	}
+	s := d.Seconds()
+	for i, le := range durationBuckets {
+		if s <= le {
+			h.counts[i]++
+			break
+		}
+	}
+	h.sum += s
+	h.count++
+
+	if err != nil {
+		m.errors[errorKey{provider, operation, ErrorCode(err)}]++
+	}
+}
+
+// Retry records that a call is about to be retried.
+func (m *Metrics) Retry(provider, operation string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.retries[callKey{provider, operation}]++
+}
+
+// coded is implemented by provider errors that carry the API's error code,
+// such as dnspodclient.Error and cloudflareclient.Error.
+type coded interface {
+	ErrorCode() string
+}
+
+// ErrorCode returns the provider error code of err, "timeout" or
+// "canceled" for context errors and "other" for anything else.
+func ErrorCode(err error) string {
+	var c coded
+	switch {
+	case errors.As(err, &c) && c.ErrorCode() != "":
+		return c.ErrorCode()
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	}
+	return "other"
+}
+
+// WritePrometheus writes every metric in the Prometheus text exposition
+// format, sorted by labels so scrapes are stable.
+func (m *Metrics) WritePrometheus(w io.Writer) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var b strings.Builder
+	b.WriteString("# HELP stalwart_dns_provider_request_duration_seconds Latency of provider API calls.\n")
+	b.WriteString("# TYPE stalwart_dns_provider_request_duration_seconds histogram\n")
+	keys := make([]callKey, 0, len(m.durations))
+	for k := range m.durations {
+		keys = append(keys, k)
+	}
+	sortCallKeys(keys)
+	for _, k := range keys {
+		h := m.durations[k]
+		labels := fmt.Sprintf(`provider="%s",operation="%s"`, escapeLabel(k.provider), escapeLabel(k.operation))
+		var cum uint64
+		for i, le := range durationBuckets {
+			cum += h.counts[i]
+			fmt.Fprintf(&b, "stalwart_dns_provider_request_duration_seconds_bucket{%s,le=\"%s\"} %d\n", labels, strconv.FormatFloat(le, 'g', -1, 64), cum)
+		}
+		fmt.Fprintf(&b, "stalwart_dns_provider_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, h.count)
+		fmt.Fprintf(&b, "stalwart_dns_provider_request_duration_seconds_sum{%s} %s\n", labels, strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(&b, "stalwart_dns_provider_request_duration_seconds_count{%s} %d\n", labels, h.count)
+	}
+
+	b.WriteString("# HELP stalwart_dns_provider_errors_total Failed provider API calls by error code.\n")
+	b.WriteString("# TYPE stalwart_dns_provider_errors_total counter\n")
+	ekeys := make([]errorKey, 0, len(m.errors))
+	for k := range m.errors {
+		ekeys = append(ekeys, k)
+	}
+	sort.Slice(ekeys, func(i, j int) bool {
+		a, c := ekeys[i], ekeys[j]
+		if a.provider != c.provider {
+			return a.provider < c.provider
+		}
+		if a.operation != c.operation {
+			return a.operation < c.operation
+		}
+		return a.code < c.code
+	})
+	for _, k := range ekeys {
+		fmt.Fprintf(&b, "stalwart_dns_provider_errors_total{provider=\"%s\",operation=\"%s\",code=\"%s\"} %d\n",
+			escapeLabel(k.provider), escapeLabel(k.operation), escapeLabel(k.code), m.errors[k])
+	}
+
+	b.WriteString("# HELP stalwart_dns_provider_retries_total Provider API calls retried after a transient error.\n")
+	b.WriteString("# TYPE stalwart_dns_provider_retries_total counter\n")
+	keys = keys[:0]
+	for k := range m.retries {
+		keys = append(keys, k)
+	}
+	sortCallKeys(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&b, "stalwart_dns_provider_retries_total{provider=\"%s\",operation=\"%s\"} %d\n",
+			escapeLabel(k.provider), escapeLabel(k.operation), m.retries[k])
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Handler serves the metrics for Prometheus to scrape.
+func (m *Metrics) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = m.WritePrometheus(w)
+	})
+}
+
+func sortCallKeys(keys []callKey) {
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].provider != keys[j].provider {
+			return keys[i].provider < keys[j].provider
+		}
+		return keys[i].operation < keys[j].operation
+	})
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
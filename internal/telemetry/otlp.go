@@ -0,0 +1,253 @@
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	otlpBatchSize     = 256
+	otlpFlushInterval = 2 * time.Second
+	otlpQueueSize     = 4096
+)
+
+// OTLPExporter sends spans to an OpenTelemetry collector with OTLP/HTTP in
+// its JSON encoding. Spans are batched in the background; spans arriving
+// while the queue is full are dropped rather than slowing down DNS calls.
+type OTLPExporter struct {
+	url     string
+	headers map[string]string
+	service string
+	client  *http.Client
+
+	queue chan SpanData
+	flush chan chan struct{}
+	done  chan struct{}
+	once  sync.Once
+
+	mu      sync.Mutex
+	lastErr error
+}
+
+// NewOTLPExporter exports to endpoint, the collector's base URL (spans go
+// to <endpoint>/v1/traces unless the URL already ends in that path).
+// headers are added to every request, e.g. for collector authentication.
+func NewOTLPExporter(endpoint, serviceName string, headers map[string]string) *OTLPExporter {
+	url := strings.TrimRight(strings.TrimSpace(endpoint), "/")
+	if !strings.HasSuffix(url, "/v1/traces") {
+		url += "/v1/traces"
+	}
+	e := &OTLPExporter{
+		url:     url,
+		headers: headers,
+		service: serviceName,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		queue:   make(chan SpanData, otlpQueueSize),
+		flush:   make(chan chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go e.loop()
+	return e
+}
+
+func (e *OTLPExporter) Export(s SpanData) {
+	select {
+	case e.queue <- s:
+	default:
+	}
+}
+
+// Shutdown sends the spans still queued and stops the exporter. It returns
+// the last export error, if any.
+func (e *OTLPExporter) Shutdown(ctx context.Context) error {
+	e.once.Do(func() {
+		ack := make(chan struct{})
+		select {
+		case e.flush <- ack:
+			select {
+			case <-ack:
+			case <-ctx.Done():
+			}
+		case <-ctx.Done():
+		}
+		close(e.done)
+	})
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.lastErr
+}
+
+func (e *OTLPExporter) loop() {
+	ticker := time.NewTicker(otlpFlushInterval)
+	defer ticker.Stop()
+
+	var batch []SpanData
+	send := func() {
+		if len(batch) == 0 {
+			return
+		}
+		err := e.send(batch)
+		e.mu.Lock()
+		e.lastErr = err
+		e.mu.Unlock()
+		batch = nil
+	}
+	for {
+		select {
+		case s := <-e.queue:
+			batch = append(batch, s)
+			if len(batch) >= otlpBatchSize {
+				send()
+			}
+		case <-ticker.C:
+			send()
+		case ack := <-e.flush:
+			for drained := false; !drained; {
+				select {
+				case s := <-e.queue:
+					batch = append(batch, s)
+				default:
+					drained = true
+				}
+			}
+			send()
+			close(ack)
+		case <-e.done:
+			return
+		}
+	}
+}
+
+func (e *OTLPExporter) send(spans []SpanData) error {
+	body, err := json.Marshal(otlpRequest(e.service, spans))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("otlp export: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("otlp export: %s returned %s", e.url, resp.Status)
+	}
+	return nil
+}
+
+// OTLP JSON payload (opentelemetry-proto ExportTraceServiceRequest).
+type (
+	otlpAnyValue struct {
+		StringValue string `json:"stringValue"`
+	}
+	otlpKeyValue struct {
+		Key   string       `json:"key"`
+		Value otlpAnyValue `json:"value"`
+	}
+	otlpStatus struct {
+		Code    int    `json:"code"`
+		Message string `json:"message,omitempty"`
+	}
+	otlpSpan struct {
+		TraceID           string         `json:"traceId"`
+		SpanID            string         `json:"spanId"`
+		ParentSpanID      string         `json:"parentSpanId,omitempty"`
+		Name              string         `json:"name"`
+		Kind              int            `json:"kind"`
+		StartTimeUnixNano string         `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+		Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+		Status            otlpStatus     `json:"status"`
+	}
+	otlpScopeSpans struct {
+		Scope struct {
+			Name string `json:"name"`
+		} `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	otlpResourceSpans struct {
+		Resource struct {
+			Attributes []otlpKeyValue `json:"attributes"`
+		} `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpTraces struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+)
+
+const (
+	otlpKindInternal = 1
+	otlpKindClient   = 3
+	otlpStatusOK     = 1
+	otlpStatusError  = 2
+)
+
+func otlpRequest(service string, spans []SpanData) otlpTraces {
+	var scope otlpScopeSpans
+	scope.Scope.Name = "ddnsjx"
+	for _, s := range spans {
+		out := otlpSpan{
+			TraceID:           hex.EncodeToString(s.TraceID[:]),
+			SpanID:            hex.EncodeToString(s.SpanID[:]),
+			Name:              s.Name,
+			Kind:              otlpKindInternal,
+			StartTimeUnixNano: strconv.FormatInt(s.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.End.UnixNano(), 10),
+			Status:            otlpStatus{Code: otlpStatusOK},
+		}
+		if s.ParentID != ([8]byte{}) {
+			out.ParentSpanID = hex.EncodeToString(s.ParentID[:])
+		}
+		if s.Client {
+			out.Kind = otlpKindClient
+		}
+		for _, a := range s.Attrs {
+			out.Attributes = append(out.Attributes, otlpKeyValue{Key: a.Key, Value: otlpAnyValue{StringValue: a.Value}})
+		}
+		if s.Err != "" {
+			out.Status = otlpStatus{Code: otlpStatusError, Message: s.Err}
+		}
+		scope.Spans = append(scope.Spans, out)
+	}
+
+	var rs otlpResourceSpans
+	rs.Resource.Attributes = []otlpKeyValue{{Key: "service.name", Value: otlpAnyValue{StringValue: service}}}
+	rs.ScopeSpans = []otlpScopeSpans{scope}
+	return otlpTraces{ResourceSpans: []otlpResourceSpans{rs}}
+}
+
+// ParseHeaders parses the OTEL_EXPORTER_OTLP_HEADERS format
+// "key1=value1,key2=value2".
+func ParseHeaders(s string) (map[string]string, error) {
+	out := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(k) == "" {
+			return nil, fmt.Errorf("invalid OTLP header %q (expected key=value)", pair)
+		}
+		out[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return out, nil
+}
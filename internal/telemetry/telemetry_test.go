@@ -0,0 +1,215 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"ddnsjx/internal/dns"
+	"ddnsjx/internal/provider"
+	"ddnsjx/internal/provider/memprovider"
+)
+
+type codedError struct{ code string }
+
+func (e codedError) Error() string     { return "[" + e.code + "] boom" }
+func (e codedError) ErrorCode() string { return e.code }
+
+func TestMetricsPrometheusOutput(t *testing.T) {
+	m := NewMetrics()
+	m.ObserveCall("dnspod", "create", 30*time.Millisecond, nil)
+	m.ObserveCall("dnspod", "create", 700*time.Millisecond, fmt.Errorf("wrapped: %w", codedError{"RequestLimitExceeded"}))
+	m.ObserveCall("cloudflare", "find", time.Minute, context.DeadlineExceeded)
+	m.Retry("dnspod", "create")
+
+	var b strings.Builder
+	if err := m.WritePrometheus(&b); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		`stalwart_dns_provider_request_duration_seconds_bucket{provider="dnspod",operation="create",le="0.05"} 1`,
+		`stalwart_dns_provider_request_duration_seconds_bucket{provider="dnspod",operation="create",le="0.5"} 1`,
+		`stalwart_dns_provider_request_duration_seconds_bucket{provider="dnspod",operation="create",le="1"} 2`,
+		`stalwart_dns_provider_request_duration_seconds_bucket{provider="cloudflare",operation="find",le="30"} 0`,
+		`stalwart_dns_provider_request_duration_seconds_bucket{provider="cloudflare",operation="find",le="+Inf"} 1`,
+		`stalwart_dns_provider_request_duration_seconds_count{provider="dnspod",operation="create"} 2`,
+		`stalwart_dns_provider_errors_total{provider="cloudflare",operation="find",code="timeout"} 1`,
+		`stalwart_dns_provider_errors_total{provider="dnspod",operation="create",code="RequestLimitExceeded"} 1`,
+		`stalwart_dns_provider_retries_total{provider="dnspod",operation="create"} 1`,
+	} {
+		if !strings.Contains(out, want+"\n") {
+			t.Fatalf("missing %q in:\n%s", want, out)
+		}
+	}
+	if strings.Index(out, `provider="cloudflare"`) > strings.Index(out, `provider="dnspod"`) {
+		t.Fatalf("series should be sorted by provider:\n%s", out)
+	}
+}
+
+type recorder struct {
+	mu    sync.Mutex
+	spans []SpanData
+}
+
+func (r *recorder) Export(s SpanData) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = append(r.spans, s)
+}
+
+type failingBatcher struct {
+	*memprovider.Client
+}
+
+func (failingBatcher) ApplyBatch(ctx context.Context, zone, line string, changes []provider.Change) ([]provider.ChangeResult, error) {
+	return nil, codedError{"81057"}
+}
+
+type dsClient struct {
+	*memprovider.Client
+}
+
+func (dsClient) DSRecords(ctx context.Context, zone string) ([]string, error) {
+	return []string{"12345 13 2 aa11"}, nil
+}
+
+func TestInstrumentKeepsCapabilitiesAndTraces(t *testing.T) {
+	rec := &recorder{}
+	SetExporter(rec)
+	defer SetExporter(nil)
+
+	mem := memprovider.New(memprovider.Options{Zones: []string{"example.com"}})
+	m := NewMetrics()
+
+	plain := Instrument(mem, "mem", m)
+	if _, ok := provider.As[provider.OwnerReader](plain); !ok {
+		t.Fatalf("wrapper should forward provider.OwnerReader")
+	}
+	if _, ok := provider.As[provider.Batcher](plain); ok {
+		t.Fatalf("wrapper must not claim provider.Batcher for a client without it")
+	}
+	if _, ok := provider.As[provider.DSReader](plain); ok {
+		t.Fatalf("wrapper must not claim provider.DSReader for a client without it")
+	}
+
+	ctx, root := StartSpan(context.Background(), "runner.apply")
+	if _, _, err := plain.CreateRecord(ctx, "example.com", "", dns.Record{SubDomain: "mail", Type: "A", Value: "192.0.2.1"}); err != nil {
+		t.Fatal(err)
+	}
+	root.End(nil)
+
+	batcher := Instrument(failingBatcher{mem}, "cf", m)
+	b, ok := provider.As[provider.Batcher](batcher)
+	if !ok {
+		t.Fatalf("wrapper should forward provider.Batcher")
+	}
+	if _, isInner := b.(failingBatcher); isInner {
+		t.Fatalf("provider.As should return the instrumented batcher, not the inner client")
+	}
+	if _, err := b.ApplyBatch(context.Background(), "example.com", "", nil); err == nil {
+		t.Fatalf("expected the batch error to be returned")
+	}
+
+	if len(rec.spans) != 3 {
+		t.Fatalf("expected 3 spans, got %+v", rec.spans)
+	}
+	create, apply, batch := rec.spans[0], rec.spans[1], rec.spans[2]
+	if create.Name != "provider.create" || !create.Client || create.TraceID != apply.TraceID || create.ParentID != apply.SpanID {
+		t.Fatalf("provider span should be a client child of the runner span: %+v / %+v", create, apply)
+	}
+	if batch.Err == "" || batch.TraceID == apply.TraceID {
+		t.Fatalf("batch span should be a failed root span: %+v", batch)
+	}
+
+	signer, ok := provider.As[provider.DSReader](Instrument(dsClient{mem}, "desec", m))
+	if !ok {
+		t.Fatalf("wrapper should forward provider.DSReader")
+	}
+	if ds, err := signer.DSRecords(context.Background(), "example.com"); err != nil || len(ds) != 1 {
+		t.Fatalf("DSRecords: %v %v", ds, err)
+	}
+
+	var out strings.Builder
+	_ = m.WritePrometheus(&out)
+	for _, want := range []string{
+		`stalwart_dns_provider_errors_total{provider="cf",operation="batch",code="81057"} 1`,
+		`stalwart_dns_provider_request_duration_seconds_count{provider="desec",operation="ds"} 1`,
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("missing %q in:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestOTLPExporter(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		bodies [][]byte
+		auth   string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/json" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, body)
+		auth = r.Header.Get("Authorization")
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	headers, err := ParseHeaders("Authorization=Bearer abc, X-Scope = ops")
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := NewOTLPExporter(srv.URL+"/", "stalwart-dns-test", headers)
+	exp.Export(SpanData{
+		TraceID: [16]byte{1}, SpanID: [8]byte{2}, ParentID: [8]byte{3}, Name: "provider.create", Client: true,
+		Start: time.Unix(1, 0), End: time.Unix(2, 0), Attrs: []Attr{String("dns.provider", "dnspod")}, Err: "boom",
+	})
+	if err := exp.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(bodies) != 1 || auth != "Bearer abc" {
+		t.Fatalf("expected one authenticated export, got %d (auth %q)", len(bodies), auth)
+	}
+	var got otlpTraces
+	if err := json.Unmarshal(bodies[0], &got); err != nil {
+		t.Fatal(err)
+	}
+	rs := got.ResourceSpans[0]
+	span := rs.ScopeSpans[0].Spans[0]
+	if rs.Resource.Attributes[0].Value.StringValue != "stalwart-dns-test" ||
+		span.TraceID != "01000000000000000000000000000000" || span.ParentSpanID != "0300000000000000" ||
+		span.Kind != otlpKindClient || span.StartTimeUnixNano != "1000000000" ||
+		span.Status.Code != otlpStatusError || span.Attributes[0].Key != "dns.provider" {
+		t.Fatalf("unexpected payload: %s", bodies[0])
+	}
+
+	if _, err := ParseHeaders("novalue"); err == nil {
+		t.Fatalf("expected an error for a header without '='")
+	}
+}
+
+func TestErrorCode(t *testing.T) {
+	if c := ErrorCode(errors.New("x")); c != "other" {
+		t.Fatalf("expected other, got %q", c)
+	}
+	if c := ErrorCode(context.Canceled); c != "canceled" {
+		t.Fatalf("expected canceled, got %q", c)
+	}
+}
@@ -0,0 +1,115 @@
+package telemetry
+
+import (
+	"context"
+	"crypto/rand"
+	"sync"
+	"time"
+)
+
+// Attr is a span attribute. Values are strings; that is all the runner and
+// provider wrappers need.
+type Attr struct {
+	Key   string
+	Value string
+}
+
+func String(key, value string) Attr {
+	return Attr{Key: key, Value: value}
+}
+
+// SpanData is a finished span handed to an Exporter.
+type SpanData struct {
+	TraceID  [16]byte
+	SpanID   [8]byte
+	ParentID [8]byte // zero for root spans
+	Name     string
+	Client   bool // span kind CLIENT (a provider call) rather than INTERNAL
+	Start    time.Time
+	End      time.Time
+	Attrs    []Attr
+	Err      string
+}
+
+// Exporter receives finished spans. Export must not block for long.
+type Exporter interface {
+	Export(SpanData)
+}
+
+var (
+	exporterMu sync.RWMutex
+	exporter   Exporter
+)
+
+// SetExporter installs the process-wide span exporter; nil disables
+// tracing, which makes StartSpan nearly free.
+func SetExporter(e Exporter) {
+	exporterMu.Lock()
+	defer exporterMu.Unlock()
+	exporter = e
+}
+
+func currentExporter() Exporter {
+	exporterMu.RLock()
+	defer exporterMu.RUnlock()
+	return exporter
+}
+
+// Span is an in-progress span. A nil *Span is valid and does nothing, which
+// is what StartSpan returns while tracing is disabled.
+type Span struct {
+	exp  Exporter
+	data SpanData
+	once sync.Once
+}
+
+type spanKey struct{}
+
+// StartSpan starts a span as a child of the span in ctx, if any.
+func StartSpan(ctx context.Context, name string, attrs ...Attr) (context.Context, *Span) {
+	return startSpan(ctx, name, false, attrs)
+}
+
+// StartClientSpan starts a span for an outgoing provider call.
+func StartClientSpan(ctx context.Context, name string, attrs ...Attr) (context.Context, *Span) {
+	return startSpan(ctx, name, true, attrs)
+}
+
+func startSpan(ctx context.Context, name string, client bool, attrs []Attr) (context.Context, *Span) {
+	exp := currentExporter()
+	if exp == nil {
+		return ctx, nil
+	}
+	s := &Span{exp: exp, data: SpanData{Name: name, Client: client, Start: time.Now(), Attrs: attrs}}
+	if parent, ok := ctx.Value(spanKey{}).(*Span); ok && parent != nil {
+		s.data.TraceID = parent.data.TraceID
+		s.data.ParentID = parent.data.SpanID
+	} else {
+		_, _ = rand.Read(s.data.TraceID[:])
+	}
+	_, _ = rand.Read(s.data.SpanID[:])
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+// SetAttr adds an attribute to the span.
+func (s *Span) SetAttr(key, value string) {
+	if s == nil {
+		return
+	}
+	s.data.Attrs = append(s.data.Attrs, Attr{Key: key, Value: value})
+}
+
+// End finishes the span, marking it failed when err is non-nil, and exports
+// it. Only the first call has an effect.
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+	s.once.Do(func() {
+		s.data.End = time.Now()
+		if err != nil {
+			s.data.Err = err.Error()
+		}
+		s.exp.Export(s.data)
+	})
+}